@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/draw"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// anonymizeBlurPasses is how often a box is blurred, two passes of a box blur are close to a gaussian.
+const anonymizeBlurPasses = 2
+
+// Anonymize is what --anonymize blurs in the collected images: the regions of some tags, like face and license-plate,
+// and the boxes an external detector finds.
+type Anonymize struct {
+	Tags     []string
+	Detector string
+}
+
+// parseAnonymize parses the comma separated tags of --anonymize.
+func parseAnonymize(tags string, detector string) Anonymize {
+	var anonymize Anonymize
+	for _, tag := range strings.Split(tags, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			anonymize.Tags = append(anonymize.Tags, tag)
+		}
+	}
+	anonymize.Detector = strings.TrimSpace(detector)
+	return anonymize
+}
+
+// enabled reports whether there is anything to blur.
+func (a Anonymize) enabled() bool {
+	return len(a.Tags) > 0 || a.Detector != ""
+}
+
+// validAnonymize checks --anonymize blurs copies of the images only, never the originals that hardlinks and symlinks
+// point at.
+func validAnonymize(anonymize Anonymize, collect string, mode string) error {
+	if !anonymize.enabled() {
+		return nil
+	}
+	if collect == "" || mode != "copy" {
+		return fmt.Errorf("Error: --anonymize and --anonymize-detector blur the copies of --collect, they need --collect with --collect-mode copy")
+	}
+	return nil
+}
+
+// anonymizeAssets blurs the regions of the anonymized tags and the boxes of the detector in the collected images
+// below root, each image once however many assets share it. Recorded checksums are updated to the blurred contents.
+// Returns the number of images blurred.
+func anonymizeAssets(ctx context.Context, assets []Asset, root string, anonymize Anonymize) (int, error) {
+	tags := make(map[string]bool)
+	for _, tag := range anonymize.Tags {
+		tags[tag] = true
+	}
+
+	var paths []string
+	boxes := make(map[string][]image.Rectangle)
+	for _, asset := range assets {
+		path := assetFilePathIn(asset, root)
+		if _, seen := boxes[path]; !seen {
+			paths = append(paths, path)
+			boxes[path] = nil
+		}
+		for _, region := range asset.Regions {
+			for _, tag := range region.Tags {
+				if tags[tag] {
+					boxes[path] = append(boxes[path], region.BoundingBox.Rect())
+					break
+				}
+			}
+		}
+	}
+
+	blurred := 0
+	changed := make(map[string]bool)
+	for _, path := range paths {
+		if anonymize.Detector != "" {
+			detected, err := detectBoxes(ctx, anonymize.Detector, path)
+			if err != nil {
+				return blurred, err
+			}
+			boxes[path] = append(boxes[path], detected...)
+		}
+		if len(boxes[path]) == 0 {
+			continue
+		}
+		img, err := decodeImageFile(path)
+		if err != nil {
+			return blurred, err
+		}
+		anonymized := image.NewRGBA(image.Rect(0, 0, img.Bounds().Dx(), img.Bounds().Dy()))
+		draw.Draw(anonymized, anonymized.Bounds(), img, img.Bounds().Min, draw.Src)
+		for _, box := range boxes[path] {
+			blurBox(anonymized, box)
+		}
+		// Formats without an encoder are written as PNG, the copy is renamed to match.
+		written := encodedName(path)
+		if err := encodeImageFile(written, anonymized); err != nil {
+			return blurred, fmt.Errorf("Error: Cannot write anonymized image '%s': %v", written, err)
+		}
+		if written != path {
+			if err := os.Remove(path); err != nil {
+				return blurred, err
+			}
+			renameAssetImages(assets, root, path, written)
+		}
+		changed[written] = true
+		blurred++
+	}
+	return blurred, rehashAssets(assets, root, changed)
+}
+
+// renameAssetImages points the assets of the image at path below root to its new path, with their name and format.
+func renameAssetImages(assets []Asset, root string, path string, renamed string) {
+	for i, asset := range assets {
+		if assetFilePathIn(asset, root) != path {
+			continue
+		}
+		ext := filepath.Ext(assets[i].Path)
+		assets[i].Path = strings.TrimSuffix(assets[i].Path, ext) + filepath.Ext(renamed)
+		assets[i].Name = filepath.Base(renamed)
+		assets[i].Format = strings.TrimPrefix(filepath.Ext(renamed), ".")
+	}
+}
+
+// detectBoxes runs the detector command with the image path added and reads the boxes it prints, one per line as
+// left top width height in pixels. Blank lines and lines starting with # are skipped.
+func detectBoxes(ctx context.Context, detector string, path string) ([]image.Rectangle, error) {
+	fields := strings.Fields(detector)
+	var stderr bytes.Buffer
+	command := exec.CommandContext(ctx, fields[0], append(fields[1:], path)...)
+	command.Stderr = &stderr
+	output, err := command.Output()
+	if err != nil {
+		return nil, fmt.Errorf("Error: Detector failed on '%s': %v %s", path, err, strings.TrimSpace(stderr.String()))
+	}
+
+	var boxes []image.Rectangle
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		values := strings.Fields(strings.ReplaceAll(line, ",", " "))
+		if len(values) != 4 {
+			return nil, fmt.Errorf("Error: Detector printed '%s' for '%s', expected left top width height", line, path)
+		}
+		var box BoundingBox
+		for i, target := range []*float64{&box.Left, &box.Top, &box.Width, &box.Height} {
+			if *target, err = strconv.ParseFloat(values[i], 64); err != nil {
+				return nil, fmt.Errorf("Error: Detector printed '%s' for '%s', expected left top width height", line, path)
+			}
+		}
+		boxes = append(boxes, box.Rect())
+	}
+	return boxes, nil
+}
+
+// blurBox box blurs the pixels of img inside box with a radius of a quarter of its larger side, so the faces and plates
+// in it can't be made out. The blur only averages pixels inside the box, nothing around it bleeds in.
+func blurBox(img *image.RGBA, box image.Rectangle) {
+	box = box.Intersect(img.Bounds())
+	if box.Empty() {
+		return
+	}
+	radius := max(max(box.Dx(), box.Dy())/4, 1)
+	for pass := 0; pass < anonymizeBlurPasses; pass++ {
+		blurPass(img, box, radius, true)
+		blurPass(img, box, radius, false)
+	}
+}
+
+// blurPass averages every pixel of box with the pixels up to radius away in its row, or in its column.
+func blurPass(img *image.RGBA, box image.Rectangle, radius int, horizontal bool) {
+	outer, inner := box.Dy(), box.Dx()
+	if !horizontal {
+		outer, inner = inner, outer
+	}
+	offset := func(o, i int) int {
+		if horizontal {
+			return img.PixOffset(box.Min.X+i, box.Min.Y+o)
+		}
+		return img.PixOffset(box.Min.X+o, box.Min.Y+i)
+	}
+	line := make([]int, 4*inner)
+	for o := 0; o < outer; o++ {
+		for i := 0; i < inner; i++ {
+			source := img.Pix[offset(o, i) : offset(o, i)+4]
+			for c := 0; c < 4; c++ {
+				line[4*i+c] = int(source[c])
+			}
+		}
+		var sum [4]int
+		count := 0
+		for i := 0; i < min(radius, inner); i++ {
+			for c := 0; c < 4; c++ {
+				sum[c] += line[4*i+c]
+			}
+			count++
+		}
+		for i := 0; i < inner; i++ {
+			if add := i + radius; add < inner {
+				for c := 0; c < 4; c++ {
+					sum[c] += line[4*add+c]
+				}
+				count++
+			}
+			if remove := i - radius - 1; remove >= 0 {
+				for c := 0; c < 4; c++ {
+					sum[c] -= line[4*remove+c]
+				}
+				count--
+			}
+			pixel := img.Pix[offset(o, i) : offset(o, i)+4]
+			for c := 0; c < 4; c++ {
+				pixel[c] = uint8(sum[c] / count)
+			}
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// checkerboard returns an image of alternating black and white pixels, which blurring turns gray.
+func checkerboard(width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if (x+y)%2 == 0 {
+				img.Set(x, y, color.White)
+			} else {
+				img.Set(x, y, color.Black)
+			}
+		}
+	}
+	return img
+}
+
+func Test_BlurBox(t *testing.T) {
+	img := checkerboard(20, 20)
+	blurBox(img, image.Rect(4, 4, 16, 16))
+
+	if r, _, _, _ := img.At(10, 10).RGBA(); r>>8 < 100 || r>>8 > 155 {
+		t.Errorf("Expected the box to be blurred to gray, found %d", r>>8)
+	}
+	if r, _, _, _ := img.At(2, 2).RGBA(); r>>8 != 255 {
+		t.Errorf("Expected the pixels outside the box to stay, found %d", r>>8)
+	}
+	// Boxes reaching out of the image are clipped.
+	blurBox(img, image.Rect(15, 15, 40, 40))
+}
+
+func Test_AnonymizeAssets(t *testing.T) {
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "street"), 0755)
+	path := filepath.Join(root, "street", "image1.png")
+	if err := encodeImageFile(path, checkerboard(20, 20)); err != nil {
+		t.Fatal(err)
+	}
+	sum, _ := fileSHA256(path)
+	assets := []Asset{{Path: "street/image1.png", Label: "street", SHA256: sum, Regions: []Region{
+		{Tags: []string{"face"}, BoundingBox: BoundingBox{Left: 0, Top: 0, Width: 10, Height: 10}},
+		{Tags: []string{"car"}, BoundingBox: BoundingBox{Left: 10, Top: 10, Width: 10, Height: 10}},
+	}}}
+
+	blurred, err := anonymizeAssets(context.Background(), assets, root, parseAnonymize("face, license-plate", ""))
+	if err != nil || blurred != 1 {
+		t.Fatalf("Expected one image blurred, found %d: %v", blurred, err)
+	}
+	img, err := decodeImageFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, _, _, _ := img.At(4, 4).RGBA(); r>>8 == 255 {
+		t.Error("Expected the face to be blurred")
+	}
+	if r, _, _, _ := img.At(14, 14).RGBA(); r>>8 != 255 {
+		t.Error("Expected the car to stay as it was")
+	}
+	if assets[0].SHA256 == sum {
+		t.Error("Expected the checksum of the blurred image")
+	}
+}
+
+func Test_AnonymizeAssetsWithoutEncoder(t *testing.T) {
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "street"), 0755)
+	path := filepath.Join(root, "street", "image1.bmp")
+	if err := encodeImageFile(path, checkerboard(20, 20)); err != nil {
+		t.Fatal(err)
+	}
+	assets := []Asset{{Name: "image1.bmp", Format: "bmp", Path: "street/image1.bmp", Label: "street", Regions: []Region{
+		{Tags: []string{"face"}, BoundingBox: BoundingBox{Left: 0, Top: 0, Width: 10, Height: 10}},
+	}}}
+
+	if _, err := anonymizeAssets(context.Background(), assets, root, parseAnonymize("face", "")); err != nil {
+		t.Fatal(err)
+	}
+	if assets[0].Path != "street/image1.png" || assets[0].Name != "image1.png" || assets[0].Format != "png" {
+		t.Errorf("Expected the asset renamed to street/image1.png, found %s %s %s", assets[0].Path, assets[0].Name, assets[0].Format)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected the original copy replaced, found %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "street", "image1.png")); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_AnonymizeDetector(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake detector is a shell script")
+	}
+	root := t.TempDir()
+	detector := filepath.Join(root, "detect")
+	os.WriteFile(detector, []byte("#!/bin/sh\necho '# left top width height'\necho '2 2 8 8'\n"), 0755)
+	path := filepath.Join(root, "image1.png")
+	if err := encodeImageFile(path, checkerboard(20, 20)); err != nil {
+		t.Fatal(err)
+	}
+
+	boxes, err := detectBoxes(context.Background(), detector, path)
+	if err != nil || len(boxes) != 1 || boxes[0] != image.Rect(2, 2, 10, 10) {
+		t.Fatalf("Expected the detected box, found %v: %v", boxes, err)
+	}
+	blurred, err := anonymizeAssets(context.Background(), []Asset{{Path: "image1.png"}}, root, parseAnonymize("", detector))
+	if err != nil || blurred != 1 {
+		t.Errorf("Expected the detected face blurred, found %d: %v", blurred, err)
+	}
+}
+
+func Test_ValidAnonymize(t *testing.T) {
+	anonymize := parseAnonymize("face", "")
+	if err := validAnonymize(anonymize, "bundle", "copy"); err != nil {
+		t.Errorf("Expected copies to be anonymized: %v", err)
+	}
+	if err := validAnonymize(anonymize, "bundle", "hardlink"); err == nil {
+		t.Error("Expected hardlinks to fail, blurring them would blur the originals")
+	}
+	if err := validAnonymize(anonymize, "", "copy"); err == nil {
+		t.Error("Expected --anonymize without --collect to fail")
+	}
+}
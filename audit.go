@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Issues of the audit report, several are joined with ;.
+const (
+	AuditUnreadable = "unreadable"
+	AuditEmpty      = "empty"
+	AuditCorrupt    = "corrupt"
+	AuditExtension  = "extension-mismatch"
+	AuditDuplicate  = "duplicate"
+	AuditNoLabel    = "no-label"
+)
+
+// auditHeader is the header row of the audit report.
+var auditHeader = []string{"path", "label", "size_bytes", "width", "height", "format", "sha256", "duplicate_of", "issues"}
+
+// AuditRow is a file of the audit report.
+type AuditRow struct {
+	Path        string
+	Label       string
+	Size        int64
+	Width       int
+	Height      int
+	Format      string
+	SHA256      string
+	DuplicateOf string
+	Issues      []string
+}
+
+// runAudit writes a CSV report of every image of a dataset, an images folder of label folders or a project file, for
+// data governance: its path, label, size, dimensions, format by content, SHA-256, the first file with the same
+// content, and its issues. Issues are reported, not failed on; votter check gates a pipeline.
+//
+//	votter.exe audit [-o audit.csv] [--workers 8] <dataset>
+func runAudit(args []string) int {
+	flags := flag.NewFlagSet("audit", flag.ExitOnError)
+	outputFlag := flags.String("o", "", "CSV file of the report, standard output when not given")
+	workersFlag := flags.Int("workers", runtime.NumCPU(), "Number of images decoded and hashed concurrently")
+	flags.Usage = func() {
+		fmt.Println("Usage: votter audit [options] <path_to_images or annotation.json>")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return ExitInvalidArguments
+	}
+
+	images, code := checkImages(flags.Arg(0))
+	if code != ExitSuccesful {
+		return code
+	}
+	rows := auditImages(images, *workersFlag)
+
+	if *outputFlag == "" {
+		if err := writeAuditCSV(os.Stdout, rows); err != nil {
+			fmt.Println(err)
+			return ExitAnnotationsWriteFailed
+		}
+		return ExitSuccesful
+	}
+	temporary := *outputFlag + ".tmp"
+	file, err := os.Create(temporary)
+	if err != nil {
+		fmt.Println(err)
+		return ExitAnnotationsWriteFailed
+	}
+	err = writeAuditCSV(file, rows)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(temporary, *outputFlag)
+	}
+	if err != nil {
+		fmt.Println(err)
+		return ExitAnnotationsWriteFailed
+	}
+
+	issues := 0
+	for _, row := range rows {
+		if len(row.Issues) > 0 {
+			issues++
+		}
+	}
+	fmt.Printf("Audited %d files, %d with issues, into '%s'.\n", len(rows), issues, *outputFlag)
+	return ExitSuccesful
+}
+
+// auditImages audits every image with workers at once, and marks the images with the content of an earlier one as
+// its duplicates. Returns a row per image, in the order of the images.
+func auditImages(images []labeledImage, workers int) []AuditRow {
+	rows := make([]AuditRow, len(images))
+	work := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < max(workers, 1); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				rows[i] = auditImage(images[i])
+			}
+		}()
+	}
+	for i := range images {
+		work <- i
+	}
+	close(work)
+	wg.Wait()
+
+	first := make(map[string]string)
+	for i, row := range rows {
+		if row.SHA256 == "" {
+			continue
+		}
+		if path, seen := first[row.SHA256]; seen {
+			rows[i].DuplicateOf = path
+			rows[i].Issues = append(rows[i].Issues, AuditDuplicate)
+			continue
+		}
+		first[row.SHA256] = row.Path
+	}
+	return rows
+}
+
+// auditImage reads, hashes and decodes an image for its row of the audit report, reading the file once.
+func auditImage(image labeledImage) AuditRow {
+	row := AuditRow{Path: image.Path, Label: image.Label}
+	if image.Label == "" {
+		row.Issues = append(row.Issues, AuditNoLabel)
+	}
+	data, err := retryRead(Retry, image.Path, OpenFiles, func() ([]byte, error) {
+		return os.ReadFile(image.Path)
+	})
+	if err != nil {
+		row.Issues = append(row.Issues, AuditUnreadable)
+		return row
+	}
+	row.Size = int64(len(data))
+	if len(data) == 0 {
+		row.Issues = append(row.Issues, AuditEmpty)
+		return row
+	}
+	sum := sha256.Sum256(data)
+	row.SHA256 = hex.EncodeToString(sum[:])
+
+	decoded, decodedFormat, err := decodeAudited(bytes.NewReader(data))
+	if err != nil {
+		row.Issues = append(row.Issues, AuditCorrupt)
+	} else {
+		row.Width, row.Height = decoded.Dx(), decoded.Dy()
+	}
+	row.Format = assetFormat(image.Path, sniffMediaType(data[:min(len(data), sniffLength)]), decodedFormat)
+	if ext := strings.TrimPrefix(filepath.Ext(image.Path), "."); row.Format != ext {
+		row.Issues = append(row.Issues, AuditExtension)
+	}
+	return row
+}
+
+// decodeAudited decodes an image to its bounds and format. The format is returned for images whose header reads and
+// whose pixels don't, as truncated files.
+func decodeAudited(reader io.ReadSeeker) (image.Rectangle, string, error) {
+	_, format, err := image.DecodeConfig(reader)
+	if err != nil {
+		return image.Rectangle{}, "", err
+	}
+	reader.Seek(0, io.SeekStart)
+	decoded, _, err := image.Decode(reader)
+	if err != nil {
+		return image.Rectangle{}, format, err
+	}
+	return decoded.Bounds(), format, nil
+}
+
+// writeAuditCSV writes the rows of the audit report with a header row.
+func writeAuditCSV(writer io.Writer, rows []AuditRow) error {
+	csvWriter := csv.NewWriter(writer)
+	csvWriter.Write(auditHeader)
+	for _, row := range rows {
+		width, height := "", ""
+		if row.Width > 0 || row.Height > 0 {
+			width, height = strconv.Itoa(row.Width), strconv.Itoa(row.Height)
+		}
+		csvWriter.Write([]string{
+			filepath.ToSlash(row.Path), row.Label, strconv.FormatInt(row.Size, 10), width, height,
+			row.Format, row.SHA256, filepath.ToSlash(row.DuplicateOf), strings.Join(row.Issues, ";"),
+		})
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
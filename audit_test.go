@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_AuditImages(t *testing.T) {
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "cat"), 0755)
+	os.MkdirAll(filepath.Join(root, "dog"), 0755)
+	good := filepath.Join(root, "cat", "a.jpg")
+	writeTestImage(t, good, 4, 3)
+	data, _ := os.ReadFile(good)
+	copied := filepath.Join(root, "dog", "b.png")
+	os.WriteFile(copied, data, 0644)
+	broken := filepath.Join(root, "dog", "c.jpg")
+	os.WriteFile(broken, data[:len(data)/2], 0644)
+	empty := filepath.Join(root, "dog", "d.jpg")
+	os.WriteFile(empty, nil, 0644)
+
+	images := []labeledImage{{Path: good, Label: "cat"}, {Path: copied, Label: "dog"}, {Path: broken, Label: "dog"}, {Path: empty, Label: "dog"}}
+	rows := auditImages(images, 2)
+	if rows[0].Width != 4 || rows[0].Height != 3 || rows[0].Format != "jpg" || len(rows[0].Issues) != 0 || rows[0].Size != int64(len(data)) {
+		t.Errorf("Expected a.jpg without issues, found %+v", rows[0])
+	}
+	if rows[1].DuplicateOf != good || !reflect.DeepEqual(rows[1].Issues, []string{AuditExtension, AuditDuplicate}) || rows[1].Format != "jpg" {
+		t.Errorf("Expected the JPEG named b.png as a duplicate of a.jpg, found %+v", rows[1])
+	}
+	if !reflect.DeepEqual(rows[2].Issues, []string{AuditCorrupt}) || rows[2].Format != "jpg" {
+		t.Errorf("Expected the truncated JPEG as corrupt, found %+v", rows[2])
+	}
+	if !reflect.DeepEqual(rows[3].Issues, []string{AuditEmpty}) || rows[3].SHA256 != "" {
+		t.Errorf("Expected the empty file as empty, found %+v", rows[3])
+	}
+
+	var report bytes.Buffer
+	if err := writeAuditCSV(&report, rows); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&report).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 5 || !reflect.DeepEqual(records[0], auditHeader) {
+		t.Fatalf("Expected a header and a row per image, found %v", records)
+	}
+	if records[2][8] != "extension-mismatch;duplicate" || records[2][7] != filepath.ToSlash(good) || records[4][3] != "" {
+		t.Errorf("Expected the issues joined and no dimensions for the empty file, found %v", records)
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Augmentations maps the names accepted by --augment to whether they swap the width and height of the image.
+var Augmentations = map[string]bool{
+	"hflip":  false,
+	"vflip":  false,
+	"rot90":  true,
+	"rot180": false,
+	"rot270": true,
+}
+
+// parseAugmentations splits a comma separated list of augmentation names and checks they are known.
+func parseAugmentations(list string) ([]string, error) {
+	var augmentations []string
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(strings.ToLower(name))
+		if name == "" {
+			continue
+		}
+		if _, ok := Augmentations[name]; !ok {
+			return nil, fmt.Errorf("Error: Unknown augmentation '%s', expected one of hflip, vflip, rot90, rot180, rot270", name)
+		}
+		augmentations = append(augmentations, name)
+	}
+	return augmentations, nil
+}
+
+// augmentAssets writes a flipped or rotated copy of every asset image for each augmentation into outDir/label/ and returns
+// the copies as new assets with transformed regions. The original assets are left untouched. Copies are named after their
+// images and augmentation, with the asset ID for images of the same name in a label folder.
+func augmentAssets(assets []Asset, augmentations []string, outDir string) ([]Asset, error) {
+	var augmented []Asset
+
+	names := make([]string, len(assets))
+	ids := make([]string, len(assets))
+	for i, asset := range assets {
+		names[i] = filepath.Join(asset.Label, encodedName(asset.Name))
+		ids[i] = asset.ID
+	}
+	names = uniqueNames(names, ids)
+
+	for i, asset := range assets {
+		if err := os.MkdirAll(filepath.Join(outDir, asset.Label), 0755); err != nil {
+			return nil, err
+		}
+
+		img, err := decodeImageFile(assetFilePath(asset))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, augmentation := range augmentations {
+			ext := filepath.Ext(names[i])
+			imgPath := filepath.Join(outDir, strings.TrimSuffix(names[i], ext)+"_"+augmentation+ext)
+			name := filepath.Base(imgPath)
+			if err := encodeImageFile(imgPath, transformImage(img, augmentation)); err != nil {
+				return nil, err
+			}
+			imgAbsolutePath, err := filepath.Abs(imgPath)
+			if err != nil {
+				return nil, err
+			}
+
+			augmentedAsset := asset
+			augmentedAsset.Name = name
+			augmentedAsset.Format = strings.TrimPrefix(filepath.Ext(name), ".")
+			augmentedAsset.Path = "file:" + filepath.ToSlash(imgAbsolutePath)
+			augmentedAsset.ID = assetID(augmentedAsset.Path)
+			if Augmentations[augmentation] {
+				augmentedAsset.Size = Size{Width: asset.Size.Height, Height: asset.Size.Width}
+			}
+			if asset.Regions != nil {
+				augmentedAsset.Regions = make([]Region, 0, len(asset.Regions))
+			}
+			for _, region := range asset.Regions {
+				augmentedAsset.Regions = append(augmentedAsset.Regions, transformRegion(region, asset.Size, augmentation))
+			}
+			augmented = append(augmented, augmentedAsset)
+		}
+	}
+
+	return augmented, nil
+}
+
+// transformPoint maps a point of an image with the given size to its position after the augmentation.
+// Rotations are clockwise. Pixels are mapped in int, region coordinates in float64.
+func transformPoint[T int | float64](x, y T, size Size, augmentation string) (T, T) {
+	width, height := T(size.Width), T(size.Height)
+	switch augmentation {
+	case "hflip":
+		return width - x, y
+	case "vflip":
+		return x, height - y
+	case "rot90":
+		return height - y, x
+	case "rot180":
+		return width - x, height - y
+	case "rot270":
+		return y, width - x
+	}
+	return x, y
+}
+
+// transformRegion applies the augmentation to the points and bounding box of a region, giving it a new ID.
+func transformRegion(region Region, size Size, augmentation string) Region {
+	box := region.BoundingBox
+	x1, y1 := transformPoint(box.Left, box.Top, size, augmentation)
+	x2, y2 := transformPoint(box.Left+box.Width, box.Top+box.Height, size, augmentation)
+	region.BoundingBox = BoundingBox{Left: min(x1, x2), Top: min(y1, y2), Width: abs(x2 - x1), Height: abs(y2 - y1)}
+
+	points := make([]Point, len(region.Points))
+	for i, point := range region.Points {
+		points[i].X, points[i].Y = transformPoint(point.X, point.Y, size, augmentation)
+	}
+	region.Points = points
+	region.Keypoints = mapKeypoints(region.Keypoints, func(x, y float64) (float64, float64, bool) {
+		x, y = transformPoint(x, y, size, augmentation)
+		return x, y, true
+	})
+	region.ID = uuid.New().String()
+	return refitOrientedBox(region)
+}
+
+// transformImage returns a flipped or rotated copy of img.
+func transformImage(img image.Image, augmentation string) image.Image {
+	bounds := img.Bounds()
+	size := Size{Width: bounds.Dx(), Height: bounds.Dy()}
+	outSize := size
+	if Augmentations[augmentation] {
+		outSize = Size{Width: size.Height, Height: size.Width}
+	}
+
+	out := image.NewRGBA(image.Rect(0, 0, outSize.Width, outSize.Height))
+	for y := 0; y < size.Height; y++ {
+		for x := 0; x < size.Width; x++ {
+			// Transform the pixel center, then step back to the pixel's top-left corner.
+			cx, cy := transformPoint(2*x+1, 2*y+1, Size{Width: 2 * size.Width, Height: 2 * size.Height}, augmentation)
+			out.Set((cx-1)/2, (cy-1)/2, img.At(bounds.Min.X+x, bounds.Min.Y+y))
+		}
+	}
+	return out
+}
+
+func abs[T int | float64](n T) T {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
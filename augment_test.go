@@ -0,0 +1,123 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_ParseAugmentations(t *testing.T) {
+	augmentations, err := parseAugmentations("hflip, ROT90")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(augmentations) != 2 || augmentations[0] != "hflip" || augmentations[1] != "rot90" {
+		t.Errorf("Expected [hflip rot90], found %v", augmentations)
+	}
+
+	if _, err := parseAugmentations("shear"); err == nil {
+		t.Error("Expected an error for an unknown augmentation")
+	}
+}
+
+func Test_TransformRegion(t *testing.T) {
+	size := Size{Width: 100, Height: 50}
+	region := Region{BoundingBox: BoundingBox{Left: 10, Top: 5, Width: 20, Height: 10}}
+
+	cases := map[string]BoundingBox{
+		"hflip":  {Left: 70, Top: 5, Width: 20, Height: 10},
+		"vflip":  {Left: 10, Top: 35, Width: 20, Height: 10},
+		"rot90":  {Left: 35, Top: 10, Width: 10, Height: 20},
+		"rot180": {Left: 70, Top: 35, Width: 20, Height: 10},
+		"rot270": {Left: 5, Top: 70, Width: 10, Height: 20},
+	}
+	for augmentation, expected := range cases {
+		if box := transformRegion(region, size, augmentation).BoundingBox; box != expected {
+			t.Errorf("%s: expected %+v, found %+v", augmentation, expected, box)
+		}
+	}
+}
+
+func Test_TransformImage(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 3, 2))
+	img.Set(0, 0, color.White)
+
+	rotated := transformImage(img, "rot90")
+	if rotated.Bounds().Dx() != 2 || rotated.Bounds().Dy() != 3 {
+		t.Fatalf("Expected 2x3 image, found %v", rotated.Bounds())
+	}
+	if r, _, _, _ := rotated.At(1, 0).RGBA(); r == 0 {
+		t.Error("Expected the top-left pixel to move to the top-right")
+	}
+}
+
+func Test_AugmentAssets(t *testing.T) {
+	rootDir := t.TempDir()
+	imgPath := filepath.Join(rootDir, "image1.jpg")
+	writeTestImage(t, imgPath, 40, 20)
+
+	asset := Asset{ID: "id1", Name: "image1.jpg", Path: "file:" + filepath.ToSlash(imgPath), Size: Size{Width: 40, Height: 20}, Label: "cat"}
+	asset.Regions = []Region{fullImageRegion(asset)}
+
+	augmented, err := augmentAssets([]Asset{asset}, []string{"hflip", "rot90"}, filepath.Join(rootDir, "augmented"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(augmented) != 2 {
+		t.Fatalf("Expected 2 augmented assets, found %d", len(augmented))
+	}
+	if augmented[1].Name != "image1_rot90.jpg" || augmented[1].Size != (Size{Width: 20, Height: 40}) {
+		t.Errorf("Expected rotated asset image1_rot90.jpg of 20x40, found %s of %+v", augmented[1].Name, augmented[1].Size)
+	}
+	if augmented[0].ID == asset.ID {
+		t.Error("Expected augmented assets to get a new ID")
+	}
+	if box := augmented[1].Regions[0].BoundingBox; box.Width != 20 || box.Height != 40 {
+		t.Errorf("Expected rotated full image region, found %+v", box)
+	}
+}
+
+func Test_AugmentAssetsWithoutEncoder(t *testing.T) {
+	rootDir := t.TempDir()
+	imgPath := filepath.Join(rootDir, "image1.bmp")
+	writeTestImage(t, imgPath, 40, 20)
+
+	asset := Asset{ID: "id1", Name: "image1.bmp", Format: "bmp", Path: "file:" + filepath.ToSlash(imgPath), Size: Size{Width: 40, Height: 20}, Label: "cat"}
+	augmented, err := augmentAssets([]Asset{asset}, []string{"hflip"}, filepath.Join(rootDir, "augmented"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if augmented[0].Name != "image1_hflip.png" || augmented[0].Format != "png" {
+		t.Errorf("Expected the copy written as image1_hflip.png of format png, found %s of %s", augmented[0].Name, augmented[0].Format)
+	}
+	if _, err := os.Stat(filepath.Join(rootDir, "augmented", "cat", "image1_hflip.png")); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_AugmentAssetsSameNames(t *testing.T) {
+	rootDir := t.TempDir()
+	var assets []Asset
+	// Written as image1.png both, the bmp without an encoder.
+	for _, name := range []string{"image1.bmp", "image1.png"} {
+		imgPath := filepath.Join(rootDir, name)
+		writeTestImage(t, imgPath, 40, 20)
+		path := "file:" + filepath.ToSlash(imgPath)
+		assets = append(assets, Asset{ID: assetID(path), Name: name, Path: path, Size: Size{Width: 40, Height: 20}, Label: "cat"})
+	}
+
+	augmented, err := augmentAssets(assets, []string{"hflip"}, filepath.Join(rootDir, "augmented"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, asset := range augmented {
+		if expected := "image1_" + assets[i].ID + "_hflip.png"; asset.Name != expected {
+			t.Errorf("Expected %s named by its asset ID, found %s", expected, asset.Name)
+		}
+		if _, err := os.Stat(assetFilePath(asset)); err != nil {
+			t.Error(err)
+		}
+	}
+}
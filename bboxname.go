@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// bboxGroups are the named groups a --bbox-from-name pattern must have: left, top, width and height in pixels.
+var bboxGroups = []string{"x", "y", "w", "h"}
+
+// parseBBoxPattern compiles a --bbox-from-name pattern, checking it has the groups x, y, w and h.
+func parseBBoxPattern(pattern string) (*regexp.Regexp, error) {
+	expression, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("Error: Invalid --bbox-from-name pattern '%s': %v", pattern, err)
+	}
+	for _, group := range bboxGroups {
+		if expression.SubexpIndex(group) < 0 {
+			return nil, fmt.Errorf("Error: --bbox-from-name pattern '%s' has no group (?P<%s>...)", pattern, group)
+		}
+	}
+	return expression, nil
+}
+
+// nameBoundingBox returns the box encoded in an image name, without extension, clipped to the image. Reports false
+// when the name doesn't match or the box is outside the image.
+func nameBoundingBox(name string, pattern *regexp.Regexp, size Size) (BoundingBox, bool) {
+	match := pattern.FindStringSubmatch(strings.TrimSuffix(name, filepath.Ext(name)))
+	if match == nil {
+		return BoundingBox{}, false
+	}
+	values := make(map[string]int)
+	for _, group := range bboxGroups {
+		value, err := strconv.Atoi(match[pattern.SubexpIndex(group)])
+		if err != nil {
+			return BoundingBox{}, false
+		}
+		values[group] = value
+	}
+
+	left, top := max(0, values["x"]), max(0, values["y"])
+	right, bottom := min(size.Width, values["x"]+values["w"]), min(size.Height, values["y"]+values["h"])
+	if right <= left || bottom <= top {
+		return BoundingBox{}, false
+	}
+	return BoundingBox{Left: float64(left), Top: float64(top), Width: float64(right - left), Height: float64(bottom - top)}, true
+}
+
+// applyNameBoundingBoxes replaces the full image region of every asset whose name encodes a box with a region of that box.
+func applyNameBoundingBoxes(assets []Asset, pattern *regexp.Regexp) []Asset {
+	for i, asset := range assets {
+		box, ok := nameBoundingBox(asset.Name, pattern, asset.Size)
+		if !ok {
+			fmt.Printf("No bounding box in the name of image '%s', keeping its regions.\n", asset.Name)
+			continue
+		}
+		assets[i].Regions = []Region{{
+			ID:          uuid.New().String(),
+			Type:        "RECTANGLE",
+			Tags:        []string{asset.Label},
+			BoundingBox: box,
+			Points:      []Point{{X: box.Left, Y: box.Top}, {X: box.Left + box.Width, Y: box.Top + box.Height}},
+		}}
+	}
+	return assets
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"testing"
+)
+
+func Test_ParseBBoxPattern(t *testing.T) {
+	if _, err := parseBBoxPattern(`(?P<x>\d+)_(?P<y>\d+)_(?P<w>\d+)_(?P<h>\d+)`); err != nil {
+		t.Error(err)
+	}
+	if _, err := parseBBoxPattern(`(?P<x>\d+)_(?P<y>\d+)_(?P<w>\d+)`); err == nil {
+		t.Error("Expected an error for a pattern without h")
+	}
+	if _, err := parseBBoxPattern(`(?P<x>\d+`); err == nil {
+		t.Error("Expected an error for an invalid pattern")
+	}
+}
+
+func Test_ApplyNameBoundingBoxes(t *testing.T) {
+	pattern, err := parseBBoxPattern(`(?P<x>\d+)_(?P<y>\d+)_(?P<w>\d+)_(?P<h>\d+)$`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	size := Size{Width: 100, Height: 80}
+	full := Region{Tags: []string{"car"}, BoundingBox: BoundingBox{Width: 100, Height: 80}}
+	assets := []Asset{
+		{Name: "car_10_20_30_40.jpg", Label: "car", Size: size, Regions: []Region{full}},
+		{Name: "car_90_70_30_40.jpg", Label: "car", Size: size, Regions: []Region{full}},
+		{Name: "car.jpg", Label: "car", Size: size, Regions: []Region{full}},
+	}
+
+	assets = applyNameBoundingBoxes(assets, pattern)
+	if box := assets[0].Regions[0].BoundingBox; box != (BoundingBox{Left: 10, Top: 20, Width: 30, Height: 40}) {
+		t.Errorf("Expected the box of the name, found %+v", box)
+	}
+	if points := assets[0].Regions[0].Points; points[1] != (Point{X: 40, Y: 60}) {
+		t.Errorf("Expected the bottom right point at 40,60, found %v", points)
+	}
+	if box := assets[1].Regions[0].BoundingBox; box != (BoundingBox{Left: 90, Top: 70, Width: 10, Height: 10}) {
+		t.Errorf("Expected the box clipped to the image, found %+v", box)
+	}
+	if box := assets[2].Regions[0].BoundingBox; box != full.BoundingBox {
+		t.Errorf("Expected the full image region for a name without box, found %+v", box)
+	}
+}
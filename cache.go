@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultCacheSize is the size --cache-dir is capped at without --cache-size.
+const DefaultCacheSize = "1GB"
+
+// cacheEntry is the metadata of a cached response, stored as <key>.json next to the body in <key>.body.
+type cacheEntry struct {
+	URL    string      `json:"url"`
+	ETag   string      `json:"etag"`
+	Status int         `json:"status"`
+	Header http.Header `json:"header"`
+}
+
+// cachingTransport keeps GET responses that have an ETag in a local directory. Repeated requests ask the server
+// with If-None-Match and answer from the cache when the object is unchanged. The bodies are capped at maxSize
+// bytes in total, evicting the least recently used.
+type cachingTransport struct {
+	base    http.RoundTripper
+	dir     string
+	maxSize int64
+	mutex   sync.Mutex
+}
+
+// newCachingTransport returns a transport over base that caches in dir, creating it if needed.
+func newCachingTransport(base http.RoundTripper, dir string, maxSize int64) (*cachingTransport, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("Error: Cannot create cache directory '%s': %v", dir, err)
+	}
+	return &cachingTransport{base: base, dir: dir, maxSize: maxSize}, nil
+}
+
+// cacheKey names the cache files of a URL.
+func cacheKey(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return hex.EncodeToString(sum[:])
+}
+
+func (transport *cachingTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	if request.Method != http.MethodGet || request.Header.Get("Range") != "" {
+		return transport.base.RoundTrip(request)
+	}
+
+	url := request.URL.String()
+	key := filepath.Join(transport.dir, cacheKey(url))
+	entry, body, cached := readCacheEntry(key, url)
+	if cached {
+		request = request.Clone(request.Context())
+		request.Header.Set("If-None-Match", entry.ETag)
+	}
+
+	response, err := transport.base.RoundTrip(request)
+	if err != nil {
+		return nil, err
+	}
+	if cached && response.StatusCode == http.StatusNotModified {
+		response.Body.Close()
+		now := time.Now()
+		os.Chtimes(key+".body", now, now)
+		return cachedResponse(request, entry, body), nil
+	}
+
+	etag := response.Header.Get("ETag")
+	if response.StatusCode != http.StatusOK || etag == "" {
+		return response, nil
+	}
+	data, err := ioutil.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	response.Body = io.NopCloser(bytes.NewReader(data))
+	if err := transport.store(key, cacheEntry{URL: url, ETag: etag, Status: response.StatusCode, Header: response.Header}, data); err != nil {
+		fmt.Printf("Cannot cache '%s': %v\n", url, err)
+	}
+	return response, nil
+}
+
+// readCacheEntry reads the cached response of url, ok is false when there is none.
+func readCacheEntry(key string, url string) (entry cacheEntry, body []byte, ok bool) {
+	data, err := ioutil.ReadFile(key + ".json")
+	if err != nil || json.Unmarshal(data, &entry) != nil || entry.URL != url || entry.ETag == "" {
+		return entry, nil, false
+	}
+	if body, err = ioutil.ReadFile(key + ".body"); err != nil {
+		return entry, nil, false
+	}
+	return entry, body, true
+}
+
+// cachedResponse answers request with a cached response.
+func cachedResponse(request *http.Request, entry cacheEntry, body []byte) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", entry.Status, http.StatusText(entry.Status)),
+		StatusCode:    entry.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        entry.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       request,
+	}
+}
+
+// store writes a response to the cache, and evicts the least recently used bodies over the size cap.
+// Both files are written next to their place and renamed, so concurrent readers never see half a response.
+func (transport *cachingTransport) store(key string, entry cacheEntry, body []byte) error {
+	if int64(len(body)) > transport.maxSize {
+		return nil
+	}
+	meta, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	for _, file := range []struct {
+		path string
+		data []byte
+	}{{key + ".body", body}, {key + ".json", meta}} {
+		if err := ioutil.WriteFile(file.path+".tmp", file.data, 0644); err != nil {
+			return err
+		}
+		if err := os.Rename(file.path+".tmp", file.path); err != nil {
+			return err
+		}
+	}
+
+	transport.mutex.Lock()
+	defer transport.mutex.Unlock()
+	return evictCache(transport.dir, transport.maxSize)
+}
+
+// evictCache removes the least recently used responses in dir until their bodies fit in maxSize bytes.
+func evictCache(dir string, maxSize int64) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	var bodies []os.FileInfo
+	var total int64
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), ".body") {
+			continue
+		}
+		if info, err := entry.Info(); err == nil {
+			bodies = append(bodies, info)
+			total += info.Size()
+		}
+	}
+	sort.Slice(bodies, func(i, j int) bool { return bodies[i].ModTime().Before(bodies[j].ModTime()) })
+	for _, body := range bodies {
+		if total <= maxSize {
+			break
+		}
+		key := filepath.Join(dir, strings.TrimSuffix(body.Name(), ".body"))
+		os.Remove(key + ".json")
+		os.Remove(key + ".body")
+		total -= body.Size()
+	}
+	return nil
+}
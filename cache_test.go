@@ -0,0 +1,65 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_CachingTransport(t *testing.T) {
+	fetches := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		fetches++
+		w.Write([]byte("image " + r.URL.Path))
+	}))
+	defer server.Close()
+
+	dir := filepath.Join(t.TempDir(), "cache")
+	transport, err := newCachingTransport(http.DefaultTransport, dir, 30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Transport: transport}
+	get := func(path string) string {
+		response, err := client.Get(server.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer response.Body.Close()
+		data, err := io.ReadAll(response.Body)
+		if err != nil || response.StatusCode != http.StatusOK {
+			t.Fatalf("Expected the object, found %s, %v", response.Status, err)
+		}
+		return string(data)
+	}
+
+	if body := get("/a.jpg"); body != "image /a.jpg" {
+		t.Errorf("Expected the object, found '%s'", body)
+	}
+	if body := get("/a.jpg"); body != "image /a.jpg" || fetches != 1 {
+		t.Errorf("Expected the unchanged object from the cache, found '%s' after %d fetches", body, fetches)
+	}
+
+	// Two bodies of 12 bytes don't fit in 30 with a third, the least recently used goes.
+	get("/b.jpg")
+	get("/c.jpg")
+	entries, _ := os.ReadDir(dir)
+	bodies := 0
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), ".body") {
+			bodies++
+		}
+	}
+	if bodies != 2 {
+		t.Errorf("Expected the cache to be capped at 2 bodies, found %d", bodies)
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// interruptContext returns a context that's cancelled by the first SIGINT or SIGTERM, so the images being decoded
+// are finished instead of the run dying mid-write. A second signal stops the process right away.
+func interruptContext() (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+		// Stopping without a signal cancels with context.Canceled as the cause.
+		if context.Cause(ctx) != context.Canceled {
+			fmt.Println("Interrupted, finishing the current images. Interrupt again to stop right away.")
+		}
+	}()
+	return ctx, stop
+}
+
+// interruption describes why ctx was cancelled, for messages.
+func interruption(ctx context.Context) string {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return "Timed out by --run-timeout"
+	}
+	return "Interrupted"
+}
+
+// partialProjectPath returns the path of the partial project of an interrupted run next to the annotations file:
+// annotations.json -> annotations.partial.json
+func partialProjectPath(annotationFile string) string {
+	ext := filepath.Ext(annotationFile)
+	return strings.TrimSuffix(annotationFile, ext) + ".partial" + ext
+}
+
+// stopInterrupted ends a run that ctx cancelled when, between its stages. With --partial-on-interrupt the assets so far
+// are written to the partial project, as they are after the stages run until then. The annotations file and hash state
+// of the last complete run are left as they are, for the next --incremental run to carry forward. Returns the exit code.
+func stopInterrupted(ctx context.Context, annotationFile string, when string, assets []Asset, labels []string, options Options) int {
+	if !options.PartialOnInterrupt {
+		fmt.Printf("Error: %s %s, no annotations written\n", interruption(ctx), when)
+		return ExitInterrupted
+	}
+	partial := partialProjectPath(annotationFile)
+	if err := writeVottModelAs(partial, buildVottModel(assets, labels), options.Format); err != nil {
+		fmt.Println(err)
+		return ExitAnnotationsWriteFailed
+	}
+	fmt.Printf("%s %s, wrote %d assets to '%s'.", interruption(ctx), when, len(assets), partial)
+	options.Incremental = true
+	if validIncremental(options) == nil {
+		fmt.Print(" Run again with --incremental to generate only the images changed since the last complete run.")
+	}
+	fmt.Println()
+	return ExitInterrupted
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_GenerateInterrupted(t *testing.T) {
+	rootDir := t.TempDir()
+	imagesDir := filepath.Join(rootDir, "images")
+	os.MkdirAll(filepath.Join(imagesDir, "cat"), 0755)
+	writeTestImage(t, filepath.Join(imagesDir, "cat", "image1.jpg"), 10, 10)
+	writeTestImage(t, filepath.Join(imagesDir, "cat", "image2.jpg"), 10, 10)
+	annotationFile := filepath.Join(rootDir, "annotations.json")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if code := generate(ctx, imagesDir, annotationFile, Options{Workers: 2, PartialOnInterrupt: true}); code != ExitInterrupted {
+		t.Errorf("Expected an interrupted run, found exit code %d", code)
+	}
+	if _, err := os.Stat(annotationFile); !os.IsNotExist(err) {
+		t.Errorf("Expected no annotations from an interrupted scan, found %v", err)
+	}
+
+	entries, errs := generateEachImageEntry(ctx, []labeledImage{{Path: filepath.Join(imagesDir, "cat", "image1.jpg"), Label: "cat"}}, 1)
+	if len(entries) != len(errs) || len(entries) > 1 {
+		t.Errorf("Expected the entries of the started images, found %d entries and %d errors", len(entries), len(errs))
+	}
+}
+
+func Test_StopInterrupted(t *testing.T) {
+	rootDir := t.TempDir()
+	imagesDir := filepath.Join(rootDir, "images")
+	os.MkdirAll(filepath.Join(imagesDir, "cat"), 0755)
+	writeTestImage(t, filepath.Join(imagesDir, "cat", "image1.jpg"), 10, 10)
+	annotationFile := filepath.Join(rootDir, "annotations.json")
+	if code := generate(context.Background(), imagesDir, annotationFile, Options{Workers: 2, Incremental: true}); code != ExitSuccesful {
+		t.Fatalf("Expected the complete run to succeed, found exit code %d", code)
+	}
+	complete, err := os.ReadFile(annotationFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The second image was done before the interrupt.
+	writeTestImage(t, filepath.Join(imagesDir, "cat", "image2.jpg"), 10, 10)
+	done, err := generateImageEntries([]labeledImage{{Path: filepath.Join(imagesDir, "cat", "image2.jpg"), Label: "cat"}}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if code := stopInterrupted(ctx, annotationFile, "before the tile stage", done, []string{"cat"}, Options{Format: FormatJSON}); code != ExitInterrupted {
+		t.Errorf("Expected an interrupted run, found exit code %d", code)
+	}
+	if _, err := os.Stat(partialProjectPath(annotationFile)); !os.IsNotExist(err) {
+		t.Errorf("Expected no partial project without --partial-on-interrupt, found %v", err)
+	}
+	if code := stopInterrupted(ctx, annotationFile, "before the tile stage", done, []string{"cat"}, Options{PartialOnInterrupt: true, Format: FormatJSON}); code != ExitInterrupted {
+		t.Errorf("Expected an interrupted run, found exit code %d", code)
+	}
+	partial, err := readVottJSON(filepath.Join(rootDir, "annotations.partial.json"))
+	if err != nil || len(partial.Assets) != 1 {
+		t.Fatalf("Expected a valid partial project of the done asset, found %+v, %v", partial.Assets, err)
+	}
+	if data, _ := os.ReadFile(annotationFile); string(data) != string(complete) {
+		t.Error("Expected the project of the complete run to be left as it is")
+	}
+
+	// The next incremental run carries forward the assets of the complete run.
+	if code := generate(context.Background(), imagesDir, annotationFile, Options{Workers: 2, Incremental: true}); code != ExitSuccesful {
+		t.Fatalf("Expected the next run to succeed, found exit code %d", code)
+	}
+	resumed, err := readVottJSON(annotationFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resumed.Assets) != 2 {
+		t.Errorf("Expected both assets after the next run, found %d", len(resumed.Assets))
+	}
+}
+
+func Test_ValidIncremental(t *testing.T) {
+	if err := validIncremental(Options{Incremental: true, Resize: 512}); err == nil {
+		t.Error("Expected --incremental with --resize to be invalid")
+	}
+	if err := validIncremental(Options{Resize: 512}); err != nil {
+		t.Errorf("Expected --resize alone to be valid, found %v", err)
+	}
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// CheckReport is the report of check, printed as JSON for the pipeline that runs it.
+type CheckReport struct {
+	Dataset string         `json:"dataset"`
+	Images  int            `json:"images"`
+	Labels  map[string]int `json:"labels"`
+	Passed  bool           `json:"passed"`
+	Checks  []CheckResult  `json:"checks"`
+}
+
+// CheckResult is the outcome of one assertion of check, named like its flag.
+type CheckResult struct {
+	Name     string   `json:"name"`
+	Passed   bool     `json:"passed"`
+	Message  string   `json:"message"`
+	Failures []string `json:"failures,omitempty"`
+}
+
+// Checks are the assertions of check, zero values are left out.
+type Checks struct {
+	MinPerLabel  int
+	MinLabels    int
+	MaxImbalance float64
+	NoCorrupt    bool
+	NoDuplicates bool
+}
+
+// runCheck runs assertions on a dataset, an images folder of label folders or a project file, and exits with
+// ExitCheckFailed when any fails, as a gate before training.
+//
+//	votter.exe check [--min-per-label 50] [--max-imbalance 10] [--no-corrupt] [--report check.json] <dataset>
+func runCheck(args []string) int {
+	flags := flag.NewFlagSet("check", flag.ExitOnError)
+	var checks Checks
+	flags.IntVar(&checks.MinPerLabel, "min-per-label", 0, "Fail labels with fewer images")
+	flags.IntVar(&checks.MinLabels, "min-labels", 0, "Fail datasets with fewer labels")
+	flags.Float64Var(&checks.MaxImbalance, "max-imbalance", 0, "Fail when the largest label has more than this many times the images of the smallest")
+	flags.BoolVar(&checks.NoCorrupt, "no-corrupt", false, "Fail images that can't be decoded")
+	flags.BoolVar(&checks.NoDuplicates, "no-duplicates", false, "Fail identical image files, by content")
+	reportFlag := flags.String("report", "", "Also write the JSON report to this file")
+	workersFlag := flags.Int("workers", runtime.NumCPU(), "Number of images decoded or hashed concurrently")
+	flags.Usage = func() {
+		fmt.Println("Usage: votter check [options] <path_to_images or annotation.json>")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return ExitInvalidArguments
+	}
+	if checks == (Checks{}) {
+		fmt.Println("Error: No checks given, see votter check -h")
+		return ExitInvalidArguments
+	}
+	dataset := flags.Arg(0)
+
+	images, code := checkImages(dataset)
+	if code != ExitSuccesful {
+		return code
+	}
+	report := checkDataset(dataset, images, checks, *workersFlag)
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		fmt.Println(err)
+		return ExitAnnotationsWriteFailed
+	}
+	fmt.Println(string(data))
+	if *reportFlag != "" {
+		if err := ioutil.WriteFile(*reportFlag, data, 0644); err != nil {
+			fmt.Println(err)
+			return ExitAnnotationsWriteFailed
+		}
+	}
+	if !report.Passed {
+		return ExitCheckFailed
+	}
+	return ExitSuccesful
+}
+
+// checkImages returns the labeled images of a dataset: the images of the label folders of a folder, or the assets
+// of a project file labeled by their label or first tag, relative paths resolved against the folder of the project.
+// Returns the exit code of failures.
+func checkImages(dataset string) ([]labeledImage, int) {
+	if isDirectory(dataset) {
+		ctx, stop := interruptContext()
+		defer stop()
+		labels, err := findImages(ctx, dataset, runtime.NumCPU())
+		if err != nil {
+			fmt.Println(err)
+			return nil, ExitImagesFolderEmpty
+		}
+		return labeledImages(dataset, labels), ExitSuccesful
+	}
+	model, err := readVottJSON(dataset)
+	if err != nil {
+		fmt.Println(err)
+		return nil, ExitAnnotationsNotReadable
+	}
+	var images []labeledImage
+	for _, id := range sortedAssetIDs(model) {
+		detail := model.Assets[id]
+		label := detail.Asset.Label
+		if label == "" && len(detail.Regions) > 0 && len(detail.Regions[0].Tags) > 0 {
+			label = detail.Regions[0].Tags[0]
+		}
+		images = append(images, labeledImage{Path: assetFilePathIn(detail.Asset, filepath.Dir(dataset)), Label: label})
+	}
+	return images, ExitSuccesful
+}
+
+// checkDataset runs the checks on the images of a dataset.
+func checkDataset(dataset string, images []labeledImage, checks Checks, workers int) CheckReport {
+	report := CheckReport{Dataset: dataset, Images: len(images), Labels: make(map[string]int), Passed: true}
+	for _, image := range images {
+		report.Labels[image.Label]++
+	}
+	labels := make([]string, 0, len(report.Labels))
+	for label := range report.Labels {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+
+	add := func(result CheckResult) {
+		result.Passed = len(result.Failures) == 0
+		report.Passed = report.Passed && result.Passed
+		report.Checks = append(report.Checks, result)
+	}
+
+	if checks.MinLabels > 0 {
+		result := CheckResult{Name: "min-labels", Message: fmt.Sprintf("%d labels, at least %d expected", len(labels), checks.MinLabels)}
+		if len(labels) < checks.MinLabels {
+			result.Failures = []string{result.Message}
+		}
+		add(result)
+	}
+	if checks.MinPerLabel > 0 {
+		result := CheckResult{Name: "min-per-label", Message: fmt.Sprintf("at least %d images per label expected", checks.MinPerLabel)}
+		for _, label := range labels {
+			if report.Labels[label] < checks.MinPerLabel {
+				result.Failures = append(result.Failures, fmt.Sprintf("%s: %d images", label, report.Labels[label]))
+			}
+		}
+		add(result)
+	}
+	if checks.MaxImbalance > 0 && len(labels) > 0 {
+		largest, smallest := labels[0], labels[0]
+		for _, label := range labels {
+			if report.Labels[label] > report.Labels[largest] {
+				largest = label
+			}
+			if report.Labels[label] < report.Labels[smallest] {
+				smallest = label
+			}
+		}
+		ratio := float64(report.Labels[largest]) / float64(report.Labels[smallest])
+		result := CheckResult{Name: "max-imbalance", Message: fmt.Sprintf("largest label %s has %.1f times the images of smallest label %s, at most %g expected", largest, ratio, smallest, checks.MaxImbalance)}
+		if ratio > checks.MaxImbalance {
+			result.Failures = []string{fmt.Sprintf("%s: %d images, %s: %d images", largest, report.Labels[largest], smallest, report.Labels[smallest])}
+		}
+		add(result)
+	}
+	if checks.NoCorrupt {
+		result := CheckResult{Name: "no-corrupt", Message: "every image decodes"}
+		for i, err := range checkEachImage(images, workers, func(path string) (string, error) {
+			_, err := decodeImageFile(path)
+			return "", err
+		}).errors {
+			if err != nil {
+				result.Failures = append(result.Failures, fmt.Sprintf("%s: %v", images[i].Path, err))
+			}
+		}
+		add(result)
+	}
+	if checks.NoDuplicates {
+		// Images that can't be read have no hash and are left to --no-corrupt.
+		result := CheckResult{Name: "no-duplicates", Message: "no image is there twice, by content"}
+		first := make(map[string]int)
+		for i, hash := range checkEachImage(images, workers, fileSHA256).values {
+			if hash == "" {
+				continue
+			}
+			if j, seen := first[hash]; seen {
+				result.Failures = append(result.Failures, fmt.Sprintf("%s: same as %s", images[i].Path, images[j].Path))
+				continue
+			}
+			first[hash] = i
+		}
+		add(result)
+	}
+	return report
+}
+
+// checkResults are the values and errors of a function run on every image, in the order of the images.
+type checkResults struct {
+	values []string
+	errors []error
+}
+
+// checkEachImage runs check on the path of every image, with workers at once.
+func checkEachImage(images []labeledImage, workers int, check func(path string) (string, error)) checkResults {
+	results := checkResults{values: make([]string, len(images)), errors: make([]error, len(images))}
+	work := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < max(workers, 1); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				results.values[i], results.errors[i] = check(images[i].Path)
+			}
+		}()
+	}
+	for i := range images {
+		work <- i
+	}
+	close(work)
+	wg.Wait()
+	return results
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_CheckDataset(t *testing.T) {
+	root := t.TempDir()
+	for i, name := range []string{"a.jpg", "b.jpg", "c.jpg"} {
+		os.MkdirAll(filepath.Join(root, "cat"), 0755)
+		writeTestImage(t, filepath.Join(root, "cat", name), 10+i, 10)
+	}
+	os.MkdirAll(filepath.Join(root, "dog"), 0755)
+	writeTestImage(t, filepath.Join(root, "dog", "a.jpg"), 20, 20)
+	images := []labeledImage{
+		{Path: filepath.Join(root, "cat", "a.jpg"), Label: "cat"},
+		{Path: filepath.Join(root, "cat", "b.jpg"), Label: "cat"},
+		{Path: filepath.Join(root, "cat", "c.jpg"), Label: "cat"},
+		{Path: filepath.Join(root, "dog", "a.jpg"), Label: "dog"},
+	}
+
+	report := checkDataset(root, images, Checks{MinPerLabel: 1, MaxImbalance: 3, NoCorrupt: true, NoDuplicates: true}, 2)
+	if !report.Passed || len(report.Checks) != 4 || report.Labels["cat"] != 3 || report.Labels["dog"] != 1 {
+		t.Fatalf("Expected all checks to pass, got %+v", report)
+	}
+
+	report = checkDataset(root, images, Checks{MinPerLabel: 2, MaxImbalance: 2}, 2)
+	if report.Passed || report.Checks[0].Passed || report.Checks[1].Passed {
+		t.Fatalf("Expected min-per-label and max-imbalance to fail, got %+v", report)
+	}
+	if len(report.Checks[0].Failures) != 1 || report.Checks[0].Failures[0] != "dog: 1 images" {
+		t.Errorf("Expected dog to fail min-per-label, got %v", report.Checks[0].Failures)
+	}
+}
+
+func Test_CheckDatasetCorruptAndDuplicates(t *testing.T) {
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "cat"), 0755)
+	writeTestImage(t, filepath.Join(root, "cat", "a.jpg"), 10, 10)
+	data, _ := ioutil.ReadFile(filepath.Join(root, "cat", "a.jpg"))
+	ioutil.WriteFile(filepath.Join(root, "cat", "copy.jpg"), data, 0644)
+	ioutil.WriteFile(filepath.Join(root, "cat", "broken.jpg"), []byte("not an image"), 0644)
+	images := []labeledImage{
+		{Path: filepath.Join(root, "cat", "a.jpg"), Label: "cat"},
+		{Path: filepath.Join(root, "cat", "broken.jpg"), Label: "cat"},
+		{Path: filepath.Join(root, "cat", "copy.jpg"), Label: "cat"},
+	}
+
+	report := checkDataset(root, images, Checks{NoCorrupt: true, NoDuplicates: true}, 2)
+	if report.Passed {
+		t.Fatalf("Expected the checks to fail, got %+v", report)
+	}
+	if len(report.Checks[0].Failures) != 1 || len(report.Checks[1].Failures) != 1 {
+		t.Errorf("Expected one corrupt image and one duplicate, got %+v", report.Checks)
+	}
+}
+
+func Test_RunCheck(t *testing.T) {
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "cat"), 0755)
+	writeTestImage(t, filepath.Join(root, "cat", "a.jpg"), 10, 10)
+	reportPath := filepath.Join(t.TempDir(), "check.json")
+
+	if code := runCheck([]string{"--min-per-label", "1", "--report", reportPath, root}); code != ExitSuccesful {
+		t.Errorf("Expected the check to pass, got exit code %d", code)
+	}
+	if _, err := os.Stat(reportPath); err != nil {
+		t.Errorf("Expected the report to be written: %v", err)
+	}
+	if code := runCheck([]string{"--min-per-label", "2", root}); code != ExitCheckFailed {
+		t.Errorf("Expected exit code %d, got %d", ExitCheckFailed, code)
+	}
+	if code := runCheck([]string{root}); code != ExitInvalidArguments {
+		t.Errorf("Expected no checks to be invalid, got %d", code)
+	}
+
+	// A project with relative paths, as --collect writes them, is checked from any folder.
+	project := filepath.Join(root, "annotations.json")
+	model := buildVottModel([]Asset{{ID: "a1", Name: "a.jpg", Path: "cat/a.jpg", Label: "cat"}}, []string{"cat"})
+	if err := writeVottModelAs(project, model, FormatJSON); err != nil {
+		t.Fatal(err)
+	}
+	if code := runCheck([]string{"--no-corrupt", project}); code != ExitSuccesful {
+		t.Errorf("Expected the images of the project to be found, got exit code %d", code)
+	}
+}
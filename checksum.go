@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+)
+
+// addChecksums records the SHA-256 of every asset image in its SHA256 field, hashing with workers in parallel.
+func addChecksums(assets []Asset, workers int) error {
+	hashes, err := hashAssets(assets, workers)
+	if err != nil {
+		return err
+	}
+	for i, hash := range hashes {
+		assets[i].SHA256 = hash
+	}
+	return nil
+}
+
+// hashAssets returns the SHA-256 of every asset image, hashing with workers in parallel.
+func hashAssets(assets []Asset, workers int) ([]string, error) {
+	hashes := make([]string, len(assets))
+	errs := make([]error, len(assets))
+	next := make(chan int)
+	var waitGroup sync.WaitGroup
+	for w := 0; w < max(1, workers); w++ {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			for i := range next {
+				hashes[i], errs[i] = fileSHA256(assetFilePath(assets[i]))
+			}
+		}()
+	}
+	for i := range assets {
+		next <- i
+	}
+	close(next)
+	waitGroup.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("Error: Cannot hash image '%s': %v", assetFilePath(assets[i]), err)
+		}
+	}
+	return hashes, nil
+}
+
+// verifyChecksum returns what is wrong with the contents of an asset image at path compared to its recorded SHA-256,
+// or an empty string if nothing is or no checksum was recorded.
+func verifyChecksum(asset Asset, path string) string {
+	if asset.SHA256 == "" {
+		return ""
+	}
+	sum, err := fileSHA256(path)
+	if err != nil {
+		return err.Error()
+	}
+	if sum != asset.SHA256 {
+		return fmt.Sprintf("SHA-256 is %s, recorded %s", sum, asset.SHA256)
+	}
+	return ""
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_Checksums(t *testing.T) {
+	rootDir := t.TempDir()
+	imgPath := filepath.Join(rootDir, "image1.jpg")
+	writeTestImage(t, imgPath, 10, 10)
+
+	assets := []Asset{{ID: "id1", Path: "file:" + filepath.ToSlash(imgPath)}}
+	if err := addChecksums(assets, 2); err != nil {
+		t.Fatal(err)
+	}
+	if len(assets[0].SHA256) != 64 {
+		t.Fatalf("Expected a SHA-256, found '%s'", assets[0].SHA256)
+	}
+
+	model := VottJsonModel{Assets: map[string]AssetDetail{"id1": {Asset: assets[0]}}}
+	if problems := verifyAssets(model, "", false, true); len(problems) != 0 {
+		t.Errorf("Expected the unchanged image to verify, found %v", problems)
+	}
+
+	writeTestImage(t, imgPath, 12, 10)
+	problems := verifyAssets(model, "", false, true)
+	if len(problems) != 1 || !strings.Contains(problems[0], "SHA-256") {
+		t.Errorf("Expected the changed image to be reported, found %v", problems)
+	}
+	if problems := verifyAssets(model, "", false, false); len(problems) != 0 {
+		t.Errorf("Expected checksums to be checked only when asked, found %v", problems)
+	}
+
+	os.Remove(imgPath)
+	if err := addChecksums(assets, 1); err == nil {
+		t.Error("Expected an error for a missing image")
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+)
+
+// readClassMap reads a JSON class map of source label to target label. A target may also list its sources:
+//
+//	{"siamese": "cat", "persian": "cat"}
+//	{"cat": ["siamese", "persian"]}
+func readClassMap(path string) (map[string]string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var entries map[string]interface{}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("Error: Cannot read class map '%s': %v", path, err)
+	}
+
+	classMap := make(map[string]string)
+	for key, value := range entries {
+		switch value := value.(type) {
+		case string:
+			classMap[key] = value
+		case []interface{}:
+			for _, source := range value {
+				name, ok := source.(string)
+				if !ok {
+					return nil, fmt.Errorf("Error: Class map '%s' lists a source of '%s' that is not a label", path, key)
+				}
+				classMap[name] = key
+			}
+		default:
+			return nil, fmt.Errorf("Error: Class map '%s' maps '%s' to something that is not a label or list of labels", path, key)
+		}
+	}
+	return classMap, nil
+}
+
+// remapImages relabels images by the class map. Labels that aren't in the map stay as they are.
+func remapImages(images []labeledImage, classMap map[string]string) []labeledImage {
+	for i, image := range images {
+		if target, ok := classMap[image.Label]; ok {
+			images[i].Label = target
+		}
+	}
+	return images
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_ReadClassMap(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "remap.json")
+	if err := os.WriteFile(path, []byte(`{"siamese": "cat", "dog": ["beagle", "pug"]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	classMap, err := readClassMap(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if classMap["siamese"] != "cat" || classMap["beagle"] != "dog" || classMap["pug"] != "dog" || len(classMap) != 3 {
+		t.Errorf("Expected siamese to cat and beagle and pug to dog, found %v", classMap)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"cat": 1}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readClassMap(path); err == nil {
+		t.Error("Expected an error for a target that is not a label")
+	}
+}
+
+func Test_RemapImages(t *testing.T) {
+	images := []labeledImage{{Path: "siamese/a.jpg", Label: "siamese"}, {Path: "bird/b.jpg", Label: "bird"}}
+	images = remapImages(images, map[string]string{"siamese": "cat"})
+	if images[0].Label != "cat" || images[1].Label != "bird" {
+		t.Errorf("Expected siamese remapped to cat and bird kept, found %v", images)
+	}
+}
@@ -0,0 +1,374 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"votter/mod/votter"
+)
+
+// FormatCOCO is the --format of COCO object detection JSON.
+const FormatCOCO = "coco"
+
+type cocoDataset struct {
+	Images      []cocoImage      `json:"images"`
+	Annotations []cocoAnnotation `json:"annotations"`
+	Categories  []cocoCategory   `json:"categories"`
+}
+
+type cocoImage struct {
+	ID       int    `json:"id"`
+	FileName string `json:"file_name"`
+	Width    int    `json:"width"`
+	Height   int    `json:"height"`
+}
+
+type cocoAnnotation struct {
+	ID           int         `json:"id"`
+	ImageID      int         `json:"image_id"`
+	CategoryID   int         `json:"category_id"`
+	BBox         []float64   `json:"bbox"`
+	Area         float64     `json:"area"`
+	Segmentation [][]float64 `json:"segmentation,omitempty"`
+	IsCrowd      int         `json:"iscrowd"`
+	Score        *float64    `json:"score,omitempty"`
+	Keypoints    []float64   `json:"keypoints,omitempty"`
+	NumKeypoints *int        `json:"num_keypoints,omitempty"`
+	// Attributes as CVAT writes them into COCO, like occluded.
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+}
+
+type cocoCategory struct {
+	ID            int      `json:"id"`
+	Name          string   `json:"name"`
+	Supercategory string   `json:"supercategory,omitempty"`
+	Keypoints     []string `json:"keypoints,omitempty"`
+}
+
+// cocoInputAnnotation is a COCO annotation as other tools write it, with fractional coordinates and RLE masks for crowds.
+type cocoInputAnnotation struct {
+	ImageID      int                    `json:"image_id"`
+	CategoryID   int                    `json:"category_id"`
+	BBox         [4]float64             `json:"bbox"`
+	Segmentation json.RawMessage        `json:"segmentation"`
+	Score        *float64               `json:"score"`
+	Keypoints    []float64              `json:"keypoints"`
+	Attributes   map[string]interface{} `json:"attributes"`
+}
+
+// cocoRegion is a region of an imported annotation whose tag and keypoint names wait for the categories, which may
+// come after the annotations.
+type cocoRegion struct {
+	category int
+	region   Region
+}
+
+func init() {
+	votter.RegisterExporter(FormatCOCO, exportCOCO)
+	votter.RegisterExporter(FormatCOCORotated, exportCOCORotated)
+	// COCO files end in .json like VoTT projects, convert tells them apart by content.
+	votter.RegisterStreamImporter(FormatCOCO, nil, decodeCOCO)
+}
+
+// exportCOCO encodes a project as COCO: an image per asset, a category per tag, and an annotation per tag of
+// every region, with the points of polygons as segmentation. IDs count from 1 in the order of the asset IDs.
+// Keypoints of regions become COCO keypoints, in the order of the keypoint names of their category. The parent of a
+// tag in --tag-hierarchy is the supercategory of its category.
+func exportCOCO(model VottJsonModel) ([]byte, error) {
+	return encodeCOCO(model, false, projectTagHierarchy(model.Tags))
+}
+
+// exportCOCORotated encodes a project as COCO with rotated boxes, Detectron2's XYWHA: the bbox of an annotation is
+// center, width, height and angle in degrees counter-clockwise. Regions without a rotated box have angle 0.
+func exportCOCORotated(model VottJsonModel) ([]byte, error) {
+	return encodeCOCO(model, true, projectTagHierarchy(model.Tags))
+}
+
+// encodeCOCO encodes a project as COCO, with rotated boxes or axis aligned ones, and the parents of the hierarchy as
+// supercategories.
+func encodeCOCO(model VottJsonModel, rotated bool, hierarchy map[string]string) ([]byte, error) {
+	keypointNames := make(map[string][]string)
+	for _, id := range sortedAssetIDs(model) {
+		for _, region := range model.Assets[id].Regions {
+			for _, tag := range region.Tags {
+				for _, keypoint := range region.Keypoints {
+					keypointNames[tag] = mergeTags(keypointNames[tag], []string{keypoint.Name})
+				}
+			}
+		}
+	}
+
+	dataset := cocoDataset{Images: []cocoImage{}, Annotations: []cocoAnnotation{}, Categories: []cocoCategory{}}
+	categories := make(map[string]int)
+	category := func(tag string) int {
+		if id, ok := categories[tag]; ok {
+			return id
+		}
+		categories[tag] = len(categories) + 1
+		dataset.Categories = append(dataset.Categories, cocoCategory{ID: categories[tag], Name: tag, Supercategory: hierarchy[tag], Keypoints: keypointNames[tag]})
+		return categories[tag]
+	}
+	for _, tag := range model.Tags {
+		category(tag.Name)
+	}
+
+	for i, id := range sortedAssetIDs(model) {
+		detail := model.Assets[id]
+		image := cocoImage{ID: i + 1, FileName: fileURIPath(detail.Asset.Path), Width: detail.Asset.Size.Width, Height: detail.Asset.Size.Height}
+		dataset.Images = append(dataset.Images, image)
+
+		for _, region := range detail.Regions {
+			box := region.BoundingBox
+			bbox, area := []float64{box.Left, box.Top, box.Width, box.Height}, box.Width*box.Height
+			if rotated {
+				oriented := OrientedBox{CX: box.Left + box.Width/2, CY: box.Top + box.Height/2, Width: box.Width, Height: box.Height}
+				if region.OrientedBox != nil {
+					oriented = *region.OrientedBox
+				}
+				bbox, area = []float64{oriented.CX, oriented.CY, oriented.Width, oriented.Height, -oriented.Angle}, oriented.Width*oriented.Height
+			}
+			var segmentation [][]float64
+			if region.Type == "POLYGON" && len(region.Points) > 2 {
+				var polygon []float64
+				for _, point := range region.Points {
+					polygon = append(polygon, point.X, point.Y)
+				}
+				segmentation = [][]float64{polygon}
+			}
+			for _, tag := range region.Tags {
+				annotation := cocoAnnotation{
+					ID:           len(dataset.Annotations) + 1,
+					ImageID:      image.ID,
+					CategoryID:   category(tag),
+					BBox:         bbox,
+					Area:         area,
+					Segmentation: segmentation,
+					Score:        region.Confidence,
+					Attributes:   region.Attributes,
+				}
+				if len(region.Keypoints) > 0 {
+					annotation.Keypoints, annotation.NumKeypoints = cocoKeypoints(region.Keypoints, keypointNames[tag])
+				}
+				dataset.Annotations = append(dataset.Annotations, annotation)
+			}
+		}
+	}
+	return json.MarshalIndent(dataset, "", "  ")
+}
+
+// cocoKeypoints flattens keypoints into COCO's x, y, visibility triplets in the order of names, with zeros for
+// the names the region has no keypoint for. Returns them with the number of labeled keypoints.
+func cocoKeypoints(keypoints []Keypoint, names []string) ([]float64, *int) {
+	byName := make(map[string]Keypoint)
+	for _, keypoint := range keypoints {
+		byName[keypoint.Name] = keypoint
+	}
+	flat := make([]float64, 0, 3*len(names))
+	labeled := 0
+	for _, name := range names {
+		keypoint := byName[name]
+		if keypoint.Visibility != KeypointUnlabeled {
+			labeled++
+		}
+		flat = append(flat, keypoint.X, keypoint.Y, float64(keypoint.Visibility))
+	}
+	return flat, &labeled
+}
+
+// importCOCO reads a COCO dataset into a project: an asset per image labeled with the category of its first
+// annotation, and a region per annotation. Polygons become POLYGON regions of their first part, RLE masks keep
+// just their box.
+func importCOCO(data []byte) (VottJsonModel, error) {
+	return decodeCOCO(bytes.NewReader(data))
+}
+
+// decodeCOCO reads a COCO dataset like importCOCO, decoding one image, annotation and category at a time, so instances
+// files of gigabytes take the memory of the project rather than of the document as well. Other top-level fields are
+// skipped without decoding them.
+func decodeCOCO(reader io.Reader) (VottJsonModel, error) {
+	decoder := json.NewDecoder(bufio.NewReaderSize(reader, 1<<20))
+	if err := expectJSONDelim(decoder, '{'); err != nil {
+		return VottJsonModel{}, err
+	}
+
+	var images []cocoImage
+	var categories []cocoCategory
+	regions := make(map[int][]cocoRegion)
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return VottJsonModel{}, err
+		}
+		switch token {
+		case "images":
+			err = decodeJSONArray(decoder, func() error {
+				var image cocoImage
+				err := decoder.Decode(&image)
+				images = append(images, image)
+				return err
+			})
+		case "categories":
+			err = decodeJSONArray(decoder, func() error {
+				var category cocoCategory
+				err := decoder.Decode(&category)
+				categories = append(categories, category)
+				return err
+			})
+		case "annotations":
+			err = decodeJSONArray(decoder, func() error {
+				var annotation cocoInputAnnotation
+				if err := decoder.Decode(&annotation); err != nil {
+					return err
+				}
+				regions[annotation.ImageID] = append(regions[annotation.ImageID], cocoAnnotationRegion(annotation))
+				return nil
+			})
+		default:
+			err = skipJSONValue(decoder)
+		}
+		if err != nil {
+			return VottJsonModel{}, err
+		}
+	}
+
+	byID := make(map[int]cocoCategory)
+	var tags []string
+	hierarchy := make(map[string]string)
+	for _, category := range categories {
+		byID[category.ID] = category
+		tags = append(tags, category.Name)
+		// Datasets with a flat list of categories often give them all the same supercategory, or their own name.
+		if category.Supercategory != "" && category.Supercategory != category.Name {
+			hierarchy[category.Name] = category.Supercategory
+		}
+	}
+
+	var assets []Asset
+	for _, image := range images {
+		name := path.Base(strings.ReplaceAll(image.FileName, "\\", "/"))
+		asset := Asset{
+			Format:  strings.TrimPrefix(path.Ext(name), "."),
+			ID:      uuid.New().String(),
+			Name:    name,
+			Path:    "file:" + strings.ReplaceAll(image.FileName, "\\", "/"),
+			Size:    Size{Width: image.Width, Height: image.Height},
+			Regions: []Region{},
+		}
+		for _, imported := range regions[image.ID] {
+			category, ok := byID[imported.category]
+			if !ok {
+				return VottJsonModel{}, fmt.Errorf("Error: COCO annotation of image %d has unknown category %d", image.ID, imported.category)
+			}
+			region := imported.region
+			region.Tags = []string{category.Name}
+			for i := range region.Keypoints {
+				// Keypoints of categories without names are named by their position from 1.
+				if i < len(category.Keypoints) {
+					region.Keypoints[i].Name = category.Keypoints[i]
+				}
+			}
+			asset.Regions = append(asset.Regions, region)
+		}
+		// Images without annotations stay without regions rather than getting a full image region.
+		if len(asset.Regions) > 0 {
+			asset.Label = asset.Regions[0].Tags[0]
+		}
+		assets = append(assets, asset)
+	}
+	model := buildVottModel(assets, tags)
+	applyTagHierarchy(&model, hierarchy)
+	return model, nil
+}
+
+// cocoAnnotationRegion returns the region of an annotation, without its tag and with its keypoints named by position.
+func cocoAnnotationRegion(annotation cocoInputAnnotation) cocoRegion {
+	box := BoundingBox{Left: annotation.BBox[0], Top: annotation.BBox[1], Width: annotation.BBox[2], Height: annotation.BBox[3]}
+	region := Region{
+		ID:          uuid.New().String(),
+		Type:        "RECTANGLE",
+		BoundingBox: box,
+		Points:      []Point{{X: box.Left, Y: box.Top}, {X: box.Left + box.Width, Y: box.Top + box.Height}},
+		Confidence:  annotation.Score,
+		Attributes:  annotation.Attributes,
+	}
+	for i := 0; i+2 < len(annotation.Keypoints); i += 3 {
+		region.Keypoints = append(region.Keypoints, Keypoint{
+			Name: fmt.Sprint(i/3 + 1), X: annotation.Keypoints[i], Y: annotation.Keypoints[i+1], Visibility: int(annotation.Keypoints[i+2]),
+		})
+	}
+	var polygons [][]float64
+	if json.Unmarshal(annotation.Segmentation, &polygons) == nil && len(polygons) > 0 && len(polygons[0]) >= 6 {
+		region.Type = "POLYGON"
+		region.Points = nil
+		for i := 0; i+1 < len(polygons[0]); i += 2 {
+			region.Points = append(region.Points, Point{X: polygons[0][i], Y: polygons[0][i+1]})
+		}
+	}
+	return cocoRegion{category: annotation.CategoryID, region: region}
+}
+
+// expectJSONDelim reads the next token of decoder, which must be delim.
+func expectJSONDelim(decoder *json.Decoder, delim json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if token != delim {
+		return fmt.Errorf("expected %v, found %v", delim, token)
+	}
+	return nil
+}
+
+// decodeJSONArray calls decode for every element of the array that is the next value of decoder. null is an empty array.
+func decodeJSONArray(decoder *json.Decoder, decode func() error) error {
+	token, err := decoder.Token()
+	if err != nil || token == nil {
+		return err
+	}
+	if token != json.Delim('[') {
+		return fmt.Errorf("expected an array, found %v", token)
+	}
+	for decoder.More() {
+		if err := decode(); err != nil {
+			return err
+		}
+	}
+	_, err = decoder.Token()
+	return err
+}
+
+// skipJSONValue reads past the next value of decoder a token at a time, without holding it in memory.
+func skipJSONValue(decoder *json.Decoder) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if token != json.Delim('{') && token != json.Delim('[') {
+		return nil
+	}
+	return skipJSONRest(decoder)
+}
+
+// skipJSONRest reads past the rest of the object or array whose opening delimiter decoder has just read.
+func skipJSONRest(decoder *json.Decoder) error {
+	for depth := 1; depth > 0; {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		switch token {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+	}
+	return nil
+}
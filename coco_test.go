@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_ExportCOCO(t *testing.T) {
+	confidence := 0.8
+	assets := []Asset{{
+		Format: "jpg",
+		ID:     "a1",
+		Name:   "image1.jpg",
+		Path:   "file:/data/cat/image1.jpg",
+		Size:   Size{Width: 40, Height: 30},
+		Label:  "cat",
+		Regions: []Region{
+			{ID: "r1", Type: "RECTANGLE", Tags: []string{"cat"}, BoundingBox: BoundingBox{Left: 1, Top: 2, Width: 10, Height: 5}, Attributes: map[string]interface{}{"occluded": true}},
+			{ID: "r2", Type: "POLYGON", Tags: []string{"dog"}, BoundingBox: BoundingBox{Left: 0, Top: 0, Width: 4, Height: 4}, Points: []Point{{X: 0, Y: 0}, {X: 4, Y: 0}, {X: 4, Y: 4}}, Confidence: &confidence},
+		},
+	}}
+	data, err := exportCOCO(buildVottModel(assets, []string{"cat"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var dataset cocoDataset
+	if err := json.Unmarshal(data, &dataset); err != nil {
+		t.Fatal(err)
+	}
+	if len(dataset.Images) != 1 || dataset.Images[0].FileName != "/data/cat/image1.jpg" || dataset.Images[0].Width != 40 {
+		t.Errorf("Expected an image per asset, found %+v", dataset.Images)
+	}
+	if len(dataset.Categories) != 2 || dataset.Categories[0].Name != "cat" || dataset.Categories[1].Name != "dog" {
+		t.Errorf("Expected the project tags, then tags only on regions, found %+v", dataset.Categories)
+	}
+	if len(dataset.Annotations) != 2 {
+		t.Fatalf("Expected an annotation per region tag, found %+v", dataset.Annotations)
+	}
+	rectangle, polygon := dataset.Annotations[0], dataset.Annotations[1]
+	if !reflect.DeepEqual(rectangle.BBox, []float64{1, 2, 10, 5}) || rectangle.Area != 50 || rectangle.Segmentation != nil {
+		t.Errorf("Expected the box as x, y, width, height, found %+v", rectangle)
+	}
+	if rectangle.Attributes["occluded"] != true || polygon.Attributes != nil {
+		t.Errorf("Expected the region attributes, found %+v and %+v", rectangle.Attributes, polygon.Attributes)
+	}
+	if polygon.CategoryID != 2 || len(polygon.Segmentation) != 1 || len(polygon.Segmentation[0]) != 6 || polygon.Score == nil || *polygon.Score != 0.8 {
+		t.Errorf("Expected the polygon points as segmentation with the score, found %+v", polygon)
+	}
+}
+
+func Test_DecodeCOCO(t *testing.T) {
+	// Categories after the annotations, and fields the importer doesn't read, as Objects365 has them.
+	document := `{"info": {"year": 2019, "contributors": ["a", {"b": [1, 2]}]},
+		"images": [{"id": 1, "file_name": "images/v1/cat.jpg", "width": 40, "height": 30}, {"id": 2, "file_name": "empty.jpg", "width": 8, "height": 6}],
+		"licenses": null,
+		"annotations": [{"id": 7, "image_id": 1, "category_id": 3, "bbox": [1.5, 2, 10, 5], "iscrowd": 0, "area": 50}],
+		"categories": [{"id": 3, "name": "cat", "supercategory": "animal"}]}`
+	model, err := decodeCOCO(strings.NewReader(document))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(model.Assets) != 2 || len(model.Tags) != 1 || model.Tags[0].Name != "cat" {
+		t.Fatalf("Expected two assets and the cat tag, found %+v", model)
+	}
+	for _, detail := range model.Assets {
+		if detail.Asset.Name == "cat.jpg" {
+			if len(detail.Regions) != 1 || detail.Regions[0].BoundingBox.Left != 1.5 || !reflect.DeepEqual(detail.Regions[0].Tags, []string{"cat"}) {
+				t.Errorf("Expected the annotation as a cat region, found %+v", detail.Regions)
+			}
+		} else if len(detail.Regions) != 0 {
+			t.Errorf("Expected no regions for the image without annotations, found %+v", detail.Regions)
+		}
+	}
+
+	unknown := `{"images": [{"id": 1, "file_name": "a.jpg"}], "annotations": [{"image_id": 1, "category_id": 9}], "categories": []}`
+	if _, err := decodeCOCO(strings.NewReader(unknown)); err == nil {
+		t.Error("Expected an annotation of an unknown category to fail")
+	}
+	if _, err := decodeCOCO(strings.NewReader(`{"images": [{"id": 1}`)); err == nil {
+		t.Error("Expected a truncated file to fail")
+	}
+}
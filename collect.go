@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// validCollectMode checks the value of the --collect-mode flag.
+func validCollectMode(mode string) error {
+	if mode != "copy" && mode != "hardlink" && mode != "symlink" {
+		return fmt.Errorf("Error: Unknown collect mode '%s', expected copy, hardlink or symlink", mode)
+	}
+	return nil
+}
+
+// validCollectLayout checks the value of the --collect-layout flag.
+func validCollectLayout(layout string) error {
+	if layout != "" && layout != "label" && layout != "hash" {
+		return fmt.Errorf("Error: Unknown collect layout '%s', expected label or hash", layout)
+	}
+	return nil
+}
+
+// collectAssets places every asset image into outDir/<label>/<name> by copy, hardlink or symlink, and returns the
+// assets with paths relative to outDir, so the folder with the annotations file in it opens anywhere.
+// Assets sharing an image share its copy, images with the same name in a label folder get their asset ID in the name.
+// Files of an earlier run are replaced, so collecting again gives the same bundle.
+// The "hash" layout stores images content-addressed as outDir/ab/cd/abcdef....jpg instead, one copy per distinct content.
+func collectAssets(assets []Asset, outDir string, mode string, layout string) ([]Asset, error) {
+	root, err := filepath.Abs(outDir)
+	if err != nil {
+		return nil, err
+	}
+
+	// The name of every image in its label folder, by its source.
+	var sources, names, ids []string
+	seen := make(map[string]bool)
+	for _, asset := range assets {
+		source := assetFilePath(asset)
+		if !seen[source] {
+			seen[source] = true
+			sources = append(sources, source)
+			names = append(names, filepath.Join(asset.Label, filepath.Base(source)))
+			ids = append(ids, asset.ID)
+		}
+	}
+	names = uniqueNames(names, ids)
+	labelNames := make(map[string]string)
+	for i, source := range sources {
+		labelNames[source] = names[i]
+	}
+
+	collected := make(map[string]string)
+	for i, asset := range assets {
+		source := assetFilePath(asset)
+		target, ok := collected[source]
+		if !ok {
+			if target, err = collectImage(source, labelNames[source], root, mode, layout); err != nil {
+				return nil, err
+			}
+			collected[source] = target
+		}
+
+		relative, err := filepath.Rel(root, target)
+		if err != nil {
+			return nil, err
+		}
+		assets[i].Name = filepath.Base(target)
+		assets[i].Path = escapeURIPath(filepath.ToSlash(relative), strings.Contains(asset.Path, "%"))
+	}
+	return assets, nil
+}
+
+// collectImage places the image at source in the layout below root and returns where it went, at name in the label
+// layout.
+func collectImage(source string, name string, root string, mode string, layout string) (string, error) {
+	target := filepath.Join(root, name)
+	if layout == "hash" {
+		sum, err := fileSHA256(source)
+		if err != nil {
+			return "", err
+		}
+		target = filepath.Join(root, sum[:2], sum[2:4], sum+strings.ToLower(filepath.Ext(source)))
+		// The same content is stored once.
+		if _, err := os.Lstat(target); err == nil {
+			return target, nil
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return "", err
+	}
+	if err := replaceFile(source, target, mode); err != nil {
+		return "", fmt.Errorf("Error: Cannot collect image '%s': %v", source, err)
+	}
+	return target, nil
+}
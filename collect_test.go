@@ -0,0 +1,93 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_CollectAssets(t *testing.T) {
+	rootDir := t.TempDir()
+	for _, dir := range []string{"a", "b"} {
+		os.MkdirAll(filepath.Join(rootDir, dir), 0755)
+		writeTestImage(t, filepath.Join(rootDir, dir, "image1.jpg"), 10, 10)
+	}
+	assets := []Asset{
+		{ID: "id1", Name: "image1.jpg", Path: "file:" + filepath.ToSlash(filepath.Join(rootDir, "a", "image1.jpg")), Label: "cat"},
+		{ID: "id2", Name: "image1.jpg", Path: "file:" + filepath.ToSlash(filepath.Join(rootDir, "b", "image1.jpg")), Label: "cat"},
+		{ID: "id3", Name: "image1.jpg", Path: "file:" + filepath.ToSlash(filepath.Join(rootDir, "a", "image1.jpg")), Label: "cat"},
+	}
+
+	bundle := filepath.Join(rootDir, "bundle")
+	collected, err := collectAssets(assets, bundle, "hardlink", "label")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"cat/image1_id1.jpg", "cat/image1_id2.jpg", "cat/image1_id1.jpg"}
+	for i, asset := range collected {
+		if asset.Path != expected[i] {
+			t.Errorf("Expected path '%s', found '%s'", expected[i], asset.Path)
+		}
+		if _, err := os.Stat(filepath.Join(bundle, filepath.FromSlash(asset.Path))); err != nil {
+			t.Errorf("Expected collected image: %v", err)
+		}
+	}
+	if collected[1].Name != "image1_id2.jpg" {
+		t.Errorf("Expected the name of the renamed copy, found '%s'", collected[1].Name)
+	}
+
+	// Collecting again replaces the bundle's images rather than adding numbered copies.
+	assets[0].Path = "file:" + filepath.ToSlash(filepath.Join(rootDir, "a", "image1.jpg"))
+	assets[1].Path = "file:" + filepath.ToSlash(filepath.Join(rootDir, "b", "image1.jpg"))
+	assets[2].Path = assets[0].Path
+	if _, err := collectAssets(assets, bundle, "copy", "label"); err != nil {
+		t.Fatal(err)
+	}
+	entries, _ := os.ReadDir(filepath.Join(bundle, "cat"))
+	if len(entries) != 2 {
+		t.Errorf("Expected the same 2 images after collecting again, found %d", len(entries))
+	}
+}
+
+func Test_CollectAssetsByHash(t *testing.T) {
+	rootDir := t.TempDir()
+	for _, name := range []string{"frame1.jpg", "frame2.jpg"} {
+		writeTestImage(t, filepath.Join(rootDir, name), 10, 10)
+	}
+	assets := []Asset{
+		{ID: "id1", Name: "frame1.jpg", Path: "file:" + filepath.ToSlash(filepath.Join(rootDir, "frame1.jpg")), Label: "cat"},
+		{ID: "id2", Name: "frame2.jpg", Path: "file:" + filepath.ToSlash(filepath.Join(rootDir, "frame2.jpg")), Label: "dog"},
+	}
+	sum, err := fileSHA256(filepath.Join(rootDir, "frame1.jpg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bundle := filepath.Join(rootDir, "bundle")
+	collected, err := collectAssets(assets, bundle, "copy", "hash")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := sum[:2] + "/" + sum[2:4] + "/" + sum + ".jpg"
+	for _, asset := range collected {
+		if asset.Path != expected {
+			t.Errorf("Expected identical frames at '%s', found '%s'", expected, asset.Path)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(bundle, filepath.FromSlash(expected))); err != nil {
+		t.Errorf("Expected stored image: %v", err)
+	}
+}
+
+func Test_ValidCollectMode(t *testing.T) {
+	for _, mode := range []string{"copy", "hardlink", "symlink"} {
+		if err := validCollectMode(mode); err != nil {
+			t.Error(err)
+		}
+	}
+	if err := validCollectMode("move"); err == nil {
+		t.Error("Expected an error for mode 'move'")
+	}
+}
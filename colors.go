@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"image"
+)
+
+// Values of --color-strategy, how project tags are colored.
+const (
+	ColorStrategyFixed    = "fixed"
+	ColorStrategyDominant = "dominant"
+)
+
+// DominantColorSamples is how many images of a tag are sampled for its color.
+const DominantColorSamples = 5
+
+// dominantColorPixels is about how many pixels of each sample image are counted, evenly spread.
+const dominantColorPixels = 4096
+
+func validColorStrategy(strategy string) error {
+	if strategy != ColorStrategyFixed && strategy != ColorStrategyDominant {
+		return fmt.Errorf("Error: Unknown color strategy '%s', expected fixed or dominant", strategy)
+	}
+	return nil
+}
+
+// colorBucket sums the pixels of a range of similar colors.
+type colorBucket struct {
+	r, g, b, count int
+}
+
+// dominantTagColors returns the dominant color of the sample images of every label as #rrggbb: the average of the
+// most common range of colors, 4 bits per channel, over up to DominantColorSamples images of the label.
+// Images that can't be decoded are left out, labels without any readable image get no color.
+func dominantTagColors(assets []Asset, labels []string) map[string]string {
+	samples := make(map[string][]Asset)
+	for _, asset := range assets {
+		if len(samples[asset.Label]) < DominantColorSamples {
+			samples[asset.Label] = append(samples[asset.Label], asset)
+		}
+	}
+
+	colors := make(map[string]string)
+	for _, label := range labels {
+		buckets := make(map[int]*colorBucket)
+		for _, asset := range samples[label] {
+			img, err := decodeImageFile(assetFilePath(asset))
+			if err != nil {
+				continue
+			}
+			countColors(img, buckets)
+		}
+		var dominant *colorBucket
+		for _, bucket := range buckets {
+			if dominant == nil || bucket.count > dominant.count {
+				dominant = bucket
+			}
+		}
+		if dominant != nil {
+			colors[label] = fmt.Sprintf("#%02x%02x%02x", dominant.r/dominant.count, dominant.g/dominant.count, dominant.b/dominant.count)
+		}
+	}
+	return colors
+}
+
+// countColors adds evenly spread pixels of img to the buckets of their 4 bit per channel color.
+func countColors(img image.Image, buckets map[int]*colorBucket) {
+	bounds := img.Bounds()
+	step := 1
+	for bounds.Dx()*bounds.Dy()/(step*step) > dominantColorPixels {
+		step++
+	}
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += step {
+		for x := bounds.Min.X; x < bounds.Max.X; x += step {
+			r, g, b, _ := img.At(x, y).RGBA()
+			r, g, b = r>>8, g>>8, b>>8
+			key := int(r>>4)<<8 | int(g>>4)<<4 | int(b>>4)
+			bucket, ok := buckets[key]
+			if !ok {
+				bucket = &colorBucket{}
+				buckets[key] = bucket
+			}
+			bucket.r += int(r)
+			bucket.g += int(g)
+			bucket.b += int(b)
+			bucket.count++
+		}
+	}
+}
+
+// applyTagColors sets the colors of the project tags that have one.
+func applyTagColors(project *VottJsonModel, colors map[string]string) {
+	for i, tag := range project.Tags {
+		if color, ok := colors[tag.Name]; ok {
+			project.Tags[i].Color = color
+		}
+	}
+}
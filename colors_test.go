@@ -0,0 +1,56 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_DominantTagColors(t *testing.T) {
+	dir := t.TempDir()
+	// Mostly green with a blue stripe.
+	img := image.NewRGBA(image.Rect(0, 0, 100, 100))
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 100; x++ {
+			c := color.RGBA{G: 200, A: 255}
+			if x < 30 {
+				c = color.RGBA{B: 255, A: 255}
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+	path := filepath.Join(dir, "frog.png")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := png.Encode(file, img); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	assets := []Asset{
+		{Label: "frog", Path: "file:" + filepath.ToSlash(path)},
+		{Label: "ghost", Path: "file:" + filepath.ToSlash(filepath.Join(dir, "missing.png"))},
+	}
+	colors := dominantTagColors(assets, []string{"frog", "ghost"})
+	if colors["frog"] != "#00c800" {
+		t.Errorf("Expected the dominant green, found %s", colors["frog"])
+	}
+	if _, ok := colors["ghost"]; ok {
+		t.Errorf("Expected no color for a label without readable images, found %s", colors["ghost"])
+	}
+
+	project := buildVottModel(nil, []string{"frog", "ghost"})
+	applyTagColors(&project, colors)
+	if project.Tags[0].Color != "#00c800" || project.Tags[1].Color != "#ff0000" {
+		t.Errorf("Expected the dominant color and the fixed red, found %+v", project.Tags)
+	}
+
+	if err := validColorStrategy("rainbow"); err == nil {
+		t.Error("Expected an error for an unknown color strategy")
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFileDefault is read from the working directory when no config file is given and it exists.
+const ConfigFileDefault = "votter.yaml"
+
+// EnvPrefix starts the environment variables that set flags, -resize-dir is set by VOTTER_RESIZE_DIR.
+const EnvPrefix = "VOTTER_"
+
+// Settings that aren't flags: the positional arguments for the images path and annotations file.
+const ConfigImages = "images"
+const ConfigOutput = "output"
+
+// ConfigProfiles holds the named credential profiles of the connectors, see loadProfiles.
+const ConfigProfiles = "profiles"
+
+// configFile returns the config file to read: path, else VOTTER_CONFIG, else votter.yaml in the working directory
+// if there is one. Returns "" for none.
+func configFile(path string) string {
+	if path == "" {
+		path = os.Getenv(EnvPrefix + "CONFIG")
+	}
+	if path == "" {
+		if _, err := os.Stat(ConfigFileDefault); err != nil {
+			return ""
+		}
+		path = ConfigFileDefault
+	}
+	return path
+}
+
+// loadConfig reads the settings of a YAML config file, keyed by flag name. Without a path it falls back to VOTTER_CONFIG,
+// then to votter.yaml in the working directory if there is one. Lists are joined with commas, as flags take them,
+// and maps become comma separated key:value pairs.
+func loadConfig(path string) (map[string]string, error) {
+	config := make(map[string]string)
+
+	if path = configFile(path); path == "" {
+		return config, nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var settings map[string]interface{}
+	if err := yaml.Unmarshal(data, &settings); err != nil {
+		return nil, fmt.Errorf("Error: Cannot read config file '%s': %v", path, err)
+	}
+
+	for name, value := range settings {
+		if name == ConfigProfiles {
+			continue
+		}
+		switch value := value.(type) {
+		case []interface{}:
+			var items []string
+			for _, item := range value {
+				items = append(items, fmt.Sprint(item))
+			}
+			config[name] = strings.Join(items, ",")
+		case map[string]interface{}:
+			var pairs []string
+			for key, item := range value {
+				if _, nested := item.(map[string]interface{}); nested {
+					return nil, fmt.Errorf("Error: Setting '%s' in config file '%s' nests too deep", name, path)
+				}
+				pairs = append(pairs, key+":"+fmt.Sprint(item))
+			}
+			sort.Strings(pairs)
+			config[name] = strings.Join(pairs, ",")
+		case nil:
+			config[name] = ""
+		default:
+			config[name] = fmt.Sprint(value)
+		}
+	}
+	return config, nil
+}
+
+// envName returns the environment variable for a flag or setting: resize-dir is VOTTER_RESIZE_DIR.
+func envName(name string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// configValue returns a setting from the config file, or else from its environment variable.
+func configValue(config map[string]string, name string) (string, bool) {
+	if value, ok := config[name]; ok {
+		return value, true
+	}
+	return os.LookupEnv(envName(name))
+}
+
+// applyConfig sets every flag not given on the command line from the config file, or else from its environment variable.
+// Precedence is environment < config file < command line.
+func applyConfig(flags *flag.FlagSet, config map[string]string) error {
+	for name := range config {
+		if name != ConfigImages && name != ConfigOutput && flags.Lookup(name) == nil {
+			return fmt.Errorf("Error: Unknown setting '%s' in config file", name)
+		}
+	}
+
+	given := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) {
+		given[f.Name] = true
+	})
+
+	var err error
+	flags.VisitAll(func(f *flag.Flag) {
+		if given[f.Name] || f.Name == "v" || f.Name == "h" || f.Name == "config" || err != nil {
+			return
+		}
+		if value, ok := configValue(config, f.Name); ok {
+			if setErr := f.Value.Set(value); setErr != nil {
+				err = fmt.Errorf("Error: Invalid value '%s' for setting '%s': %v", value, f.Name, setErr)
+			}
+		}
+	})
+	return err
+}
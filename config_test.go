@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_LoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "votter.yaml")
+	data := "images: ./dataset\nresize: 1024\naugment: [hflip, rot90]\ntag-hierarchy:\n  siamese: cat\n  cat: animal\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := loadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config["images"] != "./dataset" || config["resize"] != "1024" || config["augment"] != "hflip,rot90" || config["tag-hierarchy"] != "cat:animal,siamese:cat" {
+		t.Errorf("Expected settings as flag values, found %v", config)
+	}
+}
+
+func Test_ApplyConfig(t *testing.T) {
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	resize := flags.Int("resize", 0, "")
+	tileDir := flags.String("tile-dir", "", "")
+	augment := flags.String("augment", "", "")
+	if err := flags.Parse([]string{"-resize", "512"}); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("VOTTER_RESIZE", "256")
+	t.Setenv("VOTTER_TILE_DIR", "tiles-from-env")
+	t.Setenv("VOTTER_AUGMENT", "vflip")
+
+	if err := applyConfig(flags, map[string]string{"augment": "hflip"}); err != nil {
+		t.Fatal(err)
+	}
+	if *resize != 512 {
+		t.Errorf("Expected the command line to win, found resize %d", *resize)
+	}
+	if *augment != "hflip" {
+		t.Errorf("Expected the config file to win over the environment, found augment %s", *augment)
+	}
+	if *tileDir != "tiles-from-env" {
+		t.Errorf("Expected the environment to be used, found tile-dir %s", *tileDir)
+	}
+
+	if err := applyConfig(flags, map[string]string{"resise": "1"}); err == nil {
+		t.Error("Expected an error for an unknown setting")
+	}
+}
+
+func Test_EnvName(t *testing.T) {
+	if name := envName("resize-dir"); name != "VOTTER_RESIZE_DIR" {
+		t.Errorf("Expected VOTTER_RESIZE_DIR, found %s", name)
+	}
+}
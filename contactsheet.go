@@ -0,0 +1,202 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// ContactSheet is the grid of thumbnails of contact-sheets.
+type ContactSheet struct {
+	Columns  int
+	Thumb    int
+	PerSheet int
+}
+
+// contactSheetCaption is the height in pixels of the file name below each thumbnail.
+const contactSheetCaption = 16
+
+// runContactSheets writes the images of every label of a VoTT file as grids of thumbnails with their file names,
+// for reviewers to skim a class at a glance.
+//
+//	votter.exe contact-sheets [-columns 8] [-thumb 160] [-per-sheet 64] [-format png|pdf] <vott-annotations.json> <outputDirectory>
+func runContactSheets(args []string) int {
+	flags := flag.NewFlagSet("contact-sheets", flag.ExitOnError)
+	var sheet ContactSheet
+	flags.IntVar(&sheet.Columns, "columns", 8, "Thumbnails per row")
+	flags.IntVar(&sheet.Thumb, "thumb", 160, "Width and height in pixels the thumbnails fit in")
+	flags.IntVar(&sheet.PerSheet, "per-sheet", 64, "Thumbnails per sheet, a PNG file or PDF page each")
+	formatFlag := flags.String("format", "png", "Write a PNG per sheet with 'png', or a PDF per label with a page per sheet with 'pdf'")
+	flags.Usage = func() {
+		fmt.Println("Usage: votter contact-sheets [options] <vott-annotations.json> <outputDirectory>")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return ExitInvalidArguments
+	}
+	if *formatFlag != "png" && *formatFlag != "pdf" {
+		fmt.Printf("Error: Unknown contact sheet format '%s', expected png or pdf\n", *formatFlag)
+		return ExitInvalidArguments
+	}
+	if sheet.Columns < 1 || sheet.Thumb < 1 || sheet.PerSheet < 1 {
+		fmt.Println("Error: --columns, --thumb and --per-sheet must be at least 1")
+		return ExitInvalidArguments
+	}
+
+	model, err := readVottJSON(flags.Arg(0))
+	if err != nil {
+		fmt.Println(err)
+		return ExitAnnotationsNotReadable
+	}
+
+	files, err := writeContactSheets(model, filepath.Dir(flags.Arg(0)), flags.Arg(1), sheet, *formatFlag)
+	if err != nil {
+		fmt.Println(err)
+		return ExitImageWriteFailed
+	}
+
+	fmt.Printf("Wrote %d contact sheet files to '%s'.\n", files, flags.Arg(1))
+	return ExitSuccesful
+}
+
+// writeContactSheets renders the assets of each label, sorted by name, into sheets and writes them to outDir as
+// label-001.png, label-002.png, ... or as label.pdf. Relative asset paths are resolved against root, the folder of the
+// project. Returns the number of files written.
+func writeContactSheets(model VottJsonModel, root string, outDir string, sheet ContactSheet, format string) (int, error) {
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return 0, err
+	}
+
+	perLabel := make(map[string][]Asset)
+	for _, detail := range model.Assets {
+		label := detailLabel(detail)
+		perLabel[label] = append(perLabel[label], detail.Asset)
+	}
+	var labels []string
+	for label := range perLabel {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+
+	written := 0
+	for _, label := range labels {
+		assets := perLabel[label]
+		sort.Slice(assets, func(i, j int) bool { return assets[i].Name < assets[j].Name })
+
+		var pages []image.Image
+		for start := 0; start < len(assets); start += sheet.PerSheet {
+			page, err := sheet.render(assets[start:min(start+sheet.PerSheet, len(assets))], root)
+			if err != nil {
+				return written, err
+			}
+			if format == "pdf" {
+				pages = append(pages, page)
+				continue
+			}
+			if err := encodeImageFile(filepath.Join(outDir, fmt.Sprintf("%s-%03d.png", label, start/sheet.PerSheet+1)), page); err != nil {
+				return written, err
+			}
+			written++
+		}
+		if format == "pdf" {
+			if err := writeImagesPDF(filepath.Join(outDir, label+".pdf"), pages); err != nil {
+				return written, err
+			}
+			written++
+		}
+	}
+	return written, nil
+}
+
+// render draws the thumbnails of the assets of a project in root in a grid on white, each with its file name below.
+func (sheet ContactSheet) render(assets []Asset, root string) (image.Image, error) {
+	columns := min(sheet.Columns, len(assets))
+	rows := (len(assets) + columns - 1) / columns
+	cellWidth, cellHeight := sheet.Thumb+8, sheet.Thumb+8+contactSheetCaption
+	page := image.NewRGBA(image.Rect(0, 0, columns*cellWidth, rows*cellHeight))
+	draw.Draw(page, page.Bounds(), image.White, image.Point{}, draw.Src)
+
+	face := basicfont.Face7x13
+	for i, asset := range assets {
+		img, err := decodeImageFile(assetFilePathIn(asset, root))
+		if err != nil {
+			return nil, err
+		}
+		bounds := img.Bounds()
+		width, height := scaledSize(bounds.Dx(), bounds.Dy(), sheet.Thumb)
+		left := i%columns*cellWidth + 4 + (sheet.Thumb-width)/2
+		top := i/columns*cellHeight + 4 + (sheet.Thumb-height)/2
+		draw.ApproxBiLinear.Scale(page, image.Rect(left, top, left+width, top+height), img, bounds, draw.Src, nil)
+
+		// File names longer than the cell are cut short.
+		name := asset.Name
+		for runes := []rune(name); font.MeasureString(face, name).Ceil() > cellWidth-4 && len(runes) > 1; {
+			runes = runes[:len(runes)-1]
+			name = string(runes) + "..."
+		}
+		drawer := font.Drawer{
+			Dst:  page,
+			Src:  image.NewUniform(color.Black),
+			Face: face,
+			Dot:  fixed.P(i%columns*cellWidth+2, i/columns*cellHeight+sheet.Thumb+8+face.Metrics().Ascent.Ceil()),
+		}
+		drawer.DrawString(name)
+	}
+	return page, nil
+}
+
+// writeImagesPDF writes a PDF with each image as a JPEG filling a page of its size, a point per pixel.
+func writeImagesPDF(path string, pages []image.Image) error {
+	var pdf bytes.Buffer
+	var offsets []int
+	object := func(format string, args ...interface{}) {
+		offsets = append(offsets, pdf.Len())
+		fmt.Fprintf(&pdf, "%d 0 obj\n", len(offsets))
+		fmt.Fprintf(&pdf, format, args...)
+		pdf.WriteString("\nendobj\n")
+	}
+
+	pdf.WriteString("%PDF-1.4\n")
+	var kids []string
+	for i := range pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", 3+3*i))
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages))
+	for i, page := range pages {
+		var data bytes.Buffer
+		if err := jpeg.Encode(&data, page, &jpeg.Options{Quality: 85}); err != nil {
+			return err
+		}
+		width, height := page.Bounds().Dx(), page.Bounds().Dy()
+		content := fmt.Sprintf("q %d 0 0 %d 0 0 cm /Im0 Do Q", width, height)
+		object("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /XObject << /Im0 %d 0 R >> >> /Contents %d 0 R >>",
+			width, height, 4+3*i, 5+3*i)
+		object("<< /Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /DCTDecode /Length %d >>\nstream\n%s\nendstream",
+			width, height, data.Len(), data.Bytes())
+		object("<< /Length %d >>\nstream\n%s\nendstream", len(content), content)
+	}
+
+	xref := pdf.Len()
+	fmt.Fprintf(&pdf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&pdf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&pdf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return ioutil.WriteFile(path, pdf.Bytes(), 0644)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_WriteContactSheets(t *testing.T) {
+	dir := t.TempDir()
+	model := VottJsonModel{Assets: make(map[string]AssetDetail)}
+	for i := 0; i < 5; i++ {
+		name := fmt.Sprintf("image%d_with_a_long_file_name.jpg", i)
+		writeTestImage(t, filepath.Join(dir, name), 40+i*10, 30)
+		label, path := "cat", "file:"+filepath.ToSlash(filepath.Join(dir, name))
+		if i == 4 {
+			// A relative path, as --collect writes them, is in the folder of the project.
+			label, path = "dog", name
+		}
+		model.Assets[name] = AssetDetail{Asset: Asset{ID: name, Name: name, Path: path, Label: label}}
+	}
+	sheet := ContactSheet{Columns: 3, Thumb: 32, PerSheet: 3}
+
+	outDir := filepath.Join(dir, "sheets")
+	written, err := writeContactSheets(model, dir, outDir, sheet, "png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if written != 3 {
+		t.Errorf("Expected 2 cat sheets and 1 dog sheet, found %d", written)
+	}
+	file, err := os.Open(filepath.Join(outDir, "cat-002.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	config, _, err := image.DecodeConfig(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Width != 40 || config.Height != 32+8+contactSheetCaption {
+		t.Errorf("Expected a sheet of one thumbnail, found %dx%d", config.Width, config.Height)
+	}
+
+	pdfDir := filepath.Join(dir, "pdf")
+	if written, err = writeContactSheets(model, dir, pdfDir, sheet, "pdf"); err != nil || written != 2 {
+		t.Fatalf("Expected a PDF per label, found %d, %v", written, err)
+	}
+	data, err := os.ReadFile(filepath.Join(pdfDir, "cat.pdf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(data, []byte("%PDF-1.4")) || !bytes.Contains(data, []byte("/Count 2")) || !bytes.HasSuffix(data, []byte("%%EOF\n")) {
+		t.Error("Expected a PDF with a page per sheet")
+	}
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"votter/mod/votter"
+)
+
+// runConvert reads annotations in one format and writes them in another. Without --from the input format is detected
+// from the content, and without --to the output format is chosen by the output file's extension.
+//
+//	votter.exe convert [-from coco] [-to yaml] <input> <output>
+func runConvert(args []string) int {
+	flags := flag.NewFlagSet("convert", flag.ExitOnError)
+	fromFlag := flags.String("from", "", "Format of the input, detected from its content when not given: "+strings.Join(votter.ImportFormats(), ", "))
+	decimalsFlag := flags.Int("coordinate-decimals", -1, "Round region coordinates to this many decimals, 0 for whole pixels, -1 to keep them")
+	toFlag := flags.String("to", "", "Format of the output, by its extension when not given: "+strings.Join(votter.ExportFormats(), ", "))
+	hierarchyFlag := flags.String("tag-hierarchy", "", "Comma separated child:parent tag pairs, the supercategories of COCO, e.g. siamese:cat,cat:animal")
+	flags.Usage = func() {
+		fmt.Println("Usage: votter convert [options] <input> <output>")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return ExitInvalidArguments
+	}
+	input, output := flags.Arg(0), flags.Arg(1)
+
+	from := *fromFlag
+	if from == "" {
+		var err error
+		if from, err = sniffFormat(input); err != nil {
+			fmt.Println(err)
+			return ExitAnnotationsNotReadable
+		}
+		fmt.Printf("Reading '%s' as %s.\n", input, from)
+	}
+	importer, ok := votter.LookupImporter(from)
+	directoryImporter, directory := votter.LookupDirectoryImporter(from)
+	if !ok && !directory {
+		fmt.Printf("Error: votter can't read %s, it reads %s\n", from, strings.Join(votter.ImportFormats(), ", "))
+		return ExitInvalidArguments
+	}
+	to := *toFlag
+	if to == "" {
+		to = outputFormat(output)
+	}
+	if err := validFormat(to); err != nil {
+		fmt.Println(err)
+		return ExitInvalidArguments
+	}
+	hierarchy, err := parseTagHierarchy(*hierarchyFlag)
+	if err != nil {
+		fmt.Println(err)
+		return ExitInvalidArguments
+	}
+
+	var model VottJsonModel
+	if directory {
+		model, err = directoryImporter(input)
+	} else {
+		model, err = importFile(input, from, importer)
+	}
+	if err != nil {
+		fmt.Printf("Error: Cannot read '%s' as %s: %v\n", input, from, err)
+		return ExitAnnotationsNotReadable
+	}
+	for id, detail := range model.Assets {
+		for i := range detail.Regions {
+			detail.Regions[i] = roundRegion(detail.Regions[i], *decimalsFlag)
+		}
+		model.Assets[id] = detail
+	}
+	// Parents given replace those of the input, the others stay.
+	applyTagHierarchy(&model, hierarchy)
+	if err := writeVottModelAs(output, model, to); err != nil {
+		fmt.Println(err)
+		return ExitAnnotationsWriteFailed
+	}
+	fmt.Printf("Converted %d assets to %s in '%s'.\n", len(model.Assets), to, output)
+	return ExitSuccesful
+}
+
+// importFile reads the input of convert with the streaming importer of its format when there is one, without holding
+// the whole file in memory, and with importer otherwise.
+func importFile(path string, format string, importer votter.Importer) (VottJsonModel, error) {
+	stream, ok := votter.LookupStreamImporter(format)
+	if !ok {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return VottJsonModel{}, err
+		}
+		return importer(data)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return VottJsonModel{}, err
+	}
+	defer file.Close()
+	return stream(file)
+}
+
+// outputFormat picks the format of an output file by its extension, JSON when nothing else matches.
+func outputFormat(path string) string {
+	lower := strings.ToLower(path)
+	for format, ext := range formatExtensions {
+		if strings.HasSuffix(lower, ext) {
+			return format
+		}
+	}
+	if ext := filepath.Ext(lower); ext == ".yaml" || ext == ".yml" {
+		return FormatYAML
+	}
+	return FormatJSON
+}
+
+// sniffFormat detects the format of annotations from their content: VoTT projects in JSON or YAML, COCO JSON, CSV,
+// and directories of Pascal VOC XML or YOLO label files. Fails listing the candidates when more than one fits.
+func sniffFormat(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	var candidates []string
+	if info.IsDir() {
+		candidates, err = sniffDirectory(path)
+	} else {
+		candidates, err = sniffFile(path)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	switch len(candidates) {
+	case 0:
+		return "", fmt.Errorf("Error: Cannot tell the format of '%s', give it with --from", path)
+	case 1:
+		return candidates[0], nil
+	default:
+		return "", fmt.Errorf("Error: '%s' could be %s, choose one with --from", path, strings.Join(candidates, " or "))
+	}
+}
+
+// sniffFile returns the formats the content of a file fits. JSON objects are told apart by the kinds of their top-level
+// values, read a token at a time rather than loading files of gigabytes.
+func sniffFile(path string) ([]string, error) {
+	if document, err := sniffJSONKeys(path); err == nil {
+		return documentFormats(document, FormatJSON), nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var document map[string]interface{}
+	if json.Unmarshal(data, &document) == nil {
+		return documentFormats(document, FormatJSON), nil
+	}
+	if yaml.Unmarshal(data, &document) == nil && document != nil {
+		if formats := documentFormats(document, FormatYAML); len(formats) > 0 {
+			return formats, nil
+		}
+	}
+	if isCSV(data) {
+		return []string{FormatCSV}, nil
+	}
+	return nil, nil
+}
+
+// sniffJSONKeys returns the top-level keys of the JSON object in the file at path, each with an empty value of its kind
+// for documentFormats. Fails when the file isn't a JSON object.
+func sniffJSONKeys(path string) (map[string]interface{}, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	decoder := json.NewDecoder(bufio.NewReaderSize(file, 1<<20))
+	if err := expectJSONDelim(decoder, '{'); err != nil {
+		return nil, err
+	}
+	document := make(map[string]interface{})
+	for decoder.More() {
+		key, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		value, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch value {
+		case json.Delim('{'):
+			document[key.(string)] = map[string]interface{}{}
+		case json.Delim('['):
+			document[key.(string)] = []interface{}{}
+		default:
+			document[key.(string)] = value
+			continue
+		}
+		if err := skipJSONRest(decoder); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := decoder.Token(); err != nil {
+		return nil, err
+	}
+	// Anything after the object makes it something else than JSON.
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, fmt.Errorf("'%s' has more than a JSON object", path)
+	}
+	return document, nil
+}
+
+// documentFormats returns the formats a JSON or YAML document fits by its top-level keys, vott is reported as
+// the format the document is written in.
+func documentFormats(document map[string]interface{}, vott string) []string {
+	var formats []string
+	if _, ok := document["assets"].(map[string]interface{}); ok {
+		formats = append(formats, vott)
+	}
+	_, images := document["images"].([]interface{})
+	_, annotations := document["annotations"].([]interface{})
+	if images && annotations {
+		formats = append(formats, FormatCOCO)
+	}
+	return formats
+}
+
+// isCSV reports whether data starts with a header line and a row of the same number of comma separated fields.
+func isCSV(data []byte) bool {
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	var lines []string
+	for scanner.Scan() && len(lines) < 2 {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) < 2 {
+		return false
+	}
+	fields := strings.Count(lines[0], ",")
+	return fields > 0 && strings.Count(lines[1], ",") == fields
+}
+
+// sniffDirectory returns the formats of the annotation files in a directory and its subdirectories: Pascal VOC XML
+// or YOLO label files.
+func sniffDirectory(dir string) ([]string, error) {
+	var voc, yolo bool
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		switch strings.ToLower(filepath.Ext(entry.Name())) {
+		case ".xml":
+			if data, err := ioutil.ReadFile(path); err == nil && strings.Contains(string(data), "<annotation") {
+				voc = true
+			}
+		case ".txt":
+			if data, err := ioutil.ReadFile(path); err == nil {
+				line, _, _ := strings.Cut(strings.TrimSpace(string(data)), "\n")
+				yolo = yolo || yoloLine.MatchString(strings.TrimSpace(line))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	var formats []string
+	if voc {
+		formats = append(formats, FormatVOC)
+	}
+	if yolo {
+		formats = append(formats, FormatYOLO)
+	}
+	return formats, nil
+}
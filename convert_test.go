@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_SniffFormat(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, content string) string {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	cases := map[string]string{
+		write("vott.json", `{"name": "", "assets": {}}`):                                        FormatJSON,
+		write("vott.yaml", "name: pets\nassets: {}\n"):                                          FormatYAML,
+		write("coco.json", `{"images": [], "annotations": [], "categories": []}`):               FormatCOCO,
+		write("nested.json", `{"info": {"a": [1]}, "images": [{}], "annotations": [[]]}`):       FormatCOCO,
+		write("flow.yaml", "{name: pets, assets: {}}"):                                          FormatYAML,
+		write("boxes.csv", "image,label,xmin\na.jpg,cat,1\n"):                                   FormatCSV,
+		filepath.Dir(write("voc/a.xml", "<annotation><filename>a.jpg</filename></annotation>")): FormatVOC,
+		filepath.Dir(write("yolo/a.txt", "0 0.5 0.5 0.25 0.25\n")):                              FormatYOLO,
+	}
+	for path, expected := range cases {
+		if format, err := sniffFormat(path); err != nil || format != expected {
+			t.Errorf("Expected '%s' to be %s, found %s, %v", path, expected, format, err)
+		}
+	}
+
+	both := write("both.json", `{"assets": {}, "images": [], "annotations": []}`)
+	if _, err := sniffFormat(both); err == nil || !strings.Contains(err.Error(), "json or coco") {
+		t.Errorf("Expected the candidates on ambiguity, found %v", err)
+	}
+	if _, err := sniffFormat(write("notes.txt", "just some notes")); err == nil {
+		t.Error("Expected an unknown format to be rejected")
+	}
+}
+
+func Test_ConvertCOCO(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "instances.json")
+	coco := `{"images": [{"id": 7, "file_name": "cat/image1.jpg", "width": 40, "height": 30}],
+		"categories": [{"id": 3, "name": "cat"}],
+		"annotations": [{"image_id": 7, "category_id": 3, "bbox": [1.4, 2.6, 10, 5], "segmentation": [[0, 0, 4, 0, 4, 4]]}]}`
+	os.WriteFile(input, []byte(coco), 0644)
+	output := filepath.Join(dir, "annotations.yaml")
+
+	if code := runConvert([]string{input, output}); code != ExitSuccesful {
+		t.Fatalf("Expected the conversion to succeed, found exit code %d", code)
+	}
+	model, err := readVottJSON(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(model.Assets) != 1 || len(model.Tags) != 1 || model.Tags[0].Name != "cat" {
+		t.Fatalf("Expected an asset and the category as tag, found %+v", model)
+	}
+	for _, detail := range model.Assets {
+		if detail.Asset.Name != "image1.jpg" || detail.Asset.Label != "cat" || len(detail.Regions) != 1 {
+			t.Errorf("Expected the labeled image with its region, found %+v", detail)
+		}
+		region := detail.Regions[0]
+		if region.Type != "POLYGON" || len(region.Points) != 3 || region.BoundingBox != (BoundingBox{Left: 1.4, Top: 2.6, Width: 10, Height: 5}) {
+			t.Errorf("Expected the fractional box and the polygon, found %+v", region)
+		}
+	}
+}
+
+func Test_ConvertCOCOHierarchy(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "annotations.json")
+	assets := []Asset{{ID: "a1", Name: "image1.jpg", Path: "file:siamese/image1.jpg", Size: Size{Width: 40, Height: 30}, Label: "siamese",
+		Regions: []Region{{Tags: []string{"siamese"}, BoundingBox: BoundingBox{Width: 40, Height: 30}}}}}
+	model := buildVottModel(assets, []string{"siamese", "dog"})
+	applyTagHierarchy(&model, map[string]string{"siamese": "pet"})
+	if err := writeVottModelAs(input, model, FormatJSON); err != nil {
+		t.Fatal(err)
+	}
+	output := filepath.Join(dir, "instances.json")
+
+	if code := runConvert([]string{"-to", FormatCOCO, "-tag-hierarchy", "siamese:cat,cat:animal", input, output}); code != ExitSuccesful {
+		t.Fatalf("Expected the conversion to succeed, found exit code %d", code)
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dataset cocoDataset
+	if err := json.Unmarshal(data, &dataset); err != nil {
+		t.Fatal(err)
+	}
+	supercategories := make(map[string]string)
+	for _, category := range dataset.Categories {
+		supercategories[category.Name] = category.Supercategory
+	}
+	if !reflect.DeepEqual(supercategories, map[string]string{"siamese": "cat", "dog": ""}) {
+		t.Errorf("Expected the parent of the hierarchy as supercategory, found %v", supercategories)
+	}
+
+	back := filepath.Join(dir, "back.json")
+	if code := runConvert([]string{output, back}); code != ExitSuccesful {
+		t.Fatalf("Expected the conversion back to succeed, found exit code %d", code)
+	}
+	converted, err := readVottJSON(back)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hierarchy := projectTagHierarchy(converted.Tags); !reflect.DeepEqual(hierarchy, map[string]string{"siamese": "cat"}) {
+		t.Errorf("Expected the supercategory read back as parent, found %v", hierarchy)
+	}
+
+	if code := runConvert([]string{"-tag-hierarchy", "cat:cat", input, output}); code != ExitInvalidArguments {
+		t.Errorf("Expected a cyclic hierarchy to be rejected, found exit code %d", code)
+	}
+}
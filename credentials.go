@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CredentialProfile is a named set of credentials in the profiles of the config file, picked per connector with
+// --customvision-profile, --elasticsearch-profile, --mlflow-profile and --segment-profile.
+// Values may reference environment variables as ${NAME}, to keep secrets out of the file.
+type CredentialProfile struct {
+	Key       string `yaml:"key"`        // Custom Vision training key, or an API key sent as Authorization: ApiKey
+	Username  string `yaml:"username"`   // Basic authentication
+	Password  string `yaml:"password"`   //
+	Token     string `yaml:"token"`      // Bearer token
+	TokenFile string `yaml:"token-file"` // File holding the bearer token, like a service account token
+	SAS       string `yaml:"sas"`        // Shared access signature, a query string added to every request
+}
+
+// loadProfiles reads the credential profiles of the config file, see configFile for which file that is.
+func loadProfiles(path string) (map[string]CredentialProfile, error) {
+	profiles := make(map[string]CredentialProfile)
+	if path = configFile(path); path == "" {
+		return profiles, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var config struct {
+		Profiles map[string]CredentialProfile `yaml:"profiles"`
+	}
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("Error: Cannot read profiles in config file '%s': %v", path, err)
+	}
+	for name, profile := range config.Profiles {
+		profiles[name] = profile.expand()
+	}
+	return profiles, nil
+}
+
+// expand replaces the ${NAME} references in the values with the environment.
+func (profile CredentialProfile) expand() CredentialProfile {
+	for _, value := range []*string{&profile.Key, &profile.Username, &profile.Password, &profile.Token, &profile.TokenFile, &profile.SAS} {
+		*value = os.ExpandEnv(*value)
+	}
+	return profile
+}
+
+// findProfile returns the profile named by the --<connector>-profile flag.
+func findProfile(profiles map[string]CredentialProfile, connector string, name string) (CredentialProfile, error) {
+	profile, ok := profiles[name]
+	if !ok {
+		return profile, fmt.Errorf("Error: No profile '%s' in the config file for --%s-profile", name, connector)
+	}
+	if profile.TokenFile != "" {
+		data, err := ioutil.ReadFile(profile.TokenFile)
+		if err != nil {
+			return profile, fmt.Errorf("Error: Cannot read token file of profile '%s': %v", name, err)
+		}
+		profile.Token = strings.TrimSpace(string(data))
+	}
+	if profile.SAS != "" {
+		if _, err := url.ParseQuery(strings.TrimPrefix(profile.SAS, "?")); err != nil {
+			return profile, fmt.Errorf("Error: Invalid SAS token in profile '%s': %v", name, err)
+		}
+	}
+	return profile, nil
+}
+
+// client returns a client over base that adds the profile's credentials to every request.
+func (profile CredentialProfile) client(base *http.Client) *http.Client {
+	transport := base.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &http.Client{Transport: &credentialTransport{base: transport, profile: profile}, Timeout: base.Timeout}
+}
+
+// credentialTransport adds the credentials of a profile to requests.
+type credentialTransport struct {
+	base    http.RoundTripper
+	profile CredentialProfile
+}
+
+func (transport *credentialTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	profile := transport.profile
+	request = request.Clone(request.Context())
+	switch {
+	case profile.Username != "":
+		request.SetBasicAuth(profile.Username, profile.Password)
+	case profile.Token != "":
+		request.Header.Set("Authorization", "Bearer "+profile.Token)
+	case profile.Key != "":
+		request.Header.Set("Authorization", "ApiKey "+profile.Key)
+	}
+	if profile.SAS != "" {
+		query := request.URL.Query()
+		sas, _ := url.ParseQuery(strings.TrimPrefix(profile.SAS, "?"))
+		for name, values := range sas {
+			query[name] = values
+		}
+		request.URL.RawQuery = query.Encode()
+	}
+	return transport.base.RoundTrip(request)
+}
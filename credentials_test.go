@@ -0,0 +1,63 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_LoadProfiles(t *testing.T) {
+	dir := t.TempDir()
+	tokenFile := filepath.Join(dir, "token")
+	os.WriteFile(tokenFile, []byte("sa-token\n"), 0644)
+	path := filepath.Join(dir, "votter.yaml")
+	data := "resize: 512\nprofiles:\n  lab:\n    username: elastic\n    password: ${TEST_ES_PASSWORD}\n  cluster:\n    token-file: " + tokenFile + "\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TEST_ES_PASSWORD", "secret")
+
+	config, err := loadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := config[ConfigProfiles]; ok || config["resize"] != "512" {
+		t.Errorf("Expected the profiles to be left out of the settings, found %v", config)
+	}
+
+	profiles, err := loadProfiles(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profiles["lab"].Password != "secret" {
+		t.Errorf("Expected the password from the environment, found %+v", profiles["lab"])
+	}
+	cluster, err := findProfile(profiles, "mlflow", "cluster")
+	if err != nil || cluster.Token != "sa-token" {
+		t.Errorf("Expected the token from the token file, found %+v, %v", cluster, err)
+	}
+	if _, err := findProfile(profiles, "mlflow", "missing"); err == nil {
+		t.Error("Expected an unknown profile to be rejected")
+	}
+}
+
+func Test_CredentialProfileClient(t *testing.T) {
+	var authorization, signature string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		signature = r.URL.Query().Get("sig")
+	}))
+	defer server.Close()
+
+	client := CredentialProfile{Token: "abc", SAS: "?sv=2022-11-02&sig=xyz"}.client(http.DefaultClient)
+	response, err := client.Get(server.URL + "/images?page=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if authorization != "Bearer abc" || signature != "xyz" {
+		t.Errorf("Expected the bearer token and SAS, found '%s' and '%s'", authorization, signature)
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"image/draw"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// runExtractCrops writes every region of a VoTT file as a cropped image into a folder per tag.
+//
+//	votter.exe extract-crops <vott-annotations.json> <outputDirectory>
+func runExtractCrops(args []string) int {
+	flags := flag.NewFlagSet("extract-crops", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Println("Usage: votter extract-crops <vott-annotations.json> <outputDirectory>")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return ExitInvalidArguments
+	}
+
+	model, err := readVottJSON(flags.Arg(0))
+	if err != nil {
+		fmt.Println(err)
+		return ExitAnnotationsNotReadable
+	}
+
+	crops, err := extractCrops(model, filepath.Dir(flags.Arg(0)), flags.Arg(1))
+	if err != nil {
+		fmt.Println(err)
+		return ExitImageWriteFailed
+	}
+
+	fmt.Printf("Wrote %d crops to '%s'.\n", crops, flags.Arg(1))
+	return ExitSuccesful
+}
+
+// extractCrops writes the bounding box of every region as an image to outDir/tag/, once for each tag of the region.
+// Crops are named by the image name, asset ID and region index, so images of the same name in different folders don't
+// overwrite each other's crops. Relative asset paths are resolved against root, the folder of the project. Returns the
+// number of images written.
+func extractCrops(model VottJsonModel, root string, outDir string) (int, error) {
+	written := 0
+
+	for _, detail := range model.Assets {
+		if len(detail.Regions) == 0 {
+			continue
+		}
+
+		img, err := decodeImageFile(assetFilePathIn(detail.Asset, root))
+		if err != nil {
+			return written, err
+		}
+
+		for i, region := range detail.Regions {
+			crop := cropRegion(img, region)
+			if crop == nil {
+				continue
+			}
+
+			ext := filepath.Ext(detail.Asset.Name)
+			name := encodedName(fmt.Sprintf("%s_%s_%d%s", strings.TrimSuffix(detail.Asset.Name, ext), detail.Asset.ID, i, ext))
+			for _, tag := range region.Tags {
+				tagDir := filepath.Join(outDir, tagDirName(tag))
+				if err := os.MkdirAll(tagDir, 0755); err != nil {
+					return written, err
+				}
+				if err := encodeImageFile(filepath.Join(tagDir, name), crop); err != nil {
+					return written, err
+				}
+				written++
+			}
+		}
+	}
+
+	return written, nil
+}
+
+// tagDirName returns the name of the folder of a tag's crops, with path separators replaced so a tag like cat/dog or
+// .. stays a single folder inside the output directory.
+func tagDirName(tag string) string {
+	name := strings.NewReplacer("/", "_", "\\", "_").Replace(strings.TrimSpace(tag))
+	if name == "" || name == "." || name == ".." {
+		return "_" + name
+	}
+	return name
+}
+
+// cropRegion returns the part of img inside the bounding box of region, or nil if the box lies outside the image.
+func cropRegion(img image.Image, region Region) image.Image {
+	box := region.BoundingBox
+	bounds := img.Bounds()
+	rect := box.Rect().Add(bounds.Min).Intersect(bounds)
+	if rect.Empty() {
+		return nil
+	}
+	crop := image.NewRGBA(image.Rect(0, 0, rect.Dx(), rect.Dy()))
+	draw.Draw(crop, crop.Bounds(), img, rect.Min, draw.Src)
+	return crop
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"image"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_CropRegion(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 100, 50))
+
+	crop := cropRegion(img, Region{BoundingBox: BoundingBox{Left: 80, Top: 10, Width: 40, Height: 20}})
+	if crop == nil || crop.Bounds().Dx() != 20 || crop.Bounds().Dy() != 20 {
+		t.Errorf("Expected a 20x20 crop clipped to the image, found %v", crop)
+	}
+
+	if crop := cropRegion(img, Region{BoundingBox: BoundingBox{Left: 200, Top: 10, Width: 40, Height: 20}}); crop != nil {
+		t.Errorf("Expected no crop outside the image, found %v", crop.Bounds())
+	}
+}
+
+func Test_ExtractCrops(t *testing.T) {
+	rootDir := t.TempDir()
+	imgPath := filepath.Join(rootDir, "image1.jpg")
+	writeTestImage(t, imgPath, 60, 40)
+
+	model := VottJsonModel{Assets: map[string]AssetDetail{
+		"id1": {
+			Asset: Asset{ID: "id1", Name: "image1.jpg", Path: "file:" + filepath.ToSlash(imgPath), Size: Size{Width: 60, Height: 40}},
+			Regions: []Region{
+				{Tags: []string{"cat"}, BoundingBox: BoundingBox{Left: 0, Top: 0, Width: 30, Height: 20}},
+				{Tags: []string{"dog", "pet"}, BoundingBox: BoundingBox{Left: 30, Top: 20, Width: 30, Height: 20}},
+			},
+		},
+	}}
+
+	outDir := filepath.Join(rootDir, "crops")
+	written, err := extractCrops(model, rootDir, outDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if written != 3 {
+		t.Errorf("Expected 3 crops, found %d", written)
+	}
+
+	crop, err := decodeImageFile(filepath.Join(outDir, "dog", "image1_id1_1.jpg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if crop.Bounds().Dx() != 30 || crop.Bounds().Dy() != 20 {
+		t.Errorf("Expected a 30x20 crop, found %v", crop.Bounds())
+	}
+}
+
+func Test_ExtractCropsSameNames(t *testing.T) {
+	rootDir := t.TempDir()
+	model := VottJsonModel{Assets: map[string]AssetDetail{}}
+	for _, label := range []string{"a", "b"} {
+		os.MkdirAll(filepath.Join(rootDir, label), 0755)
+		writeTestImage(t, filepath.Join(rootDir, label, "image1.jpg"), 20, 20)
+		// Relative paths, as --collect writes them, are in the folder of the project.
+		asset := Asset{ID: assetID(label), Name: "image1.jpg", Path: label + "/image1.jpg", Size: Size{Width: 20, Height: 20}}
+		model.Assets[asset.ID] = AssetDetail{Asset: asset, Regions: []Region{{Tags: []string{"../cat"}, BoundingBox: BoundingBox{Width: 10, Height: 10}}}}
+	}
+
+	outDir := filepath.Join(rootDir, "crops")
+	if _, err := extractCrops(model, rootDir, outDir); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := os.ReadDir(filepath.Join(outDir, ".._cat"))
+	if err != nil || len(entries) != 2 {
+		t.Errorf("Expected both crops in the tag folder inside the output, found %v: %v", entries, err)
+	}
+	if _, err := os.Stat(filepath.Join(rootDir, "cat")); !os.IsNotExist(err) {
+		t.Errorf("Expected nothing written outside the output, found %v", err)
+	}
+}
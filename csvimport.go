@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"votter/mod/votter"
+)
+
+// FormatCSV is the format of CSV files of boxes, a row per box like the TensorFlow Object Detection API's
+//
+//	filename,width,height,class,xmin,ymin,xmax,ymax
+//	cat/image1.jpg,40,30,cat,1,2,11,7
+const FormatCSV = "csv"
+
+// csvColumns are the names a column of the CSV importer goes by in the header row, in any case.
+var csvColumns = map[string][]string{
+	"image":  {"filename", "image", "path", "file", "image_path"},
+	"label":  {"class", "label", "tag"},
+	"width":  {"width", "image_width"},
+	"height": {"height", "image_height"},
+	"xmin":   {"xmin", "x_min"},
+	"ymin":   {"ymin", "y_min"},
+	"xmax":   {"xmax", "x_max"},
+	"ymax":   {"ymax", "y_max"},
+}
+
+func init() {
+	// CSV files hold other things than boxes too, convert tells them apart by content.
+	votter.RegisterImporter(FormatCSV, nil, importCSV)
+}
+
+// importCSV reads a CSV file of boxes with a header row naming its columns: an asset per image, in the order of their
+// first row, and a rectangle per row with a box. Rows without the box columns label the whole image. Only the image
+// column is required.
+func importCSV(data []byte) (VottJsonModel, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return VottJsonModel{}, err
+	}
+	if len(records) == 0 {
+		return VottJsonModel{}, fmt.Errorf("Error: The CSV has no header row")
+	}
+
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		for column, names := range csvColumns {
+			for _, candidate := range names {
+				if _, seen := columns[column]; !seen && strings.EqualFold(strings.TrimSpace(name), candidate) {
+					columns[column] = i
+				}
+			}
+		}
+	}
+	if _, ok := columns["image"]; !ok {
+		return VottJsonModel{}, fmt.Errorf("Error: The CSV has no image column, expected one of %s", strings.Join(csvColumns["image"], ", "))
+	}
+	_, boxes := columns["xmin"]
+	for _, column := range []string{"ymin", "xmax", "ymax"} {
+		if _, ok := columns[column]; ok != boxes {
+			return VottJsonModel{}, fmt.Errorf("Error: The CSV has some of the box columns xmin, ymin, xmax and ymax, it needs all of them or none")
+		}
+	}
+
+	var assets []Asset
+	var tags []string
+	index := make(map[string]int)
+	for n, record := range records[1:] {
+		value := func(column string) string {
+			if i, ok := columns[column]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		number := func(column string) float64 {
+			if v, parseErr := strconv.ParseFloat(value(column), 64); parseErr == nil {
+				return v
+			} else if err == nil {
+				err = fmt.Errorf("Error: Row %d of the CSV has no number for %s: %v", n+2, column, parseErr)
+			}
+			return 0
+		}
+
+		imagePath := strings.ReplaceAll(value("image"), "\\", "/")
+		if imagePath == "" {
+			continue
+		}
+		i, seen := index[imagePath]
+		if !seen {
+			i = len(assets)
+			index[imagePath] = i
+			assets = append(assets, Asset{
+				Format: strings.TrimPrefix(path.Ext(imagePath), "."),
+				ID:     assetID(imagePath),
+				Name:   path.Base(imagePath),
+				Path:   "file:" + imagePath,
+				Label:  value("label"),
+			})
+			if value("width") != "" || value("height") != "" {
+				assets[i].Size = Size{Width: int(number("width")), Height: int(number("height"))}
+			}
+		}
+		label := value("label")
+		if label != "" {
+			tags = mergeTags(tags, []string{label})
+		}
+		if !boxes {
+			continue
+		}
+		left, top, right, bottom := number("xmin"), number("ymin"), number("xmax"), number("ymax")
+		if err != nil {
+			return VottJsonModel{}, err
+		}
+		region := Region{
+			ID:          uuid.New().String(),
+			Type:        "RECTANGLE",
+			Tags:        []string{},
+			BoundingBox: BoundingBox{Left: left, Top: top, Width: right - left, Height: bottom - top},
+			Points:      []Point{{X: left, Y: top}, {X: right, Y: bottom}},
+		}
+		if label != "" {
+			region.Tags = []string{label}
+		}
+		assets[i].Regions = append(assets[i].Regions, region)
+	}
+	if err != nil {
+		return VottJsonModel{}, err
+	}
+	return buildVottModel(assets, tags), nil
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"testing"
+)
+
+func Test_ImportCSV(t *testing.T) {
+	model, err := importCSV([]byte("filename,width,height,class,xmin,ymin,xmax,ymax\n" +
+		"cat/image1.jpg,40,30,cat,1,2,11,7\n" +
+		"cat/image1.jpg,40,30,dog,20,10,30,20\n" +
+		"dog/image2.jpg,10,10,dog,0,0,10,10\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(model.Assets) != 2 || len(model.Tags) != 2 || model.Tags[0].Name != "cat" {
+		t.Fatalf("Expected two assets and the tags in order, found %+v", model)
+	}
+	detail := model.Assets[assetID("cat/image1.jpg")]
+	if detail.Asset.Label != "cat" || detail.Asset.Size != (Size{Width: 40, Height: 30}) || len(detail.Regions) != 2 {
+		t.Errorf("Expected the image with its two boxes, found %+v", detail)
+	}
+	if box := detail.Regions[1].BoundingBox; box != (BoundingBox{Left: 20, Top: 10, Width: 10, Height: 10}) || detail.Regions[1].Tags[0] != "dog" {
+		t.Errorf("Expected the box of the dog, found %+v", detail.Regions[1])
+	}
+
+	// Without boxes the label is the whole image's.
+	model, err = importCSV([]byte("image,label\na.jpg,cat\n"))
+	if err != nil || len(model.Assets) != 1 || model.Assets[assetID("a.jpg")].Regions[0].Tags[0] != "cat" {
+		t.Errorf("Expected the image labeled cat, found %+v: %v", model.Assets, err)
+	}
+
+	for _, data := range []string{"label\ncat\n", "image,xmin\na.jpg,1\n", "image,xmin,ymin,xmax,ymax\na.jpg,one,2,3,4\n"} {
+		if _, err := importCSV([]byte(data)); err == nil {
+			t.Errorf("Expected %q to be rejected", data)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// CustomVisionBatchSize is the most images the Custom Vision training API takes in one upload.
+const CustomVisionBatchSize = 64
+
+// CustomVision is the Custom Vision project the images and regions are uploaded to with --push-customvision.
+type CustomVision struct {
+	Endpoint string // https://westeurope.api.cognitive.microsoft.com
+	Project  string
+	Key      string
+	Client   *http.Client
+}
+
+type customVisionTag struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type customVisionRegion struct {
+	TagID  string  `json:"tagId"`
+	Left   float64 `json:"left"`
+	Top    float64 `json:"top"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+type customVisionImage struct {
+	Name     string               `json:"name"`
+	Contents []byte               `json:"contents"`
+	TagIDs   []string             `json:"tagIds,omitempty"`
+	Regions  []customVisionRegion `json:"regions,omitempty"`
+}
+
+type customVisionBatch struct {
+	Images []customVisionImage `json:"images"`
+}
+
+type customVisionBatchResult struct {
+	IsBatchSuccessful bool `json:"isBatchSuccessful"`
+	Images            []struct {
+		SourceURL string `json:"sourceUrl"`
+		Status    string `json:"status"`
+	} `json:"images"`
+}
+
+// validate checks the project settings are complete.
+func (cv CustomVision) validate() error {
+	if cv.Endpoint == "" || cv.Project == "" || cv.Key == "" {
+		return fmt.Errorf("Error: --push-customvision needs --customvision-endpoint, --customvision-project and --customvision-key")
+	}
+	return nil
+}
+
+// push creates the missing tags in the project and uploads the assets' images with their regions, in batches.
+// Returns the number of images uploaded.
+func (cv CustomVision) push(assets []Asset, tags []string) (int, error) {
+	tagIDs, err := cv.ensureTags(tags)
+	if err != nil {
+		return 0, err
+	}
+
+	uploaded := 0
+	for start := 0; start < len(assets); start += CustomVisionBatchSize {
+		end := min(start+CustomVisionBatchSize, len(assets))
+		var batch customVisionBatch
+		for _, asset := range assets[start:end] {
+			image, err := cv.image(asset, tagIDs)
+			if err != nil {
+				return uploaded, err
+			}
+			batch.Images = append(batch.Images, image)
+		}
+
+		var result customVisionBatchResult
+		if err := cv.call("POST", "images/files", batch, &result); err != nil {
+			return uploaded, err
+		}
+		for _, image := range result.Images {
+			if image.Status != "OK" && image.Status != "OKDuplicate" {
+				return uploaded, fmt.Errorf("Error: Custom Vision rejected image '%s': %s", image.SourceURL, image.Status)
+			}
+		}
+		uploaded += end - start
+		fmt.Printf("Uploaded %d of %d images to Custom Vision.\n", uploaded, len(assets))
+	}
+	return uploaded, nil
+}
+
+// ensureTags returns the IDs of the tags by name, creating the ones the project doesn't have yet.
+func (cv CustomVision) ensureTags(tags []string) (map[string]string, error) {
+	var existing []customVisionTag
+	if err := cv.call("GET", "tags", nil, &existing); err != nil {
+		return nil, err
+	}
+	tagIDs := make(map[string]string)
+	for _, tag := range existing {
+		tagIDs[tag.Name] = tag.ID
+	}
+	for _, name := range tags {
+		if _, ok := tagIDs[name]; ok {
+			continue
+		}
+		var created customVisionTag
+		if err := cv.call("POST", "tags?name="+url.QueryEscape(name), nil, &created); err != nil {
+			return nil, err
+		}
+		tagIDs[name] = created.ID
+	}
+	return tagIDs, nil
+}
+
+// image reads the image of an asset, with its regions in coordinates relative to the image size.
+func (cv CustomVision) image(asset Asset, tagIDs map[string]string) (customVisionImage, error) {
+	contents, err := ioutil.ReadFile(assetFilePath(asset))
+	if err != nil {
+		return customVisionImage{}, err
+	}
+	image := customVisionImage{Name: asset.Name, Contents: contents}
+	for _, region := range asset.Regions {
+		box := region.BoundingBox
+		for _, tag := range region.Tags {
+			image.Regions = append(image.Regions, customVisionRegion{
+				TagID:  tagIDs[tag],
+				Left:   float64(box.Left) / float64(asset.Size.Width),
+				Top:    float64(box.Top) / float64(asset.Size.Height),
+				Width:  float64(box.Width) / float64(asset.Size.Width),
+				Height: float64(box.Height) / float64(asset.Size.Height),
+			})
+		}
+	}
+	if image.Regions == nil && asset.Regions == nil {
+		image.TagIDs = []string{tagIDs[asset.Label]}
+	}
+	return image, nil
+}
+
+// call sends a request to the training API of the project and decodes the JSON response into result.
+func (cv CustomVision) call(method string, path string, body interface{}, result interface{}) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+	address := fmt.Sprintf("%s/customvision/v3.3/training/projects/%s/%s", strings.TrimSuffix(cv.Endpoint, "/"), cv.Project, path)
+	request, err := http.NewRequest(method, address, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Training-Key", cv.Key)
+	request.Header.Set("Content-Type", "application/json")
+
+	client := cv.Client
+	if client == nil {
+		client = HTTPClient
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return fmt.Errorf("Error: Cannot reach Custom Vision: %v", err)
+	}
+	defer response.Body.Close()
+	data, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+	if response.StatusCode/100 != 2 {
+		return fmt.Errorf("Error: Custom Vision answered %s: %s", response.Status, strings.TrimSpace(string(data)))
+	}
+	return json.Unmarshal(data, result)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_CustomVisionPush(t *testing.T) {
+	dir := t.TempDir()
+	imgPath := filepath.Join(dir, "image1.jpg")
+	writeTestImage(t, imgPath, 100, 50)
+	assets := []Asset{{
+		Name:    "image1.jpg",
+		Path:    "file:" + filepath.ToSlash(imgPath),
+		Size:    Size{Width: 100, Height: 50},
+		Label:   "cat",
+		Regions: []Region{{Tags: []string{"cat"}, BoundingBox: BoundingBox{Left: 10, Top: 5, Width: 50, Height: 25}}},
+	}}
+
+	var created []string
+	var batch customVisionBatch
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Training-Key") != "secret" {
+			http.Error(w, "no key", http.StatusUnauthorized)
+			return
+		}
+		switch r.Method + " " + r.URL.Path {
+		case "GET /customvision/v3.3/training/projects/p1/tags":
+			json.NewEncoder(w).Encode([]customVisionTag{{ID: "t-dog", Name: "dog"}})
+		case "POST /customvision/v3.3/training/projects/p1/tags":
+			created = append(created, r.URL.Query().Get("name"))
+			json.NewEncoder(w).Encode(customVisionTag{ID: "t-" + r.URL.Query().Get("name"), Name: r.URL.Query().Get("name")})
+		case "POST /customvision/v3.3/training/projects/p1/images/files":
+			json.NewDecoder(r.Body).Decode(&batch)
+			w.Write([]byte(`{"isBatchSuccessful": true, "images": [{"sourceUrl": "image1.jpg", "status": "OK"}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	customVision := CustomVision{Endpoint: server.URL + "/", Project: "p1", Key: "secret"}
+	uploaded, err := customVision.push(assets, []string{"cat", "dog"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uploaded != 1 {
+		t.Errorf("Expected 1 image uploaded, found %d", uploaded)
+	}
+	if len(created) != 1 || created[0] != "cat" {
+		t.Errorf("Expected only the missing tag cat created, found %v", created)
+	}
+
+	contents, err := os.ReadFile(imgPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(batch.Images) != 1 || string(batch.Images[0].Contents) != string(contents) {
+		t.Fatalf("Expected the image contents uploaded, found %d images", len(batch.Images))
+	}
+	expected := customVisionRegion{TagID: "t-cat", Left: 0.1, Top: 0.1, Width: 0.5, Height: 0.5}
+	if regions := batch.Images[0].Regions; len(regions) != 1 || regions[0] != expected {
+		t.Errorf("Expected region %+v, found %+v", expected, regions)
+	}
+
+	customVision.Key = "wrong"
+	if _, err := customVision.push(assets, []string{"cat"}); err == nil {
+		t.Error("Expected an error for a wrong key")
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+
+	"votter/mod/votter"
+)
+
+// DatasetFiles describe a dataset in its root folder, the first one found is read. README.md only counts with YAML front matter.
+var DatasetFiles = []string{"dataset.yaml", "dataset.yml", "README.md"}
+
+// DatasetMetadata is the description of a dataset, written to the project's metadata block.
+type DatasetMetadata = votter.DatasetMetadata
+
+// readDatasetMetadata reads the description of the dataset in root from dataset.yaml or the front matter of its README.md.
+// Returns nil if there is neither.
+func readDatasetMetadata(root string) (*DatasetMetadata, error) {
+	for _, name := range DatasetFiles {
+		path := filepath.Join(root, name)
+		data, err := ioutil.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if name == "README.md" {
+			var found bool
+			if data, found = frontMatter(data); !found {
+				return nil, nil
+			}
+		}
+
+		var metadata DatasetMetadata
+		if err := yaml.Unmarshal(data, &metadata); err != nil {
+			return nil, fmt.Errorf("Error: Cannot read dataset description '%s': %v", path, err)
+		}
+		return &metadata, nil
+	}
+	return nil, nil
+}
+
+// frontMatter returns the YAML between the --- lines at the start of a Markdown file.
+func frontMatter(data []byte) ([]byte, bool) {
+	data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+	rest, found := bytes.CutPrefix(data, []byte("---\n"))
+	if !found {
+		return nil, false
+	}
+	end := bytes.Index(rest, []byte("\n---"))
+	if end < 0 {
+		return nil, false
+	}
+	return rest[:end+1], true
+}
+
+// applyDatasetMetadata names and describes the project after the dataset, and adds its metadata block.
+func applyDatasetMetadata(project *VottJsonModel, metadata *DatasetMetadata) {
+	if metadata == nil {
+		return
+	}
+	if metadata.Name != "" {
+		project.Name = metadata.Name
+	}
+	project.Description = metadata.Description
+	project.Metadata = metadata
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_ReadDatasetMetadata(t *testing.T) {
+	rootDir := t.TempDir()
+	if metadata, err := readDatasetMetadata(rootDir); err != nil || metadata != nil {
+		t.Fatalf("Expected no metadata without a description, found %v, %v", metadata, err)
+	}
+
+	readme := "---\r\nname: Pets\r\nlicense: CC-BY-4.0\r\n---\r\n# Pets\r\n"
+	os.WriteFile(filepath.Join(rootDir, "README.md"), []byte(readme), 0644)
+	metadata, err := readDatasetMetadata(rootDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := (&DatasetMetadata{Name: "Pets", License: "CC-BY-4.0"}); !reflect.DeepEqual(metadata, expected) {
+		t.Errorf("Expected front matter %v, found %v", expected, metadata)
+	}
+
+	os.WriteFile(filepath.Join(rootDir, "dataset.yaml"), []byte("name: Shelter pets\ndescription: Cats and dogs\nversion: 1.2.0\n"), 0644)
+	if metadata, err = readDatasetMetadata(rootDir); err != nil {
+		t.Fatal(err)
+	}
+	project := buildVottModel(nil, nil)
+	applyDatasetMetadata(&project, metadata)
+	if project.Name != "Shelter pets" || project.Description != "Cats and dogs" || project.Metadata.Version != "1.2.0" {
+		t.Errorf("Expected dataset.yaml to describe the project, found %+v", project)
+	}
+}
+
+func Test_FrontMatter(t *testing.T) {
+	if _, found := frontMatter([]byte("# Pets\n---\nname: Pets\n---\n")); found {
+		t.Error("Expected front matter only at the start")
+	}
+	if _, found := frontMatter([]byte("---\nname: Pets\n")); found {
+		t.Error("Expected unterminated front matter to be ignored")
+	}
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Levels of the findings of doctor.
+const (
+	DoctorOK   = "OK"
+	DoctorWarn = "WARN"
+	DoctorFail = "FAIL"
+)
+
+// doctorMinOpenFiles is the soft limit of open files below which doctor warns, the workers and connectors of large
+// datasets need more.
+const doctorMinOpenFiles = 1024
+
+// doctorImageHeaders are the first bytes of the image formats by extension, to find out whether a decoder is compiled in.
+var doctorImageHeaders = map[string]string{
+	".png":  "\x89PNG\r\n\x1a\n",
+	".jpg":  "\xff\xd8\xff",
+	".jpeg": "\xff\xd8\xff",
+	".gif":  "GIF89a",
+	".bmp":  "BM",
+	".webp": "RIFF\x00\x00\x00\x00WEBPVP8 ",
+	".tif":  "II*\x00",
+	".tiff": "II*\x00",
+}
+
+// doctorConnectors are the settings of the connectors doctor reaches with the credential profile of their -profile setting.
+var doctorConnectors = []struct {
+	setting string
+	profile string
+}{
+	{"mlflow-uri", "mlflow"},
+	{"push-elasticsearch", "elasticsearch"},
+	{"segment-url", "segment"},
+	{"webdav", "webdav"},
+	{"customvision-endpoint", "customvision"},
+}
+
+// Finding is a result of doctor: its level, what was checked, and what to do about it.
+type Finding struct {
+	Level   string
+	Check   string
+	Message string
+}
+
+// runDoctor checks the environment of a run before it starts, the images and output folders, the open files limit,
+// the decoders of the image extensions, the external tools and the connectors of the config file, and prints what to
+// fix. Exits with ExitCheckFailed when a check fails.
+//
+//	votter.exe doctor [--config votter.yaml] [path_to_images] [annotation.json]
+func runDoctor(args []string) int {
+	flags := flag.NewFlagSet("doctor", flag.ExitOnError)
+	configFlag := flags.String("config", "", "YAML file of settings by flag name (default votter.yaml if present)")
+	timeoutFlag := flags.Duration("timeout", 10*time.Second, "How long to wait for each connector to answer")
+	flags.Usage = func() {
+		fmt.Println("Usage: votter doctor [options] [path_to_images] [annotation.json]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() > 2 {
+		flags.Usage()
+		return ExitInvalidArguments
+	}
+
+	config, err := loadConfig(*configFlag)
+	if err != nil {
+		fmt.Println(err)
+		return ExitInvalidArguments
+	}
+	imagesPath, annotationFile := OptionalPathToImagesDefault, OptionalAnnotationsFilenameDefault
+	if value, ok := configValue(config, ConfigImages); ok {
+		imagesPath = value
+	}
+	if value, ok := configValue(config, ConfigOutput); ok {
+		annotationFile = value
+	}
+	if flags.NArg() > 0 {
+		imagesPath = flags.Arg(0)
+	}
+	if flags.NArg() > 1 {
+		annotationFile = flags.Arg(1)
+	}
+
+	var findings []Finding
+	findings = append(findings, doctorFolders(imagesPath, annotationFile)...)
+	findings = append(findings, doctorOpenFiles(openFilesSoftLimit()))
+	findings = append(findings, Finding{DoctorOK, "workers", fmt.Sprintf("%d CPUs, --workers defaults to %d.", runtime.NumCPU(), runtime.NumCPU())})
+	findings = append(findings, doctorDecoders(config)...)
+	findings = append(findings, doctorTools(config)...)
+	profiles, err := loadProfiles(*configFlag)
+	if err != nil {
+		findings = append(findings, Finding{DoctorFail, "config", fmt.Sprintf("%v. Fix the profiles of the config file.", err)})
+	} else {
+		findings = append(findings, doctorConnectorFindings(config, profiles, *timeoutFlag)...)
+	}
+
+	failed := 0
+	for _, finding := range findings {
+		fmt.Printf("%-4s  %s: %s\n", finding.Level, finding.Check, finding.Message)
+		if finding.Level == DoctorFail {
+			failed++
+		}
+	}
+	if failed > 0 {
+		fmt.Printf("Error: %d of %d checks failed.\n", failed, len(findings))
+		return ExitCheckFailed
+	}
+	return ExitSuccesful
+}
+
+// doctorFolders checks the images folder can be read and the folder of the annotations file written.
+func doctorFolders(imagesPath string, annotationFile string) []Finding {
+	var findings []Finding
+	if entries, err := os.ReadDir(imagesPath); err != nil {
+		findings = append(findings, Finding{DoctorFail, "images", fmt.Sprintf("Cannot read '%s': %v. Pass the folder of the label folders, or mount it.", imagesPath, err)})
+	} else {
+		folders := 0
+		for _, entry := range entries {
+			if isWalkedDir(imagesPath, entry) {
+				folders++
+			}
+		}
+		level := DoctorOK
+		message := fmt.Sprintf("'%s' has %d label folders.", imagesPath, folders)
+		if folders == 0 {
+			level = DoctorWarn
+			message = fmt.Sprintf("'%s' has no label folders, only images in a folder per label are annotated.", imagesPath)
+		}
+		findings = append(findings, Finding{level, "images", message})
+	}
+
+	dir := filepath.Dir(annotationFile)
+	if file, err := os.CreateTemp(dir, ".votter-doctor-*"); err != nil {
+		findings = append(findings, Finding{DoctorFail, "output", fmt.Sprintf("Cannot write to '%s': %v. Create the folder or write the annotations elsewhere.", dir, err)})
+	} else {
+		file.Close()
+		os.Remove(file.Name())
+		findings = append(findings, Finding{DoctorOK, "output", fmt.Sprintf("'%s' is writable.", dir)})
+	}
+	return findings
+}
+
+// doctorOpenFiles checks the soft limit of open files, 0 when the system doesn't have one.
+func doctorOpenFiles(soft int) Finding {
+	switch {
+	case soft == 0:
+		return Finding{DoctorOK, "open files", "No limit to check on this system."}
+	case soft < doctorMinOpenFiles:
+		return Finding{DoctorWarn, "open files", fmt.Sprintf("The soft limit is %d, large datasets run out of files. Raise it with ulimit -n 65536, or lower --workers.", soft)}
+	}
+	return Finding{DoctorOK, "open files", fmt.Sprintf("The soft limit is %d, --max-open-files defaults to %d.", soft, soft-reservedFiles)}
+}
+
+// doctorDecoders checks there's a decoder for every image extension of the extensions setting, or of the defaults.
+func doctorDecoders(config map[string]string) []Finding {
+	extensions := DefaultImageExtensions
+	if value, ok := configValue(config, "extensions"); ok {
+		parsed, err := parseExtensions(value)
+		if err != nil {
+			return []Finding{{DoctorFail, "decoders", err.Error()}}
+		}
+		extensions = parsed
+	}
+	var missing []string
+	for _, ext := range extensions {
+		if !hasDecoder(ext) {
+			missing = append(missing, ext)
+		}
+	}
+	if len(missing) > 0 {
+		return []Finding{{DoctorWarn, "decoders", fmt.Sprintf("No decoder for %s in this build, those images fail to decode. Convert them, or leave them out with --extensions.", strings.Join(missing, ", "))}}
+	}
+	return []Finding{{DoctorOK, "decoders", fmt.Sprintf("Decoders for %s.", strings.Join(extensions, ", "))}}
+}
+
+// hasDecoder reports whether images of an extension decode, by the error of decoding the start of one.
+func hasDecoder(ext string) bool {
+	header, known := doctorImageHeaders[ext]
+	if !known {
+		return false
+	}
+	_, _, err := image.DecodeConfig(bytes.NewReader([]byte(header)))
+	return !errors.Is(err, image.ErrFormat)
+}
+
+// doctorTools checks the external programs of the settings are installed: sftp for --sftp, and the command of
+// --anonymize-detector.
+func doctorTools(config map[string]string) []Finding {
+	var findings []Finding
+	tools := []struct{ setting, command string }{{"sftp", SFTPCommand}}
+	if detector, ok := configValue(config, "anonymize-detector"); ok && strings.TrimSpace(detector) != "" {
+		tools = append(tools, struct{ setting, command string }{"anonymize-detector", strings.Fields(detector)[0]})
+	}
+	for _, tool := range tools {
+		if value, ok := configValue(config, tool.setting); !ok || value == "" {
+			continue
+		}
+		if path, err := exec.LookPath(tool.command); err != nil {
+			findings = append(findings, Finding{DoctorFail, tool.setting, fmt.Sprintf("'%s' isn't installed or not on the PATH. Install it, or add its folder to PATH.", tool.command)})
+		} else {
+			findings = append(findings, Finding{DoctorOK, tool.setting, fmt.Sprintf("'%s' is %s.", tool.command, path)})
+		}
+	}
+	return findings
+}
+
+// doctorConnectorFindings asks every connector of the settings for its URL with the credentials of its profile, and
+// reports connectors that can't be reached or reject the credentials.
+func doctorConnectorFindings(config map[string]string, profiles map[string]CredentialProfile, timeout time.Duration) []Finding {
+	var findings []Finding
+	for _, connector := range doctorConnectors {
+		address, ok := configValue(config, connector.setting)
+		if !ok || address == "" {
+			continue
+		}
+		client := &http.Client{Transport: HTTPClient.Transport, Timeout: timeout}
+		if name, ok := configValue(config, connector.profile+"-profile"); ok && name != "" {
+			profile, err := findProfile(profiles, connector.profile, name)
+			if err != nil {
+				findings = append(findings, Finding{DoctorFail, connector.setting, fmt.Sprintf("%v. Add the profile to the config file.", err)})
+				continue
+			}
+			client = profile.client(client)
+		}
+		findings = append(findings, doctorConnector(client, connector.setting, connector.profile, address))
+	}
+	return findings
+}
+
+// doctorConnector requests address and reports whether it answered and took the credentials of the connector's profile.
+func doctorConnector(client *http.Client, setting string, connector string, address string) Finding {
+	target, err := url.Parse(address)
+	if setting == "webdav" {
+		target, err = parseWebDAV(address)
+	}
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") {
+		return Finding{DoctorFail, setting, fmt.Sprintf("'%s' is not an http or https URL.", address)}
+	}
+	response, err := client.Get(target.String())
+	if err != nil {
+		return Finding{DoctorFail, setting, fmt.Sprintf("Cannot reach %s: %v. Check the URL, the network and HTTPS_PROXY.", target.Redacted(), err)}
+	}
+	response.Body.Close()
+	if response.StatusCode == http.StatusUnauthorized || response.StatusCode == http.StatusForbidden {
+		return Finding{DoctorFail, setting, fmt.Sprintf("%s rejects the credentials with %s. Check the profile of --%s-profile and its secrets.", target.Redacted(), response.Status, connector)}
+	}
+	return Finding{DoctorOK, setting, fmt.Sprintf("%s answers with %s.", target.Redacted(), response.Status)}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func Test_DoctorFolders(t *testing.T) {
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "cat"), 0755)
+
+	findings := doctorFolders(root, filepath.Join(root, "annotations.json"))
+	if len(findings) != 2 || findings[0].Level != DoctorOK || findings[1].Level != DoctorOK {
+		t.Errorf("Expected readable images and a writable output, found %+v", findings)
+	}
+	findings = doctorFolders(filepath.Join(root, "missing"), filepath.Join(root, "missing", "annotations.json"))
+	if findings[0].Level != DoctorFail || findings[1].Level != DoctorFail {
+		t.Errorf("Expected a missing images folder and output folder to fail, found %+v", findings)
+	}
+}
+
+func Test_DoctorOpenFiles(t *testing.T) {
+	if finding := doctorOpenFiles(256); finding.Level != DoctorWarn {
+		t.Errorf("Expected a low limit to warn, found %+v", finding)
+	}
+	if finding := doctorOpenFiles(65536); finding.Level != DoctorOK {
+		t.Errorf("Expected a high limit to pass, found %+v", finding)
+	}
+}
+
+func Test_DoctorDecoders(t *testing.T) {
+	if !hasDecoder(".jpg") || !hasDecoder(".png") || hasDecoder(".heic") {
+		t.Error("Expected decoders for jpg and png, none for heic")
+	}
+	if findings := doctorDecoders(map[string]string{"extensions": "jpg,png"}); findings[0].Level != DoctorOK {
+		t.Errorf("Expected jpg and png to decode, found %+v", findings)
+	}
+	if findings := doctorDecoders(map[string]string{"extensions": "jpg,heic"}); findings[0].Level != DoctorWarn {
+		t.Errorf("Expected heic to warn, found %+v", findings)
+	}
+}
+
+func Test_DoctorConnectors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+	profiles := map[string]CredentialProfile{"good": {Token: "secret"}, "bad": {Token: "wrong"}}
+
+	for profile, expected := range map[string]string{"good": DoctorOK, "bad": DoctorFail, "missing": DoctorFail} {
+		config := map[string]string{"mlflow-uri": server.URL, "mlflow-profile": profile}
+		findings := doctorConnectorFindings(config, profiles, time.Second)
+		if len(findings) != 1 || findings[0].Level != expected {
+			t.Errorf("Expected %s for profile %s, found %+v", expected, profile, findings)
+		}
+	}
+	config := map[string]string{"segment-url": "http://127.0.0.1:1/segment"}
+	if findings := doctorConnectorFindings(config, profiles, time.Second); len(findings) != 1 || findings[0].Level != DoctorFail {
+		t.Errorf("Expected an unreachable connector to fail, found %+v", findings)
+	}
+}
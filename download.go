@@ -0,0 +1,231 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// DownloadUnlabeled is the folder of URLs listed without a label.
+const DownloadUnlabeled = "unlabeled"
+
+// DownloadFailuresFile is written next to the annotations file with the URLs that failed to download.
+const DownloadFailuresFile = "download-failures.csv"
+
+// downloadItem is a line of a URL list: the URL of an image, its label, and where it's downloaded to.
+type downloadItem struct {
+	URL   string
+	Label string
+	Path  string
+}
+
+// readURLList reads a CSV or text file of image URLs, one per line with an optional label after a comma. A header
+// line starting with url is skipped, as are blank lines and lines starting with #.
+func readURLList(path string) ([]downloadItem, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.Comment = '#'
+	reader.TrimLeadingSpace = true
+	var items []downloadItem
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Error: Cannot read URL list '%s': %v", path, err)
+		}
+		rawURL := strings.TrimSpace(record[0])
+		if rawURL == "" || (len(items) == 0 && strings.EqualFold(rawURL, "url")) {
+			continue
+		}
+		if parsed, err := url.Parse(rawURL); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+			return nil, fmt.Errorf("Error: '%s' in URL list '%s' is not an http or https URL", rawURL, path)
+		}
+		label := DownloadUnlabeled
+		if len(record) > 1 && strings.TrimSpace(record[1]) != "" {
+			label = strings.TrimSpace(record[1])
+		}
+		// Labels are folder names, they must stay below the images folder.
+		if strings.ContainsAny(label, `/\`) || label == "." || label == ".." {
+			return nil, fmt.Errorf("Error: Label '%s' of '%s' in URL list '%s' is not a folder name", label, rawURL, path)
+		}
+		items = append(items, downloadItem{URL: rawURL, Label: label})
+	}
+	return items, nil
+}
+
+// placeDownloads sets the path of every item in the folder of its label, named after the last segment of the URL.
+// URLs of the same label and name get a short hash of the URL added, so reruns find the same files.
+func placeDownloads(items []downloadItem, imagesPath string) {
+	names := make(map[string]string)
+	for i, item := range items {
+		name := ""
+		if parsed, err := url.Parse(item.URL); err == nil {
+			name = path.Base(parsed.Path)
+		}
+		if name == "" || name == "/" || name == "." || name == ".." {
+			name = "image"
+		}
+		key := item.Label + "/" + name
+		if other, taken := names[key]; taken && other != item.URL {
+			sum := sha256.Sum256([]byte(item.URL))
+			ext := path.Ext(name)
+			name = strings.TrimSuffix(name, ext) + "-" + hex.EncodeToString(sum[:4]) + ext
+			key = item.Label + "/" + name
+		}
+		names[key] = item.URL
+		items[i].Path = filepath.Join(imagesPath, item.Label, name)
+	}
+}
+
+// downloadURLs downloads the images of a URL list into a folder per label below imagesPath with client, with workers
+// at once. Files that are there from an earlier run aren't downloaded again. Returns the number downloaded and the failures.
+func downloadURLs(ctx context.Context, client *http.Client, items []downloadItem, workers int) (int, []SkippedFile) {
+	var mutex sync.Mutex
+	var downloaded int
+	var failures []SkippedFile
+	work := make(chan downloadItem)
+	var wg sync.WaitGroup
+	for w := 0; w < max(workers, 1); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range work {
+				fetched, err := downloadImage(ctx, client, item)
+				mutex.Lock()
+				if err != nil {
+					failures = append(failures, SkippedFile{Path: item.URL, Label: item.Label, Reason: err.Error()})
+				} else if fetched {
+					downloaded++
+				}
+				mutex.Unlock()
+				Progress.step(item.Label)
+			}
+		}()
+	}
+feed:
+	for _, item := range items {
+		select {
+		case work <- item:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(work)
+	wg.Wait()
+	return downloaded, failures
+}
+
+// downloadImage fetches an image into its path, through a temporary file so failures leave nothing behind.
+// Reports false when the file is already there.
+func downloadImage(ctx context.Context, client *http.Client, item downloadItem) (bool, error) {
+	if _, err := os.Stat(item.Path); err == nil {
+		return false, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(item.Path), 0755); err != nil {
+		return false, err
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, item.URL, nil)
+	if err != nil {
+		return false, err
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return false, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("%s", response.Status)
+	}
+	// File servers and shares send images they don't know as plain bytes, those are decoded like any other file.
+	mediaType, _, _ := mime.ParseMediaType(response.Header.Get("Content-Type"))
+	if mediaType != "" && mediaType != "application/octet-stream" && !strings.HasPrefix(mediaType, "image/") {
+		return false, fmt.Errorf("not an image but %s", mediaType)
+	}
+
+	tmp := item.Path + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return false, err
+	}
+	_, err = io.Copy(file, response.Body)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return false, err
+	}
+	return true, os.Rename(tmp, item.Path)
+}
+
+// writeDownloadFailures writes the failed URLs with their label and reason as CSV.
+func writeDownloadFailures(path string, failures []SkippedFile) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	writer := csv.NewWriter(file)
+	writer.Write([]string{"url", "label", "reason"})
+	for _, failure := range failures {
+		writer.Write([]string{failure.Path, failure.Label, failure.Reason})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// downloadURLList downloads the images of the URL list at listPath for --download-urls, and writes the URLs that
+// failed next to the annotations file. Failed downloads are reported and left out, not fatal. Returns the exit code.
+func downloadURLList(ctx context.Context, listPath string, imagesPath string, annotationFile string, workers int) int {
+	items, err := readURLList(listPath)
+	if err != nil {
+		fmt.Println(err)
+		return ExitInvalidArguments
+	}
+	placeDownloads(items, imagesPath)
+
+	Progress.begin("download", len(items))
+	downloaded, failures := downloadURLs(ctx, HTTPClient, items, workers)
+	Progress.finish(len(items))
+	if ctx.Err() != nil {
+		fmt.Printf("Error: %s while downloading images\n", interruption(ctx))
+		return ExitInterrupted
+	}
+	fmt.Printf("Downloaded %d of %d images into '%s'.\n", downloaded, len(items), imagesPath)
+	return reportDownloadFailures(failures, annotationFile)
+}
+
+// reportDownloadFailures prints the failed downloads and lists them next to the annotations file. Returns the exit code.
+func reportDownloadFailures(failures []SkippedFile, annotationFile string) int {
+	if len(failures) > 0 {
+		for _, failure := range failures {
+			fmt.Printf("Failed to download '%s': %s\n", failure.Path, failure.Reason)
+		}
+		failuresPath := filepath.Join(filepath.Dir(annotationFile), DownloadFailuresFile)
+		if err := writeDownloadFailures(failuresPath, failures); err != nil {
+			fmt.Println(err)
+			return ExitAnnotationsWriteFailed
+		}
+		fmt.Printf("Listed %d failed downloads in '%s'.\n", len(failures), failuresPath)
+	}
+	return ExitSuccesful
+}
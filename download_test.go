@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func Test_DownloadURLs(t *testing.T) {
+	var pngData bytes.Buffer
+	png.Encode(&pngData, image.NewRGBA(image.Rect(0, 0, 4, 4)))
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		switch r.URL.Path {
+		case "/a/cat.png", "/b/cat.png":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write(pngData.Bytes())
+		case "/page.html":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("<html></html>"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	list := filepath.Join(dir, "urls.csv")
+	os.WriteFile(list, []byte("url,label\n"+server.URL+"/a/cat.png,cat\n"+server.URL+"/b/cat.png,cat\n# gone\n"+
+		server.URL+"/missing.jpg,dog\n"+server.URL+"/page.html\n"), 0644)
+	items, err := readURLList(list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 4 || items[0].Label != "cat" || items[3].Label != DownloadUnlabeled {
+		t.Fatalf("Expected the URLs with their labels, found %+v", items)
+	}
+
+	images := filepath.Join(dir, "images")
+	placeDownloads(items, images)
+	if items[0].Path != filepath.Join(images, "cat", "cat.png") || items[1].Path == items[0].Path || !strings.HasSuffix(items[1].Path, ".png") {
+		t.Errorf("Expected distinct files in the label folder, found %s and %s", items[0].Path, items[1].Path)
+	}
+
+	downloaded, failures := downloadURLs(context.Background(), HTTPClient, items, 2)
+	if downloaded != 2 || len(failures) != 2 {
+		t.Fatalf("Expected 2 downloads and 2 failures, found %d and %+v", downloaded, failures)
+	}
+	if _, err := os.Stat(items[1].Path); err != nil {
+		t.Errorf("Expected the downloaded image: %v", err)
+	}
+	if _, err := os.Stat(items[3].Path); err == nil {
+		t.Error("Expected nothing written for the page that isn't an image")
+	}
+
+	before := requests.Load()
+	if downloaded, _ := downloadURLs(context.Background(), HTTPClient, items[:2], 2); downloaded != 0 || requests.Load() != before {
+		t.Errorf("Expected downloaded files not fetched again, found %d downloads and %d requests", downloaded, requests.Load()-before)
+	}
+
+	failuresPath := filepath.Join(dir, DownloadFailuresFile)
+	if err := writeDownloadFailures(failuresPath, failures); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(failuresPath); strings.Count(string(data), "\n") != 3 {
+		t.Errorf("Expected a header and a line per failure, found %s", data)
+	}
+
+	os.WriteFile(list, []byte(server.URL+"/a.png,../outside\n"), 0644)
+	if _, err := readURLList(list); err == nil {
+		t.Error("Expected an error for a label that isn't a folder name")
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// parseDriftThreshold reads a --drift-threshold like 10% or 10 as the fraction 0.1.
+func parseDriftThreshold(value string) (float64, error) {
+	percent, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(value, "%")), 64)
+	if err != nil || percent <= 0 {
+		return 0, fmt.Errorf("Error: Invalid --drift-threshold '%s', expected a percentage like 10%%", value)
+	}
+	return percent / 100, nil
+}
+
+// detailLabel returns the label of an asset, or the first tag of its regions for projects not written by votter.
+func detailLabel(detail AssetDetail) string {
+	if detail.Asset.Label == "" && len(detail.Regions) > 0 && len(detail.Regions[0].Tags) > 0 {
+		return detail.Regions[0].Tags[0]
+	}
+	return detail.Asset.Label
+}
+
+// previousLabelDistribution counts the assets per label of the existing project. Returns nil if there's no project yet.
+func previousLabelDistribution(annotationFile string) (map[string]int, error) {
+	model, err := readVottJSON(annotationFile)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int)
+	for _, detail := range model.Assets {
+		counts[detailLabel(detail)]++
+	}
+	return counts, nil
+}
+
+// labelDrift describes the labels whose asset count changed by more than threshold, a fraction, between the previous
+// and current distribution, sorted by label. Labels that appeared or disappeared are always reported.
+func labelDrift(previous map[string]int, current map[string]int, threshold float64) []string {
+	var labels []string
+	for label := range previous {
+		labels = append(labels, label)
+	}
+	for label := range current {
+		if _, found := previous[label]; !found {
+			labels = append(labels, label)
+		}
+	}
+	sort.Strings(labels)
+
+	var drift []string
+	for _, label := range labels {
+		before, after := previous[label], current[label]
+		switch {
+		case before == 0:
+			drift = append(drift, fmt.Sprintf("Label '%s' is new with %d assets", label, after))
+		case after == 0:
+			drift = append(drift, fmt.Sprintf("Label '%s' has no assets anymore, it had %d", label, before))
+		default:
+			change := float64(after-before) / float64(before)
+			if math.Abs(change) > threshold {
+				drift = append(drift, fmt.Sprintf("Label '%s' changed from %d to %d assets (%+.1f%%)", label, before, after, change*100))
+			}
+		}
+	}
+	return drift
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_LabelDrift(t *testing.T) {
+	previous := map[string]int{"cat": 100, "dog": 100, "gate-2": 40}
+	current := map[string]int{"cat": 105, "dog": 80, "bird": 3}
+
+	expected := []string{
+		"Label 'bird' is new with 3 assets",
+		"Label 'dog' changed from 100 to 80 assets (-20.0%)",
+		"Label 'gate-2' has no assets anymore, it had 40",
+	}
+	if drift := labelDrift(previous, current, 0.1); !reflect.DeepEqual(drift, expected) {
+		t.Errorf("Expected %v, found %v", expected, drift)
+	}
+}
+
+func Test_ParseDriftThreshold(t *testing.T) {
+	for value, expected := range map[string]float64{"10%": 0.1, "25": 0.25, " 5 %": 0.05} {
+		if threshold, err := parseDriftThreshold(value); err != nil || threshold != expected {
+			t.Errorf("%s: expected %v, found %v, %v", value, expected, threshold, err)
+		}
+	}
+	for _, value := range []string{"ten", "0%", "-5%"} {
+		if _, err := parseDriftThreshold(value); err == nil {
+			t.Errorf("%s: expected an error", value)
+		}
+	}
+}
+
+func Test_PreviousLabelDistribution(t *testing.T) {
+	annotationFile := filepath.Join(t.TempDir(), "annotations.json")
+	if counts, err := previousLabelDistribution(annotationFile); err != nil || counts != nil {
+		t.Errorf("Expected no distribution without a project, found %v, %v", counts, err)
+	}
+
+	assets := []Asset{{ID: "id1", Label: "cat"}, {ID: "id2", Label: "cat"}, {ID: "id3", Label: "dog"}}
+	if err := writeVottJSON(annotationFile, assets, []string{"cat", "dog"}); err != nil {
+		t.Fatal(err)
+	}
+	counts, err := previousLabelDistribution(annotationFile)
+	if err != nil || !reflect.DeepEqual(counts, map[string]int{"cat": 2, "dog": 1}) {
+		t.Errorf("Expected the counts per label, found %v, %v", counts, err)
+	}
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Mask modes of export-masks: a pixel value per tag, or per region.
+const (
+	MaskModeClass    = "class"
+	MaskModeInstance = "instance"
+)
+
+// runExportMasks renders the regions of every asset of a VoTT file into a mask PNG, for semantic or instance segmentation training.
+//
+//	votter.exe export-masks [-mode class|instance] [-palette cat:#ff0000,dog:#00ff00] <vott-annotations.json> <outputDirectory>
+func runExportMasks(args []string) int {
+	flags := flag.NewFlagSet("export-masks", flag.ExitOnError)
+	modeFlag := flags.String("mode", MaskModeClass, "Pixel values by 'class', the tag's index in classes.txt, or by 'instance', the region's number")
+	paletteFlag := flags.String("palette", "", "Comma separated tag:#rrggbb colors of class masks, other tags get the Pascal VOC colors")
+	flags.Usage = func() {
+		fmt.Println("Usage: votter export-masks [options] <vott-annotations.json> <outputDirectory>")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return ExitInvalidArguments
+	}
+	if *modeFlag != MaskModeClass && *modeFlag != MaskModeInstance {
+		fmt.Printf("Error: Unknown mask mode '%s', expected class or instance\n", *modeFlag)
+		return ExitInvalidArguments
+	}
+	palette, err := parseMaskPalette(*paletteFlag)
+	if err != nil {
+		fmt.Println(err)
+		return ExitInvalidArguments
+	}
+
+	model, err := readVottJSON(flags.Arg(0))
+	if err != nil {
+		fmt.Println(err)
+		return ExitAnnotationsNotReadable
+	}
+
+	masks, err := exportMasks(model, filepath.Dir(flags.Arg(0)), flags.Arg(1), *modeFlag, palette)
+	if err != nil {
+		fmt.Println(err)
+		return ExitImageWriteFailed
+	}
+
+	fmt.Printf("Wrote %d masks to '%s'.\n", masks, flags.Arg(1))
+	return ExitSuccesful
+}
+
+// parseMaskPalette parses comma separated tag:#rrggbb pairs into a map of tag to color.
+func parseMaskPalette(value string) (map[string]color.RGBA, error) {
+	palette := make(map[string]color.RGBA)
+	for _, pair := range strings.Split(value, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		tag, hex, found := strings.Cut(pair, ":")
+		tag, hex = strings.TrimSpace(tag), strings.TrimPrefix(strings.TrimSpace(hex), "#")
+		rgb, err := strconv.ParseUint(hex, 16, 32)
+		if !found || tag == "" || len(hex) != 6 || err != nil {
+			return nil, fmt.Errorf("Error: Invalid palette color '%s', expected tag:#rrggbb pairs", pair)
+		}
+		palette[tag] = color.RGBA{R: uint8(rgb >> 16), G: uint8(rgb >> 8), B: uint8(rgb), A: 255}
+	}
+	return palette, nil
+}
+
+// vocColor returns the color of index i in the Pascal VOC colormap, black for the background at 0.
+func vocColor(i int) color.RGBA {
+	var c color.RGBA
+	for bit := 7; i > 0; bit-- {
+		c.R |= uint8(i&1) << bit
+		c.G |= uint8(i>>1&1) << bit
+		c.B |= uint8(i>>2&1) << bit
+		i >>= 3
+	}
+	c.A = 255
+	return c
+}
+
+// exportMasks writes a paletted PNG the size of each asset to outDir, named after the image. In class mode a pixel is the
+// index of the tag of the region covering it, as listed in outDir/classes.txt with the background at 0. In instance mode it's
+// the number of the region, up to 255. Later regions paint over earlier ones. Assets without a size, as imported from
+// CSV files without the size columns, get it from their image, relative paths resolved against root, the folder of the
+// project. Returns the number of masks written.
+func exportMasks(model VottJsonModel, root string, outDir string, mode string, palette map[string]color.RGBA) (int, error) {
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return 0, err
+	}
+
+	// Classes are the project tags in order, then tags only found on regions.
+	classes := []string{"_background_"}
+	classIndex := make(map[string]int)
+	addClass := func(tag string) {
+		if _, found := classIndex[tag]; !found && len(classes) < 256 {
+			classIndex[tag] = len(classes)
+			classes = append(classes, tag)
+		}
+	}
+	for _, tag := range model.Tags {
+		addClass(tag.Name)
+	}
+	for _, id := range sortedAssetIDs(model) {
+		for _, region := range model.Assets[id].Regions {
+			if len(region.Tags) > 0 {
+				addClass(region.Tags[0])
+			}
+		}
+	}
+
+	colors := make(color.Palette, 256)
+	for i := range colors {
+		colors[i] = vocColor(i)
+	}
+	if mode == MaskModeClass {
+		for tag, c := range palette {
+			if i, found := classIndex[tag]; found {
+				colors[i] = c
+			}
+		}
+		if err := ioutil.WriteFile(filepath.Join(outDir, "classes.txt"), []byte(strings.Join(classes, "\n")+"\n"), 0644); err != nil {
+			return 0, err
+		}
+	}
+
+	// Masks are named after their images, with the asset ID for images of the same name.
+	ids := sortedAssetIDs(model)
+	names := make([]string, len(ids))
+	for i, id := range ids {
+		name := model.Assets[id].Asset.Name
+		names[i] = strings.TrimSuffix(name, filepath.Ext(name)) + ".png"
+	}
+	names = uniqueNames(names, ids)
+
+	written := 0
+	for n, id := range ids {
+		detail := model.Assets[id]
+		size := detail.Asset.Size
+		if size.Width <= 0 || size.Height <= 0 {
+			var err error
+			if size, err = imageSize(assetFilePathIn(detail.Asset, root)); err != nil {
+				return written, err
+			}
+		}
+		mask := image.NewPaletted(image.Rect(0, 0, size.Width, size.Height), colors)
+		for i, region := range detail.Regions {
+			// Polylines and points cover no area.
+			if region.Type == "POLYLINE" || region.Type == "POINT" {
+				continue
+			}
+			value := i + 1
+			if mode == MaskModeClass {
+				if len(region.Tags) == 0 {
+					continue
+				}
+				value = classIndex[region.Tags[0]]
+			}
+			if value > 0 && value < 256 {
+				fillRegion(mask, region, uint8(value))
+			}
+		}
+
+		if err := encodeImageFile(filepath.Join(outDir, names[n]), mask); err != nil {
+			return written, err
+		}
+		written++
+	}
+	return written, nil
+}
+
+// fillRegion sets the pixels inside a rectangle or polygon region to value. Pixels count as inside when their center is.
+func fillRegion(mask *image.Paletted, region Region, value uint8) {
+	bounds := mask.Bounds()
+	if region.Type != "POLYGON" || len(region.Points) < 3 {
+		box := region.BoundingBox
+		rect := box.Rect().Intersect(bounds)
+		for y := rect.Min.Y; y < rect.Max.Y; y++ {
+			for x := rect.Min.X; x < rect.Max.X; x++ {
+				mask.SetColorIndex(x, y, value)
+			}
+		}
+		return
+	}
+
+	// Scanlines through the pixel centers, filling between pairs of edge crossings.
+	points := region.Points
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		cy := float64(y) + 0.5
+		var crossings []float64
+		for i := range points {
+			a, b := points[i], points[(i+1)%len(points)]
+			ay, by := float64(a.Y), float64(b.Y)
+			if (ay <= cy) != (by <= cy) {
+				crossings = append(crossings, float64(a.X)+(cy-ay)/(by-ay)*float64(b.X-a.X))
+			}
+		}
+		sort.Float64s(crossings)
+		for i := 0; i+1 < len(crossings); i += 2 {
+			for x := max(bounds.Min.X, int(crossings[i]+0.5)); x < min(bounds.Max.X, int(crossings[i+1]+0.5)); x++ {
+				mask.SetColorIndex(x, y, value)
+			}
+		}
+	}
+}
+
+// sortedAssetIDs returns the IDs of the project's assets sorted, for output in a stable order.
+func sortedAssetIDs(model VottJsonModel) []string {
+	var ids []string
+	for id := range model.Assets {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_ExportMasks(t *testing.T) {
+	model := VottJsonModel{
+		Tags: []Tag{{Name: "cat"}, {Name: "dog"}},
+		Assets: map[string]AssetDetail{"id1": {
+			Asset: Asset{ID: "id1", Name: "image1.jpg", Size: Size{Width: 10, Height: 10}},
+			Regions: []Region{
+				{Type: "RECTANGLE", Tags: []string{"dog"}, BoundingBox: BoundingBox{Left: 0, Top: 0, Width: 4, Height: 2}},
+				{Type: "POLYGON", Tags: []string{"cat"}, Points: []Point{{X: 2, Y: 2}, {X: 8, Y: 2}, {X: 8, Y: 8}, {X: 2, Y: 8}}},
+			},
+		}},
+	}
+	outDir := t.TempDir()
+	palette := map[string]color.RGBA{"cat": {R: 255, A: 255}}
+	if written, err := exportMasks(model, "", outDir, MaskModeClass, palette); err != nil || written != 1 {
+		t.Fatalf("Expected 1 mask, found %d, %v", written, err)
+	}
+
+	mask := readTestMask(t, filepath.Join(outDir, "image1.png"))
+	cases := map[image.Point]uint8{{1, 1}: 2, {5, 5}: 1, {2, 2}: 1, {8, 8}: 0, {9, 0}: 0}
+	for p, expected := range cases {
+		if value := mask.ColorIndexAt(p.X, p.Y); value != expected {
+			t.Errorf("Pixel %v: expected class %d, found %d", p, expected, value)
+		}
+	}
+	if mask.Palette[1] != (color.RGBA{R: 255, A: 255}) || mask.Palette[2] != vocColor(2) {
+		t.Errorf("Expected the given color for cat and the VOC color for dog, found %v and %v", mask.Palette[1], mask.Palette[2])
+	}
+	classes, _ := os.ReadFile(filepath.Join(outDir, "classes.txt"))
+	if string(classes) != "_background_\ncat\ndog\n" {
+		t.Errorf("Expected the classes by index, found %q", classes)
+	}
+
+	instanceDir := t.TempDir()
+	if _, err := exportMasks(model, "", instanceDir, MaskModeInstance, nil); err != nil {
+		t.Fatal(err)
+	}
+	if value := readTestMask(t, filepath.Join(instanceDir, "image1.png")).ColorIndexAt(1, 1); value != 1 {
+		t.Errorf("Expected the first region's number, found %d", value)
+	}
+
+	// Images of the same name get their asset ID, and exporting again writes the same files.
+	model.Assets["id2"] = AssetDetail{Asset: Asset{ID: "id2", Name: "image1.jpg", Size: Size{Width: 10, Height: 10}}}
+	for run := 0; run < 2; run++ {
+		if _, err := exportMasks(model, "", instanceDir, MaskModeInstance, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries, _ := os.ReadDir(instanceDir)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	if strings.Join(names, ",") != "image1.png,image1_id1.png,image1_id2.png" {
+		t.Errorf("Expected the masks named by asset ID, found %v", names)
+	}
+}
+
+func Test_ExportMasksImageSize(t *testing.T) {
+	rootDir := t.TempDir()
+	writeTestImage(t, filepath.Join(rootDir, "image1.jpg"), 12, 8)
+	// Without a size, the size of the image is read, at a relative path in the folder of the project.
+	model := VottJsonModel{Assets: map[string]AssetDetail{"id1": {
+		Asset:   Asset{ID: "id1", Name: "image1.jpg", Path: "image1.jpg"},
+		Regions: []Region{{Type: "RECTANGLE", Tags: []string{"cat"}, BoundingBox: BoundingBox{Width: 4, Height: 4}}},
+	}}}
+	outDir := t.TempDir()
+	if written, err := exportMasks(model, rootDir, outDir, MaskModeClass, nil); err != nil || written != 1 {
+		t.Fatalf("Expected 1 mask, found %d, %v", written, err)
+	}
+	if bounds := readTestMask(t, filepath.Join(outDir, "image1.png")).Bounds(); bounds.Dx() != 12 || bounds.Dy() != 8 {
+		t.Errorf("Expected a mask the size of the image, found %v", bounds)
+	}
+}
+
+func Test_ParseMaskPalette(t *testing.T) {
+	palette, err := parseMaskPalette("cat:#ff8000, dog:00ff00")
+	if err != nil || palette["cat"] != (color.RGBA{R: 255, G: 128, A: 255}) || palette["dog"] != (color.RGBA{G: 255, A: 255}) {
+		t.Errorf("Expected the colors by tag, found %v, %v", palette, err)
+	}
+	if _, err := parseMaskPalette("cat:red"); err == nil || !strings.Contains(err.Error(), "cat:red") {
+		t.Errorf("Expected an error for a color name, found %v", err)
+	}
+}
+
+func Test_VocColor(t *testing.T) {
+	expected := []color.RGBA{{0, 0, 0, 255}, {128, 0, 0, 255}, {0, 128, 0, 255}, {128, 128, 0, 255}, {0, 0, 128, 255}}
+	for i, c := range expected {
+		if found := vocColor(i); found != c {
+			t.Errorf("Index %d: expected %v, found %v", i, c, found)
+		}
+	}
+}
+
+func readTestMask(t *testing.T, path string) *image.Paletted {
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	img, _, err := image.Decode(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mask, ok := img.(*image.Paletted)
+	if !ok {
+		t.Fatalf("Expected a paletted mask, found %T", img)
+	}
+	return mask
+}
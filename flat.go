@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// LabelsFromSidecar labels every image of a flat folder by a text file of the same base name next to it.
+const LabelsFromSidecar = "sidecar"
+
+// findFlatImages finds the images directly in root and labels them from their sidecar files (source "sidecar"),
+// or from a synset_labels.txt style file (any other source). Images without a label are skipped.
+func findFlatImages(root string, source string) ([]labeledImage, error) {
+	names, err := listImages(root)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+
+	var imageLabels map[string]string
+	if source != LabelsFromSidecar {
+		if imageLabels, err = readImageLabelsFile(source, names); err != nil {
+			return nil, err
+		}
+	}
+
+	var images []labeledImage
+	for _, name := range names {
+		label := imageLabels[name]
+		if source == LabelsFromSidecar {
+			if label, err = readSidecarLabel(root, name); err != nil {
+				return nil, err
+			}
+		}
+		if label == "" {
+			fmt.Printf("Skipped image '%s', no label found.\n", name)
+			continue
+		}
+		images = append(images, labeledImage{Path: filepath.Join(root, name), Label: label})
+	}
+
+	if len(images) == 0 {
+		return nil, fmt.Errorf("Error: No labeled images found in '%s'.", root)
+	}
+	return images, nil
+}
+
+// readSidecarLabel returns the first line of the label file of an image: image1.txt, image1.cls or image1.jpg.txt.
+// Returns an empty string if there's none.
+func readSidecarLabel(dir string, imgFileName string) (string, error) {
+	base := strings.TrimSuffix(imgFileName, filepath.Ext(imgFileName))
+	for _, name := range []string{base + ".txt", base + ".cls", imgFileName + ".txt"} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		firstLine, _, _ := strings.Cut(string(data), "\n")
+		return strings.TrimSpace(firstLine), nil
+	}
+	return "", nil
+}
+
+// readImageLabelsFile reads the labels of a flat folder of images from one file. Lines are either "image label" pairs,
+// or only labels, as in ImageNet's synset_labels.txt, which belong to the images in sorted name order.
+func readImageLabelsFile(path string, sortedNames []string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	imageLabels := make(map[string]string)
+	line := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		switch len(fields) {
+		case 0:
+			continue
+		case 1:
+			if line < len(sortedNames) {
+				imageLabels[sortedNames[line]] = fields[0]
+			}
+		default:
+			imageLabels[fields[0]] = fields[1]
+		}
+		line++
+	}
+	return imageLabels, scanner.Err()
+}
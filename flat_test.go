@@ -0,0 +1,57 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_FindFlatImagesFromSidecars(t *testing.T) {
+	rootDir := t.TempDir()
+	for _, name := range []string{"image1.jpg", "image2.jpg", "image3.jpg"} {
+		writeTestImage(t, filepath.Join(rootDir, name), 10, 10)
+	}
+	os.WriteFile(filepath.Join(rootDir, "image1.txt"), []byte("cat\n"), 0644)
+	os.WriteFile(filepath.Join(rootDir, "image2.cls"), []byte(" dog \n"), 0644)
+
+	images, err := findFlatImages(rootDir, LabelsFromSidecar)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(images) != 2 {
+		t.Fatalf("Expected 2 labeled images, found %v", images)
+	}
+	if images[0].Label != "cat" || images[1].Label != "dog" || images[1].Path != filepath.Join(rootDir, "image2.jpg") {
+		t.Errorf("Expected image1.jpg as cat and image2.jpg as dog, found %v", images)
+	}
+}
+
+func Test_FindFlatImagesFromSynsetLabels(t *testing.T) {
+	rootDir := t.TempDir()
+	for _, name := range []string{"val_00002.JPEG", "val_00001.JPEG"} {
+		writeTestImage(t, filepath.Join(rootDir, name), 10, 10)
+	}
+	labelsFile := filepath.Join(t.TempDir(), "synset_labels.txt")
+	os.WriteFile(labelsFile, []byte("n01440764\nn01443537\n"), 0644)
+
+	images, err := findFlatImages(rootDir, labelsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(images) != 2 || images[0].Label != "n01440764" || filepath.Base(images[0].Path) != "val_00001.JPEG" {
+		t.Errorf("Expected labels in sorted image order, found %v", images)
+	}
+}
+
+func Test_ReadImageLabelsFile(t *testing.T) {
+	labelsFile := filepath.Join(t.TempDir(), "labels.txt")
+	os.WriteFile(labelsFile, []byte("b.jpg dog\na.jpg cat\n"), 0644)
+
+	imageLabels, err := readImageLabelsFile(labelsFile, []string{"a.jpg", "b.jpg"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imageLabels["a.jpg"] != "cat" || imageLabels["b.jpg"] != "dog" {
+		t.Errorf("Expected labels by image name, found %v", imageLabels)
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"votter/mod/votter"
+)
+
+// Formats of --format for the project file.
+const (
+	FormatJSON = votter.FormatJSON
+	FormatYAML = votter.FormatYAML
+)
+
+// validFormat checks the --format of the project file against the registered exporters.
+func validFormat(format string) error {
+	if _, ok := votter.LookupExporter(format); !ok {
+		return fmt.Errorf("Error: Unknown format '%s', expected %s", format, strings.Join(votter.ExportFormats(), " or "))
+	}
+	return nil
+}
+
+// FormatOutput is one more format of --format and the file it's written to, "" for the annotations file with the
+// extension of the format.
+type FormatOutput struct {
+	Format string
+	Path   string
+}
+
+// formatExtensions are the file extensions of formats not named like their extension.
+var formatExtensions = map[string]string{FormatCOCO: ".coco.json", FormatCOCORotated: ".coco-rotated.json"}
+
+// formatAliases are other names of formats in --format, vott for the VoTT JSON project.
+var formatAliases = map[string]string{"vott": FormatJSON}
+
+// parseFormats splits a --format list like json,yaml,coco=out/coco.json into the format of the annotations file
+// and the other formats with their paths.
+func parseFormats(value string) (string, []FormatOutput, error) {
+	var first string
+	var others []FormatOutput
+	seen := make(map[string]bool)
+	for i, item := range strings.Split(value, ",") {
+		format, path, _ := strings.Cut(strings.TrimSpace(item), "=")
+		if alias, ok := formatAliases[format]; ok {
+			format = alias
+		}
+		if _, ok := votter.LookupDirectoryExporter(format); ok {
+			if i == 0 {
+				return "", nil, fmt.Errorf("Error: Format '%s' writes a directory, it can't be the first --format, which is the annotations file", format)
+			}
+		} else if _, ok := votter.LookupExporter(format); !ok {
+			return "", nil, fmt.Errorf("Error: Unknown format '%s', expected %s", format, strings.Join(votter.OutputFormats(), " or "))
+		}
+		if seen[format] {
+			return "", nil, fmt.Errorf("Error: Format '%s' is listed twice in --format", format)
+		}
+		seen[format] = true
+		if i == 0 {
+			if path != "" {
+				return "", nil, fmt.Errorf("Error: The first --format '%s' is written to the annotations file, it takes no path", format)
+			}
+			first = format
+			continue
+		}
+		others = append(others, FormatOutput{Format: format, Path: path})
+	}
+	return first, others, nil
+}
+
+// path returns where the format is written, next to the annotations file unless given: annotations.json -> annotations.yaml,
+// and a directory for directory formats: annotations.json -> annotations-yolo
+func (output FormatOutput) path(annotationFile string) string {
+	if output.Path != "" {
+		return output.Path
+	}
+	ext, ok := formatExtensions[output.Format]
+	if _, directory := votter.LookupDirectoryExporter(output.Format); directory {
+		ext = "-" + output.Format
+	} else if !ok {
+		ext = "." + output.Format
+	}
+	return strings.TrimSuffix(annotationFile, filepath.Ext(annotationFile)) + ext
+}
+
+// writeFormat writes a project in a registered format to path, the directory of a directory format.
+func writeFormat(path string, model VottJsonModel, format string) error {
+	if exporter, ok := votter.LookupDirectoryExporter(format); ok {
+		return exporter(model, path)
+	}
+	return writeVottModelAs(path, model, format)
+}
+
+// encodeVottModel encodes a project in a registered format, JSON when none is given.
+func encodeVottModel(model VottJsonModel, format string) ([]byte, error) {
+	if format == "" {
+		format = FormatJSON
+	}
+	exporter, ok := votter.LookupExporter(format)
+	if !ok {
+		return nil, validFormat(format)
+	}
+	return exporter(model)
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"votter/mod/votter"
+)
+
+func Test_WriteVottModelAsYAML(t *testing.T) {
+	assets := []Asset{{
+		Format:  "jpg",
+		ID:      "a1",
+		Name:    "true",
+		Path:    "file:/data/cat/true",
+		Size:    Size{Width: 4, Height: 3},
+		Label:   "cat",
+		Regions: []Region{{ID: "r1", Type: "RECTANGLE", Tags: []string{"cat"}, BoundingBox: BoundingBox{Width: 4, Height: 3}, Points: []Point{{X: 0, Y: 0}, {X: 4, Y: 3}}}},
+	}}
+	model := buildVottModel(assets, []string{"cat"})
+	path := filepath.Join(t.TempDir(), "annotations.yaml")
+	if err := writeVottModelAs(path, model, FormatYAML); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := string(data)
+	if !strings.HasPrefix(text, "name: \"\"\nsecurityToken:") || !strings.Contains(text, "version: 2.2.0") || strings.Contains(text, "{") {
+		t.Errorf("Expected block style YAML in the order of the JSON fields, found:\n%s", text)
+	}
+
+	read, err := readVottJSON(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(read.Assets["a1"].Regions, model.Assets["a1"].Regions) || read.Assets["a1"].Asset.Name != "true" {
+		t.Errorf("Expected the YAML to read back as the project, found %+v", read.Assets["a1"])
+	}
+}
+
+func Test_ParseFormats(t *testing.T) {
+	first, others, err := parseFormats("json, yaml,coco=out/coco.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != FormatJSON || !reflect.DeepEqual(others, []FormatOutput{{Format: FormatYAML}, {Format: FormatCOCO, Path: "out/coco.json"}}) {
+		t.Errorf("Expected json for the annotations file and the others with their paths, found %s and %+v", first, others)
+	}
+	if path := others[0].path("data/annotations.json"); path != "data/annotations.yaml" {
+		t.Errorf("Expected the YAML next to the annotations file, found %s", path)
+	}
+	if path := (FormatOutput{Format: FormatCOCO}).path("annotations.json"); path != "annotations.coco.json" {
+		t.Errorf("Expected the COCO extension, found %s", path)
+	}
+
+	for _, value := range []string{"json,json", "json=a.json", "json,xml"} {
+		if _, _, err := parseFormats(value); err == nil {
+			t.Errorf("Expected --format %s to be rejected", value)
+		}
+	}
+}
+
+func Test_GenerateMoreFormats(t *testing.T) {
+	rootDir := t.TempDir()
+	imagesDir := filepath.Join(rootDir, "images")
+	os.MkdirAll(filepath.Join(imagesDir, "cat"), 0755)
+	writeTestImage(t, filepath.Join(imagesDir, "cat", "image1.jpg"), 10, 10)
+	annotationFile := filepath.Join(rootDir, "annotations.json")
+
+	options := Options{Workers: 2, Format: FormatJSON, MoreFormats: []FormatOutput{{Format: FormatYAML}, {Format: FormatCOCO}}}
+	if code := generate(context.Background(), imagesDir, annotationFile, options); code != ExitSuccesful {
+		t.Fatalf("Expected success, found exit code %d", code)
+	}
+	for _, path := range []string{annotationFile, filepath.Join(rootDir, "annotations.yaml"), filepath.Join(rootDir, "annotations.coco.json")} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("Expected every format to be written, found %v", err)
+		}
+	}
+}
+
+func Test_RegisteredFormat(t *testing.T) {
+	// Formats registered with the votter package, as from an init outside main, are formats of the command.
+	votter.RegisterExporter("lines", func(model votter.VottJsonModel) ([]byte, error) {
+		return []byte(model.Name + "\n"), nil
+	})
+	votter.RegisterImporter("lines", []string{".LINES"}, func(data []byte) (votter.VottJsonModel, error) {
+		return votter.VottJsonModel{Name: strings.TrimSpace(string(data))}, nil
+	})
+
+	if err := validFormat("lines"); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "project.lines")
+	if err := writeVottModelAs(path, VottJsonModel{Name: "pets"}, "lines"); err != nil {
+		t.Fatal(err)
+	}
+	model, err := readVottJSON(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if model.Name != "pets" {
+		t.Errorf("Expected the registered importer to read the project, found %+v", model)
+	}
+
+	if err := validFormat("xml"); err == nil || !strings.Contains(err.Error(), "coco or coco-rotated or json or lines or yaml") {
+		t.Errorf("Expected an error listing the formats, found %v", err)
+	}
+}
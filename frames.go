@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Modes of --multi-frame for animated GIFs: the first frame's size as one asset, an asset per frame, or skip the image.
+const (
+	MultiFrameFirst  = "first"
+	MultiFrameFrames = "frames"
+	MultiFrameSkip   = "skip"
+)
+
+// validMultiFrame checks the --multi-frame mode.
+func validMultiFrame(mode string) error {
+	if mode != MultiFrameFirst && mode != MultiFrameFrames && mode != MultiFrameSkip {
+		return fmt.Errorf("Error: Unknown multi-frame mode '%s', expected first, frames or skip", mode)
+	}
+	return nil
+}
+
+// decodeGIFFile decodes all frames of the GIF at path.
+func decodeGIFFile(path string) (*gif.GIF, error) {
+	return retryRead(Retry, path, nil, func() (*gif.GIF, error) {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		return gif.DecodeAll(file)
+	})
+}
+
+// expandFrames handles the animated GIFs among the assets by mode. With frames, every frame is written as a png into
+// outDir/label/ and becomes an asset with the regions of the GIF. With skip, animated GIFs are left out and returned as skipped.
+func expandFrames(assets []Asset, mode string, outDir string) ([]Asset, []SkippedFile, error) {
+	if mode != MultiFrameFrames && mode != MultiFrameSkip {
+		return assets, nil, nil
+	}
+
+	var expanded []Asset
+	var skipped []SkippedFile
+	for _, asset := range assets {
+		if strings.ToLower(filepath.Ext(asset.Name)) != ".gif" {
+			expanded = append(expanded, asset)
+			continue
+		}
+		animation, err := decodeGIFFile(assetFilePath(asset))
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(animation.Image) < 2 {
+			expanded = append(expanded, asset)
+			continue
+		}
+
+		if mode == MultiFrameSkip {
+			fmt.Printf("Skipping animated image '%s' with %d frames.\n", asset.Name, len(animation.Image))
+			skipped = append(skipped, SkippedFile{Path: assetFilePath(asset), Label: asset.Label, Reason: fmt.Sprintf("animated with %d frames", len(animation.Image))})
+			continue
+		}
+
+		labelDir := filepath.Join(outDir, asset.Label)
+		if err := os.MkdirAll(labelDir, 0755); err != nil {
+			return nil, nil, err
+		}
+		for i, frame := range gifFrames(animation) {
+			name := fmt.Sprintf("%s_frame%03d.png", strings.TrimSuffix(asset.Name, filepath.Ext(asset.Name)), i+1)
+			imgPath := filepath.Join(labelDir, name)
+			if err := encodeImageFile(imgPath, frame); err != nil {
+				return nil, nil, err
+			}
+			imgAbsolutePath, err := filepath.Abs(imgPath)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			frameAsset := asset
+			frameAsset.Name = name
+			frameAsset.Format = "png"
+			frameAsset.Path = "file:" + filepath.ToSlash(imgAbsolutePath)
+			frameAsset.ID = assetID(frameAsset.Path)
+			if asset.Regions != nil {
+				frameAsset.Regions = make([]Region, 0, len(asset.Regions))
+			}
+			for _, region := range asset.Regions {
+				region.ID = uuid.New().String()
+				frameAsset.Regions = append(frameAsset.Regions, region)
+			}
+			expanded = append(expanded, frameAsset)
+		}
+	}
+	return expanded, skipped, nil
+}
+
+// gifFrames composes the frames of an animation as they are shown, each frame drawn over what its disposal left of the previous ones.
+func gifFrames(animation *gif.GIF) []image.Image {
+	bounds := image.Rect(0, 0, animation.Config.Width, animation.Config.Height)
+	canvas := image.NewRGBA(bounds)
+	var frames []image.Image
+	for i, frame := range animation.Image {
+		var previous *image.RGBA
+		disposal := byte(gif.DisposalNone)
+		if i < len(animation.Disposal) {
+			disposal = animation.Disposal[i]
+		}
+		if disposal == gif.DisposalPrevious {
+			previous = image.NewRGBA(bounds)
+			draw.Draw(previous, bounds, canvas, image.Point{}, draw.Src)
+		}
+
+		draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+		shown := image.NewRGBA(bounds)
+		draw.Draw(shown, bounds, canvas, image.Point{}, draw.Src)
+		frames = append(frames, shown)
+
+		switch disposal {
+		case gif.DisposalBackground:
+			draw.Draw(canvas, frame.Bounds(), image.Transparent, image.Point{}, draw.Src)
+		case gif.DisposalPrevious:
+			canvas = previous
+		}
+	}
+	return frames
+}
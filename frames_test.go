@@ -0,0 +1,78 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"image/gif"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTestGIF writes an animation of frames, each a 2x2 square of its own color at a step further to the right.
+func writeTestGIF(t *testing.T, path string, frames int) {
+	t.Helper()
+	palette := color.Palette{color.Transparent, color.White, color.Black}
+	animation := &gif.GIF{Config: image.Config{Width: 2 * frames, Height: 2, ColorModel: palette}}
+	for i := 0; i < frames; i++ {
+		frame := image.NewPaletted(image.Rect(2*i, 0, 2*i+2, 2), palette)
+		for j := range frame.Pix {
+			frame.Pix[j] = uint8(1 + i%2)
+		}
+		animation.Image = append(animation.Image, frame)
+		animation.Delay = append(animation.Delay, 10)
+		animation.Disposal = append(animation.Disposal, gif.DisposalBackground)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if err := gif.EncodeAll(file, animation); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_ExpandFrames(t *testing.T) {
+	dir := t.TempDir()
+	animated := filepath.Join(dir, "animated.gif")
+	still := filepath.Join(dir, "still.gif")
+	writeTestGIF(t, animated, 3)
+	writeTestGIF(t, still, 1)
+	assets := []Asset{
+		{Name: "animated.gif", Label: "cat", Path: "file:" + filepath.ToSlash(animated), Size: Size{Width: 6, Height: 2}, Regions: []Region{{ID: "r1", Tags: []string{"cat"}}}},
+		{Name: "still.gif", Label: "cat", Path: "file:" + filepath.ToSlash(still), Size: Size{Width: 2, Height: 2}},
+	}
+
+	kept, skipped, err := expandFrames(assets, MultiFrameSkip, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(kept) != 1 || kept[0].Name != "still.gif" || len(skipped) != 1 || skipped[0].Path != animated {
+		t.Errorf("Expected only the animated image skipped, found %v and %v", kept, skipped)
+	}
+
+	framesDir := filepath.Join(dir, "frames")
+	expanded, _, err := expandFrames(assets, MultiFrameFrames, framesDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(expanded) != 4 || expanded[0].Name != "animated_frame001.png" || expanded[3].Name != "still.gif" {
+		t.Fatalf("Expected 3 frames and the still image, found %v", expanded)
+	}
+	if expanded[1].Regions[0].ID == "r1" || expanded[1].Regions[0].Tags[0] != "cat" {
+		t.Errorf("Expected the regions of the GIF with new IDs, found %v", expanded[1].Regions)
+	}
+
+	// The disposal clears the first frame's square before the second is drawn.
+	second, err := decodeImageFile(filepath.Join(framesDir, "cat", "animated_frame002.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, _, a := second.At(0, 0).RGBA(); a != 0 {
+		t.Errorf("Expected the first square disposed in the second frame")
+	}
+	if r, _, _, a := second.At(2, 0).RGBA(); a == 0 || r != 0 {
+		t.Errorf("Expected the black square of the second frame")
+	}
+}
@@ -0,0 +1,449 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// The GraphQL of serve is the query language without a library: a single query of fields with arguments, aliases
+// and variables. Fragments, directives, mutations and introspection aren't supported.
+
+// graphqlField is a field of a query, with the fields selected of its value.
+type graphqlField struct {
+	Alias      string
+	Name       string
+	Args       map[string]interface{}
+	Selections []graphqlField
+}
+
+// key is the name of the field in the result.
+func (field graphqlField) key() string {
+	if field.Alias != "" {
+		return field.Alias
+	}
+	return field.Name
+}
+
+// graphqlObject resolves the fields of an object.
+type graphqlObject func(field graphqlField) (interface{}, error)
+
+// graphqlResult is an object of the result, with the fields in the order they were selected.
+type graphqlResult []graphqlEntry
+
+type graphqlEntry struct {
+	Key   string
+	Value interface{}
+}
+
+func (result graphqlResult) MarshalJSON() ([]byte, error) {
+	if result == nil {
+		return []byte("null"), nil
+	}
+	var buffer bytes.Buffer
+	buffer.WriteString("{")
+	for i, entry := range result {
+		if i > 0 {
+			buffer.WriteString(",")
+		}
+		key, _ := json.Marshal(entry.Key)
+		value, err := json.Marshal(entry.Value)
+		if err != nil {
+			return nil, err
+		}
+		buffer.Write(key)
+		buffer.WriteString(":")
+		buffer.Write(value)
+	}
+	buffer.WriteString("}")
+	return buffer.Bytes(), nil
+}
+
+// executeGraphQL runs a query against the root object.
+func executeGraphQL(root graphqlObject, query string, variables map[string]interface{}) (graphqlResult, error) {
+	selections, err := parseGraphQL(query, variables)
+	if err != nil {
+		return nil, err
+	}
+	return resolveSelections(root, selections)
+}
+
+// resolveSelections resolves the selected fields of an object.
+func resolveSelections(object graphqlObject, selections []graphqlField) (graphqlResult, error) {
+	var result graphqlResult
+	for _, field := range selections {
+		value, err := object(field)
+		if err != nil {
+			return nil, err
+		}
+		if value, err = resolveValue(value, field); err != nil {
+			return nil, err
+		}
+		result = append(result, graphqlEntry{Key: field.key(), Value: value})
+	}
+	return result, nil
+}
+
+// resolveValue resolves the selections of a field's value, objects need some and scalars take none.
+func resolveValue(value interface{}, field graphqlField) (interface{}, error) {
+	switch value := value.(type) {
+	case graphqlObject:
+		if value == nil {
+			return nil, nil
+		}
+		if len(field.Selections) == 0 {
+			return nil, fmt.Errorf("Field '%s' is an object, select its fields", field.Name)
+		}
+		return resolveSelections(value, field.Selections)
+	case []graphqlObject:
+		list := make([]interface{}, 0, len(value))
+		for _, item := range value {
+			resolved, err := resolveValue(item, field)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, resolved)
+		}
+		return list, nil
+	}
+	if len(field.Selections) > 0 {
+		return nil, fmt.Errorf("Field '%s' has no fields to select", field.Name)
+	}
+	return value, nil
+}
+
+// unknownField is the error of a field an object doesn't have.
+func unknownField(object string, field graphqlField) error {
+	return fmt.Errorf("Cannot query field '%s' on type '%s'", field.Name, object)
+}
+
+// graphqlParser parses a query into the fields it selects, substituting the variables.
+type graphqlParser struct {
+	tokens    []string
+	position  int
+	variables map[string]interface{}
+}
+
+// parseGraphQL parses a query document of a single query operation, shorthand or named.
+func parseGraphQL(query string, variables map[string]interface{}) ([]graphqlField, error) {
+	tokens, err := lexGraphQL(query)
+	if err != nil {
+		return nil, err
+	}
+	parser := &graphqlParser{tokens: tokens, variables: make(map[string]interface{})}
+	if parser.peek() != "{" {
+		switch operation := parser.next(); operation {
+		case "query":
+		case "mutation", "subscription":
+			return nil, fmt.Errorf("Only queries are supported, not %ss", operation)
+		default:
+			return nil, fmt.Errorf("Expected a query, found '%s'", operation)
+		}
+		if isGraphQLName(parser.peek()) {
+			parser.next()
+		}
+		if parser.peek() == "(" {
+			if err := parser.variableDefinitions(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for name, value := range variables {
+		parser.variables[name] = value
+	}
+	selections, err := parser.selectionSet()
+	if err != nil {
+		return nil, err
+	}
+	if parser.peek() != "" {
+		return nil, fmt.Errorf("Expected a single query, found '%s' after it", parser.peek())
+	}
+	return selections, nil
+}
+
+func (parser *graphqlParser) peek() string {
+	if parser.position < len(parser.tokens) {
+		return parser.tokens[parser.position]
+	}
+	return ""
+}
+
+func (parser *graphqlParser) next() string {
+	token := parser.peek()
+	parser.position++
+	return token
+}
+
+func (parser *graphqlParser) expect(token string) error {
+	if found := parser.next(); found != token {
+		if found == "" {
+			found = "the end"
+		}
+		return fmt.Errorf("Expected '%s', found '%s'", token, found)
+	}
+	return nil
+}
+
+// variableDefinitions reads ($name: Type = default, ...), keeping the defaults.
+func (parser *graphqlParser) variableDefinitions() error {
+	parser.next()
+	for parser.peek() != ")" {
+		if parser.peek() != "$" {
+			return fmt.Errorf("Expected a variable, found '%s'", parser.peek())
+		}
+		parser.next()
+		name := parser.next()
+		if err := parser.expect(":"); err != nil {
+			return err
+		}
+		// The type is left to the resolvers, which check their arguments.
+		for depth := 0; ; {
+			token := parser.peek()
+			if token == "" || (depth == 0 && (token == "=" || token == ")" || token == "$")) {
+				break
+			}
+			if token == "[" {
+				depth++
+			} else if token == "]" {
+				depth--
+			}
+			parser.next()
+		}
+		if parser.peek() == "=" {
+			parser.next()
+			value, err := parser.value()
+			if err != nil {
+				return err
+			}
+			parser.variables[name] = value
+		}
+	}
+	return parser.expect(")")
+}
+
+// selectionSet reads { field field ... }.
+func (parser *graphqlParser) selectionSet() ([]graphqlField, error) {
+	if err := parser.expect("{"); err != nil {
+		return nil, err
+	}
+	var fields []graphqlField
+	for parser.peek() != "}" {
+		token := parser.next()
+		switch {
+		case token == "...":
+			return nil, fmt.Errorf("Fragments are not supported")
+		case !isGraphQLName(token):
+			return nil, fmt.Errorf("Expected a field, found '%s'", token)
+		}
+		field := graphqlField{Name: token}
+		if parser.peek() == ":" {
+			parser.next()
+			field.Alias = field.Name
+			if field.Name = parser.next(); !isGraphQLName(field.Name) {
+				return nil, fmt.Errorf("Expected a field after alias '%s'", field.Alias)
+			}
+		}
+		if parser.peek() == "(" {
+			args, err := parser.arguments()
+			if err != nil {
+				return nil, err
+			}
+			field.Args = args
+		}
+		if parser.peek() == "@" {
+			return nil, fmt.Errorf("Directives are not supported")
+		}
+		if parser.peek() == "{" {
+			selections, err := parser.selectionSet()
+			if err != nil {
+				return nil, err
+			}
+			field.Selections = selections
+		}
+		fields = append(fields, field)
+	}
+	parser.next()
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("Expected fields in { }")
+	}
+	return fields, nil
+}
+
+// arguments reads (name: value, ...).
+func (parser *graphqlParser) arguments() (map[string]interface{}, error) {
+	parser.next()
+	args := make(map[string]interface{})
+	for parser.peek() != ")" {
+		name := parser.next()
+		if !isGraphQLName(name) {
+			return nil, fmt.Errorf("Expected an argument, found '%s'", name)
+		}
+		if err := parser.expect(":"); err != nil {
+			return nil, err
+		}
+		value, err := parser.value()
+		if err != nil {
+			return nil, err
+		}
+		args[name] = value
+	}
+	parser.next()
+	return args, nil
+}
+
+// value reads a variable, number, string, boolean, null, enum value, list or object.
+func (parser *graphqlParser) value() (interface{}, error) {
+	token := parser.next()
+	switch {
+	case token == "$":
+		name := parser.next()
+		value, ok := parser.variables[name]
+		if !ok {
+			return nil, nil // unset variables are null
+		}
+		return value, nil
+	case token == "[":
+		var list []interface{}
+		for parser.peek() != "]" {
+			if parser.peek() == "" {
+				return nil, fmt.Errorf("Expected ']'")
+			}
+			item, err := parser.value()
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, item)
+		}
+		parser.next()
+		return list, nil
+	case token == "{":
+		object := make(map[string]interface{})
+		for parser.peek() != "}" {
+			name := parser.next()
+			if err := parser.expect(":"); err != nil {
+				return nil, err
+			}
+			item, err := parser.value()
+			if err != nil {
+				return nil, err
+			}
+			object[name] = item
+		}
+		parser.next()
+		return object, nil
+	case strings.HasPrefix(token, `"`):
+		return strconv.Unquote(token)
+	case token == "true" || token == "false":
+		return token == "true", nil
+	case token == "null":
+		return nil, nil
+	case isGraphQLName(token):
+		return token, nil
+	}
+	if number, err := strconv.ParseFloat(token, 64); err == nil {
+		return number, nil
+	}
+	return nil, fmt.Errorf("Expected a value, found '%s'", token)
+}
+
+// lexGraphQL splits a query into tokens: punctuators, names, numbers and quoted strings. Commas and comments are
+// skipped as GraphQL ignores them.
+func lexGraphQL(query string) ([]string, error) {
+	var tokens []string
+	runes := []rune(query)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r) || r == ',' || r == '\uFEFF':
+			i++
+		case r == '#':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case strings.ContainsRune("{}()[]:=!$@", r):
+			tokens = append(tokens, string(r))
+			i++
+		case r == '.':
+			if i+2 >= len(runes) || runes[i+1] != '.' || runes[i+2] != '.' {
+				return nil, fmt.Errorf("Unexpected '.' in query")
+			}
+			tokens = append(tokens, "...")
+			i += 3
+		case r == '"':
+			j := i + 1
+			for ; j < len(runes) && runes[j] != '"'; j++ {
+				if runes[j] == '\\' {
+					j++
+				}
+			}
+			if j >= len(runes) {
+				return nil, fmt.Errorf("Unterminated string in query")
+			}
+			tokens = append(tokens, string(runes[i:j+1]))
+			i = j + 1
+		case r == '_' || r == '-' || unicode.IsLetter(r) || unicode.IsDigit(r):
+			j := i + 1
+			for j < len(runes) && (runes[j] == '_' || runes[j] == '.' || runes[j] == '+' || runes[j] == '-' || unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j])) {
+				j++
+			}
+			tokens = append(tokens, string(runes[i:j]))
+			i = j
+		default:
+			return nil, fmt.Errorf("Unexpected '%c' in query", r)
+		}
+	}
+	return tokens, nil
+}
+
+// isGraphQLName reports whether a token is a name, of fields, arguments or enum values.
+func isGraphQLName(token string) bool {
+	if token == "" || !(token[0] == '_' || unicode.IsLetter(rune(token[0]))) {
+		return false
+	}
+	for _, r := range token {
+		if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// Arguments of resolvers, by type. Missing and null arguments are the zero value and not set.
+
+func stringArg(field graphqlField, name string) (string, bool, error) {
+	value, ok := field.Args[name]
+	if !ok || value == nil {
+		return "", false, nil
+	}
+	text, ok := value.(string)
+	if !ok {
+		return "", false, fmt.Errorf("Argument '%s' of '%s' must be a String", name, field.Name)
+	}
+	return text, true, nil
+}
+
+func floatArg(field graphqlField, name string) (float64, bool, error) {
+	value, ok := field.Args[name]
+	if !ok || value == nil {
+		return 0, false, nil
+	}
+	number, ok := value.(float64)
+	if !ok {
+		return 0, false, fmt.Errorf("Argument '%s' of '%s' must be a number", name, field.Name)
+	}
+	return number, true, nil
+}
+
+func intArg(field graphqlField, name string) (int, bool, error) {
+	number, set, err := floatArg(field, name)
+	if err != nil || !set {
+		return 0, set, err
+	}
+	if number != float64(int(number)) || number < 0 {
+		return 0, false, fmt.Errorf("Argument '%s' of '%s' must be a positive Int", name, field.Name)
+	}
+	return int(number), true, nil
+}
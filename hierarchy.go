@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// parseTagHierarchy parses comma separated child:parent pairs into a map of tag to parent tag.
+// A tag has one parent, and no tag may be its own ancestor.
+func parseTagHierarchy(value string) (map[string]string, error) {
+	hierarchy := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		child, parent, found := strings.Cut(pair, ":")
+		child, parent = strings.TrimSpace(child), strings.TrimSpace(parent)
+		if !found || child == "" || parent == "" {
+			return nil, fmt.Errorf("Error: Invalid tag hierarchy '%s', expected child:parent pairs", pair)
+		}
+		if existing, ok := hierarchy[child]; ok && existing != parent {
+			return nil, fmt.Errorf("Error: Tag '%s' has two parents, '%s' and '%s'", child, existing, parent)
+		}
+		hierarchy[child] = parent
+	}
+
+	for tag := range hierarchy {
+		seen := map[string]bool{tag: true}
+		for parent, ok := hierarchy[tag]; ok; parent, ok = hierarchy[parent] {
+			if seen[parent] {
+				return nil, fmt.Errorf("Error: Tag hierarchy has a cycle through '%s'", parent)
+			}
+			seen[parent] = true
+		}
+	}
+	return hierarchy, nil
+}
+
+// tagAncestors returns the parent, grandparent and so on of a tag.
+func tagAncestors(tag string, hierarchy map[string]string) []string {
+	var ancestors []string
+	for parent, ok := hierarchy[tag]; ok; parent, ok = hierarchy[parent] {
+		ancestors = append(ancestors, parent)
+	}
+	return ancestors
+}
+
+// withAncestorTags adds the ancestors of every tag after it, once.
+func withAncestorTags(tags []string, hierarchy map[string]string) []string {
+	var all []string
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		for _, t := range append([]string{tag}, tagAncestors(tag, hierarchy)...) {
+			if !seen[t] {
+				seen[t] = true
+				all = append(all, t)
+			}
+		}
+	}
+	return all
+}
+
+// applyTagHierarchy sets the parent of the project tags in the hierarchy.
+func applyTagHierarchy(project *VottJsonModel, hierarchy map[string]string) {
+	for i, tag := range project.Tags {
+		if parent, ok := hierarchy[tag.Name]; ok {
+			project.Tags[i].Parent = parent
+		}
+	}
+}
+
+// projectTagHierarchy returns the hierarchy of the parents of the project tags.
+func projectTagHierarchy(tags []Tag) map[string]string {
+	hierarchy := make(map[string]string)
+	for _, tag := range tags {
+		if tag.Parent != "" {
+			hierarchy[tag.Name] = tag.Parent
+		}
+	}
+	return hierarchy
+}
+
+// addAncestorTags tags every region with the ancestors of its tags as well.
+func addAncestorTags(assets []Asset, hierarchy map[string]string) []Asset {
+	for i := range assets {
+		for j := range assets[i].Regions {
+			assets[i].Regions[j].Tags = withAncestorTags(assets[i].Regions[j].Tags, hierarchy)
+		}
+	}
+	return assets
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_ParseTagHierarchy(t *testing.T) {
+	hierarchy, err := parseTagHierarchy("siamese:cat, cat:animal,dog:animal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hierarchy["siamese"] != "cat" || hierarchy["cat"] != "animal" || len(hierarchy) != 3 {
+		t.Errorf("Expected 3 child:parent pairs, found %v", hierarchy)
+	}
+
+	for _, invalid := range []string{"cat", "cat:animal,animal:cat", "cat:animal,cat:pet"} {
+		if _, err := parseTagHierarchy(invalid); err == nil {
+			t.Errorf("Expected an error for '%s'", invalid)
+		}
+	}
+}
+
+func Test_AddAncestorTags(t *testing.T) {
+	hierarchy := map[string]string{"siamese": "cat", "cat": "animal", "dog": "animal"}
+	assets := []Asset{{Regions: []Region{{Tags: []string{"siamese", "dog"}}}}}
+
+	assets = addAncestorTags(assets, hierarchy)
+	if tags := assets[0].Regions[0].Tags; !reflect.DeepEqual(tags, []string{"siamese", "cat", "animal", "dog"}) {
+		t.Errorf("Expected leaf and ancestor tags, found %v", tags)
+	}
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// HistoryDir holds the snapshots of annotation files next to them, content-addressed in objects/ with index.json listing them.
+const HistoryDir = ".votter-history"
+
+// HistoryIndex lists the snapshots of every annotation file in a folder, oldest first.
+type HistoryIndex struct {
+	Entries []HistoryEntry `json:"entries"`
+}
+
+// HistoryEntry is a version of an annotation file.
+type HistoryEntry struct {
+	File     string `json:"file"`
+	Snapshot string `json:"snapshot"`
+	Size     int64  `json:"size"`
+	Time     string `json:"time"`
+	Command  string `json:"command"`
+}
+
+// historyPaths returns the folder of the snapshots and the index for an annotation file.
+func historyPaths(annotationFile string) (string, string) {
+	dir := filepath.Join(filepath.Dir(annotationFile), HistoryDir)
+	return filepath.Join(dir, "objects"), filepath.Join(dir, "index.json")
+}
+
+// readHistory reads the history index for an annotation file. A missing index is an empty history.
+func readHistory(annotationFile string) (HistoryIndex, error) {
+	var index HistoryIndex
+	_, indexPath := historyPaths(annotationFile)
+	data, err := ioutil.ReadFile(indexPath)
+	if os.IsNotExist(err) {
+		return index, nil
+	}
+	if err != nil {
+		return index, err
+	}
+	if err := json.Unmarshal(data, &index); err != nil {
+		return index, fmt.Errorf("Error: Cannot read history '%s': %v", indexPath, err)
+	}
+	return index, nil
+}
+
+// fileHistory returns the entries of one annotation file, oldest first. Version n is entry n-1.
+func fileHistory(index HistoryIndex, annotationFile string) []HistoryEntry {
+	var entries []HistoryEntry
+	for _, entry := range index.Entries {
+		if entry.File == filepath.Base(annotationFile) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// recordHistory snapshots the annotation file as written by command. Contents equal to the latest version are not recorded again.
+func recordHistory(annotationFile string, command string) error {
+	index, err := readHistory(annotationFile)
+	if err != nil {
+		return err
+	}
+	snapshot, err := fileSHA256(annotationFile)
+	if err != nil {
+		return err
+	}
+	if entries := fileHistory(index, annotationFile); len(entries) > 0 && entries[len(entries)-1].Snapshot == snapshot {
+		return nil
+	}
+
+	objectsDir, indexPath := historyPaths(annotationFile)
+	if err := os.MkdirAll(objectsDir, 0755); err != nil {
+		return err
+	}
+	object := filepath.Join(objectsDir, snapshot)
+	if _, err := os.Stat(object); os.IsNotExist(err) {
+		if err := copyFile(annotationFile, object); err != nil {
+			return err
+		}
+	}
+	info, err := os.Stat(annotationFile)
+	if err != nil {
+		return err
+	}
+
+	index.Entries = append(index.Entries, HistoryEntry{
+		File:     filepath.Base(annotationFile),
+		Snapshot: snapshot,
+		Size:     info.Size(),
+		Time:     time.Now().Format(time.RFC3339),
+		Command:  command,
+	})
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(indexPath, data, 0644)
+}
+
+// rollbackHistory restores version n of the annotation file and records the rollback as its newest version.
+func rollbackHistory(annotationFile string, n int) error {
+	index, err := readHistory(annotationFile)
+	if err != nil {
+		return err
+	}
+	entries := fileHistory(index, annotationFile)
+	if n < 1 || n > len(entries) {
+		return fmt.Errorf("Error: No version %d of '%s', its history has %d versions", n, annotationFile, len(entries))
+	}
+
+	objectsDir, _ := historyPaths(annotationFile)
+	if err := copyFile(filepath.Join(objectsDir, entries[n-1].Snapshot), annotationFile); err != nil {
+		return err
+	}
+	return recordHistory(annotationFile, "rollback "+strconv.Itoa(n))
+}
+
+// runHistory lists the versions of an annotation file kept in its history, oldest first.
+//
+//	votter.exe history <vott-annotations.json>
+func runHistory(args []string) int {
+	flags := flag.NewFlagSet("history", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Println("Usage: votter history <vott-annotations.json>")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return ExitInvalidArguments
+	}
+	annotationFile := flags.Arg(0)
+
+	index, err := readHistory(annotationFile)
+	if err != nil {
+		fmt.Println(err)
+		return ExitAnnotationsNotReadable
+	}
+	entries := fileHistory(index, annotationFile)
+	if len(entries) == 0 {
+		fmt.Printf("No history of '%s'.\n", annotationFile)
+		return ExitSuccesful
+	}
+	for i, entry := range entries {
+		fmt.Printf("%4d  %s  %s  %10d bytes  %s\n", i+1, entry.Time, entry.Snapshot[:12], entry.Size, entry.Command)
+	}
+	return ExitSuccesful
+}
+
+// runRollback restores a version of an annotation file listed by votter history.
+//
+//	votter.exe rollback <n> <vott-annotations.json>
+func runRollback(args []string) int {
+	flags := flag.NewFlagSet("rollback", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Println("Usage: votter rollback <n> <vott-annotations.json>")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return ExitInvalidArguments
+	}
+	n, err := strconv.Atoi(flags.Arg(0))
+	if err != nil {
+		fmt.Printf("Error: Invalid version '%s', expected a number from votter history\n", flags.Arg(0))
+		return ExitInvalidArguments
+	}
+	annotationFile := flags.Arg(1)
+
+	if err := rollbackHistory(annotationFile, n); err != nil {
+		fmt.Println(err)
+		return ExitAnnotationsWriteFailed
+	}
+	fmt.Printf("Rolled '%s' back to version %d.\n", annotationFile, n)
+	return ExitSuccesful
+}
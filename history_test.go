@@ -0,0 +1,41 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_HistoryRollback(t *testing.T) {
+	annotationFile := filepath.Join(t.TempDir(), "annotations.json")
+	for _, contents := range []string{`{"version": 1}`, `{"version": 2}`, `{"version": 2}`} {
+		os.WriteFile(annotationFile, []byte(contents), 0644)
+		if err := recordHistory(annotationFile, "generate"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	index, err := readHistory(annotationFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entries := fileHistory(index, annotationFile); len(entries) != 2 {
+		t.Fatalf("Expected unchanged contents to be recorded once, found %d versions", len(entries))
+	}
+
+	if err := rollbackHistory(annotationFile, 1); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(annotationFile); string(data) != `{"version": 1}` {
+		t.Errorf("Expected version 1 restored, found %s", data)
+	}
+	index, _ = readHistory(annotationFile)
+	entries := fileHistory(index, annotationFile)
+	if len(entries) != 3 || entries[2].Command != "rollback 1" || entries[2].Snapshot != entries[0].Snapshot {
+		t.Errorf("Expected the rollback recorded as the newest version, found %+v", entries)
+	}
+
+	if err := rollbackHistory(annotationFile, 4); err == nil {
+		t.Error("Expected an error for a version not in the history")
+	}
+}
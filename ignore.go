@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// IgnoreFile lists glob patterns of files and folders below the images root that votter skips, one per line.
+const IgnoreFile = ".votterignore"
+
+// readIgnoreFile reads the patterns of the .votterignore in root. Blank lines and lines starting with # are skipped.
+// Returns no patterns if there's no ignore file.
+func readIgnoreFile(root string) ([]string, error) {
+	file, err := os.Open(filepath.Join(root, IgnoreFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var patterns []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		pattern := strings.TrimSpace(scanner.Text())
+		if pattern == "" || strings.HasPrefix(pattern, "#") {
+			continue
+		}
+		patterns = append(patterns, strings.Trim(filepath.ToSlash(pattern), "/"))
+	}
+	return patterns, scanner.Err()
+}
+
+// isIgnored reports whether a path relative to the images root matches an ignore pattern. Patterns with a slash
+// match the whole relative path, others match its file or folder name.
+func isIgnored(patterns []string, relativePath string) bool {
+	relativePath = filepath.ToSlash(relativePath)
+	name := filepath.Base(relativePath)
+	for _, pattern := range patterns {
+		subject := name
+		if strings.Contains(pattern, "/") {
+			subject = relativePath
+		}
+		if matched, _ := filepath.Match(pattern, subject); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// parseIgnorePatterns splits the comma separated patterns of --ignore, in the form of .votterignore lines.
+func parseIgnorePatterns(value string) []string {
+	var patterns []string
+	for _, pattern := range strings.Split(value, ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			patterns = append(patterns, strings.Trim(filepath.ToSlash(pattern), "/"))
+		}
+	}
+	return patterns
+}
+
+// ignoreImages leaves out the images below root that match a pattern, or are in a folder that does.
+// Returns the images kept and the number left out.
+func ignoreImages(images []labeledImage, root string, patterns []string) ([]labeledImage, int) {
+	if len(patterns) == 0 {
+		return images, 0
+	}
+	var kept []labeledImage
+	for _, image := range images {
+		relative, err := filepath.Rel(root, image.Path)
+		if err != nil {
+			relative = image.Path
+		}
+		ignored := false
+		for path := filepath.ToSlash(relative); path != "." && path != "/" && !ignored; path = filepath.ToSlash(filepath.Dir(path)) {
+			ignored = isIgnored(patterns, path)
+		}
+		if !ignored {
+			kept = append(kept, image)
+		}
+	}
+	return kept, len(images) - len(kept)
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_IsIgnored(t *testing.T) {
+	patterns := []string{".*", "cat/raw", "*_backup"}
+	cases := map[string]bool{
+		".cache":             true,
+		"cat/.DS_Store":      true,
+		"cat/raw":            true,
+		"dog/raw":            false,
+		"cat_backup":         true,
+		"cat/image1.jpg":     false,
+		"cat/raw/image1.jpg": false,
+	}
+	for path, expected := range cases {
+		if ignored := isIgnored(patterns, path); ignored != expected {
+			t.Errorf("%s: expected ignored %v, found %v", path, expected, ignored)
+		}
+	}
+}
+
+func Test_FindImagesIgnored(t *testing.T) {
+	rootDir := t.TempDir()
+	for _, dir := range []string{"cat", "cat_backup"} {
+		os.MkdirAll(filepath.Join(rootDir, dir), 0755)
+		writeTestImage(t, filepath.Join(rootDir, dir, "image1.jpg"), 10, 10)
+	}
+	os.WriteFile(filepath.Join(rootDir, IgnoreFile), []byte("# copies\n*_backup\n"), 0644)
+
+	labels, err := findImages(context.Background(), rootDir, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, found := labels["cat_backup"]; found || len(labels) != 1 {
+		t.Errorf("Expected the ignored folder to be skipped, found %v", labels)
+	}
+}
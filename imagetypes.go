@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultImageExtensions are the file extensions taken for images without --extensions.
+var DefaultImageExtensions = []string{".png", ".jpg", ".jpeg", ".gif", ".bmp"}
+
+// ImageExtensions are the file extensions taken for images, set by --extensions.
+var ImageExtensions = DefaultImageExtensions
+
+// VoTT asset types, of the type field of assets.
+const (
+	AssetTypeUnknown    = 0
+	AssetTypeImage      = 1
+	AssetTypeVideo      = 2
+	AssetTypeVideoFrame = 3
+)
+
+// sniffLength is how many bytes of a file are read to sniff its MIME type.
+const sniffLength = 512
+
+// SniffImages makes files count as images by their content rather than their extension, set by --sniff.
+var SniffImages = false
+
+// parseExtensions parses a comma separated list of extensions like jpg,.PNG,tif into lower case with a dot.
+func parseExtensions(value string) ([]string, error) {
+	var extensions []string
+	for _, ext := range strings.Split(value, ",") {
+		ext = strings.ToLower(strings.TrimSpace(ext))
+		if ext == "" {
+			continue
+		}
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		if strings.ContainsAny(ext[1:], `./\`) {
+			return nil, fmt.Errorf("Error: Invalid extension '%s', expected a list like jpg,png", ext)
+		}
+		extensions = append(extensions, ext)
+	}
+	if len(extensions) == 0 {
+		return nil, fmt.Errorf("Error: --extensions lists no extensions")
+	}
+	return extensions, nil
+}
+
+// isImage reports whether a file name has one of the image extensions.
+func isImage(filename string) bool {
+	ext := strings.ToLower(filepath.Ext(filename))
+	for _, candidate := range ImageExtensions {
+		if ext == candidate {
+			return true
+		}
+	}
+	return false
+}
+
+// isImageFile reports whether the file at path is an image: by its content with --sniff, else by its extension.
+func isImageFile(path string) bool {
+	if SniffImages {
+		return sniffImageFormat(path) != ""
+	}
+	return isImage(path)
+}
+
+// sniffImageFormat returns the format of an image file by its content, as registered with the image package,
+// or an empty string when it's not an image votter can decode.
+func sniffImageFormat(path string) string {
+	OpenFiles.acquire()
+	defer OpenFiles.release()
+	file, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+	_, format, err := image.DecodeConfig(file)
+	if err != nil {
+		return ""
+	}
+	return format
+}
+
+// sniffMediaType returns the MIME type of content, the start of a file, without parameters, like image/jpeg.
+func sniffMediaType(content []byte) string {
+	mediaType, _, err := mime.ParseMediaType(http.DetectContentType(content))
+	if err != nil {
+		return ""
+	}
+	return mediaType
+}
+
+// assetType returns the VoTT asset type of a file by its sniffed MIME type. Files that decoded as an image are
+// images whatever their MIME type, like TIFFs that aren't sniffed.
+func assetType(mediaType string, decoded string) int {
+	switch {
+	case strings.HasPrefix(mediaType, "image/") || decoded != "":
+		return AssetTypeImage
+	case strings.HasPrefix(mediaType, "video/"):
+		return AssetTypeVideo
+	}
+	return AssetTypeUnknown
+}
+
+// assetFormat returns the format of an asset by its content: the subtype of its sniffed MIME type, or else the
+// format it decoded as, so a JPEG named .png is a jpg. The file extension is kept when it agrees with the content,
+// like .jpeg, or when the content says nothing.
+func assetFormat(filename string, mediaType string, decoded string) string {
+	ext := strings.TrimPrefix(filepath.Ext(filename), ".")
+	content := decoded
+	if subtype, found := strings.CutPrefix(mediaType, "image/"); found {
+		content = strings.TrimPrefix(subtype, "x-")
+	}
+	if content == "jpeg" {
+		content = "jpg"
+	}
+	lower := strings.ToLower(ext)
+	if content == "" || lower == content || (lower == "jpeg" && content == "jpg") {
+		return ext
+	}
+	return content
+}
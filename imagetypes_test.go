@@ -0,0 +1,86 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_ParseExtensions(t *testing.T) {
+	extensions, err := parseExtensions(" jpg,.PNG,, tif")
+	if err != nil || !reflect.DeepEqual(extensions, []string{".jpg", ".png", ".tif"}) {
+		t.Errorf("Expected [.jpg .png .tif], found %v: %v", extensions, err)
+	}
+	for _, value := range []string{"", ",", "tar.gz", "../jpg"} {
+		if _, err := parseExtensions(value); err == nil {
+			t.Errorf("Expected an error for '%s'", value)
+		}
+	}
+}
+
+func Test_SniffImages(t *testing.T) {
+	defer func() { ImageExtensions, SniffImages = DefaultImageExtensions, false }()
+	dir := filepath.Join(t.TempDir(), "cat")
+	os.MkdirAll(dir, 0755)
+	writeTestImage(t, filepath.Join(dir, "photo.png"), 10, 10) // a JPEG
+	writeTestImage(t, filepath.Join(dir, "IMG_0001"), 10, 10)
+	writeTestImage(t, filepath.Join(dir, "scan.tif"), 10, 10)
+	os.WriteFile(filepath.Join(dir, "notes.jpg"), []byte("not an image"), 0644)
+
+	if names, _ := listImages(dir); !reflect.DeepEqual(names, []string{"notes.jpg", "photo.png"}) {
+		t.Errorf("Expected the images by extension, found %v", names)
+	}
+	ImageExtensions = []string{".tif"}
+	if names, _ := listImages(dir); !reflect.DeepEqual(names, []string{"scan.tif"}) {
+		t.Errorf("Expected the images of --extensions, found %v", names)
+	}
+
+	SniffImages = true
+	names, _ := listImages(dir)
+	if !reflect.DeepEqual(names, []string{"IMG_0001", "photo.png", "scan.tif"}) {
+		t.Errorf("Expected the images by content, found %v", names)
+	}
+	asset, err := generateVottEntry(filepath.Join(dir, "photo.png"), "cat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if asset.Format != "jpg" {
+		t.Errorf("Expected the format of the content, found %s", asset.Format)
+	}
+}
+
+func Test_AssetFormat(t *testing.T) {
+	cases := []struct {
+		filename, mediaType, decoded, format string
+	}{
+		{"image.jpg", "image/jpeg", "jpeg", "jpg"},
+		{"image.jpeg", "image/jpeg", "jpeg", "jpeg"},
+		{"image.PNG", "image/png", "png", "PNG"},
+		{"image.png", "image/jpeg", "jpeg", "jpg"},
+		{"IMG_0001", "image/bmp", "bmp", "bmp"},
+		{"scan.tiff", "application/octet-stream", "tiff", "tiff"},
+		{"scan.tif", "application/octet-stream", "", "tif"},
+	}
+	for _, c := range cases {
+		if format := assetFormat(c.filename, c.mediaType, c.decoded); format != c.format {
+			t.Errorf("Expected format %s of %s, found %s", c.format, c.filename, format)
+		}
+	}
+}
+
+func Test_AssetType(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "cat")
+	os.MkdirAll(dir, 0755)
+	writeTestImage(t, filepath.Join(dir, "photo.gif"), 10, 10) // a JPEG
+	asset, err := generateVottEntry(filepath.Join(dir, "photo.gif"), "cat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if asset.Type != AssetTypeImage || asset.Format != "jpg" {
+		t.Errorf("Expected a jpg image by its content, found type %d format %s", asset.Type, asset.Format)
+	}
+	if assetType("video/mp4", "") != AssetTypeVideo || assetType("text/plain", "") != AssetTypeUnknown {
+		t.Errorf("Expected asset types by MIME type")
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// HashState records the content hash of every image at the last run, keyed by asset path.
+type HashState struct {
+	Files map[string]string `json:"files"`
+}
+
+// hashStatePath returns the path of the hash state next to the annotations file: annotations.json -> annotations-hashes.json
+func hashStatePath(annotationFile string) string {
+	ext := filepath.Ext(annotationFile)
+	return strings.TrimSuffix(annotationFile, ext) + "-hashes" + ext
+}
+
+// readHashState reads the hash state of the last run. A missing file is an empty state, everything is new.
+func readHashState(path string) (HashState, error) {
+	state := HashState{Files: make(map[string]string)}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return state, err
+	}
+	err = json.Unmarshal(data, &state)
+	return state, err
+}
+
+// validIncremental checks that the options go together with --incremental, when it's set.
+func validIncremental(options Options) error {
+	if !options.Incremental {
+		return nil
+	}
+	if options.OnlyLabels != "" {
+		return fmt.Errorf("Error: --only-labels and --incremental don't go together")
+	}
+	if options.Collect != "" {
+		return fmt.Errorf("Error: --collect and --incremental don't go together")
+	}
+	if options.Tile != "" || options.Resize > 0 || options.Augment != "" || options.Rename != "" || options.ShardSize > 0 {
+		return fmt.Errorf("Error: --incremental cannot be combined with --tile, --resize, --augment, --rename or --shard-size")
+	}
+	return nil
+}
+
+// writeHashState writes the hash state for the next run.
+func writeHashState(path string, state HashState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+// imageAssetPath returns the file: asset path of an image, as generateVottEntry writes it.
+func imageAssetPath(imgPath string) (string, error) {
+	imgAbsolutePath, err := filepath.Abs(imgPath)
+	if err != nil {
+		return "", err
+	}
+	return "file:" + filepath.ToSlash(imgAbsolutePath), nil
+}
+
+// incrementalChanges hashes every labeled image and compares it to the hash state and project of the last run.
+// Returns the assets of unchanged images as they were, the images that are new or changed and need generating,
+// and the hash state for the next run.
+func incrementalChanges(images []labeledImage, annotationFile string, workers int) ([]Asset, []labeledImage, HashState, error) {
+	current := HashState{Files: make(map[string]string)}
+
+	previous, err := readHashState(hashStatePath(annotationFile))
+	if err != nil {
+		return nil, nil, current, err
+	}
+	previousAssets := make(map[string]AssetDetail)
+	if len(previous.Files) > 0 {
+		model, err := readVottJSON(annotationFile)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, nil, current, err
+		}
+		for _, detail := range model.Assets {
+			previousAssets[fileURIPath(detail.Asset.Path)] = detail
+		}
+	}
+
+	type job struct {
+		image     labeledImage
+		assetPath string
+		hash      string
+		err       error
+	}
+	var jobs []*job
+	for _, image := range images {
+		jobs = append(jobs, &job{image: image})
+	}
+
+	next := make(chan *job)
+	var waitGroup sync.WaitGroup
+	for w := 0; w < max(1, workers); w++ {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			for j := range next {
+				if j.assetPath, j.err = imageAssetPath(j.image.Path); j.err == nil {
+					j.hash, j.err = fileSHA256(j.image.Path)
+				}
+			}
+		}()
+	}
+	for _, j := range jobs {
+		next <- j
+	}
+	close(next)
+	waitGroup.Wait()
+
+	var unchanged []Asset
+	var changed []labeledImage
+	for _, j := range jobs {
+		if j.err != nil {
+			return nil, nil, current, j.err
+		}
+		current.Files[j.assetPath] = j.hash
+
+		detail, found := previousAssets[fileURIPath(j.assetPath)]
+		if found && previous.Files[j.assetPath] == j.hash && detail.Asset.Label == j.image.Label {
+			asset := detail.Asset
+			asset.Regions = detail.Regions
+			unchanged = append(unchanged, asset)
+			continue
+		}
+		changed = append(changed, j.image)
+	}
+
+	return unchanged, changed, current, nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_IncrementalChanges(t *testing.T) {
+	rootDir := t.TempDir()
+	labelDir := filepath.Join(rootDir, "cat")
+	if err := os.Mkdir(labelDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeTestImage(t, filepath.Join(labelDir, "image1.jpg"), 10, 10)
+	writeTestImage(t, filepath.Join(labelDir, "image2.jpg"), 10, 10)
+	images := labeledImages(rootDir, map[string][]string{"cat": {"image1.jpg", "image2.jpg"}})
+	annotationFile := filepath.Join(rootDir, "annotations.json")
+
+	// The first run has no state, everything is generated.
+	unchanged, changed, state, err := incrementalChanges(images, annotationFile, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(unchanged) != 0 || len(changed) != 2 {
+		t.Fatalf("Expected all images to need generating, found %d unchanged and %v", len(unchanged), changed)
+	}
+	assets, err := generateImageEntries(changed, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writeVottJSON(annotationFile, assets, []string{"cat"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeHashState(hashStatePath(annotationFile), state); err != nil {
+		t.Fatal(err)
+	}
+
+	// The second run only regenerates the changed image and keeps the asset ID of the other.
+	writeTestImage(t, filepath.Join(labelDir, "image2.jpg"), 20, 10)
+	unchanged, changed, _, err = incrementalChanges(images, annotationFile, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(unchanged) != 1 || unchanged[0].Name != "image1.jpg" || len(unchanged[0].Regions) != 1 {
+		t.Errorf("Expected image1.jpg to be carried forward with its region, found %v", unchanged)
+	}
+	if len(changed) != 1 || filepath.Base(changed[0].Path) != "image2.jpg" {
+		t.Errorf("Expected image2.jpg to need generating, found %v", changed)
+	}
+
+	var previousID string
+	for _, asset := range assets {
+		if asset.Name == "image1.jpg" {
+			previousID = asset.ID
+		}
+	}
+	if unchanged[0].ID != previousID {
+		t.Errorf("Expected the asset ID %s to be kept, found %s", previousID, unchanged[0].ID)
+	}
+}
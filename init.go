@@ -0,0 +1,100 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// initIgnore is the starter .votterignore, skipping the usual clutter of copied folders.
+const initIgnore = `# Files and folders votter skips, as glob patterns on names or paths relative to this folder.
+.*
+Thumbs.db
+*_backup
+`
+
+// initConfig is the starter votter.yaml, with the images and output of the project and options to start from.
+const initConfig = `# Run votter in this folder to write the annotations. Options are named after the flags.
+images: ./images
+output: ./vott-annotations.json
+strict-labels: warn
+# resize: 1024
+# augment: [hflip]
+`
+
+// runInit creates the folder skeleton of a new dataset: a folder per label with labels.txt, dataset.yaml and
+// .votterignore next to them, and a votter.yaml config to run votter from the project folder.
+//
+//	votter.exe init <name> [-labels cat,dog,bird]
+func runInit(args []string) int {
+	flags := flag.NewFlagSet("init", flag.ExitOnError)
+	labelsFlag := flags.String("labels", "", "Comma separated labels to create a folder for")
+	flags.Usage = func() {
+		fmt.Println("Usage: votter init <name> [options]")
+		flags.PrintDefaults()
+	}
+	// Options may come before or after the name.
+	flags.Parse(args)
+	name := flags.Arg(0)
+	if flags.NArg() > 0 {
+		flags.Parse(flags.Args()[1:])
+	}
+	if name == "" || flags.NArg() != 0 {
+		flags.Usage()
+		return ExitInvalidArguments
+	}
+
+	var labels []string
+	for _, label := range strings.Split(*labelsFlag, ",") {
+		if label = strings.TrimSpace(label); label != "" {
+			labels = append(labels, label)
+		}
+	}
+
+	if err := initProject(name, labels); err != nil {
+		fmt.Println(err)
+		return ExitImageWriteFailed
+	}
+	fmt.Printf("Created project '%s' with %d labels. Put the images in '%s', then run votter in '%s'.\n",
+		name, len(labels), filepath.Join(name, "images", "<label>"), name)
+	return ExitSuccesful
+}
+
+// initProject creates the project skeleton in a new or empty folder dir.
+func initProject(dir string, labels []string) error {
+	if entries, err := os.ReadDir(dir); err == nil && len(entries) > 0 {
+		return fmt.Errorf("Error: Cannot create project in '%s', the folder is not empty", dir)
+	}
+
+	imagesDir := filepath.Join(dir, "images")
+	for _, label := range labels {
+		if err := os.MkdirAll(filepath.Join(imagesDir, label), 0755); err != nil {
+			return err
+		}
+	}
+	if err := os.MkdirAll(imagesDir, 0755); err != nil {
+		return err
+	}
+
+	dataset, err := yaml.Marshal(DatasetMetadata{Name: filepath.Base(dir), Version: "0.1.0"})
+	if err != nil {
+		return err
+	}
+	files := map[string]string{
+		filepath.Join(dir, ConfigFileDefault):       initConfig,
+		filepath.Join(imagesDir, LabelsFileDefault): strings.Join(append(labels, ""), "\n"),
+		filepath.Join(imagesDir, IgnoreFile):        initIgnore,
+		filepath.Join(imagesDir, DatasetFiles[0]):   string(dataset),
+	}
+	for path, contents := range files {
+		if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_InitProject(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "pets")
+	if err := initProject(dir, []string{"cat", "dog"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{"votter.yaml", "images/cat", "images/dog", "images/.votterignore"} {
+		if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(path))); err != nil {
+			t.Errorf("Expected '%s' in the project: %v", path, err)
+		}
+	}
+	labels, err := readLabelsFile(filepath.Join(dir, "images", LabelsFileDefault))
+	if err != nil || !reflect.DeepEqual(labels, []string{"cat", "dog"}) {
+		t.Errorf("Expected the labels in labels.txt, found %v, %v", labels, err)
+	}
+	metadata, err := readDatasetMetadata(filepath.Join(dir, "images"))
+	if err != nil || metadata == nil || metadata.Name != "pets" {
+		t.Errorf("Expected the dataset to be named after the project, found %v, %v", metadata, err)
+	}
+
+	if err := initProject(dir, nil); err == nil {
+		t.Error("Expected an error for a folder that is not empty")
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"github.com/google/uuid"
+
+	"votter/mod/votter"
+)
+
+// Keypoint visibilities, as in COCO.
+const (
+	KeypointUnlabeled = votter.KeypointUnlabeled
+	KeypointHidden    = votter.KeypointHidden
+	KeypointVisible   = votter.KeypointVisible
+)
+
+// Keypoint is a named point of a region, with its COCO visibility.
+type Keypoint = votter.Keypoint
+
+// SidecarKeypoints is an instance in the keypoints of an image sidecar, a region of named points.
+// It's tagged with the asset's label unless it has tags of its own.
+type SidecarKeypoints struct {
+	Tags       []string               `json:"tags"`
+	Points     []Keypoint             `json:"points"`
+	Attributes map[string]interface{} `json:"attributes"`
+}
+
+// keypointRegion returns a rectangle region around the labeled keypoints of an instance, carrying the keypoints.
+func keypointRegion(asset Asset, instance SidecarKeypoints) Region {
+	region := fullImageRegion(asset)
+	if len(instance.Tags) > 0 {
+		region.Tags = instance.Tags
+	}
+	region.Keypoints = instance.Points
+	region.Attributes = instance.Attributes
+
+	var labeled []Point
+	for _, keypoint := range instance.Points {
+		if keypoint.Visibility != KeypointUnlabeled {
+			labeled = append(labeled, Point{X: keypoint.X, Y: keypoint.Y})
+		}
+	}
+	if len(labeled) > 0 {
+		box := pointsBounds(labeled)
+		region.BoundingBox = box
+		region.Points = []Point{{X: box.Left, Y: box.Top}, {X: box.Left + box.Width, Y: box.Top + box.Height}}
+	}
+	return region
+}
+
+// keypointPointRegions adds a VoTT POINT region per labeled keypoint, tagged with its name, for --keypoint-regions.
+// Returns the assets and the keypoint names in order of appearance, for the project tags.
+func keypointPointRegions(assets []Asset) ([]Asset, []string) {
+	var names []string
+	for i, asset := range assets {
+		var points []Region
+		for _, region := range asset.Regions {
+			for _, keypoint := range region.Keypoints {
+				if keypoint.Visibility == KeypointUnlabeled {
+					continue
+				}
+				points = append(points, Region{
+					ID:          uuid.New().String(),
+					Type:        "POINT",
+					Tags:        []string{keypoint.Name},
+					BoundingBox: BoundingBox{Left: keypoint.X, Top: keypoint.Y},
+					Points:      []Point{{X: keypoint.X, Y: keypoint.Y}},
+				})
+				names = mergeTags(names, []string{keypoint.Name})
+			}
+		}
+		assets[i].Regions = append(assets[i].Regions, points...)
+	}
+	return assets, names
+}
+
+// mapKeypoints returns the keypoints with their coordinates mapped by transform. Keypoints that transform drops
+// become unlabeled at 0,0, as COCO has them.
+func mapKeypoints(keypoints []Keypoint, transform func(x, y float64) (float64, float64, bool)) []Keypoint {
+	if keypoints == nil {
+		return nil
+	}
+	mapped := make([]Keypoint, len(keypoints))
+	for i, keypoint := range keypoints {
+		x, y, ok := transform(keypoint.X, keypoint.Y)
+		if !ok || keypoint.Visibility == KeypointUnlabeled {
+			mapped[i] = Keypoint{Name: keypoint.Name, Visibility: KeypointUnlabeled}
+			continue
+		}
+		keypoint.X, keypoint.Y = x, y
+		mapped[i] = keypoint
+	}
+	return mapped
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_SidecarKeypoints(t *testing.T) {
+	dir := t.TempDir()
+	image := filepath.Join(dir, "image1.jpg")
+	sidecar := `{"keypoints": [{"points": [
+		{"name": "nose", "x": 10.5, "y": 20, "visibility": 2},
+		{"name": "left_eye", "x": 30, "y": 5, "visibility": 1},
+		{"name": "right_eye", "x": 0, "y": 0, "visibility": 0}
+	]}, {"tags": ["dog"], "points": [{"name": "nose", "x": 1, "y": 2, "visibility": 2}]}]}`
+	if err := ioutil.WriteFile(sidecarPath(image), []byte(sidecar), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	assets := []Asset{{Path: "file:" + filepath.ToSlash(image), Label: "person", Size: Size{Width: 40, Height: 30}, Regions: []Region{{Tags: []string{"person"}}}}}
+	assets, tags, err := applySidecars(assets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(tags, []string{"person", "dog"}) {
+		t.Errorf("Expected the instance tags [person dog], found %v", tags)
+	}
+	if len(assets[0].Regions) != 2 {
+		t.Fatalf("Expected a region per instance in place of the default region, found %+v", assets[0].Regions)
+	}
+	person := assets[0].Regions[0]
+	if person.BoundingBox != (BoundingBox{Left: 10.5, Top: 5, Width: 19.5, Height: 15}) || len(person.Keypoints) != 3 {
+		t.Errorf("Expected a box around the labeled keypoints, found %+v", person)
+	}
+
+	assets, names := keypointPointRegions(assets)
+	if !reflect.DeepEqual(names, []string{"nose", "left_eye"}) {
+		t.Errorf("Expected the labeled keypoint names, found %v", names)
+	}
+	if len(assets[0].Regions) != 5 || assets[0].Regions[2].Type != "POINT" || assets[0].Regions[2].Points[0] != (Point{X: 10.5, Y: 20}) {
+		t.Errorf("Expected a POINT region per labeled keypoint, found %+v", assets[0].Regions)
+	}
+}
+
+func Test_MapKeypoints(t *testing.T) {
+	keypoints := []Keypoint{{Name: "a", X: 5, Y: 5, Visibility: 2}, {Name: "b", X: 50, Y: 5, Visibility: 1}, {Name: "c", Visibility: 0}}
+	mapped := mapKeypoints(keypoints, func(x, y float64) (float64, float64, bool) {
+		return x * 2, y * 2, x < 10
+	})
+	expected := []Keypoint{{Name: "a", X: 10, Y: 10, Visibility: 2}, {Name: "b"}, {Name: "c"}}
+	if !reflect.DeepEqual(mapped, expected) {
+		t.Errorf("Expected %+v, found %+v", expected, mapped)
+	}
+	if mapKeypoints(nil, nil) != nil {
+		t.Error("Expected no keypoints for regions without them")
+	}
+}
+
+func Test_COCOKeypoints(t *testing.T) {
+	assets := []Asset{{
+		Format: "jpg", ID: "a1", Name: "image1.jpg", Path: "file:/data/image1.jpg", Size: Size{Width: 40, Height: 30}, Label: "person",
+		Regions: []Region{
+			{ID: "r1", Type: "RECTANGLE", Tags: []string{"person"}, Keypoints: []Keypoint{{Name: "nose", X: 1, Y: 2, Visibility: 2}}},
+			{ID: "r2", Type: "RECTANGLE", Tags: []string{"person"}, Keypoints: []Keypoint{{Name: "neck", X: 3, Y: 4, Visibility: 1}}},
+		},
+	}}
+	data, err := exportCOCO(buildVottModel(assets, []string{"person"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dataset cocoDataset
+	if err := json.Unmarshal(data, &dataset); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(dataset.Categories[0].Keypoints, []string{"nose", "neck"}) {
+		t.Errorf("Expected the keypoint names of the category, found %+v", dataset.Categories)
+	}
+	second := dataset.Annotations[1]
+	if !reflect.DeepEqual(second.Keypoints, []float64{0, 0, 0, 3, 4, 1}) || *second.NumKeypoints != 1 {
+		t.Errorf("Expected keypoints in the category's order, found %+v", second)
+	}
+
+	model, err := importCOCO(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, detail := range model.Assets {
+		expected := []Keypoint{{Name: "nose"}, {Name: "neck", X: 3, Y: 4, Visibility: 1}}
+		if !reflect.DeepEqual(detail.Regions[1].Keypoints, expected) {
+			t.Errorf("Expected the keypoints read back, found %+v", detail.Regions[1].Keypoints)
+		}
+	}
+}
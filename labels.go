@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// LabelsFileDefault is read from the images root when no labels file is given and it exists.
+const LabelsFileDefault = "labels.txt"
+
+// readLabelsFile reads a labels file of one class per line. Blank lines and lines starting with # are skipped.
+func readLabelsFile(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var labels []string
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		label := strings.TrimSpace(scanner.Text())
+		if label == "" || strings.HasPrefix(label, "#") || seen[label] {
+			continue
+		}
+		seen[label] = true
+		labels = append(labels, label)
+	}
+	return labels, scanner.Err()
+}
+
+// findLabelsFile returns the labels file to use: the given path, or else labels.txt in the images root if there is one.
+func findLabelsFile(path string, imagesPath string) string {
+	if path != "" {
+		return path
+	}
+	if _, err := os.Stat(filepath.Join(imagesPath, LabelsFileDefault)); err == nil {
+		return filepath.Join(imagesPath, LabelsFileDefault)
+	}
+	return ""
+}
+
+// orderLabels returns the fixed labels in their order, all of them so their positions stay the same across datasets,
+// followed by any other found labels sorted by name. A label's position is its tag order and class index.
+func orderLabels(found []string, fixed []string) []string {
+	ordered := append([]string{}, fixed...)
+	known := make(map[string]bool)
+	for _, label := range fixed {
+		known[label] = true
+	}
+
+	var extra []string
+	for _, label := range found {
+		if !known[label] {
+			extra = append(extra, label)
+		}
+	}
+	sort.Strings(extra)
+	return append(ordered, extra...)
+}
+
+// StrictLabels is the --strict-labels mode for labels missing from the labels file. It's a boolean flag that also takes
+// 'warn': -strict-labels fails the run, -strict-labels=warn only reports them.
+type StrictLabels string
+
+const (
+	StrictLabelsOff  StrictLabels = ""
+	StrictLabelsFail StrictLabels = "fail"
+	StrictLabelsWarn StrictLabels = "warn"
+)
+
+func (s *StrictLabels) String() string { return string(*s) }
+
+func (s *StrictLabels) IsBoolFlag() bool { return true }
+
+func (s *StrictLabels) Set(value string) error {
+	switch strings.ToLower(value) {
+	case "true", "fail":
+		*s = StrictLabelsFail
+	case "warn":
+		*s = StrictLabelsWarn
+	case "false", "off", "":
+		*s = StrictLabelsOff
+	default:
+		return fmt.Errorf("expected true, false or warn")
+	}
+	return nil
+}
+
+// unknownLabels describes every found label missing from the fixed labels, suggesting a fixed label that differs only in case.
+func unknownLabels(found []string, fixed []string) []string {
+	known := make(map[string]bool)
+	for _, label := range fixed {
+		known[label] = true
+	}
+
+	var unknown []string
+	for _, label := range found {
+		if known[label] {
+			continue
+		}
+		problem := fmt.Sprintf("Label '%s' is not in the labels file", label)
+		for _, fixedLabel := range fixed {
+			if strings.EqualFold(strings.TrimSpace(label), fixedLabel) {
+				problem += fmt.Sprintf(", did you mean '%s'?", fixedLabel)
+				break
+			}
+		}
+		unknown = append(unknown, problem)
+	}
+	sort.Strings(unknown)
+	return unknown
+}
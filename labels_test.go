@@ -0,0 +1,74 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_ReadLabelsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "labels.txt")
+	if err := os.WriteFile(path, []byte("# classes\ndog\n\ncat \ndog\nbird\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	labels, err := readLabelsFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(labels, []string{"dog", "cat", "bird"}) {
+		t.Errorf("Expected [dog cat bird], found %v", labels)
+	}
+}
+
+func Test_FindLabelsFile(t *testing.T) {
+	dir := t.TempDir()
+	if path := findLabelsFile("", dir); path != "" {
+		t.Errorf("Expected no labels file, found %s", path)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "labels.txt"), []byte("cat\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if path := findLabelsFile("", dir); path != filepath.Join(dir, "labels.txt") {
+		t.Errorf("Expected labels.txt in the images root, found %s", path)
+	}
+	if path := findLabelsFile("other.txt", dir); path != "other.txt" {
+		t.Errorf("Expected the given labels file, found %s", path)
+	}
+}
+
+func Test_OrderLabels(t *testing.T) {
+	ordered := orderLabels([]string{"zebra", "cat", "ant"}, []string{"dog", "cat"})
+	if !reflect.DeepEqual(ordered, []string{"dog", "cat", "ant", "zebra"}) {
+		t.Errorf("Expected [dog cat ant zebra], found %v", ordered)
+	}
+
+	ordered = orderLabels([]string{"zebra", "cat", "ant"}, nil)
+	if !reflect.DeepEqual(ordered, []string{"ant", "cat", "zebra"}) {
+		t.Errorf("Expected labels sorted by name, found %v", ordered)
+	}
+}
+
+func Test_StrictLabels(t *testing.T) {
+	var strict StrictLabels
+	for value, expected := range map[string]StrictLabels{"true": StrictLabelsFail, "warn": StrictLabelsWarn, "false": StrictLabelsOff} {
+		if err := strict.Set(value); err != nil || strict != expected {
+			t.Errorf("Set(%s): expected %q, found %q (%v)", value, expected, strict, err)
+		}
+	}
+	if err := strict.Set("maybe"); err == nil {
+		t.Error("Expected an error for an unknown mode")
+	}
+}
+
+func Test_UnknownLabels(t *testing.T) {
+	unknown := unknownLabels([]string{"cat", "Dog", "bird"}, []string{"cat", "dog"})
+	expected := []string{
+		"Label 'Dog' is not in the labels file, did you mean 'dog'?",
+		"Label 'bird' is not in the labels file",
+	}
+	if !reflect.DeepEqual(unknown, expected) {
+		t.Errorf("Expected %v, found %v", expected, unknown)
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LabelSettingsFile is the optional file in a label folder that overrides the global settings for its class.
+const LabelSettingsFile = "label.yaml"
+
+// hexColor is a tag color as VoTT writes them.
+var hexColor = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// LabelSettings are the overrides of a label folder's label.yaml.
+type LabelSettings struct {
+	// Name is the tag of the folder's images, in place of the folder name.
+	Name string `yaml:"name"`
+	// Color is the tag's color in VoTT, #rrggbb.
+	Color string `yaml:"color"`
+	// Parent is the parent of the tag in the tag hierarchy, as with --tag-hierarchy.
+	Parent string `yaml:"parent"`
+	// Attributes are added to the assets of the folder's images.
+	Attributes map[string]interface{} `yaml:"attributes"`
+	// Skip leaves the folder's images out.
+	Skip bool `yaml:"skip"`
+	// Description, Shortcut and ExternalID are added to the tag.
+	Description string `yaml:"description"`
+	Shortcut    string `yaml:"shortcut"`
+	ExternalID  string `yaml:"external-id"`
+}
+
+// readLabelSettings reads the label.yaml files of the images' label folders below imagesPath, by folder name.
+// Labels without a folder or file have no settings.
+func readLabelSettings(imagesPath string, images []labeledImage) (map[string]LabelSettings, error) {
+	settings := make(map[string]LabelSettings)
+	read := make(map[string]bool)
+	shortcuts := make(map[string]string)
+	for _, image := range images {
+		if read[image.Label] {
+			continue
+		}
+		read[image.Label] = true
+		path := filepath.Join(imagesPath, image.Label, LabelSettingsFile)
+		data, err := ioutil.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		var label LabelSettings
+		if err := yaml.Unmarshal(data, &label); err != nil {
+			return nil, fmt.Errorf("Error: Cannot parse '%s': %v", path, err)
+		}
+		if label.Color != "" && !hexColor.MatchString(label.Color) {
+			return nil, fmt.Errorf("Error: Invalid color '%s' in '%s', expected #rrggbb", label.Color, path)
+		}
+		if label.Shortcut != "" {
+			if other, taken := shortcuts[strings.ToLower(label.Shortcut)]; taken {
+				return nil, fmt.Errorf("Error: Shortcut '%s' of '%s' is taken by label %s already", label.Shortcut, path, other)
+			}
+			shortcuts[strings.ToLower(label.Shortcut)] = image.Label
+		}
+		settings[image.Label] = label
+	}
+	return settings, nil
+}
+
+// applyLabelSettings leaves out the images of skipped labels and renames the labels with a name.
+// Returns the images and the skipped labels.
+func applyLabelSettings(images []labeledImage, settings map[string]LabelSettings) ([]labeledImage, []string) {
+	var kept []labeledImage
+	var skipped []string
+	for _, image := range images {
+		label, ok := settings[image.Label]
+		if ok && label.Skip {
+			skipped = mergeTags(skipped, []string{image.Label})
+			continue
+		}
+		if ok && label.Name != "" {
+			image.Label = label.Name
+		}
+		kept = append(kept, image)
+	}
+	return kept, skipped
+}
+
+// labelSettingsTag returns the tag of a label folder's images, its name or else the folder name.
+func labelSettingsTag(folder string, label LabelSettings) string {
+	if label.Name != "" {
+		return label.Name
+	}
+	return folder
+}
+
+// labelSettingsHierarchy returns the tag hierarchy with the parents of label.yaml files added. It fails when a
+// tag gets two parents.
+func labelSettingsHierarchy(hierarchy map[string]string, settings map[string]LabelSettings) (map[string]string, error) {
+	merged := make(map[string]string, len(hierarchy))
+	for child, parent := range hierarchy {
+		merged[child] = parent
+	}
+	for folder, label := range settings {
+		if label.Parent == "" {
+			continue
+		}
+		tag := labelSettingsTag(folder, label)
+		if existing, ok := merged[tag]; ok && existing != label.Parent {
+			return nil, fmt.Errorf("Error: Tag '%s' has two parents, '%s' and '%s' from its %s", tag, existing, label.Parent, LabelSettingsFile)
+		}
+		merged[tag] = label.Parent
+	}
+	return merged, nil
+}
+
+// labelSettingsColors returns the colors of the tags of label.yaml files that set one.
+func labelSettingsColors(settings map[string]LabelSettings) map[string]string {
+	colors := make(map[string]string)
+	for folder, label := range settings {
+		if label.Color != "" {
+			colors[labelSettingsTag(folder, label)] = label.Color
+		}
+	}
+	return colors
+}
+
+// applyLabelSettingsTags sets the description, shortcut and external ID of the project tags of label.yaml files.
+func applyLabelSettingsTags(project *VottJsonModel, settings map[string]LabelSettings) {
+	byTag := make(map[string]LabelSettings)
+	for folder, label := range settings {
+		byTag[labelSettingsTag(folder, label)] = label
+	}
+	for i, tag := range project.Tags {
+		if label, ok := byTag[tag.Name]; ok {
+			project.Tags[i].Description = label.Description
+			project.Tags[i].Shortcut = label.Shortcut
+			project.Tags[i].ExternalID = label.ExternalID
+		}
+	}
+}
+
+// applyLabelSettingsAttributes adds the attributes of label.yaml files to the assets of the images in their folder
+// below imagesPath. Attributes the asset has already, from sidecars or asset lines, stay.
+func applyLabelSettingsAttributes(assets []Asset, imagesPath string, settings map[string]LabelSettings) []Asset {
+	byFolder := make(map[string]map[string]interface{})
+	for folder, label := range settings {
+		if len(label.Attributes) > 0 {
+			if abs, err := filepath.Abs(filepath.Join(imagesPath, folder)); err == nil {
+				byFolder[abs] = label.Attributes
+			}
+		}
+	}
+	if len(byFolder) == 0 {
+		return assets
+	}
+	for i, asset := range assets {
+		folder, err := filepath.Abs(filepath.Dir(assetFilePath(asset)))
+		if err != nil || byFolder[folder] == nil {
+			continue
+		}
+		attributes := make(map[string]interface{}, len(byFolder[folder])+len(asset.Attributes))
+		for key, value := range byFolder[folder] {
+			attributes[key] = value
+		}
+		for key, value := range asset.Attributes {
+			attributes[key] = value
+		}
+		assets[i].Attributes = attributes
+	}
+	return assets
+}
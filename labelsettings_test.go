@@ -0,0 +1,90 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_LabelSettings(t *testing.T) {
+	dir := t.TempDir()
+	for _, label := range []string{"cat", "dog", "blurry"} {
+		os.MkdirAll(filepath.Join(dir, label), 0755)
+		writeTestImage(t, filepath.Join(dir, label, "image1.jpg"), 10, 10)
+	}
+	os.WriteFile(filepath.Join(dir, "cat", LabelSettingsFile), []byte("name: Domestic cat\ncolor: \"#ff8800\"\nparent: animal\nattributes: {source: shelter}\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "blurry", LabelSettingsFile), []byte("skip: true\n"), 0644)
+	images := []labeledImage{
+		{Path: filepath.Join(dir, "blurry", "image1.jpg"), Label: "blurry"},
+		{Path: filepath.Join(dir, "cat", "image1.jpg"), Label: "cat"},
+		{Path: filepath.Join(dir, "dog", "image1.jpg"), Label: "dog"},
+	}
+
+	settings, err := readLabelSettings(dir, images)
+	if err != nil {
+		t.Fatal(err)
+	}
+	images, skipped := applyLabelSettings(images, settings)
+	if !reflect.DeepEqual(skipped, []string{"blurry"}) || len(images) != 2 || images[0].Label != "Domestic cat" || images[1].Label != "dog" {
+		t.Fatalf("Expected blurry skipped and cat renamed, found %v and %+v", skipped, images)
+	}
+	hierarchy, err := labelSettingsHierarchy(map[string]string{"dog": "animal"}, settings)
+	if err != nil || !reflect.DeepEqual(hierarchy, map[string]string{"dog": "animal", "Domestic cat": "animal"}) {
+		t.Errorf("Expected the parent added to the hierarchy, found %v: %v", hierarchy, err)
+	}
+	if _, err := labelSettingsHierarchy(map[string]string{"Domestic cat": "pet"}, settings); err == nil {
+		t.Error("Expected an error for a tag with two parents")
+	}
+	if colors := labelSettingsColors(settings); !reflect.DeepEqual(colors, map[string]string{"Domestic cat": "#ff8800"}) {
+		t.Errorf("Expected the color of the renamed tag, found %v", colors)
+	}
+
+	assets, err := generateImageEntries(images, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assets[0].Attributes = map[string]interface{}{"camera": "gate-2"}
+	assets = applyLabelSettingsAttributes(assets, dir, settings)
+	if !reflect.DeepEqual(assets[0].Attributes, map[string]interface{}{"camera": "gate-2", "source": "shelter"}) || assets[1].Attributes != nil {
+		t.Errorf("Expected the folder's attributes added and dog without attributes, found %v and %v", assets[0].Attributes, assets[1].Attributes)
+	}
+
+	os.WriteFile(filepath.Join(dir, "dog", LabelSettingsFile), []byte("color: orange\n"), 0644)
+	if _, err := readLabelSettings(dir, images); err == nil {
+		t.Error("Expected an error for a color that isn't #rrggbb")
+	}
+}
+
+func Test_LabelSettingsTags(t *testing.T) {
+	dir := t.TempDir()
+	for _, label := range []string{"cat", "dog"} {
+		os.MkdirAll(filepath.Join(dir, label), 0755)
+	}
+	os.WriteFile(filepath.Join(dir, "cat", LabelSettingsFile), []byte("name: Domestic cat\ndescription: Any house cat\nshortcut: c\nexternal-id: wikidata:Q146\n"), 0644)
+	images := []labeledImage{{Path: filepath.Join(dir, "cat", "image1.jpg"), Label: "cat"}, {Path: filepath.Join(dir, "dog", "image1.jpg"), Label: "dog"}}
+
+	settings, err := readLabelSettings(dir, images)
+	if err != nil {
+		t.Fatal(err)
+	}
+	project := buildVottModel(nil, []string{"Domestic cat", "dog"})
+	applyLabelSettingsTags(&project, settings)
+	expected := Tag{Name: "Domestic cat", Color: "#ff0000", Description: "Any house cat", Shortcut: "c", ExternalID: "wikidata:Q146"}
+	if project.Tags[0] != expected || project.Tags[1] != (Tag{Name: "dog", Color: "#ff0000"}) {
+		t.Errorf("Expected the metadata of the cat tag only, found %+v", project.Tags)
+	}
+	data, err := encodeVottModel(project, FormatJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"externalId": "wikidata:Q146"`) || strings.Count(string(data), `"shortcut"`) != 1 {
+		t.Errorf("Expected the metadata written for the cat tag only, found %s", data)
+	}
+
+	os.WriteFile(filepath.Join(dir, "dog", LabelSettingsFile), []byte("shortcut: C\n"), 0644)
+	if _, err := readLabelSettings(dir, images); err == nil {
+		t.Error("Expected an error for two labels with the same shortcut")
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import "time"
+
+// FileLimit caps the number of files that concurrent workers have open at the same time. A nil limit doesn't.
+type FileLimit chan struct{}
+
+// OpenFiles is the limit of --max-open-files for listing directories, decoding and hashing images.
+var OpenFiles FileLimit
+
+// reservedFiles are the file descriptors left for stdio, output files and the network.
+const reservedFiles = 32
+
+// newFileLimit returns a limit of max open files, or no limit when max isn't positive.
+func newFileLimit(max int) FileLimit {
+	if max <= 0 {
+		return nil
+	}
+	return make(FileLimit, max)
+}
+
+// acquire waits for a file to be free to open.
+func (limit FileLimit) acquire() {
+	if limit != nil {
+		limit <- struct{}{}
+	}
+}
+
+// acquireUntil waits for a file to be free to open until expired fires. Returns false if it did first.
+func (limit FileLimit) acquireUntil(expired <-chan time.Time) bool {
+	if limit == nil {
+		return true
+	}
+	select {
+	case limit <- struct{}{}:
+		return true
+	case <-expired:
+		return false
+	}
+}
+
+// release frees a file opened after acquire.
+func (limit FileLimit) release() {
+	if limit != nil {
+		<-limit
+	}
+}
+
+// defaultMaxOpenFiles returns the soft limit of open files of the process less the reserved files, or 0 when it's unknown.
+func defaultMaxOpenFiles() int {
+	soft := openFilesSoftLimit()
+	if soft <= reservedFiles {
+		return 0
+	}
+	return soft - reservedFiles
+}
+
+// stageWorkers returns the workers of a stage, or the general number of workers when the stage has none set.
+func stageWorkers(stage int, workers int) int {
+	if stage > 0 {
+		return stage
+	}
+	return workers
+}
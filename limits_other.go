@@ -0,0 +1,8 @@
+//go:build !unix
+
+package main
+
+// openFilesSoftLimit returns 0, there's no soft limit of open files to detect on this platform.
+func openFilesSoftLimit() int {
+	return 0
+}
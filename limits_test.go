@@ -0,0 +1,51 @@
+package main
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_FileLimit(t *testing.T) {
+	if newFileLimit(0) != nil {
+		t.Error("Expected no limit for 0")
+	}
+	var unlimited FileLimit
+	unlimited.acquire()
+	unlimited.release()
+
+	limit := newFileLimit(2)
+	var open, most int32
+	var waitGroup sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			limit.acquire()
+			defer limit.release()
+			now := atomic.AddInt32(&open, 1)
+			for {
+				previous := atomic.LoadInt32(&most)
+				if now <= previous || atomic.CompareAndSwapInt32(&most, previous, now) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			atomic.AddInt32(&open, -1)
+		}()
+	}
+	waitGroup.Wait()
+	if most > 2 {
+		t.Errorf("Expected at most 2 files open at a time, found %d", most)
+	}
+}
+
+func Test_StageWorkers(t *testing.T) {
+	if workers := stageWorkers(0, 8); workers != 8 {
+		t.Errorf("Expected the general workers, found %d", workers)
+	}
+	if workers := stageWorkers(2, 8); workers != 2 {
+		t.Errorf("Expected the stage workers, found %d", workers)
+	}
+}
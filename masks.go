@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// maskFile returns the mask of an image, an image with the same base name in masksDir/folder/ where folder is the
+// image's own folder, or directly in masksDir for flat folders. Returns an empty string if there's none.
+func maskFile(masksDir string, imgPath string) string {
+	imgFileName := filepath.Base(imgPath)
+	base := strings.TrimSuffix(imgFileName, filepath.Ext(imgFileName))
+	for _, dir := range []string{filepath.Join(masksDir, filepath.Base(filepath.Dir(imgPath))), masksDir} {
+		for _, ext := range []string{".png", ".gif", ".jpg", ".jpeg", ".bmp"} {
+			path := filepath.Join(dir, base+ext)
+			if _, err := os.Stat(path); err == nil {
+				return path
+			}
+		}
+	}
+	return ""
+}
+
+// applyMasks replaces the regions of every asset that has a binary mask in masksDir with a region per connected
+// blob of the mask, as a rectangle ("box") or polygon outline ("polygon"). Assets without a mask keep their regions.
+func applyMasks(assets []Asset, masksDir string, shape string) ([]Asset, error) {
+	for i, asset := range assets {
+		path := maskFile(masksDir, assetFilePath(asset))
+		if path == "" {
+			fmt.Printf("No mask for image '%s', keeping its regions.\n", asset.Name)
+			continue
+		}
+
+		mask, err := decodeImageFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		// Masks of another size than their image are scaled to it.
+		scaleX := float64(asset.Size.Width) / float64(mask.Bounds().Dx())
+		scaleY := float64(asset.Size.Height) / float64(mask.Bounds().Dy())
+
+		// An empty mask means no regions at all, not the full image region.
+		regions := []Region{}
+		for _, component := range connectedComponents(mask) {
+			region := componentRegion(component, shape, asset.Label)
+			if scaleX != 1 || scaleY != 1 {
+				region = scaleRegion(region, scaleX, scaleY)
+			}
+			regions = append(regions, region)
+		}
+		assets[i].Regions = regions
+	}
+	return assets, nil
+}
+
+// connectedComponents returns the pixels of every 8-connected blob of foreground pixels in a mask, ordered top to bottom.
+// Pixels brighter than half intensity are foreground.
+func connectedComponents(mask image.Image) [][]image.Point {
+	bounds := mask.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	foreground := make([]bool, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			gray := color.GrayModel.Convert(mask.At(bounds.Min.X+x, bounds.Min.Y+y)).(color.Gray)
+			foreground[y*width+x] = gray.Y > 127
+		}
+	}
+
+	var components [][]image.Point
+	visited := make([]bool, width*height)
+	for start := range foreground {
+		if !foreground[start] || visited[start] {
+			continue
+		}
+
+		var component []image.Point
+		stack := []int{start}
+		visited[start] = true
+		for len(stack) > 0 {
+			current := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			x, y := current%width, current/width
+			component = append(component, image.Pt(x, y))
+
+			for dy := -1; dy <= 1; dy++ {
+				for dx := -1; dx <= 1; dx++ {
+					nx, ny := x+dx, y+dy
+					if nx < 0 || ny < 0 || nx >= width || ny >= height {
+						continue
+					}
+					if neighbour := ny*width + nx; foreground[neighbour] && !visited[neighbour] {
+						visited[neighbour] = true
+						stack = append(stack, neighbour)
+					}
+				}
+			}
+		}
+		components = append(components, component)
+	}
+	return components
+}
+
+// componentRegion returns a region tagged with label around the pixels of a blob: its bounding rectangle for "box",
+// or the outline of its convex hull for "polygon".
+func componentRegion(component []image.Point, shape string, label string) Region {
+	rect := image.Rectangle{Min: component[0], Max: component[0].Add(image.Pt(1, 1))}
+	for _, p := range component {
+		rect = rect.Union(image.Rectangle{Min: p, Max: p.Add(image.Pt(1, 1))})
+	}
+	region := Region{
+		ID:          uuid.New().String(),
+		Type:        "RECTANGLE",
+		Tags:        []string{label},
+		BoundingBox: BoundingBox{Left: float64(rect.Min.X), Top: float64(rect.Min.Y), Width: float64(rect.Dx()), Height: float64(rect.Dy())},
+		Points:      []Point{{X: float64(rect.Min.X), Y: float64(rect.Min.Y)}, {X: float64(rect.Max.X), Y: float64(rect.Max.Y)}},
+	}
+
+	if shape == "polygon" {
+		// The hull goes around the pixel corners, so single pixel wide blobs still have an area.
+		var corners []image.Point
+		for _, p := range component {
+			corners = append(corners, p, p.Add(image.Pt(1, 0)), p.Add(image.Pt(0, 1)), p.Add(image.Pt(1, 1)))
+		}
+		region.Type = "POLYGON"
+		region.Points = nil
+		for _, p := range convexHull(corners) {
+			region.Points = append(region.Points, Point{X: float64(p.X), Y: float64(p.Y)})
+		}
+	}
+	return region
+}
+
+// convexHull returns the convex hull of the points in clockwise order on screen, by Andrew's monotone chain.
+func convexHull(points []image.Point) []image.Point {
+	sorted := append([]image.Point{}, points...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].X < sorted[j].X || (sorted[i].X == sorted[j].X && sorted[i].Y < sorted[j].Y)
+	})
+
+	cross := func(o, a, b image.Point) int {
+		return (a.X-o.X)*(b.Y-o.Y) - (a.Y-o.Y)*(b.X-o.X)
+	}
+
+	var hull []image.Point
+	for pass := 0; pass < 2; pass++ {
+		start := len(hull)
+		for _, p := range sorted {
+			for len(hull) >= start+2 && cross(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+				hull = hull[:len(hull)-1]
+			}
+			hull = append(hull, p)
+		}
+		// Drop the last point, it starts the other half.
+		hull = hull[:len(hull)-1]
+		for i, j := 0, len(sorted)-1; i < j; i, j = i+1, j-1 {
+			sorted[i], sorted[j] = sorted[j], sorted[i]
+		}
+	}
+	return hull
+}
+
+// validMaskShape checks the value of the --mask-regions flag.
+func validMaskShape(shape string) error {
+	if shape != "box" && shape != "polygon" {
+		return fmt.Errorf("Error: Unknown mask region shape '%s', expected box or polygon", shape)
+	}
+	return nil
+}
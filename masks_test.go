@@ -0,0 +1,108 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// twoBlobMask returns a 20x10 mask with a 3x2 blob at 1,1 and a diagonal blob from 10,5 to 12,7.
+func twoBlobMask() *image.Gray {
+	mask := image.NewGray(image.Rect(0, 0, 20, 10))
+	for y := 1; y < 3; y++ {
+		for x := 1; x < 4; x++ {
+			mask.SetGray(x, y, color.Gray{Y: 255})
+		}
+	}
+	for i := 0; i < 3; i++ {
+		mask.SetGray(10+i, 5+i, color.Gray{Y: 255})
+	}
+	return mask
+}
+
+func Test_ConnectedComponents(t *testing.T) {
+	components := connectedComponents(twoBlobMask())
+	if len(components) != 2 {
+		t.Fatalf("Expected 2 components, found %d", len(components))
+	}
+	if len(components[0]) != 6 || len(components[1]) != 3 {
+		t.Errorf("Expected components of 6 and 3 pixels, found %d and %d", len(components[0]), len(components[1]))
+	}
+}
+
+func Test_ComponentRegion(t *testing.T) {
+	component := []image.Point{{X: 1, Y: 1}, {X: 2, Y: 1}, {X: 1, Y: 2}}
+
+	box := componentRegion(component, "box", "cell")
+	if box.BoundingBox != (BoundingBox{Left: 1, Top: 1, Width: 2, Height: 2}) || box.Tags[0] != "cell" {
+		t.Errorf("Expected a 2x2 box tagged cell, found %+v", box)
+	}
+
+	polygon := componentRegion(component, "polygon", "cell")
+	if polygon.Type != "POLYGON" || len(polygon.Points) != 5 {
+		t.Errorf("Expected an L shaped hull of 5 points, found %v", polygon.Points)
+	}
+	if polygon.BoundingBox != box.BoundingBox {
+		t.Errorf("Expected the polygon to have the same bounding box, found %+v", polygon.BoundingBox)
+	}
+}
+
+func Test_ApplyMasks(t *testing.T) {
+	masksDir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(masksDir, "cell"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Create(filepath.Join(masksDir, "cell", "image1.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := png.Encode(file, twoBlobMask()); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	assets := []Asset{
+		{Name: "image1.jpg", Path: "file:/dataset/cell/image1.jpg", Label: "cell", Size: Size{Width: 40, Height: 20}},
+		{Name: "image2.jpg", Path: "file:/dataset/cell/image2.jpg", Label: "cell", Size: Size{Width: 40, Height: 20}, Regions: []Region{{ID: "full"}}},
+	}
+	assets, err = applyMasks(assets, masksDir, "box")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(assets[0].Regions) != 2 {
+		t.Fatalf("Expected a region per blob, found %d", len(assets[0].Regions))
+	}
+	if box := assets[0].Regions[0].BoundingBox; box != (BoundingBox{Left: 2, Top: 2, Width: 6, Height: 4}) {
+		t.Errorf("Expected the box scaled to the image size, found %+v", box)
+	}
+	if len(assets[1].Regions) != 1 || assets[1].Regions[0].ID != "full" {
+		t.Errorf("Expected an image without mask to keep its regions, found %v", assets[1].Regions)
+	}
+}
+
+func Test_ApplyEmptyMask(t *testing.T) {
+	masksDir := t.TempDir()
+	file, err := os.Create(filepath.Join(masksDir, "image1.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := png.Encode(file, image.NewGray(image.Rect(0, 0, 10, 10))); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	assets, err := applyMasks([]Asset{{Name: "image1.jpg", Path: "file:/dataset/image1.jpg", Size: Size{Width: 10, Height: 10}}}, masksDir, "box")
+	if err != nil {
+		t.Fatal(err)
+	}
+	model := buildVottModel(assets, nil)
+	for _, detail := range model.Assets {
+		if len(detail.Regions) != 0 {
+			t.Errorf("Expected no regions for an empty mask, found %v", detail.Regions)
+		}
+	}
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sort"
+)
+
+// memoryEstimateSamples is how many assets are encoded to estimate the size of all of them.
+const memoryEstimateSamples = 100
+
+// spillChunkShare is the share of the memory budget a spilled chunk takes, its assets are sorted in memory.
+const spillChunkShare = 4
+
+// setMemoryBudget makes the garbage collector keep the heap below --max-memory, collecting more often as it gets close.
+func setMemoryBudget(budget int64) {
+	if budget > 0 {
+		debug.SetMemoryLimit(budget)
+	}
+}
+
+// estimateAssetsSize estimates the bytes of the assets in memory from the JSON size of evenly spread samples.
+// Building the project and encoding it takes about this much again, each.
+func estimateAssetsSize(assets []Asset) int64 {
+	if len(assets) == 0 {
+		return 0
+	}
+	step := max(len(assets)/memoryEstimateSamples, 1)
+	var sampled, size int64
+	for i := 0; i < len(assets); i += step {
+		data, err := json.Marshal(assets[i])
+		if err != nil {
+			continue
+		}
+		size += int64(len(data))
+		sampled++
+	}
+	if sampled == 0 {
+		return 0
+	}
+	return size * int64(len(assets)) / sampled
+}
+
+// spillsAssets reports whether the assets are spilled to disk to stay within the memory budget: when building the
+// project in memory and encoding it would take more than the budget left after the assets themselves.
+func spillsAssets(assets []Asset, budget int64) bool {
+	return budget > 0 && 3*estimateAssetsSize(assets) > budget
+}
+
+// assetSource calls yield with assets in the order of their IDs, the order of the keys of encoded projects.
+type assetSource func(yield func(Asset) error) error
+
+// sortedAssets is the source of assets held in memory.
+func sortedAssets(assets []Asset) assetSource {
+	return func(yield func(Asset) error) error {
+		order := make([]int, len(assets))
+		for i := range order {
+			order[i] = i
+		}
+		sort.SliceStable(order, func(a, b int) bool { return assets[order[a]].ID < assets[order[b]].ID })
+		for _, i := range order {
+			if err := yield(assets[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// addSourceAssets adds the assets of source to the project, like addVottAssets.
+func addSourceAssets(model *VottJsonModel, source assetSource) error {
+	return source(func(asset Asset) error {
+		model.Assets[asset.ID] = assetDetail(asset)
+		return nil
+	})
+}
+
+// assetSpill holds assets on disk instead of in memory, as NDJSON chunks each sorted by asset ID in a temporary folder.
+type assetSpill struct {
+	dir    string
+	chunks []string
+	count  int
+}
+
+// spilledAsset is the JSON line of an asset, with the ID it's sorted by.
+type spilledAsset struct {
+	id   string
+	line []byte
+}
+
+// spillLine is an asset as a line of a chunk, with its regions that the asset's own JSON leaves out. Regions stay
+// null when the asset has none, for the full image region to be added when writing as without spilling.
+type spillLine struct {
+	Asset   Asset    `json:"asset"`
+	Regions []Region `json:"regions"`
+}
+
+// spillAssets writes the assets to chunks of about chunkBytes of JSON each in a temporary folder inside dir. Only
+// the chunk being written is held in memory.
+func spillAssets(dir string, assets []Asset, chunkBytes int64) (*assetSpill, error) {
+	temporary, err := os.MkdirTemp(dir, ".votter-spill-")
+	if err != nil {
+		return nil, fmt.Errorf("Error: Cannot create the folder to spill the assets to: %v", err)
+	}
+	spill := &assetSpill{dir: temporary}
+	var chunk []spilledAsset
+	var size int64
+	for i, asset := range assets {
+		line, err := json.Marshal(spillLine{Asset: asset, Regions: asset.Regions})
+		if err != nil {
+			spill.remove()
+			return nil, err
+		}
+		chunk = append(chunk, spilledAsset{id: asset.ID, line: line})
+		size += int64(len(line))
+		if size >= chunkBytes || i == len(assets)-1 {
+			if err := spill.writeChunk(chunk); err != nil {
+				spill.remove()
+				return nil, err
+			}
+			chunk, size = nil, 0
+		}
+	}
+	return spill, nil
+}
+
+// writeChunk writes the lines of a chunk sorted by ID.
+func (spill *assetSpill) writeChunk(chunk []spilledAsset) error {
+	sort.SliceStable(chunk, func(a, b int) bool { return chunk[a].id < chunk[b].id })
+	path := filepath.Join(spill.dir, fmt.Sprintf("chunk-%04d.ndjson", len(spill.chunks)))
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("Error: Cannot spill the assets to '%s': %v", path, err)
+	}
+	writer := bufio.NewWriter(file)
+	for _, spilled := range chunk {
+		writer.Write(spilled.line)
+		writer.WriteString("\n")
+	}
+	if err := writer.Flush(); err != nil {
+		file.Close()
+		return fmt.Errorf("Error: Cannot spill the assets to '%s': %v", path, err)
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	spill.chunks = append(spill.chunks, path)
+	spill.count += len(chunk)
+	return nil
+}
+
+// each merges the chunks by asset ID, holding one asset of every chunk in memory. There are few chunks, a budget
+// apart, so the next asset is the smallest of their heads.
+func (spill *assetSpill) each(yield func(Asset) error) error {
+	decoders := make([]*json.Decoder, len(spill.chunks))
+	heads := make([]*Asset, len(spill.chunks))
+	next := func(i int) error {
+		var line spillLine
+		if err := decoders[i].Decode(&line); err == io.EOF {
+			heads[i] = nil
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("Error: Cannot read the spilled assets of '%s': %v", spill.chunks[i], err)
+		}
+		line.Asset.Regions = line.Regions
+		heads[i] = &line.Asset
+		return nil
+	}
+	for i, path := range spill.chunks {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		decoders[i] = json.NewDecoder(bufio.NewReader(file))
+		if err := next(i); err != nil {
+			return err
+		}
+	}
+
+	for {
+		smallest := -1
+		for i, head := range heads {
+			if head != nil && (smallest < 0 || head.ID < heads[smallest].ID) {
+				smallest = i
+			}
+		}
+		if smallest < 0 {
+			return nil
+		}
+		if err := yield(*heads[smallest]); err != nil {
+			return err
+		}
+		if err := next(smallest); err != nil {
+			return err
+		}
+	}
+}
+
+// readAll reads the spilled assets back into memory, in the order of their IDs.
+func (spill *assetSpill) readAll() ([]Asset, error) {
+	assets := make([]Asset, 0, spill.count)
+	err := spill.each(func(asset Asset) error {
+		assets = append(assets, asset)
+		return nil
+	})
+	return assets, err
+}
+
+// remove deletes the chunks and their folder.
+func (spill *assetSpill) remove() error {
+	return os.RemoveAll(spill.dir)
+}
+
+// writeVottJSONStream writes the JSON of project with the assets of source one at a time, the same bytes as encoding
+// the project with them, without holding the project or its encoding in memory. Goes through a temporary file like
+// writeVottModelAs.
+func writeVottJSONStream(path string, project VottJsonModel, source assetSource) error {
+	project.Assets = map[string]AssetDetail{}
+	head, err := json.MarshalIndent(project, "", "  ")
+	if err != nil {
+		return err
+	}
+	// The empty assets object is where the assets go.
+	const emptyAssets = `"assets": {}`
+	before, after, found := bytes.Cut(head, []byte(emptyAssets))
+	if !found {
+		if err := addSourceAssets(&project, source); err != nil {
+			return err
+		}
+		return writeVottModelAs(path, project, FormatJSON)
+	}
+
+	temporary := path + ".tmp"
+	file, err := os.Create(temporary)
+	if err != nil {
+		return err
+	}
+	writer := bufio.NewWriter(file)
+	writer.Write(before)
+	writer.WriteString(`"assets": {`)
+	written := 0
+	err = source(func(asset Asset) error {
+		if written > 0 {
+			writer.WriteString(",")
+		}
+		id, err := json.Marshal(asset.ID)
+		if err != nil {
+			return err
+		}
+		detail, err := json.MarshalIndent(assetDetail(asset), "    ", "  ")
+		if err != nil {
+			return err
+		}
+		writer.WriteString("\n    ")
+		writer.Write(id)
+		writer.WriteString(": ")
+		writer.Write(detail)
+		written++
+		return nil
+	})
+	if err != nil {
+		file.Close()
+		os.Remove(temporary)
+		return err
+	}
+	if written > 0 {
+		writer.WriteString("\n  ")
+	}
+	writer.WriteString("}")
+	writer.Write(after)
+	if err := writer.Flush(); err != nil {
+		file.Close()
+		os.Remove(temporary)
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(temporary, path)
+}
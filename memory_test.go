@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_WriteVottJSONStream(t *testing.T) {
+	assets := []Asset{
+		{ID: "b2", Name: "image2.jpg", Path: "file:/data/dog/image2.jpg", Size: Size{Width: 20, Height: 10}, Label: "dog", Regions: []Region{{ID: "r2", Tags: []string{"dog"}}}},
+		{ID: "a1", Name: "image1.jpg", Path: "file:/data/cat/image1.jpg", Size: Size{Width: 40, Height: 30}, Label: "cat",
+			Regions: []Region{{ID: "r1", Type: "RECTANGLE", Tags: []string{"cat"}, BoundingBox: BoundingBox{Left: 1, Top: 2, Width: 3, Height: 4}}}},
+	}
+	dir := t.TempDir()
+	project := buildVottModel(nil, []string{"cat", "dog"})
+
+	streamed := filepath.Join(dir, "streamed.json")
+	if err := writeVottJSONStream(streamed, project, sortedAssets(assets)); err != nil {
+		t.Fatal(err)
+	}
+	addVottAssets(&project, assets)
+	encoded := filepath.Join(dir, "encoded.json")
+	if err := writeVottModelAs(encoded, project, FormatJSON); err != nil {
+		t.Fatal(err)
+	}
+	want, _ := os.ReadFile(encoded)
+	got, _ := os.ReadFile(streamed)
+	if string(got) != string(want) {
+		t.Errorf("Expected the same bytes as encoding the project, found\n%s\nexpected\n%s", got, want)
+	}
+
+	empty := filepath.Join(dir, "empty.json")
+	if err := writeVottJSONStream(empty, buildVottModel(nil, nil), sortedAssets(nil)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readVottJSON(empty); err != nil {
+		t.Errorf("Expected a valid project without assets: %v", err)
+	}
+}
+
+func Test_SpillsAssets(t *testing.T) {
+	assets := make([]Asset, 1000)
+	for i := range assets {
+		assets[i] = Asset{ID: "id", Name: "image.jpg", Path: "file:/data/cat/image.jpg", Label: "cat"}
+	}
+	size := estimateAssetsSize(assets)
+	if size < 100000 || size > 200000 {
+		t.Errorf("Expected about 150 bytes per asset, found %d in all", size)
+	}
+	if spillsAssets(assets, 0) {
+		t.Error("Expected no spilling without a budget")
+	}
+	if !spillsAssets(assets, 1024) || spillsAssets(assets, 1<<30) {
+		t.Error("Expected spilling only over the budget")
+	}
+}
+
+func Test_AssetSpill(t *testing.T) {
+	var assets []Asset
+	for _, id := range []string{"e", "b", "d", "a", "c"} {
+		assets = append(assets, Asset{ID: id, Name: id + ".jpg", Path: "file:/data/cat/" + id + ".jpg", Label: "cat"})
+	}
+	assets[3].Regions = []Region{{ID: "r1", Tags: []string{"cat"}}}
+	dir := t.TempDir()
+	// Two assets a chunk.
+	line, _ := json.Marshal(spillLine{Asset: assets[0]})
+	spill, err := spillAssets(dir, assets, int64(2*len(line)-1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(spill.chunks) != 3 {
+		t.Errorf("Expected 3 chunks, found %v", spill.chunks)
+	}
+	read, err := spill.readAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, asset := range read {
+		ids = append(ids, asset.ID)
+	}
+	if !reflect.DeepEqual(ids, []string{"a", "b", "c", "d", "e"}) || read[0].Path != "file:/data/cat/a.jpg" {
+		t.Errorf("Expected the assets merged in the order of their IDs, found %v", read)
+	}
+	if len(read[0].Regions) != 1 || read[0].Regions[0].ID != "r1" || read[1].Regions != nil {
+		t.Errorf("Expected the regions kept, and none where there were none, found %v and %v", read[0].Regions, read[1].Regions)
+	}
+
+	spill.remove()
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("Expected the chunks removed, found %v", entries)
+	}
+}
+
+func Test_GenerateSpilled(t *testing.T) {
+	rootDir := t.TempDir()
+	imagesDir := filepath.Join(rootDir, "images")
+	for _, label := range []string{"cat", "dog"} {
+		os.MkdirAll(filepath.Join(imagesDir, label), 0755)
+		for _, name := range []string{"image1.jpg", "image2.jpg", "image3.jpg"} {
+			writeTestImage(t, filepath.Join(imagesDir, label, name), 10, 10)
+		}
+	}
+	annotationFile := filepath.Join(rootDir, "annotations.yaml")
+	patchFile := filepath.Join(rootDir, "patch.json")
+
+	// A budget of a byte spills every asset to a chunk of its own.
+	options := Options{Workers: 2, NoHistory: true, MaxMemory: 1, Format: FormatYAML, MoreFormats: []FormatOutput{{Format: FormatJSON}}, JSONPatch: patchFile}
+	for run := 0; run < 2; run++ {
+		if code := generate(context.Background(), imagesDir, annotationFile, options); code != ExitSuccesful {
+			t.Fatalf("Expected run %d to succeed, found exit code %d", run+1, code)
+		}
+	}
+	for _, path := range []string{annotationFile, filepath.Join(rootDir, "annotations.json")} {
+		model, err := readVottJSON(path)
+		if err != nil || len(model.Assets) != 6 {
+			t.Errorf("Expected the 6 assets in '%s', found %d: %v", path, len(model.Assets), err)
+		}
+	}
+	data, _ := os.ReadFile(patchFile)
+	if strings.TrimSpace(string(data)) != "[]" {
+		t.Errorf("Expected an empty patch for the same images, found %s", data)
+	}
+	matches, _ := filepath.Glob(filepath.Join(rootDir, ".votter-spill-*"))
+	if len(matches) != 0 {
+		t.Errorf("Expected the spilled chunks removed, found %v", matches)
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+)
+
+// mergeDuplicateAssets merges the assets of identical image files, by content hash, into the first of them for
+// --merge-duplicates. It gets the regions of the duplicates with other labels, those with a label it has already are
+// dropped as copies. Returns the assets and a line per merged duplicate for the report.
+func mergeDuplicateAssets(assets []Asset, workers int) ([]Asset, []string, error) {
+	hashes, err := hashAssets(assets, workers)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	first := make(map[string]int)
+	labels := make(map[int][]string)
+	var merged []Asset
+	var report []string
+	for i, asset := range assets {
+		target, duplicate := first[hashes[i]]
+		if !duplicate {
+			first[hashes[i]] = len(merged)
+			labels[len(merged)] = []string{asset.Label}
+			merged = append(merged, asset)
+			continue
+		}
+
+		kept := &merged[target]
+		if slices.Contains(labels[target], asset.Label) {
+			report = append(report, fmt.Sprintf("Merged '%s' into '%s', a copy with the same label '%s'.", assetFilePath(asset), assetFilePath(*kept), asset.Label))
+			continue
+		}
+		labels[target] = append(labels[target], asset.Label)
+		if kept.Regions == nil {
+			kept.Regions = []Region{fullImageRegion(*kept)}
+		}
+		regions := asset.Regions
+		if regions == nil {
+			regions = []Region{fullImageRegion(asset)}
+		}
+		kept.Regions = append(kept.Regions, regions...)
+		report = append(report, fmt.Sprintf("Merged '%s' into '%s', adding label '%s' to '%s'.", assetFilePath(asset), assetFilePath(*kept), asset.Label, kept.Label))
+	}
+	return merged, report, nil
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_MergeDuplicateAssets(t *testing.T) {
+	dir := t.TempDir()
+	for _, label := range []string{"cat", "pet", "dog"} {
+		os.MkdirAll(filepath.Join(dir, label), 0755)
+	}
+	writeTestImage(t, filepath.Join(dir, "cat", "image1.jpg"), 10, 10)
+	writeTestImage(t, filepath.Join(dir, "dog", "image2.jpg"), 20, 10)
+	data, _ := os.ReadFile(filepath.Join(dir, "cat", "image1.jpg"))
+	os.WriteFile(filepath.Join(dir, "pet", "copy.jpg"), data, 0644)
+	os.WriteFile(filepath.Join(dir, "cat", "again.jpg"), data, 0644)
+
+	images := []labeledImage{
+		{Path: filepath.Join(dir, "cat", "image1.jpg"), Label: "cat"},
+		{Path: filepath.Join(dir, "dog", "image2.jpg"), Label: "dog"},
+		{Path: filepath.Join(dir, "pet", "copy.jpg"), Label: "pet"},
+		{Path: filepath.Join(dir, "cat", "again.jpg"), Label: "cat"},
+	}
+	assets, err := generateImageEntries(images, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assets, report, err := mergeDuplicateAssets(assets, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(assets) != 2 || assets[0].Label != "cat" || assets[1].Label != "dog" {
+		t.Fatalf("Expected the first of the identical images and the other image, found %+v", assets)
+	}
+	if regions := assets[0].Regions; len(regions) != 2 || regions[0].Tags[0] != "cat" || regions[1].Tags[0] != "pet" {
+		t.Errorf("Expected a region per label, found %+v", regions)
+	}
+	if len(report) != 2 || !strings.Contains(report[0], "adding label 'pet'") || !strings.Contains(report[1], "same label 'cat'") {
+		t.Errorf("Expected a line per merged duplicate, found %q", report)
+	}
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"html"
+	"io"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+	"unicode/utf16"
+)
+
+// metadataReadLimit is how much of an image is searched for metadata. Metadata comes before the pixels.
+const metadataReadLimit = 1 << 20
+
+var (
+	xmpSubject = regexp.MustCompile(`(?s)<dc:subject>(.*?)</dc:subject>`)
+	xmpItem    = regexp.MustCompile(`(?s)<rdf:li[^>]*>(.*?)</rdf:li>`)
+)
+
+// addMetadataTags tags the regions of every asset with the keywords embedded in its image as well. Returns the
+// assets and the keywords found, in order of appearance.
+func addMetadataTags(assets []Asset) ([]Asset, []string, error) {
+	var keywords []string
+	for i, asset := range assets {
+		imageKeywords, err := readImageKeywords(assetFilePath(asset))
+		if err != nil {
+			return nil, nil, err
+		}
+		for j := range assets[i].Regions {
+			assets[i].Regions[j].Tags = mergeTags(assets[i].Regions[j].Tags, imageKeywords)
+		}
+		keywords = mergeTags(keywords, imageKeywords)
+	}
+	return assets, keywords, nil
+}
+
+// mergeTags appends the tags of more that aren't in tags yet.
+func mergeTags(tags []string, more []string) []string {
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		seen[tag] = true
+	}
+	for _, tag := range more {
+		if !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// readImageKeywords returns the keywords of an image from its XMP dc:subject, IPTC keywords and EXIF XPKeywords.
+// Metadata that can't be parsed is ignored.
+func readImageKeywords(path string) (keywords []string, err error) {
+	data, err := retryRead(Retry, path, nil, func() ([]byte, error) {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		return ioutil.ReadAll(io.LimitReader(file, metadataReadLimit))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	keywords = mergeTags(keywords, xmpKeywords(data))
+	for _, segment := range jpegSegments(data) {
+		switch {
+		case segment.marker == 0xE1 && bytes.HasPrefix(segment.data, []byte("Exif\x00\x00")):
+			keywords = mergeTags(keywords, exifKeywords(segment.data[6:]))
+		case segment.marker == 0xED:
+			keywords = mergeTags(keywords, iptcKeywords(segment.data))
+		}
+	}
+	return keywords, nil
+}
+
+type jpegSegment struct {
+	marker byte
+	data   []byte
+}
+
+// jpegSegments returns the segments of a JPEG up to the start of the image data, or none for other formats.
+func jpegSegments(data []byte) []jpegSegment {
+	if !bytes.HasPrefix(data, []byte{0xFF, 0xD8}) {
+		return nil
+	}
+	var segments []jpegSegment
+	for offset := 2; offset+4 <= len(data) && data[offset] == 0xFF; {
+		marker := data[offset+1]
+		length := int(binary.BigEndian.Uint16(data[offset+2:]))
+		if marker == 0xDA || length < 2 || offset+2+length > len(data) {
+			break
+		}
+		segments = append(segments, jpegSegment{marker: marker, data: data[offset+4 : offset+2+length]})
+		offset += 2 + length
+	}
+	return segments
+}
+
+// xmpKeywords returns the items of the dc:subject bag of an XMP packet anywhere in data.
+func xmpKeywords(data []byte) []string {
+	var keywords []string
+	for _, subject := range xmpSubject.FindAllSubmatch(data, -1) {
+		for _, item := range xmpItem.FindAllSubmatch(subject[1], -1) {
+			keywords = appendKeyword(keywords, html.UnescapeString(string(item[1])))
+		}
+	}
+	return keywords
+}
+
+// iptcKeywords returns the IPTC keywords (record 2, dataset 25) in a Photoshop APP13 segment.
+func iptcKeywords(data []byte) []string {
+	var keywords []string
+	for offset := 0; offset+5 <= len(data); offset++ {
+		if data[offset] != 0x1C || data[offset+1] != 2 || data[offset+2] != 25 {
+			continue
+		}
+		length := int(binary.BigEndian.Uint16(data[offset+3:]))
+		if offset+5+length > len(data) {
+			break
+		}
+		keywords = appendKeyword(keywords, string(data[offset+5:offset+5+length]))
+		offset += 4 + length
+	}
+	return keywords
+}
+
+// exifKeywords returns the semicolon separated XPKeywords of IFD0 in EXIF data, as Windows writes them.
+func exifKeywords(tiff []byte) []string {
+	if len(tiff) < 8 {
+		return nil
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return nil
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd+2 > len(tiff) {
+		return nil
+	}
+	entries := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < entries; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return nil
+		}
+		if order.Uint16(tiff[entry:]) != 0x9C9E {
+			continue
+		}
+		count := int(order.Uint32(tiff[entry+4:]))
+		value := entry + 8
+		if count > 4 {
+			value = int(order.Uint32(tiff[entry+8:]))
+		}
+		if value < 0 || value+count > len(tiff) {
+			return nil
+		}
+
+		// XPKeywords is null terminated UTF-16LE, whatever the byte order of the rest.
+		units := make([]uint16, count/2)
+		for j := range units {
+			units[j] = binary.LittleEndian.Uint16(tiff[value+2*j:])
+		}
+		text := strings.TrimRight(string(utf16.Decode(units)), "\x00")
+		var keywords []string
+		for _, keyword := range strings.Split(text, ";") {
+			keywords = appendKeyword(keywords, keyword)
+		}
+		return keywords
+	}
+	return nil
+}
+
+// appendKeyword appends keyword without surrounding spaces, unless it's empty.
+func appendKeyword(keywords []string, keyword string) []string {
+	if keyword = strings.TrimSpace(keyword); keyword != "" {
+		keywords = append(keywords, keyword)
+	}
+	return keywords
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/jpeg"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"unicode/utf16"
+)
+
+// jpegWithSegment returns a JPEG image with an extra segment right after its start marker.
+func jpegWithSegment(t *testing.T, marker byte, payload []byte) []byte {
+	t.Helper()
+	var encoded bytes.Buffer
+	if err := jpeg.Encode(&encoded, image.NewRGBA(image.Rect(0, 0, 4, 3)), nil); err != nil {
+		t.Fatal(err)
+	}
+	segment := []byte{0xFF, marker, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	data := append([]byte{}, encoded.Bytes()[:2]...)
+	data = append(data, segment...)
+	data = append(data, payload...)
+	return append(data, encoded.Bytes()[2:]...)
+}
+
+func Test_ReadImageKeywords(t *testing.T) {
+	xmp := []byte("http://ns.adobe.com/xap/1.0/\x00<x:xmpmeta><rdf:RDF><rdf:Description><dc:subject><rdf:Bag>" +
+		"<rdf:li>cat</rdf:li><rdf:li> tabby &amp; striped </rdf:li></rdf:Bag></dc:subject></rdf:Description></rdf:RDF></x:xmpmeta>")
+	iptc := []byte("Photoshop 3.0\x008BIM\x04\x04\x00\x00\x00\x00\x00\x0e\x1c\x02\x19\x00\x03cat\x1c\x02\x19\x00\x04yard")
+
+	// EXIF with one IFD0 entry: XPKeywords as UTF-16LE, stored after the IFD.
+	units := utf16.Encode([]rune("sofa; cat\x00"))
+	exif := []byte("Exif\x00\x00II*\x00\x08\x00\x00\x00\x01\x00\x9e\x9c\x01\x00")
+	exif = binary.LittleEndian.AppendUint32(exif, uint32(2*len(units)))
+	exif = binary.LittleEndian.AppendUint32(exif, 26)
+	exif = binary.LittleEndian.AppendUint32(exif, 0)
+	for _, unit := range units {
+		exif = binary.LittleEndian.AppendUint16(exif, unit)
+	}
+
+	dir := t.TempDir()
+	tests := map[string]struct {
+		data     []byte
+		expected []string
+	}{
+		"xmp.jpg":  {jpegWithSegment(t, 0xE1, xmp), []string{"cat", "tabby & striped"}},
+		"iptc.jpg": {jpegWithSegment(t, 0xED, iptc), []string{"cat", "yard"}},
+		"exif.jpg": {jpegWithSegment(t, 0xE1, exif), []string{"sofa", "cat"}},
+		"none.jpg": {jpegWithSegment(t, 0xFE, []byte("comment")), nil},
+	}
+	for name, test := range tests {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, test.data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		keywords, err := readImageKeywords(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(keywords, test.expected) {
+			t.Errorf("Expected %v in %s, found %v", test.expected, name, keywords)
+		}
+	}
+}
+
+func Test_MergeTags(t *testing.T) {
+	tags := mergeTags([]string{"cat", "dog"}, []string{"dog", "yard", "yard"})
+	if !reflect.DeepEqual(tags, []string{"cat", "dog", "yard"}) {
+		t.Errorf("Expected [cat dog yard], found %v", tags)
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MLflow is the run that --mlflow-uri and --mlflow-run log the generated dataset to.
+type MLflow struct {
+	URI    string // http://localhost:5000
+	RunID  string
+	Client *http.Client
+}
+
+type mlflowParam struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+type mlflowMetric struct {
+	Key       string  `json:"key"`
+	Value     float64 `json:"value"`
+	Timestamp int64   `json:"timestamp"`
+	Step      int     `json:"step"`
+}
+
+type mlflowRun struct {
+	Run struct {
+		Info struct {
+			ArtifactURI string `json:"artifact_uri"`
+		} `json:"info"`
+	} `json:"run"`
+}
+
+// validate checks the run settings are complete.
+func (m MLflow) validate() error {
+	if (m.URI == "") != (m.RunID == "") {
+		return fmt.Errorf("Error: --mlflow-uri and --mlflow-run go together")
+	}
+	return nil
+}
+
+// labelDistribution counts the assets per label.
+func labelDistribution(assets []Asset) map[string]int {
+	counts := make(map[string]int)
+	for _, asset := range assets {
+		counts[asset.Label]++
+	}
+	return counts
+}
+
+// log logs the run summary as params and metrics of the MLflow run, and the summary, label distribution and
+// annotations file as its artifacts. annotationFile is left out when empty.
+func (m MLflow) log(summary RunSummary, assets []Asset, annotationFile string) error {
+	now := time.Now().UnixMilli()
+	distribution := labelDistribution(assets)
+	params := []mlflowParam{
+		{Key: "votter_version", Value: Version},
+		{Key: "labels", Value: strconv.Itoa(len(summary.Labels))},
+	}
+	if annotationFile != "" {
+		params = append(params, mlflowParam{Key: "annotations_file", Value: filepath.Base(annotationFile)})
+	}
+	metrics := []mlflowMetric{
+		{Key: "images", Value: float64(summary.Images), Timestamp: now},
+		{Key: "assets", Value: float64(summary.Assets), Timestamp: now},
+		{Key: "skipped", Value: float64(len(summary.Skipped)), Timestamp: now},
+		{Key: "duration_seconds", Value: summary.Duration, Timestamp: now},
+	}
+	var labels []string
+	for label := range distribution {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	for _, label := range labels {
+		metrics = append(metrics, mlflowMetric{Key: "assets_" + mlflowKey(label), Value: float64(distribution[label]), Timestamp: now})
+	}
+	batch := map[string]interface{}{"run_id": m.RunID, "params": params, "metrics": metrics}
+	if err := m.call("POST", "/api/2.0/mlflow/runs/log-batch", batch, nil); err != nil {
+		return err
+	}
+
+	// Artifacts go through the tracking server's artifact proxy.
+	var run mlflowRun
+	if err := m.call("GET", "/api/2.0/mlflow/runs/get?run_id="+url.QueryEscape(m.RunID), nil, &run); err != nil {
+		return err
+	}
+	artifactPath, ok := strings.CutPrefix(run.Run.Info.ArtifactURI, "mlflow-artifacts:")
+	if !ok {
+		return fmt.Errorf("Error: MLflow run '%s' stores artifacts in '%s', only the artifact proxy (mlflow-artifacts:) is supported", m.RunID, run.Run.Info.ArtifactURI)
+	}
+	artifacts := make(map[string][]byte)
+	if artifacts["summary.json"], ok = jsonArtifact(summary); !ok {
+		return fmt.Errorf("Error: Cannot encode the run summary")
+	}
+	if artifacts["label_distribution.json"], ok = jsonArtifact(distribution); !ok {
+		return fmt.Errorf("Error: Cannot encode the label distribution")
+	}
+	if annotationFile != "" {
+		data, err := ioutil.ReadFile(annotationFile)
+		if err != nil {
+			return err
+		}
+		artifacts[filepath.Base(annotationFile)] = data
+	}
+	for name, data := range artifacts {
+		path := "/api/2.0/mlflow-artifacts/artifacts/" + strings.Trim(artifactPath, "/") + "/" + url.PathEscape(name)
+		if err := m.call("PUT", path, data, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mlflowKey replaces the characters MLflow doesn't allow in keys by underscores.
+func mlflowKey(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("_-./ ", r) {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// jsonArtifact encodes value as indented JSON.
+func jsonArtifact(value interface{}) ([]byte, bool) {
+	data, err := json.MarshalIndent(value, "", "  ")
+	return data, err == nil
+}
+
+// call sends a request to the tracking server, a JSON body unless it's raw bytes, and decodes the JSON response into result.
+func (m MLflow) call(method string, path string, body interface{}, result interface{}) error {
+	payload, raw := body.([]byte)
+	if !raw && body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+	request, err := http.NewRequest(method, strings.TrimSuffix(m.URI, "/")+path, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	if !raw {
+		request.Header.Set("Content-Type", "application/json")
+	}
+
+	client := m.Client
+	if client == nil {
+		client = HTTPClient
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return fmt.Errorf("Error: Cannot reach MLflow: %v", err)
+	}
+	defer response.Body.Close()
+	data, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+	if response.StatusCode/100 != 2 {
+		return fmt.Errorf("Error: MLflow answered %s: %s", response.Status, strings.TrimSpace(string(data)))
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(data, result)
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_MLflowLog(t *testing.T) {
+	annotationFile := filepath.Join(t.TempDir(), "annotations.json")
+	if err := os.WriteFile(annotationFile, []byte(`{"assets": {}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var batch struct {
+		RunID   string         `json:"run_id"`
+		Params  []mlflowParam  `json:"params"`
+		Metrics []mlflowMetric `json:"metrics"`
+	}
+	artifacts := make(map[string]string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/api/2.0/mlflow/runs/log-batch":
+			json.NewDecoder(r.Body).Decode(&batch)
+			w.Write([]byte(`{}`))
+		case r.Method == "GET" && r.URL.Path == "/api/2.0/mlflow/runs/get" && r.URL.Query().Get("run_id") == "run1":
+			w.Write([]byte(`{"run": {"info": {"artifact_uri": "mlflow-artifacts:/0/run1/artifacts"}}}`))
+		case r.Method == "PUT":
+			data, _ := ioutil.ReadAll(r.Body)
+			artifacts[r.URL.Path] = string(data)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	assets := []Asset{{Label: "cat"}, {Label: "cat"}, {Label: "dog"}}
+	summary := RunSummary{Labels: []string{"cat", "dog"}, Images: 3, Assets: 3}
+	if err := (MLflow{URI: server.URL, RunID: "run1"}).log(summary, assets, annotationFile); err != nil {
+		t.Fatal(err)
+	}
+
+	if batch.RunID != "run1" {
+		t.Errorf("Expected the batch for run1, found %s", batch.RunID)
+	}
+	metrics := make(map[string]float64)
+	for _, metric := range batch.Metrics {
+		metrics[metric.Key] = metric.Value
+	}
+	if metrics["assets"] != 3 || metrics["assets_cat"] != 2 || metrics["assets_dog"] != 1 {
+		t.Errorf("Expected asset counts in total and per label, found %v", metrics)
+	}
+	prefix := "/api/2.0/mlflow-artifacts/artifacts/0/run1/artifacts/"
+	if artifacts[prefix+"annotations.json"] != `{"assets": {}}` {
+		t.Errorf("Expected the annotations file as artifact, found %v", artifacts)
+	}
+	if _, ok := artifacts[prefix+"summary.json"]; !ok {
+		t.Errorf("Expected the summary as artifact, found %v", artifacts)
+	}
+	if artifacts[prefix+"label_distribution.json"] != "{\n  \"cat\": 2,\n  \"dog\": 1\n}" {
+		t.Errorf("Expected the label distribution as artifact, found %v", artifacts)
+	}
+}
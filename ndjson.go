@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// AssetLine is a line of --ndjson input, describing an asset in full, for bridging any upstream system into votter:
+//
+//	{"path": "/data/image1.jpg", "labels": ["cat", "indoor"], "boxes": [{"left": 10, "top": 5, "width": 50, "height": 40, "tags": ["cat"]}], "attributes": {"camera": "gate-2"}}
+type AssetLine struct {
+	Path        string                 `json:"path"`
+	Label       string                 `json:"label"`
+	Labels      []string               `json:"labels"`
+	Boxes       []AssetLineBox         `json:"boxes"`
+	Attributes  map[string]interface{} `json:"attributes"`
+	CaptureTime string                 `json:"captureTime"`
+}
+
+// AssetLineBox is a rectangle region of an AssetLine. Boxes without tags get the labels of the line.
+type AssetLineBox struct {
+	Left       float64                `json:"left"`
+	Top        float64                `json:"top"`
+	Width      float64                `json:"width"`
+	Height     float64                `json:"height"`
+	Tags       []string               `json:"tags"`
+	Confidence *float64               `json:"confidence"`
+	Attributes map[string]interface{} `json:"attributes"`
+}
+
+// labels returns the label and labels of the line, or else the name of the image's folder.
+func (line AssetLine) labels() []string {
+	var labels []string
+	if line.Label != "" {
+		labels = append(labels, line.Label)
+	}
+	labels = mergeTags(labels, line.Labels)
+	if len(labels) == 0 {
+		labels = []string{filepath.Base(filepath.Dir(line.Path))}
+	}
+	return labels
+}
+
+// readAssetLinesFile reads the asset lines of the --ndjson file, or of standard input for "-".
+func readAssetLinesFile(path string) ([]labeledImage, map[string]AssetLine, error) {
+	if path == "-" {
+		return readAssetLines(os.Stdin)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+	return readAssetLines(file)
+}
+
+// readAssetLines reads NDJSON asset lines. Returns the images labeled by their first label, to generate as usual,
+// and the lines by absolute image path, to apply to the generated assets. Blank lines are skipped.
+func readAssetLines(r io.Reader) ([]labeledImage, map[string]AssetLine, error) {
+	var images []labeledImage
+	lines := make(map[string]AssetLine)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for number := 1; scanner.Scan(); number++ {
+		data := scanner.Bytes()
+		if len(bytes.TrimSpace(data)) == 0 {
+			continue
+		}
+		var line AssetLine
+		if err := json.Unmarshal(data, &line); err != nil {
+			return nil, nil, fmt.Errorf("Error: Cannot parse asset line %d: %v", number, err)
+		}
+		if line.Path == "" {
+			return nil, nil, fmt.Errorf("Error: Asset line %d has no path", number)
+		}
+		absolute, err := filepath.Abs(line.Path)
+		if err != nil {
+			return nil, nil, err
+		}
+		images = append(images, labeledImage{Path: line.Path, Label: line.labels()[0]})
+		lines[absolute] = line
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+	if len(images) == 0 {
+		return nil, nil, fmt.Errorf("Error: No asset lines found.")
+	}
+	return images, lines, nil
+}
+
+// applyAssetLines gives the assets the boxes, labels, attributes and capture time of their asset lines. Lines with several
+// labels and no boxes get a full image region with all of them. Labels and box tags are renamed by relabel, the
+// remapping of the labels of the images.
+func applyAssetLines(assets []Asset, lines map[string]AssetLine, relabel map[string]string) []Asset {
+	for i, asset := range assets {
+		line, found := lines[assetFilePath(asset)]
+		if !found {
+			continue
+		}
+		labels := relabelTags(line.labels(), relabel)
+		if len(line.Boxes) > 0 {
+			assets[i].Regions = nil
+			for _, box := range line.Boxes {
+				region := fullImageRegion(asset)
+				region.BoundingBox = BoundingBox{Left: box.Left, Top: box.Top, Width: box.Width, Height: box.Height}
+				region.Points = []Point{{X: box.Left, Y: box.Top}, {X: box.Left + box.Width, Y: box.Top + box.Height}}
+				region.Tags = labels
+				if len(box.Tags) > 0 {
+					region.Tags = relabelTags(box.Tags, relabel)
+				}
+				region.Confidence = box.Confidence
+				region.Attributes = box.Attributes
+				assets[i].Regions = append(assets[i].Regions, region)
+			}
+		} else if len(labels) > 1 {
+			region := fullImageRegion(asset)
+			region.Tags = labels
+			assets[i].Regions = []Region{region}
+		}
+		if len(line.Attributes) > 0 {
+			assets[i].Attributes = line.Attributes
+		}
+		if line.CaptureTime != "" {
+			assets[i].CaptureTime = line.CaptureTime
+		}
+	}
+	return assets
+}
+
+// assetLineTags returns the labels and box tags of all lines, in the order of the images, renamed by relabel.
+func assetLineTags(images []labeledImage, lines map[string]AssetLine, relabel map[string]string) []string {
+	var tags []string
+	for _, image := range images {
+		absolute, _ := filepath.Abs(image.Path)
+		line, found := lines[absolute]
+		if !found {
+			continue
+		}
+		tags = mergeTags(tags, relabelTags(line.labels(), relabel))
+		for _, box := range line.Boxes {
+			tags = mergeTags(tags, relabelTags(box.Tags, relabel))
+		}
+	}
+	return tags
+}
+
+// tagImages returns an image without a path per tag, so the tags are remapped by the class map, synsets,
+// translations, normalization and slugs along with the labels of the images.
+func tagImages(tags []string) []labeledImage {
+	images := make([]labeledImage, len(tags))
+	for i, tag := range tags {
+		images[i] = labeledImage{Label: tag}
+	}
+	return images
+}
+
+// relabelTags renames the tags by relabel, once each when several are renamed to the same tag.
+func relabelTags(tags []string, relabel map[string]string) []string {
+	var renamed []string
+	for _, tag := range tags {
+		if target, ok := relabel[tag]; ok {
+			tag = target
+		}
+		renamed = mergeTags(renamed, []string{tag})
+	}
+	return renamed
+}
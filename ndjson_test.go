@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_AssetLines(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "cat"), 0755)
+	for _, name := range []string{"cat/image1.jpg", "image2.jpg", "image3.jpg"} {
+		writeTestImage(t, filepath.Join(dir, filepath.FromSlash(name)), 100, 50)
+	}
+	cat := filepath.ToSlash(filepath.Join(dir, "cat", "image1.jpg"))
+	image2 := filepath.ToSlash(filepath.Join(dir, "image2.jpg"))
+	image3 := filepath.ToSlash(filepath.Join(dir, "image3.jpg"))
+	input := `{"path": "` + cat + `", "attributes": {"camera": "gate-2"}}
+
+{"path": "` + image2 + `", "label": "dog", "boxes": [{"left": 10, "top": 5, "width": 50, "height": 40}, {"left": 0, "top": 0, "width": 5, "height": 5, "tags": ["ball"], "confidence": 0.5, "attributes": {"occluded": true}}]}
+{"path": "` + image3 + `", "labels": ["bird", "outdoor"]}
+`
+	images, lines, err := readAssetLines(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(images) != 3 || images[0].Label != "cat" || images[1].Label != "dog" || images[2].Label != "bird" {
+		t.Fatalf("Expected the images with their first label or folder, found %v", images)
+	}
+	if tags := assetLineTags(images, lines, nil); strings.Join(tags, ",") != "cat,dog,ball,bird,outdoor" {
+		t.Errorf("Expected all labels and box tags, found %v", tags)
+	}
+
+	assets, err := generateImageEntries(images, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assets = applyAssetLines(assets, lines, nil)
+	if len(assets[0].Regions) != 1 || assets[0].Regions[0].Tags[0] != "cat" || assets[0].Attributes["camera"] != "gate-2" {
+		t.Errorf("Expected the full image region and the attributes, found %+v", assets[0])
+	}
+	if regions := assets[1].Regions; len(regions) != 2 || regions[0].Tags[0] != "dog" || regions[0].BoundingBox.Width != 50 ||
+		regions[1].Tags[0] != "ball" || regions[1].Score() != 0.5 || regions[1].Attributes["occluded"] != true || regions[0].Attributes != nil {
+		t.Errorf("Expected a region per box, found %+v", regions)
+	}
+	if regions := assets[2].Regions; len(regions) != 1 || strings.Join(regions[0].Tags, ",") != "bird,outdoor" {
+		t.Errorf("Expected a full image region with all labels, found %+v", regions)
+	}
+}
+
+func Test_GenerateAssetLinesRemapped(t *testing.T) {
+	dir := t.TempDir()
+	image1 := filepath.Join(dir, "image1.jpg")
+	writeTestImage(t, image1, 100, 50)
+	ndjson := filepath.Join(dir, "assets.ndjson")
+	line := `{"path": "` + filepath.ToSlash(image1) + `", "labels": ["siamese", "Tabby Cat"], "boxes": [{"left": 0, "top": 0, "width": 5, "height": 5, "tags": ["Tennis Ball"]}]}`
+	os.WriteFile(ndjson, []byte(line+"\n"), 0644)
+	classMap := filepath.Join(dir, "classmap.json")
+	os.WriteFile(classMap, []byte(`{"siamese": "cat"}`), 0644)
+
+	annotationFile := filepath.Join(dir, "annotations.json")
+	options := Options{Workers: 2, NoHistory: true, NDJSON: ndjson, ClassMap: classMap, SlugifyLabels: true}
+	if code := generate(context.Background(), dir, annotationFile, options); code != ExitSuccesful {
+		t.Fatalf("Expected success, found exit code %d", code)
+	}
+	model, err := readVottJSON(annotationFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tags []string
+	for _, tag := range model.Tags {
+		tags = append(tags, tag.Name)
+	}
+	if strings.Join(tags, ",") != "cat,tabby-cat,tennis-ball" {
+		t.Errorf("Expected the labels and box tags remapped like folder labels, found %v", tags)
+	}
+	for _, detail := range model.Assets {
+		if len(detail.Regions) != 1 || strings.Join(detail.Regions[0].Tags, ",") != "tennis-ball" {
+			t.Errorf("Expected the box tagged tennis-ball, found %+v", detail.Regions)
+		}
+	}
+}
+
+func Test_AssetLinesInvalid(t *testing.T) {
+	for _, input := range []string{"", `{"label": "cat"}`, `{"path": `} {
+		if _, _, err := readAssetLines(strings.NewReader(input)); err == nil {
+			t.Errorf("%q: expected an error", input)
+		}
+	}
+}
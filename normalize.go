@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Canonical forms of --normalize-labels for labels that differ only in case or surrounding whitespace.
+const (
+	NormalizeLower  = "lower"
+	NormalizeUpper  = "upper"
+	NormalizeCommon = "common"
+)
+
+// validNormalizeForm checks the --normalize-labels form, empty for none.
+func validNormalizeForm(form string) error {
+	if form != "" && form != NormalizeLower && form != NormalizeUpper && form != NormalizeCommon {
+		return fmt.Errorf("Error: Unknown label form '%s', expected lower, upper or common", form)
+	}
+	return nil
+}
+
+// normalizeLabels merges the labels of the images that differ only in case or surrounding whitespace: Dog, dog and
+// 'DOG ' become dog in the lower form, DOG in the upper form, and the spelling most images have in the common form.
+// Returns the images and a description of every merge.
+func normalizeLabels(images []labeledImage, form string) ([]labeledImage, []string) {
+	if form == "" {
+		return images, nil
+	}
+
+	counts := make(map[string]map[string]int)
+	for _, image := range images {
+		key := strings.ToLower(strings.TrimSpace(image.Label))
+		if counts[key] == nil {
+			counts[key] = make(map[string]int)
+		}
+		counts[key][image.Label]++
+	}
+
+	canonical := make(map[string]string)
+	var merges []string
+	for key, variants := range counts {
+		var spellings []string
+		for spelling := range variants {
+			spellings = append(spellings, spelling)
+		}
+		sort.Strings(spellings)
+
+		var target string
+		switch form {
+		case NormalizeLower:
+			target = key
+		case NormalizeUpper:
+			target = strings.ToUpper(key)
+		default:
+			for _, spelling := range spellings {
+				if target == "" || variants[spelling] > variants[target] {
+					target = spelling
+				}
+			}
+			target = strings.TrimSpace(target)
+		}
+
+		var merged []string
+		for _, spelling := range spellings {
+			canonical[spelling] = target
+			if spelling != target {
+				merged = append(merged, fmt.Sprintf("'%s'", spelling))
+			}
+		}
+		if len(merged) > 0 {
+			merges = append(merges, fmt.Sprintf("Merged label %s into '%s'.", strings.Join(merged, ", "), target))
+		}
+	}
+	sort.Strings(merges)
+
+	for i, image := range images {
+		images[i].Label = canonical[image.Label]
+	}
+	return images, merges
+}
+
+// slugFolds spells common accented letters in ASCII for slugs.
+var slugFolds = strings.NewReplacer(
+	"à", "a", "á", "a", "â", "a", "ã", "a", "ä", "a", "å", "a", "æ", "ae", "ç", "c", "è", "e", "é", "e", "ê", "e", "ë", "e",
+	"ì", "i", "í", "i", "î", "i", "ï", "i", "ñ", "n", "ò", "o", "ó", "o", "ô", "o", "õ", "o", "ö", "o", "ø", "o", "œ", "oe",
+	"ù", "u", "ú", "u", "û", "u", "ü", "u", "ý", "y", "ÿ", "y", "ß", "ss", "ł", "l", "š", "s", "ž", "z", "č", "c", "ř", "r",
+)
+
+// slugifyLabel turns a label into a clean tag name: lower case letters and digits separated by single dashes.
+// Red Pandas (2023) becomes red-pandas-2023, Crème Brûlée becomes creme-brulee. Other letters are kept as they are.
+func slugifyLabel(label string) string {
+	var slug strings.Builder
+	dash := false
+	for _, r := range slugFolds.Replace(strings.ToLower(label)) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && slug.Len() > 0 {
+				slug.WriteRune('-')
+			}
+			slug.WriteRune(r)
+			dash = false
+			continue
+		}
+		dash = true
+	}
+	if slug.Len() == 0 {
+		return label
+	}
+	return slug.String()
+}
+
+// slugifyLabels slugifies the labels of the images. Returns the images and the labels that changed, to their slugs.
+func slugifyLabels(images []labeledImage) ([]labeledImage, map[string]string) {
+	mapping := make(map[string]string)
+	for i, image := range images {
+		slug := slugifyLabel(image.Label)
+		if slug != image.Label {
+			mapping[image.Label] = slug
+			images[i].Label = slug
+		}
+	}
+	return images, mapping
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_NormalizeLabels(t *testing.T) {
+	labelsOf := func(images []labeledImage) []string {
+		var labels []string
+		for _, image := range images {
+			labels = append(labels, image.Label)
+		}
+		return labels
+	}
+	newImages := func() []labeledImage {
+		return []labeledImage{{Label: "Dog"}, {Label: "dog "}, {Label: "Dog"}, {Label: "DOG"}, {Label: "cat"}}
+	}
+
+	tests := map[string][]string{
+		"":              {"Dog", "dog ", "Dog", "DOG", "cat"},
+		NormalizeLower:  {"dog", "dog", "dog", "dog", "cat"},
+		NormalizeUpper:  {"DOG", "DOG", "DOG", "DOG", "CAT"},
+		NormalizeCommon: {"Dog", "Dog", "Dog", "Dog", "cat"},
+	}
+	for form, expected := range tests {
+		images, _ := normalizeLabels(newImages(), form)
+		if labels := labelsOf(images); !reflect.DeepEqual(labels, expected) {
+			t.Errorf("Expected %v for form '%s', found %v", expected, form, labels)
+		}
+	}
+
+	_, merges := normalizeLabels(newImages(), NormalizeLower)
+	if !reflect.DeepEqual(merges, []string{"Merged label 'DOG', 'Dog', 'dog ' into 'dog'."}) {
+		t.Errorf("Expected the dog spellings reported, found %v", merges)
+	}
+}
+
+func Test_SlugifyLabel(t *testing.T) {
+	tests := map[string]string{
+		"Red Pandas (2023)": "red-pandas-2023",
+		"  Crème Brûlée!":   "creme-brulee",
+		"dog":               "dog",
+		"Ωmega_point":       "ωmega-point",
+		"???":               "???",
+	}
+	for label, expected := range tests {
+		if slug := slugifyLabel(label); slug != expected {
+			t.Errorf("Expected '%s' for '%s', found '%s'", expected, label, slug)
+		}
+	}
+
+	images, mapping := slugifyLabels([]labeledImage{{Label: "Red Pandas"}, {Label: "dog"}})
+	if images[0].Label != "red-pandas" || !reflect.DeepEqual(mapping, map[string]string{"Red Pandas": "red-pandas"}) {
+		t.Errorf("Expected only Red Pandas mapped, found %v and %v", images, mapping)
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// OnError is the --on-error policy for images that fail to read or decode.
+type OnError string
+
+const (
+	OnErrorFail       OnError = "fail"
+	OnErrorSkip       OnError = "skip"
+	OnErrorQuarantine OnError = "quarantine"
+)
+
+func (o *OnError) String() string { return string(*o) }
+
+func (o *OnError) Set(value string) error {
+	switch OnError(strings.ToLower(value)) {
+	case OnErrorFail, "":
+		*o = OnErrorFail
+	case OnErrorSkip:
+		*o = OnErrorSkip
+	case OnErrorQuarantine:
+		*o = OnErrorQuarantine
+	default:
+		return fmt.Errorf("expected fail, skip or quarantine")
+	}
+	return nil
+}
+
+// Modes of --quarantine-mode.
+const (
+	QuarantineCopy = "copy"
+	QuarantineMove = "move"
+)
+
+// SkippedFile is an image left out of the project, with the reason why.
+type SkippedFile struct {
+	Path   string `json:"path"`
+	Label  string `json:"label"`
+	Reason string `json:"reason"`
+	// Quarantined is where the image was copied or moved to with --on-error quarantine.
+	Quarantined string `json:"quarantined,omitempty"`
+}
+
+// Quarantine is where --on-error quarantine puts the images left out: below Dir, at their path below Root, copied or
+// moved by Mode.
+type Quarantine struct {
+	Dir  string
+	Root string
+	Mode string
+}
+
+func validQuarantineMode(mode string) error {
+	if mode != QuarantineCopy && mode != QuarantineMove {
+		return fmt.Errorf("Error: Unknown quarantine mode '%s', expected copy or move", mode)
+	}
+	return nil
+}
+
+// skipFailedImages drops the assets of the images that failed, or returns the first error when the policy is fail.
+// With quarantine, the failed images go into the quarantine folder.
+func skipFailedImages(images []labeledImage, entries []Asset, errs []error, policy OnError, quarantine Quarantine) ([]Asset, []SkippedFile, error) {
+	var assets []Asset
+	var skipped []SkippedFile
+	for i, err := range errs {
+		if err == nil {
+			assets = append(assets, entries[i])
+			continue
+		}
+		if policy != OnErrorSkip && policy != OnErrorQuarantine {
+			return nil, nil, err
+		}
+
+		image := images[i]
+		fmt.Printf("Skipping image '%s': %v\n", image.Path, err)
+		skipped = append(skipped, SkippedFile{Path: image.Path, Label: image.Label, Reason: err.Error()})
+	}
+	if policy == OnErrorQuarantine {
+		if err := quarantine.files(skipped); err != nil {
+			return nil, nil, err
+		}
+	}
+	return assets, skipped, nil
+}
+
+// files copies or moves the skipped images into the quarantine folder, and records where each went.
+func (quarantine Quarantine) files(skipped []SkippedFile) error {
+	for i, file := range skipped {
+		target, err := quarantine.file(file)
+		if err != nil {
+			return err
+		}
+		skipped[i].Quarantined = target
+	}
+	return nil
+}
+
+// file copies or moves an image into the quarantine folder for later inspection, at its path below the images folder
+// so the data owners find it where it came from: images/cat/2023/a.jpg -> quarantine/cat/2023/a.jpg. Images outside
+// the images folder go into their label folder. Returns the path in the quarantine.
+func (quarantine Quarantine) file(file SkippedFile) (string, error) {
+	relative := filepath.Join(file.Label, filepath.Base(file.Path))
+	if quarantine.Root != "" {
+		if rel, err := filepath.Rel(quarantine.Root, file.Path); err == nil && filepath.IsLocal(rel) {
+			relative = rel
+		}
+	}
+	target := filepath.Join(quarantine.Dir, relative)
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return "", fmt.Errorf("Error: Cannot create quarantine directory '%s': %v", filepath.Dir(target), err)
+	}
+	mode := quarantine.Mode
+	if mode == "" {
+		mode = QuarantineCopy
+	}
+	if err := replaceFile(file.Path, target, mode); err != nil {
+		return "", fmt.Errorf("Error: Cannot quarantine image '%s': %v", file.Path, err)
+	}
+	fmt.Printf("Quarantined image '%s' as '%s'.\n", file.Path, target)
+	return target, nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_SkipFailedImages(t *testing.T) {
+	rootDir := t.TempDir()
+	good := filepath.Join(rootDir, "cat", "good.png")
+	broken := filepath.Join(rootDir, "cat", "broken.png")
+	if err := os.MkdirAll(filepath.Join(rootDir, "cat"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeTestImage(t, good, 4, 3)
+	if err := os.WriteFile(broken, []byte("not an image"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	images := []labeledImage{{Path: broken, Label: "cat"}, {Path: good, Label: "cat"}}
+	entries, errs := generateEachImageEntry(context.Background(), images, 2)
+
+	if _, _, err := skipFailedImages(images, entries, errs, OnErrorFail, Quarantine{}); err == nil {
+		t.Error("Expected the broken image to fail the run")
+	}
+
+	assets, skipped, err := skipFailedImages(images, entries, errs, OnErrorSkip, Quarantine{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(assets) != 1 || assets[0].Name != "good.png" {
+		t.Errorf("Expected only good.png, found %v", assets)
+	}
+	if len(skipped) != 1 || skipped[0].Path != broken || skipped[0].Reason == "" {
+		t.Errorf("Expected broken.png skipped with a reason, found %v", skipped)
+	}
+
+	quarantineDir := filepath.Join(t.TempDir(), "quarantine")
+	if _, _, err := skipFailedImages(images, entries, errs, OnErrorQuarantine, Quarantine{Dir: quarantineDir}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(quarantineDir, "cat", "broken.png")); err != nil {
+		t.Errorf("Expected a copy of broken.png in the quarantine: %v", err)
+	}
+	if _, err := os.Stat(broken); err != nil {
+		t.Errorf("Expected broken.png to stay in place: %v", err)
+	}
+}
+
+func Test_QuarantineMirrorsLayout(t *testing.T) {
+	rootDir := t.TempDir()
+	broken := filepath.Join(rootDir, "cat", "2023", "broken.png")
+	if err := os.MkdirAll(filepath.Dir(broken), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(broken, []byte("not an image"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	outside := filepath.Join(t.TempDir(), "elsewhere.png")
+	if err := os.WriteFile(outside, []byte("not an image either"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	quarantine := Quarantine{Dir: filepath.Join(t.TempDir(), "quarantine"), Root: rootDir, Mode: QuarantineMove}
+	skipped := []SkippedFile{{Path: broken, Label: "cat", Reason: "corrupt"}, {Path: outside, Label: "dog", Reason: "corrupt"}}
+	if err := quarantine.files(skipped); err != nil {
+		t.Fatal(err)
+	}
+	if expected := filepath.Join(quarantine.Dir, "cat", "2023", "broken.png"); skipped[0].Quarantined != expected {
+		t.Errorf("Expected broken.png at its path below the images folder, found '%s'", skipped[0].Quarantined)
+	}
+	if expected := filepath.Join(quarantine.Dir, "dog", "elsewhere.png"); skipped[1].Quarantined != expected {
+		t.Errorf("Expected an image outside the images folder in its label folder, found '%s'", skipped[1].Quarantined)
+	}
+	if _, err := os.Stat(broken); !os.IsNotExist(err) {
+		t.Errorf("Expected broken.png moved out of the images folder: %v", err)
+	}
+
+	// Quarantining the image again replaces its earlier copy.
+	os.WriteFile(outside, []byte("still not an image"), 0644)
+	skipped = []SkippedFile{{Path: outside, Label: "dog", Reason: "corrupt"}}
+	if err := quarantine.files(skipped); err != nil || skipped[0].Quarantined != filepath.Join(quarantine.Dir, "dog", "elsewhere.png") {
+		t.Errorf("Expected the image at the same place in the quarantine, found '%s': %v", skipped[0].Quarantined, err)
+	}
+	if err := validQuarantineMode("link"); err == nil {
+		t.Error("Expected an unknown quarantine mode to fail")
+	}
+}
+
+func Test_QuarantineNotScanned(t *testing.T) {
+	rootDir := t.TempDir()
+	for _, dir := range []string{"cat", filepath.Join("quarantine", "cat")} {
+		os.MkdirAll(filepath.Join(rootDir, dir), 0755)
+		writeTestImage(t, filepath.Join(rootDir, dir, "image1.jpg"), 4, 3)
+	}
+
+	labels, err := findImagesIn(context.Background(), rootDir, nil, []string{filepath.Join(rootDir, "quarantine")}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	images := labeledImages(rootDir, labels)
+	if len(images) != 1 || images[0].Path != filepath.Join(rootDir, "cat", "image1.jpg") {
+		t.Errorf("Expected the quarantined image left out of the scan, found %v", images)
+	}
+}
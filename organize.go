@@ -0,0 +1,212 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// runOrganize places the images of a VoTT file into a folder per label, derived from the tags of their regions.
+//
+//	votter.exe organize [-mode copy|move|symlink] [-multi-tag first|all|majority|skip] <vott-annotations.json> <outputDirectory>
+func runOrganize(args []string) int {
+	flags := flag.NewFlagSet("organize", flag.ExitOnError)
+	modeFlag := flags.String("mode", "copy", "How to place images: copy, move or symlink")
+	multiTagFlag := flags.String("multi-tag", "first", "Label for assets with several tags: first, all, majority or skip")
+	flags.Usage = func() {
+		fmt.Println("Usage: votter organize [options] <vott-annotations.json> <outputDirectory>")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return ExitInvalidArguments
+	}
+	if *modeFlag != "copy" && *modeFlag != "move" && *modeFlag != "symlink" {
+		fmt.Printf("Error: Unknown mode '%s', expected copy, move or symlink\n", *modeFlag)
+		return ExitInvalidArguments
+	}
+	if *multiTagFlag != "first" && *multiTagFlag != "all" && *multiTagFlag != "majority" && *multiTagFlag != "skip" {
+		fmt.Printf("Error: Unknown multi-tag policy '%s', expected first, all, majority or skip\n", *multiTagFlag)
+		return ExitInvalidArguments
+	}
+
+	model, err := readVottJSON(flags.Arg(0))
+	if err != nil {
+		fmt.Println(err)
+		return ExitAnnotationsNotReadable
+	}
+
+	if err := organizeImages(model, filepath.Dir(flags.Arg(0)), flags.Arg(1), *modeFlag, *multiTagFlag); err != nil {
+		fmt.Println(err)
+		return ExitImageWriteFailed
+	}
+	return ExitSuccesful
+}
+
+// organizeImages copies, moves or symlinks the image of every asset into outDir/label/.
+// Assets tagged with several labels are placed according to the multi-tag policy. Relative asset paths are resolved
+// against root, the folder of the project.
+func organizeImages(model VottJsonModel, root string, outDir string, mode string, multiTag string) error {
+	var ids []string
+	for id := range model.Assets {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		detail := model.Assets[id]
+		labels := assetLabels(detail, multiTag)
+		if len(labels) == 0 {
+			fmt.Printf("Skipped image '%s', no label to organize by.\n", detail.Asset.Name)
+			continue
+		}
+
+		source := assetFilePathIn(detail.Asset, root)
+		for i, label := range labels {
+			labelDir := filepath.Join(outDir, label)
+			if err := os.MkdirAll(labelDir, 0755); err != nil {
+				return err
+			}
+			target := uniquePath(filepath.Join(labelDir, detail.Asset.Name))
+
+			// A moved image is moved once and copied from its new place for any further labels.
+			placeMode := mode
+			if mode == "move" && i > 0 {
+				placeMode = "copy"
+			}
+			if err := placeFile(source, target, placeMode); err != nil {
+				return err
+			}
+			if placeMode == "move" {
+				source = target
+			}
+			fmt.Printf("Label '%s' for image '%s'.\n", label, target)
+		}
+	}
+	return nil
+}
+
+// assetLabels returns the distinct region tags of an asset, reduced to the labels the multi-tag policy places it under.
+func assetLabels(detail AssetDetail, multiTag string) []string {
+	var tags []string
+	counts := make(map[string]int)
+	for _, region := range detail.Regions {
+		for _, tag := range region.Tags {
+			if counts[tag] == 0 {
+				tags = append(tags, tag)
+			}
+			counts[tag]++
+		}
+	}
+
+	if len(tags) <= 1 {
+		return tags
+	}
+	switch multiTag {
+	case "all":
+		return tags
+	case "skip":
+		return nil
+	case "majority":
+		best := tags[0]
+		for _, tag := range tags[1:] {
+			if counts[tag] > counts[best] {
+				best = tag
+			}
+		}
+		return []string{best}
+	}
+	return tags[:1]
+}
+
+// uniquePath returns path, or path with a numbered suffix if a file already exists there.
+func uniquePath(path string) string {
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+	for i := 1; ; i++ {
+		if _, err := os.Lstat(path); os.IsNotExist(err) {
+			return path
+		}
+		path = fmt.Sprintf("%s_%d%s", base, i, ext)
+	}
+}
+
+// uniqueNames returns the names with the asset ID of each before the extension where several share a name,
+// image1_<id>.jpg, so outputs named after their images are the same in every run rather than numbered as written.
+func uniqueNames(names []string, ids []string) []string {
+	counts := make(map[string]int)
+	for _, name := range names {
+		counts[name]++
+	}
+	unique := make([]string, len(names))
+	for i, name := range names {
+		unique[i] = name
+		if counts[name] > 1 {
+			ext := filepath.Ext(name)
+			unique[i] = strings.TrimSuffix(name, ext) + "_" + ids[i] + ext
+		}
+	}
+	return unique
+}
+
+// replaceFile places source at target like placeFile, replacing the file of an earlier run there. A target that
+// already is source, hardlinked or symlinked, is left as it is.
+func replaceFile(source, target, mode string) error {
+	if sourceInfo, err := os.Stat(source); err == nil {
+		if targetInfo, err := os.Stat(target); err == nil && os.SameFile(sourceInfo, targetInfo) {
+			return nil
+		}
+	}
+	if err := os.Remove(target); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return placeFile(source, target, mode)
+}
+
+// placeFile copies, moves, hardlinks or symlinks source to target.
+func placeFile(source, target, mode string) error {
+	switch mode {
+	case "hardlink":
+		return os.Link(source, target)
+	case "symlink":
+		absolute, err := filepath.Abs(source)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(absolute, target)
+	case "move":
+		if err := os.Rename(source, target); err == nil {
+			return nil
+		}
+		// Renaming fails across devices, fall back to copy and remove.
+		if err := copyFile(source, target); err != nil {
+			return err
+		}
+		return os.Remove(source)
+	}
+	return copyFile(source, target)
+}
+
+// copyFile copies the contents of source to a new file at target.
+func copyFile(source, target string) error {
+	in, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_AssetLabels(t *testing.T) {
+	detail := AssetDetail{Regions: []Region{
+		{Tags: []string{"cat"}},
+		{Tags: []string{"dog"}},
+		{Tags: []string{"dog"}},
+	}}
+
+	cases := map[string][]string{
+		"first":    {"cat"},
+		"all":      {"cat", "dog"},
+		"majority": {"dog"},
+		"skip":     nil,
+	}
+	for policy, expected := range cases {
+		if labels := assetLabels(detail, policy); !reflect.DeepEqual(labels, expected) {
+			t.Errorf("%s: expected %v, found %v", policy, expected, labels)
+		}
+	}
+}
+
+func Test_OrganizeImages(t *testing.T) {
+	rootDir := t.TempDir()
+	imgPath := filepath.Join(rootDir, "image1.jpg")
+	writeTestImage(t, imgPath, 10, 10)
+
+	// A relative path, as --collect writes them, is in the folder of the project.
+	model := VottJsonModel{Assets: map[string]AssetDetail{
+		"id1": {
+			Asset:   Asset{ID: "id1", Name: "image1.jpg", Path: "image1.jpg"},
+			Regions: []Region{{Tags: []string{"cat", "pet"}}},
+		},
+	}}
+
+	outDir := filepath.Join(rootDir, "organized")
+	if err := organizeImages(model, rootDir, outDir, "move", "all"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, label := range []string{"cat", "pet"} {
+		if _, err := os.Stat(filepath.Join(outDir, label, "image1.jpg")); err != nil {
+			t.Errorf("Expected image in label folder '%s': %v", label, err)
+		}
+	}
+	if _, err := os.Stat(imgPath); !os.IsNotExist(err) {
+		t.Error("Expected the source image to be moved")
+	}
+}
+
+func Test_UniquePath(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "image1.jpg")
+	if unique := uniquePath(path); unique != path {
+		t.Errorf("Expected %s, found %s", path, unique)
+	}
+
+	writeTestImage(t, path, 1, 1)
+	if unique := uniquePath(path); unique != filepath.Join(dir, "image1_1.jpg") {
+		t.Errorf("Expected numbered path, found %s", unique)
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// OverlayLineWidth is the width in pixels of the region outlines drawn by --draw-overlays.
+const OverlayLineWidth = 2
+
+// drawOverlays writes a copy of every asset image to outDir/label/ with its regions outlined and their tags written above
+// them, a color per tag in the order of tags.
+func drawOverlays(assets []Asset, tags []string, outDir string) error {
+	colors := make(map[string]color.RGBA)
+	for i, tag := range tags {
+		colors[tag] = vocColor(i + 1)
+	}
+
+	// Overlays are named after their images, with the asset ID for images of the same name in a label folder.
+	names := make([]string, len(assets))
+	ids := make([]string, len(assets))
+	for i, asset := range assets {
+		names[i] = filepath.Join(asset.Label, encodedName(filepath.Base(assetFilePath(asset))))
+		ids[i] = asset.ID
+	}
+	names = uniqueNames(names, ids)
+
+	for i, asset := range assets {
+		source := assetFilePath(asset)
+		img, err := decodeImageFile(source)
+		if err != nil {
+			return err
+		}
+		overlay := image.NewRGBA(image.Rect(0, 0, img.Bounds().Dx(), img.Bounds().Dy()))
+		draw.Draw(overlay, overlay.Bounds(), img, img.Bounds().Min, draw.Src)
+
+		regions := asset.Regions
+		if regions == nil {
+			regions = []Region{fullImageRegion(asset)}
+		}
+		for _, region := range regions {
+			c := color.RGBA{R: 255, A: 255}
+			if len(region.Tags) > 0 {
+				if tagColor, found := colors[region.Tags[0]]; found {
+					c = tagColor
+				}
+			}
+			drawRegionOutline(overlay, region, c)
+			drawRegionTags(overlay, region, c)
+		}
+
+		labelDir := filepath.Join(outDir, asset.Label)
+		if err := os.MkdirAll(labelDir, 0755); err != nil {
+			return err
+		}
+		if err := encodeImageFile(filepath.Join(outDir, names[i]), overlay); err != nil {
+			return err
+		}
+		Progress.step(asset.Label)
+	}
+	return nil
+}
+
+// drawRegionOutline draws the outline of a polygon region, the line of a polyline, or else its bounding box.
+func drawRegionOutline(img *image.RGBA, region Region, c color.RGBA) {
+	var points []image.Point
+	if region.Type == "POLYLINE" && len(region.Points) >= 2 {
+		for i := 1; i < len(region.Points); i++ {
+			drawLine(img, region.Points[i-1].Pixel(), region.Points[i].Pixel(), c)
+		}
+		return
+	}
+	if region.Type == "POLYGON" && len(region.Points) >= 3 {
+		for _, point := range region.Points {
+			points = append(points, point.Pixel())
+		}
+	} else {
+		rect := region.BoundingBox.Rect()
+		right, bottom := rect.Max.X-1, rect.Max.Y-1
+		points = []image.Point{rect.Min, {X: right, Y: rect.Min.Y}, {X: right, Y: bottom}, {X: rect.Min.X, Y: bottom}}
+	}
+	for i := range points {
+		drawLine(img, points[i], points[(i+1)%len(points)], c)
+	}
+}
+
+// drawLine draws a line OverlayLineWidth pixels wide from a to b, inwards and down from the points.
+func drawLine(img *image.RGBA, a, b image.Point, c color.RGBA) {
+	dx, dy := abs(b.X-a.X), -abs(b.Y-a.Y)
+	sx, sy := 1, 1
+	if a.X > b.X {
+		sx = -1
+	}
+	if a.Y > b.Y {
+		sy = -1
+	}
+	bounds := img.Bounds()
+	for x, y, e := a.X, a.Y, dx+dy; ; {
+		for w := 0; w < OverlayLineWidth*OverlayLineWidth; w++ {
+			p := image.Pt(x+w%OverlayLineWidth, y+w/OverlayLineWidth)
+			if p.In(bounds) {
+				img.SetRGBA(p.X, p.Y, c)
+			}
+		}
+		if x == b.X && y == b.Y {
+			return
+		}
+		if e2 := 2 * e; e2 >= dy {
+			e += dy
+			x += sx
+		} else {
+			e += dx
+			y += sy
+		}
+	}
+}
+
+// drawRegionTags writes the tags of a region in white on its color, above its top left corner or inside when at the top.
+func drawRegionTags(img *image.RGBA, region Region, c color.RGBA) {
+	if len(region.Tags) == 0 {
+		return
+	}
+	text := region.Tags[0]
+	for _, tag := range region.Tags[1:] {
+		text += ", " + tag
+	}
+
+	face := basicfont.Face7x13
+	height := face.Metrics().Height.Ceil()
+	rect := region.BoundingBox.Rect()
+	left, top := rect.Min.X, rect.Min.Y-height
+	if top < 0 {
+		top = rect.Min.Y
+	}
+	width := font.MeasureString(face, text).Ceil()
+	draw.Draw(img, image.Rect(left, top, left+width+4, top+height), image.NewUniform(c), image.Point{}, draw.Src)
+
+	drawer := font.Drawer{
+		Dst:  img,
+		Src:  image.White,
+		Face: face,
+		Dot:  fixed.P(left+2, top+face.Metrics().Ascent.Ceil()),
+	}
+	drawer.DrawString(text)
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_DrawOverlays(t *testing.T) {
+	dir := t.TempDir()
+	imgPath := filepath.Join(dir, "image1.jpg")
+	writeTestImage(t, imgPath, 100, 60)
+	assets := []Asset{{
+		Name:  "image1.jpg",
+		Path:  "file:" + filepath.ToSlash(imgPath),
+		Size:  Size{Width: 100, Height: 60},
+		Label: "cat",
+		Regions: []Region{
+			{Type: "RECTANGLE", Tags: []string{"cat"}, BoundingBox: BoundingBox{Left: 20, Top: 30, Width: 40, Height: 20}},
+			{Type: "POLYGON", Tags: []string{"dog"}, Points: []Point{{X: 70, Y: 5}, {X: 95, Y: 5}, {X: 95, Y: 25}}},
+		},
+	}}
+
+	outDir := filepath.Join(dir, "overlays")
+	if err := drawOverlays(assets, []string{"cat", "dog"}, outDir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(outDir, "cat", "image1.jpg")); err != nil {
+		t.Fatalf("Expected the overlay in the label folder: %v", err)
+	}
+
+	// Drawing again replaces the overlay rather than adding a numbered copy.
+	if err := drawOverlays(assets, []string{"cat", "dog"}, outDir); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := os.ReadDir(filepath.Join(outDir, "cat")); len(entries) != 1 {
+		t.Errorf("Expected the one overlay after drawing again, found %d", len(entries))
+	}
+}
+
+func Test_DrawRegionOutline(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 20, 20))
+	red := color.RGBA{R: 255, A: 255}
+	drawRegionOutline(img, Region{Type: "RECTANGLE", BoundingBox: BoundingBox{Left: 2, Top: 2, Width: 10, Height: 6}}, red)
+
+	for _, p := range []image.Point{{2, 2}, {11, 2}, {11, 7}, {2, 7}, {6, 3}} {
+		if img.RGBAAt(p.X, p.Y) != red {
+			t.Errorf("Expected the outline at %v", p)
+		}
+	}
+	if img.RGBAAt(6, 5) == red {
+		t.Error("Expected the inside of the box untouched")
+	}
+}
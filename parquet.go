@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// ParquetRow is a row of --parquet files, a region with its image.
+type ParquetRow struct {
+	Path      string  `parquet:"path"`
+	AssetID   string  `parquet:"asset_id"`
+	Name      string  `parquet:"name"`
+	Width     int32   `parquet:"width"`
+	Height    int32   `parquet:"height"`
+	Label     string  `parquet:"label"`
+	RegionID  string  `parquet:"region_id"`
+	Tags      string  `parquet:"tags"`
+	Left      float64 `parquet:"left"`
+	Top       float64 `parquet:"top"`
+	BoxWidth  float64 `parquet:"box_width"`
+	BoxHeight float64 `parquet:"box_height"`
+	// Confidence is null for regions that aren't predicted.
+	Confidence *float64 `parquet:"confidence,optional"`
+}
+
+// parquetRows flattens the assets into a row per region, with the region's tags comma separated. Assets without
+// regions have no rows.
+func parquetRows(assets []Asset) []ParquetRow {
+	var rows []ParquetRow
+	for _, asset := range assets {
+		regions := asset.Regions
+		if regions == nil {
+			regions = []Region{fullImageRegion(asset)}
+		}
+		for _, region := range regions {
+			box := region.BoundingBox
+			rows = append(rows, ParquetRow{
+				Path:       assetFilePath(asset),
+				AssetID:    asset.ID,
+				Name:       asset.Name,
+				Width:      int32(asset.Size.Width),
+				Height:     int32(asset.Size.Height),
+				Label:      asset.Label,
+				RegionID:   region.ID,
+				Tags:       strings.Join(region.Tags, ","),
+				Left:       box.Left,
+				Top:        box.Top,
+				BoxWidth:   box.Width,
+				BoxHeight:  box.Height,
+				Confidence: region.Confidence,
+			})
+		}
+	}
+	return rows
+}
+
+// writeParquet writes a row per region of the assets into a Parquet file at path, for DuckDB, Spark or pandas.
+func writeParquet(path string, assets []Asset) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("Error: Cannot write Parquet file '%s': %v", path, err)
+	}
+	defer file.Close()
+
+	writer := parquet.NewGenericWriter[ParquetRow](file)
+	if _, err := writer.Write(parquetRows(assets)); err != nil {
+		return fmt.Errorf("Error: Cannot write Parquet file '%s': %v", path, err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("Error: Cannot write Parquet file '%s': %v", path, err)
+	}
+	return file.Close()
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+func Test_WriteParquet(t *testing.T) {
+	confidence := 0.75
+	assets := []Asset{
+		{ID: "a1", Name: "image1.jpg", Path: "file:/data/cat/image1.jpg", Size: Size{Width: 4, Height: 3}, Label: "cat",
+			Regions: []Region{
+				{ID: "r1", Tags: []string{"cat", "animal"}, BoundingBox: BoundingBox{Left: 1, Top: 1, Width: 2, Height: 2}},
+				{ID: "r2", Tags: []string{"cat"}, BoundingBox: BoundingBox{Left: 0, Top: 0, Width: 1, Height: 1}, Confidence: &confidence},
+			}},
+		{ID: "a2", Name: "image2.jpg", Path: "file:/data/dog/image2.jpg", Size: Size{Width: 4, Height: 3}, Label: "dog", Regions: []Region{}},
+	}
+	path := filepath.Join(t.TempDir(), "regions.parquet")
+	if err := writeParquet(path, assets); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := parquet.ReadFile[ParquetRow](path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("Expected a row per region, found %v", rows)
+	}
+	expected := ParquetRow{Path: filepath.FromSlash("/data/cat/image1.jpg"), AssetID: "a1", Name: "image1.jpg", Width: 4, Height: 3, Label: "cat",
+		RegionID: "r1", Tags: "cat,animal", Left: 1, Top: 1, BoxWidth: 2, BoxHeight: 2}
+	if rows[0] != expected {
+		t.Errorf("Expected %+v, found %+v", expected, rows[0])
+	}
+	if rows[1].Confidence == nil || *rows[1].Confidence != 0.75 {
+		t.Errorf("Expected the confidence of the predicted region, found %v", rows[1].Confidence)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// parseOnlyLabels splits the comma separated labels of --only-labels into a set.
+func parseOnlyLabels(value string) map[string]bool {
+	labels := make(map[string]bool)
+	for _, label := range strings.Split(value, ",") {
+		if label = strings.TrimSpace(label); label != "" {
+			labels[label] = true
+		}
+	}
+	return labels
+}
+
+// onlyLabelImages returns the images with one of the labels.
+func onlyLabelImages(images []labeledImage, labels map[string]bool) []labeledImage {
+	var only []labeledImage
+	for _, image := range images {
+		if labels[image.Label] {
+			only = append(only, image)
+		}
+	}
+	return only
+}
+
+// otherLabelAssets reads the existing project and returns its assets whose label is not one of labels, with their
+// regions, and its tags. These are kept as they are when --only-labels regenerates the rest.
+func otherLabelAssets(annotationFile string, labels map[string]bool) ([]Asset, []string, error) {
+	model, err := readVottJSON(annotationFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error: --only-labels needs the existing project '%s': %v", annotationFile, err)
+	}
+
+	var assets []Asset
+	for _, detail := range model.Assets {
+		asset := detail.Asset
+		asset.Label = detailLabel(detail)
+		if labels[asset.Label] {
+			continue
+		}
+		asset.Regions = detail.Regions
+		assets = append(assets, asset)
+	}
+	sort.Slice(assets, func(i, j int) bool { return assets[i].Path < assets[j].Path })
+
+	var tags []string
+	for _, tag := range model.Tags {
+		tags = append(tags, tag.Name)
+	}
+	return assets, tags, nil
+}
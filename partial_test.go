@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_OnlyLabels(t *testing.T) {
+	rootDir := t.TempDir()
+	imagesDir := filepath.Join(rootDir, "images")
+	for _, label := range []string{"cat", "dog"} {
+		os.MkdirAll(filepath.Join(imagesDir, label), 0755)
+		writeTestImage(t, filepath.Join(imagesDir, label, "image1.jpg"), 10, 10)
+	}
+	annotationFile := filepath.Join(rootDir, "annotations.json")
+	if code := generate(context.Background(), imagesDir, annotationFile, Options{Workers: 2}); code != ExitSuccesful {
+		t.Fatalf("Expected the first run to succeed, found exit code %d", code)
+	}
+	before, err := readVottJSON(annotationFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only cat is regenerated, the dog asset stays even though its image is gone.
+	writeTestImage(t, filepath.Join(imagesDir, "cat", "image2.jpg"), 10, 10)
+	os.Remove(filepath.Join(imagesDir, "dog", "image1.jpg"))
+	os.MkdirAll(filepath.Join(imagesDir, "bird"), 0755)
+	writeTestImage(t, filepath.Join(imagesDir, "bird", "image1.jpg"), 10, 10)
+	if code := generate(context.Background(), imagesDir, annotationFile, Options{Workers: 2, OnlyLabels: "cat"}); code != ExitSuccesful {
+		t.Fatalf("Expected the partial run to succeed, found exit code %d", code)
+	}
+	after, err := readVottJSON(annotationFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counts := make(map[string]int)
+	for id, detail := range after.Assets {
+		counts[detail.Asset.Label]++
+		if detail.Asset.Label == "dog" {
+			if _, found := before.Assets[id]; !found {
+				t.Errorf("Expected the dog asset to keep its ID, found '%s'", id)
+			}
+		}
+	}
+	if counts["cat"] != 2 || counts["dog"] != 1 || counts["bird"] != 0 {
+		t.Errorf("Expected 2 cat and the kept dog asset, found %v", counts)
+	}
+}
+
+func Test_OnlyLabelsWithoutProject(t *testing.T) {
+	if _, _, err := otherLabelAssets(filepath.Join(t.TempDir(), "annotations.json"), parseOnlyLabels("cat")); err == nil {
+		t.Error("Expected an error without an existing project")
+	}
+}
+
+func Test_OnlyLabelsKeepsAugmentedAssets(t *testing.T) {
+	rootDir := t.TempDir()
+	imagesDir := filepath.Join(rootDir, "images")
+	for _, label := range []string{"cat", "dog"} {
+		os.MkdirAll(filepath.Join(imagesDir, label), 0755)
+		writeTestImage(t, filepath.Join(imagesDir, label, "image1.jpg"), 10, 10)
+	}
+	annotationFile := filepath.Join(rootDir, "annotations.json")
+	options := Options{Workers: 2, Augmentations: []string{"hflip"}, AugmentDir: filepath.Join(rootDir, "augmented")}
+	if code := generate(context.Background(), imagesDir, annotationFile, options); code != ExitSuccesful {
+		t.Fatalf("Expected the first run to succeed, found exit code %d", code)
+	}
+
+	// The dog assets, the image and its flipped copy, aren't augmented again by runs for cat.
+	options.OnlyLabels = "cat"
+	for run := 0; run < 2; run++ {
+		if code := generate(context.Background(), imagesDir, annotationFile, options); code != ExitSuccesful {
+			t.Fatalf("Expected the partial run to succeed, found exit code %d", code)
+		}
+	}
+	after, err := readVottJSON(annotationFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := make(map[string]int)
+	for _, detail := range after.Assets {
+		counts[detail.Asset.Label]++
+	}
+	if counts["cat"] != 2 || counts["dog"] != 2 {
+		t.Errorf("Expected the image and its flipped copy of each label, found %v", counts)
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PatchOperation is an operation of an RFC 6902 JSON Patch. Value is left out of remove operations, and kept when
+// it's null, false or 0.
+type PatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// readProjectDocument reads a project file as generic JSON, the way its importer decodes it, so JSON and YAML projects
+// compare the same. Returns nil when there's no project yet.
+func readProjectDocument(path string) (interface{}, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, nil
+	}
+	model, err := readVottJSON(path)
+	if err != nil {
+		return nil, fmt.Errorf("Error: Cannot read '%s' for the JSON patch: %v", path, err)
+	}
+	data, err := json.Marshal(model)
+	if err != nil {
+		return nil, err
+	}
+	var document interface{}
+	err = json.Unmarshal(data, &document)
+	return document, err
+}
+
+// writeJSONPatch writes the JSON Patch from before, the document of the project before a run or nil if there was
+// none, to the project now at path, for --json-patch. Returns the number of operations.
+func writeJSONPatch(patchPath string, before interface{}, path string) (int, error) {
+	after, err := readProjectDocument(path)
+	if err != nil {
+		return 0, err
+	}
+	operations, err := jsonPatch(before, after)
+	if err != nil {
+		return 0, err
+	}
+	data, err := json.MarshalIndent(operations, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	temporary := patchPath + ".tmp"
+	if err := ioutil.WriteFile(temporary, data, 0644); err != nil {
+		return 0, err
+	}
+	return len(operations), os.Rename(temporary, patchPath)
+}
+
+// jsonPatch returns the operations that turn the generic JSON document before into after: add and remove for the
+// keys of objects, replace for changed values. Arrays of the same length are compared element by element, others
+// are replaced whole. A missing document before is added whole. Keys are in sorted order, so patches are stable.
+func jsonPatch(before interface{}, after interface{}) ([]PatchOperation, error) {
+	operations := []PatchOperation{}
+	if before == nil {
+		return appendPatch(operations, "add", "", after)
+	}
+	return diffJSON(operations, "", before, after)
+}
+
+// diffJSON appends the operations that turn the value at pointer from before into after.
+func diffJSON(operations []PatchOperation, pointer string, before interface{}, after interface{}) ([]PatchOperation, error) {
+	if reflect.DeepEqual(before, after) {
+		return operations, nil
+	}
+	switch before := before.(type) {
+	case map[string]interface{}:
+		after, ok := after.(map[string]interface{})
+		if !ok {
+			break
+		}
+		var err error
+		for _, key := range sortedKeys(before) {
+			if _, kept := after[key]; !kept {
+				operations = append(operations, PatchOperation{Op: "remove", Path: pointer + "/" + escapePointer(key)})
+			}
+		}
+		for _, key := range sortedKeys(after) {
+			if value, existed := before[key]; existed {
+				operations, err = diffJSON(operations, pointer+"/"+escapePointer(key), value, after[key])
+			} else {
+				operations, err = appendPatch(operations, "add", pointer+"/"+escapePointer(key), after[key])
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		return operations, nil
+	case []interface{}:
+		after, ok := after.([]interface{})
+		if !ok || len(after) != len(before) {
+			break
+		}
+		var err error
+		for i := range before {
+			if operations, err = diffJSON(operations, pointer+"/"+strconv.Itoa(i), before[i], after[i]); err != nil {
+				return nil, err
+			}
+		}
+		return operations, nil
+	}
+	return appendPatch(operations, "replace", pointer, after)
+}
+
+// appendPatch appends an operation with a value.
+func appendPatch(operations []PatchOperation, op string, pointer string, value interface{}) ([]PatchOperation, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return append(operations, PatchOperation{Op: op, Path: pointer, Value: data}), nil
+}
+
+// escapePointer escapes a key for a JSON Pointer, RFC 6901: ~ as ~0 and / as ~1.
+func escapePointer(key string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
+
+func sortedKeys(object map[string]interface{}) []string {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_JSONPatch(t *testing.T) {
+	var before, after interface{}
+	json.Unmarshal([]byte(`{"name": "pets", "tags": [{"name": "cat"}], "assets": {"a1": {"state": 1}, "a/2": {}}, "flag": true}`), &before)
+	json.Unmarshal([]byte(`{"name": "pets", "tags": [{"name": "cat"}, {"name": "dog"}], "assets": {"a1": {"state": 2}, "a3": null}, "flag": false}`), &after)
+
+	operations, err := jsonPatch(before, after)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []PatchOperation{
+		{Op: "remove", Path: "/assets/a~12"},
+		{Op: "replace", Path: "/assets/a1/state", Value: json.RawMessage(`2`)},
+		{Op: "add", Path: "/assets/a3", Value: json.RawMessage(`null`)},
+		{Op: "replace", Path: "/flag", Value: json.RawMessage(`false`)},
+		{Op: "replace", Path: "/tags", Value: json.RawMessage(`[{"name":"cat"},{"name":"dog"}]`)},
+	}
+	if !reflect.DeepEqual(operations, expected) {
+		t.Errorf("Expected %+v, found %+v", expected, operations)
+	}
+	if operations, _ := jsonPatch(after, after); len(operations) != 0 {
+		t.Errorf("Expected no operations for the same document, found %+v", operations)
+	}
+}
+
+func Test_WriteJSONPatch(t *testing.T) {
+	dir := t.TempDir()
+	annotationFile := filepath.Join(dir, "annotations.json")
+	patchFile := filepath.Join(dir, "patch.json")
+	assets := []Asset{{ID: "a1", Path: "file:/data/cat/image1.jpg", Label: "cat"}}
+	if err := writeVottModel(annotationFile, buildVottModel(assets, []string{"cat"})); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without a previous project the whole project is added.
+	previous, err := readProjectDocument(filepath.Join(dir, "missing.json"))
+	if err != nil || previous != nil {
+		t.Fatalf("Expected no document for a missing project, found %v: %v", previous, err)
+	}
+	if operations, err := writeJSONPatch(patchFile, nil, annotationFile); err != nil || operations != 1 {
+		t.Fatalf("Expected the project added whole, found %d operations: %v", operations, err)
+	}
+
+	previous, err = readProjectDocument(annotationFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assets[0].Label = "dog"
+	if err := writeVottModel(annotationFile, buildVottModel(assets, []string{"dog"})); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writeJSONPatch(patchFile, previous, annotationFile); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := ioutil.ReadFile(patchFile)
+	var operations []PatchOperation
+	if err := json.Unmarshal(data, &operations); err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, operation := range operations {
+		if operation.Path == "/assets/a1/asset/Label" && string(operation.Value) == `"dog"` {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected the label of the asset replaced, found %s", data)
+	}
+}
+
+func Test_JSONPatchBetweenRuns(t *testing.T) {
+	rootDir := t.TempDir()
+	imagesDir := filepath.Join(rootDir, "images")
+	for _, label := range []string{"cat", "dog"} {
+		os.MkdirAll(filepath.Join(imagesDir, label), 0755)
+		for _, name := range []string{"image1.jpg", "image2.jpg", "image3.jpg"} {
+			writeTestImage(t, filepath.Join(imagesDir, label, name), 10, 10)
+		}
+	}
+	annotationFile := filepath.Join(rootDir, "annotations.json")
+	patchFile := filepath.Join(rootDir, "patch.json")
+	options := Options{Workers: 2, NoHistory: true, JSONPatch: patchFile}
+	if code := generate(context.Background(), imagesDir, annotationFile, options); code != ExitSuccesful {
+		t.Fatalf("Expected the first run to succeed, found exit code %d", code)
+	}
+
+	// The same images again change nothing.
+	if code := generate(context.Background(), imagesDir, annotationFile, options); code != ExitSuccesful {
+		t.Fatalf("Expected the second run to succeed, found exit code %d", code)
+	}
+	var operations []PatchOperation
+	data, _ := ioutil.ReadFile(patchFile)
+	if err := json.Unmarshal(data, &operations); err != nil || len(operations) != 0 {
+		t.Fatalf("Expected an empty patch for the same images, found %s: %v", data, err)
+	}
+
+	// A new image adds its asset only.
+	writeTestImage(t, filepath.Join(imagesDir, "dog", "image4.jpg"), 10, 10)
+	if code := generate(context.Background(), imagesDir, annotationFile, options); code != ExitSuccesful {
+		t.Fatalf("Expected the third run to succeed, found exit code %d", code)
+	}
+	data, _ = ioutil.ReadFile(patchFile)
+	if err := json.Unmarshal(data, &operations); err != nil || len(operations) != 1 || operations[0].Op != "add" {
+		t.Errorf("Expected the new asset added, found %s: %v", data, err)
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// runPaths rewrites the asset paths of a VoTT file from absolute file: URIs to paths relative to a root directory, or back.
+// Asset IDs and regions stay as they are.
+//
+//	votter.exe paths -to relative|absolute [-root dir] [-o output.json] <vott-annotations.json>
+func runPaths(args []string) int {
+	flags := flag.NewFlagSet("paths", flag.ExitOnError)
+	toFlag := flags.String("to", "relative", "Rewrite asset paths to 'relative' or 'absolute'")
+	rootFlag := flags.String("root", "", "Root directory the relative paths start from (default the directory of the annotations file)")
+	outputFlag := flags.String("o", "", "Write the rewritten project here instead of overwriting the input")
+	flags.Usage = func() {
+		fmt.Println("Usage: votter paths [options] <vott-annotations.json>")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return ExitInvalidArguments
+	}
+	if *toFlag != "relative" && *toFlag != "absolute" {
+		fmt.Printf("Error: Unknown path style '%s', expected relative or absolute\n", *toFlag)
+		return ExitInvalidArguments
+	}
+	annotationFile := flags.Arg(0)
+
+	root := *rootFlag
+	if root == "" {
+		root = filepath.Dir(annotationFile)
+	}
+	root, err := filepath.Abs(root)
+	if err != nil {
+		fmt.Println(err)
+		return ExitInvalidArguments
+	}
+
+	data, err := ioutil.ReadFile(annotationFile)
+	if err != nil {
+		fmt.Println(err)
+		return ExitAnnotationsNotReadable
+	}
+
+	rewrite := func(assetPath string) (string, bool) { return relativeAssetPath(assetPath, root) }
+	if *toFlag == "absolute" {
+		rewrite = func(assetPath string) (string, bool) { return absoluteAssetPath(assetPath, root) }
+	}
+	rewritten, count, err := rewriteAssetPaths(data, rewrite)
+	if err != nil {
+		fmt.Println(err)
+		return ExitAnnotationsNotReadable
+	}
+
+	output := annotationFile
+	if *outputFlag != "" {
+		output = *outputFlag
+	}
+	if err := ioutil.WriteFile(output, rewritten, 0644); err != nil {
+		fmt.Println(err)
+		return ExitAnnotationsWriteFailed
+	}
+
+	fmt.Printf("Rewrote %d asset paths to %s in '%s'.\n", count, *toFlag, output)
+	return ExitSuccesful
+}
+
+// relativeAssetPath rewrites an absolute file: asset path to a forward slash path relative to root.
+// Returns false for paths that are already relative.
+func relativeAssetPath(assetPath, root string) (string, bool) {
+	absolute := fileURIPath(assetPath)
+	if !isAbsoluteSlashPath(absolute) {
+		return assetPath, false
+	}
+	relative, err := filepath.Rel(root, filepath.FromSlash(absolute))
+	if err != nil {
+		return assetPath, false
+	}
+	return escapeURIPath(filepath.ToSlash(relative), strings.Contains(assetPath, "%")), true
+}
+
+// absoluteAssetPath rewrites a relative asset path to a file: URI below root. Returns false for paths that are already absolute.
+func absoluteAssetPath(assetPath, root string) (string, bool) {
+	relative := fileURIPath(assetPath)
+	if isAbsoluteSlashPath(relative) {
+		return assetPath, false
+	}
+	escaped := strings.Contains(assetPath, "%")
+	if escaped {
+		if unescaped, err := url.PathUnescape(relative); err == nil {
+			relative = unescaped
+		}
+	}
+	absolute := path.Join(filepath.ToSlash(root), relative)
+	return formatFileURI(absolute, escaped), true
+}
+
+// isAbsoluteSlashPath reports whether a forward slash path is absolute on any OS: /data, //server/share or C:/data.
+func isAbsoluteSlashPath(slashPath string) bool {
+	return strings.HasPrefix(slashPath, "/") || (len(slashPath) > 1 && slashPath[1] == ':')
+}
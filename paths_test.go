@@ -0,0 +1,29 @@
+package main
+
+import (
+	"testing"
+)
+
+func Test_RelativeAssetPath(t *testing.T) {
+	if relative, ok := relativeAssetPath("file:/data/set/cat/a.jpg", "/data/set"); !ok || relative != "cat/a.jpg" {
+		t.Errorf("Expected cat/a.jpg, found %s", relative)
+	}
+	if relative, ok := relativeAssetPath("file:/data/set/red%20cat/a.jpg", "/data/set"); !ok || relative != "red%20cat/a.jpg" {
+		t.Errorf("Expected percent-encoding to be kept, found %s", relative)
+	}
+	if _, ok := relativeAssetPath("cat/a.jpg", "/data/set"); ok {
+		t.Error("Expected relative paths to be left alone")
+	}
+}
+
+func Test_AbsoluteAssetPath(t *testing.T) {
+	if absolute, ok := absoluteAssetPath("cat/a.jpg", "/data/set"); !ok || absolute != "file:/data/set/cat/a.jpg" {
+		t.Errorf("Expected file:/data/set/cat/a.jpg, found %s", absolute)
+	}
+	if absolute, ok := absoluteAssetPath("red%20cat/a.jpg", "/data/set"); !ok || absolute != "file:/data/set/red%20cat/a.jpg" {
+		t.Errorf("Expected percent-encoding to be kept, found %s", absolute)
+	}
+	if _, ok := absoluteAssetPath("file:C:/data/a.jpg", "/data/set"); ok {
+		t.Error("Expected absolute paths to be left alone")
+	}
+}
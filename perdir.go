@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// generatePerDirectory writes a VoTT project for every top-level directory of root, each holding its own label folders:
+// root/camera1/cat/image1.jpg goes into annotations-camera1.json next to annotationFile. Directories that fail
+// don't stop the others. Returns the exit code of the last failure, or success.
+func generatePerDirectory(ctx context.Context, root string, annotationFile string, options Options) int {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		fmt.Println(err)
+		return ExitImagesFolderNotFound
+	}
+
+	exitCode := ExitSuccesful
+	projects := 0
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		projectFile := perDirectoryAnnotationFile(annotationFile, entry.Name())
+		fmt.Printf("Project '%s' for directory '%s'.\n", projectFile, entry.Name())
+
+		// Copies of images go into a subdirectory per project, so equally named labels and images don't collide.
+		projectOptions := options
+		outputDirs := map[*string]string{
+			&projectOptions.ResizeDir:     "resized",
+			&projectOptions.AugmentDir:    "augmented",
+			&projectOptions.TileDir:       "tiles",
+			&projectOptions.RenameDir:     "renamed",
+			&projectOptions.QuarantineDir: "quarantine",
+			&projectOptions.FramesDir:     "frames",
+		}
+		for dir, defaultDir := range outputDirs {
+			if *dir == "" {
+				*dir = filepath.Join(filepath.Dir(annotationFile), defaultDir)
+			}
+			*dir = filepath.Join(*dir, entry.Name())
+		}
+		if options.DrawOverlays != "" {
+			projectOptions.DrawOverlays = filepath.Join(options.DrawOverlays, entry.Name())
+		}
+		if options.Collect != "" {
+			projectOptions.Collect = filepath.Join(options.Collect, entry.Name())
+		}
+		if len(options.MoreFormats) > 0 {
+			projectOptions.MoreFormats = nil
+			for _, output := range options.MoreFormats {
+				if output.Path != "" {
+					output.Path = perDirectoryAnnotationFile(output.Path, entry.Name())
+				}
+				projectOptions.MoreFormats = append(projectOptions.MoreFormats, output)
+			}
+		}
+		// Files written next to the project are named after it, as the annotations file is.
+		for _, file := range []*string{
+			&projectOptions.SummaryFile, &projectOptions.SQLite, &projectOptions.Parquet,
+			&projectOptions.Rekognition.Manifest, &projectOptions.JSONPatch,
+		} {
+			if *file != "" {
+				*file = perDirectoryAnnotationFile(*file, entry.Name())
+			}
+		}
+
+		if ctx.Err() != nil {
+			return ExitInterrupted
+		}
+		if code := generate(ctx, filepath.Join(root, entry.Name()), projectFile, projectOptions); code != ExitSuccesful {
+			exitCode = code
+			continue
+		}
+		projects++
+	}
+
+	if projects == 0 && exitCode == ExitSuccesful {
+		fmt.Printf("Error: No directories found in '%s'.\n", root)
+		return ExitImagesFolderEmpty
+	}
+	return exitCode
+}
+
+// perDirectoryAnnotationFile returns the annotations file for the project of a top-level directory: annotations.json -> annotations-camera1.json
+func perDirectoryAnnotationFile(annotationFile string, dir string) string {
+	ext := filepath.Ext(annotationFile)
+	return strings.TrimSuffix(annotationFile, ext) + "-" + dir + ext
+}
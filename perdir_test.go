@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_PerDirectoryAnnotationFile(t *testing.T) {
+	if path := perDirectoryAnnotationFile(filepath.Join("out", "annotations.json"), "camera1"); path != filepath.Join("out", "annotations-camera1.json") {
+		t.Errorf("Expected annotations-camera1.json, found %s", path)
+	}
+}
+
+func Test_GeneratePerDirectory(t *testing.T) {
+	rootDir := t.TempDir()
+	for _, dir := range []string{filepath.Join("camera1", "cat"), filepath.Join("camera2", "dog")} {
+		if err := os.MkdirAll(filepath.Join(rootDir, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+		writeTestImage(t, filepath.Join(rootDir, dir, "image1.jpg"), 10, 10)
+	}
+
+	outDir := t.TempDir()
+	annotationFile := filepath.Join(outDir, "annotations.json")
+	if code := generatePerDirectory(context.Background(), rootDir, annotationFile, Options{Workers: 2}); code != ExitSuccesful {
+		t.Fatalf("Expected success, found exit code %d", code)
+	}
+
+	for camera, label := range map[string]string{"camera1": "cat", "camera2": "dog"} {
+		model, err := readVottJSON(filepath.Join(outDir, "annotations-"+camera+".json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(model.Assets) != 1 || len(model.Tags) != 1 || model.Tags[0].Name != label {
+			t.Errorf("Expected project %s with 1 asset tagged %s, found %d assets and tags %v", camera, label, len(model.Assets), model.Tags)
+		}
+	}
+}
+
+func Test_GeneratePerDirectoryOutputFiles(t *testing.T) {
+	rootDir := t.TempDir()
+	for _, dir := range []string{filepath.Join("camera1", "cat"), filepath.Join("camera2", "dog")} {
+		if err := os.MkdirAll(filepath.Join(rootDir, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+		writeTestImage(t, filepath.Join(rootDir, dir, "image1.jpg"), 10, 10)
+	}
+
+	outDir := t.TempDir()
+	options := Options{Workers: 2, NoHistory: true, JSONPatch: filepath.Join(outDir, "patch.json")}
+	options.Rekognition.Manifest = filepath.Join(outDir, "manifest.jsonl")
+	if code := generatePerDirectory(context.Background(), rootDir, filepath.Join(outDir, "annotations.json"), options); code != ExitSuccesful {
+		t.Fatalf("Expected success, found exit code %d", code)
+	}
+	for _, name := range []string{"patch-camera1.json", "patch-camera2.json", "manifest-camera1.jsonl", "manifest-camera2.jsonl"} {
+		if _, err := os.Stat(filepath.Join(outDir, name)); err != nil {
+			t.Errorf("Expected %s for its project: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(options.JSONPatch); err == nil {
+		t.Errorf("Expected no patch shared by the projects")
+	}
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Plan splits the label folders of an images folder into parts that machines generate on their own, for stores too
+// large for one machine to scan. Each part is a contiguous range of label folders in name order, a key prefix range
+// on object stores, and writes a project of its own that merge-parts combines.
+type Plan struct {
+	Version string     `json:"version"`
+	Images  string     `json:"images"`
+	Parts   []PlanPart `json:"parts"`
+}
+
+// PlanPart is a work unit of a plan, numbered from 1.
+type PlanPart struct {
+	Part   int      `json:"part"`
+	First  string   `json:"first"`
+	Last   string   `json:"last"`
+	Labels []string `json:"labels"`
+}
+
+// splitByPrefix splits the label folders of root into parts of about as many folders each, in name order.
+// Fails if there are fewer folders than parts.
+func splitByPrefix(root string, parts int) (Plan, error) {
+	plan := Plan{Version: Version, Images: root}
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return plan, err
+	}
+	var labels []string
+	for _, entry := range entries {
+		if isWalkedDir(root, entry) {
+			labels = append(labels, entry.Name())
+		}
+	}
+	sort.Strings(labels)
+	if len(labels) < parts {
+		return plan, fmt.Errorf("Error: Cannot split %d label folders of '%s' into %d parts", len(labels), root, parts)
+	}
+	for i := 0; i < parts; i++ {
+		share := labels[i*len(labels)/parts : (i+1)*len(labels)/parts]
+		plan.Parts = append(plan.Parts, PlanPart{Part: i + 1, First: share[0], Last: share[len(share)-1], Labels: share})
+	}
+	return plan, nil
+}
+
+// readPlan reads a plan file.
+func readPlan(path string) (Plan, error) {
+	var plan Plan
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return plan, err
+	}
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return plan, fmt.Errorf("Error: Cannot read plan '%s': %v", path, err)
+	}
+	return plan, nil
+}
+
+// part returns the part numbered n.
+func (plan Plan) part(n int) (PlanPart, error) {
+	for _, part := range plan.Parts {
+		if part.Part == n {
+			return part, nil
+		}
+	}
+	return PlanPart{}, fmt.Errorf("Error: No part %d in the plan, it has parts 1 to %d", n, len(plan.Parts))
+}
+
+// partPath returns the path of a part's project: annotations.json -> annotations-part-001.json
+func partPath(annotationFile string, part int) string {
+	ext := filepath.Ext(annotationFile)
+	return fmt.Sprintf("%s-part-%03d%s", strings.TrimSuffix(annotationFile, ext), part, ext)
+}
+
+// runPlan writes a plan that splits the label folders into parts, and prints the command that generates each part.
+//
+//	votter.exe plan --split-by-prefix 4 [-o plan.json] <path_to_images>
+func runPlan(args []string) int {
+	flags := flag.NewFlagSet("plan", flag.ExitOnError)
+	partsFlag := flags.Int("split-by-prefix", 0, "Number of parts, each a range of label folders in name order")
+	outputFlag := flags.String("o", "plan.json", "Plan file to write")
+	flags.Usage = func() {
+		fmt.Println("Usage: votter plan --split-by-prefix N [options] <path_to_images>")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 || *partsFlag < 1 {
+		flags.Usage()
+		return ExitInvalidArguments
+	}
+	root := flags.Arg(0)
+	if !isDirectory(root) {
+		fmt.Printf("Error: '%s' is not an existing directory\n", root)
+		return ExitImagesFolderNotFound
+	}
+
+	plan, err := splitByPrefix(root, *partsFlag)
+	if err != nil {
+		fmt.Println(err)
+		return ExitImagesFolderEmpty
+	}
+	data, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		fmt.Println(err)
+		return ExitAnnotationsWriteFailed
+	}
+	if err := ioutil.WriteFile(*outputFlag, data, 0644); err != nil {
+		fmt.Println(err)
+		return ExitAnnotationsWriteFailed
+	}
+
+	fmt.Printf("Planned %d parts in '%s', generate each on any machine:\n", len(plan.Parts), *outputFlag)
+	for _, part := range plan.Parts {
+		fmt.Printf("    votter --plan %s --part %d %s %s    # %d labels, %s to %s\n", *outputFlag, part.Part, root,
+			partPath("annotations.json", part.Part), len(part.Labels), part.First, part.Last)
+	}
+	fmt.Println("then combine them with votter merge-parts annotations-part-*.json.")
+	return ExitSuccesful
+}
+
+// runMergeParts combines the projects of the parts of a plan into one VoTT project, reading one part at a time.
+// The tags are those of all parts.
+//
+//	votter.exe merge-parts [-o annotations.json] [-no-history] [-json-patch patch.json] <part.json>...
+func runMergeParts(args []string) int {
+	flags := flag.NewFlagSet("merge-parts", flag.ExitOnError)
+	outputFlag := flags.String("o", "annotations.json", "Merged project file")
+	noHistoryFlag := flags.Bool("no-history", false, "Don't keep a snapshot of the merged file in .votter-history for votter rollback")
+	patchFlag := flags.String("json-patch", "", "Write an RFC 6902 JSON Patch from the previous merged file to the new one to this file")
+	flags.Usage = func() {
+		fmt.Println("Usage: votter merge-parts [options] <part.json>...")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return ExitInvalidArguments
+	}
+	paths := flags.Args()
+
+	// The tags of every part are needed before the first asset is written.
+	var tags []Tag
+	for _, path := range paths {
+		model, err := readVottJSON(path)
+		if err != nil {
+			fmt.Println(err)
+			return ExitAnnotationsNotReadable
+		}
+		tags = mergeProjectTags(tags, model.Tags)
+	}
+
+	var previous interface{}
+	if *patchFlag != "" {
+		var err error
+		if previous, err = readProjectDocument(*outputFlag); err != nil {
+			fmt.Println(err)
+			return ExitAnnotationsNotReadable
+		}
+	}
+
+	merged, duplicates, err := mergeProjects(paths, tags, *outputFlag)
+	if err != nil {
+		fmt.Println(err)
+		return ExitAnnotationsWriteFailed
+	}
+	if duplicates > 0 {
+		fmt.Printf("Left out %d assets of images in more than one part.\n", duplicates)
+	}
+	if !*noHistoryFlag {
+		if err := recordHistory(*outputFlag, "merge-parts"); err != nil {
+			fmt.Println(err)
+			return ExitAnnotationsWriteFailed
+		}
+	}
+
+	if *patchFlag != "" {
+		operations, err := writeJSONPatch(*patchFlag, previous, *outputFlag)
+		if err != nil {
+			fmt.Println(err)
+			return ExitAnnotationsWriteFailed
+		}
+		fmt.Printf("Wrote %d JSON Patch operations to '%s'.\n", operations, *patchFlag)
+	}
+
+	fmt.Printf("Merged %d assets of %d parts into '%s'.\n", merged, len(paths), *outputFlag)
+	return ExitSuccesful
+}
+
+// mergeProjectTags adds the tags that aren't there yet, keeping the colors of the tags that are, sorted by name.
+func mergeProjectTags(tags []Tag, more []Tag) []Tag {
+	for _, tag := range more {
+		found := false
+		for _, existing := range tags {
+			found = found || existing.Name == tag.Name
+		}
+		if !found {
+			tags = append(tags, tag)
+		}
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+	return tags
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_PlanAndMergeParts(t *testing.T) {
+	rootDir := t.TempDir()
+	for _, label := range []string{"ant", "bee", "cat", "dog", "eel"} {
+		os.MkdirAll(filepath.Join(rootDir, label), 0755)
+		writeTestImage(t, filepath.Join(rootDir, label, "image.jpg"), 10, 10)
+	}
+
+	plan, err := splitByPrefix(rootDir, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Parts) != 2 || !reflect.DeepEqual(plan.Parts[0].Labels, []string{"ant", "bee"}) || plan.Parts[1].First != "cat" || plan.Parts[1].Last != "eel" {
+		t.Fatalf("Expected two ranges of label folders, found %+v", plan.Parts)
+	}
+	if _, err := splitByPrefix(rootDir, 6); err == nil {
+		t.Error("Expected an error for more parts than label folders")
+	}
+	if _, err := plan.part(3); err == nil {
+		t.Error("Expected an error for a part that isn't in the plan")
+	}
+
+	// Every part scans its own label folders only.
+	outDir := t.TempDir()
+	var parts []string
+	for _, part := range plan.Parts {
+		labels, err := findImagesIn(context.Background(), rootDir, part.Labels, nil, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(labels) != len(part.Labels) {
+			t.Errorf("Expected the labels of part %d only, found %v", part.Part, labels)
+		}
+		assets, err := generateImageEntries(labeledImages(rootDir, labels), 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		path := partPath(filepath.Join(outDir, "annotations.json"), part.Part)
+		if err := writeVottJSON(path, assets, part.Labels); err != nil {
+			t.Fatal(err)
+		}
+		parts = append(parts, path)
+	}
+	// A part generated twice has its images merged once.
+	parts = append(parts, parts[0])
+
+	merged := filepath.Join(outDir, "annotations.json")
+	if code := runMergeParts(append([]string{"-o", merged, "-no-history"}, parts...)); code != ExitSuccesful {
+		t.Fatalf("Expected the parts merged, found exit code %d", code)
+	}
+	model, err := readVottJSON(merged)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tags []string
+	for _, tag := range model.Tags {
+		tags = append(tags, tag.Name)
+	}
+	if len(model.Assets) != 5 || !reflect.DeepEqual(tags, []string{"ant", "bee", "cat", "dog", "eel"}) {
+		t.Errorf("Expected 5 assets and the tags of all parts, found %d assets and %v", len(model.Assets), tags)
+	}
+}
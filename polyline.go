@@ -0,0 +1,37 @@
+package main
+
+import "math"
+
+// SidecarPolyline is an open line of points in the polylines of an image sidecar, like a lane marking or a seam.
+// It's tagged with the asset's label unless it has tags of its own.
+type SidecarPolyline struct {
+	Tags       []string               `json:"tags"`
+	Points     []Point                `json:"points"`
+	Attributes map[string]interface{} `json:"attributes"`
+}
+
+// polylineRegion returns a VoTT POLYLINE region of the points of a sidecar polyline, with the box around them.
+func polylineRegion(asset Asset, polyline SidecarPolyline) Region {
+	region := fullImageRegion(asset)
+	region.Type = "POLYLINE"
+	if len(polyline.Tags) > 0 {
+		region.Tags = polyline.Tags
+	}
+	region.Points = polyline.Points
+	region.BoundingBox = pointsBounds(polyline.Points)
+	region.Attributes = polyline.Attributes
+	return region
+}
+
+// pointsBounds returns the smallest box holding the points, an empty box at the origin for none.
+func pointsBounds(points []Point) BoundingBox {
+	if len(points) == 0 {
+		return BoundingBox{}
+	}
+	left, top, right, bottom := math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+	for _, point := range points {
+		left, top = min(left, point.X), min(top, point.Y)
+		right, bottom = max(right, point.X), max(bottom, point.Y)
+	}
+	return BoundingBox{Left: left, Top: top, Width: right - left, Height: bottom - top}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_SidecarPolylines(t *testing.T) {
+	dir := t.TempDir()
+	image := filepath.Join(dir, "image1.jpg")
+	sidecar := `{"polylines": [{"points": [{"x": 0, "y": 10.5}, {"x": 20, "y": 4}, {"x": 40, "y": 6}]}, {"tags": ["seam"], "points": [{"x": 1, "y": 1}, {"x": 2, "y": 2}]}]}`
+	if err := ioutil.WriteFile(sidecarPath(image), []byte(sidecar), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	assets := []Asset{{Path: "file:" + filepath.ToSlash(image), Label: "lane", Size: Size{Width: 40, Height: 30}, Regions: []Region{{Tags: []string{"lane"}}}}}
+	assets, tags, err := applySidecars(assets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(tags, []string{"lane", "seam"}) {
+		t.Errorf("Expected the polyline tags [lane seam], found %v", tags)
+	}
+	if len(assets[0].Regions) != 2 {
+		t.Fatalf("Expected a region per polyline in place of the default region, found %+v", assets[0].Regions)
+	}
+	lane := assets[0].Regions[0]
+	if lane.Type != "POLYLINE" || len(lane.Points) != 3 || lane.BoundingBox != (BoundingBox{Left: 0, Top: 4, Width: 40, Height: 6.5}) {
+		t.Errorf("Expected a POLYLINE region with the box around its points, found %+v", lane)
+	}
+}
+
+func Test_DrawPolylineOutline(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 20, 20))
+	red := color.RGBA{R: 255, A: 255}
+	drawRegionOutline(img, Region{Type: "POLYLINE", Points: []Point{{X: 2, Y: 2}, {X: 12, Y: 2}, {X: 12, Y: 12}}}, red)
+
+	for _, p := range []image.Point{{2, 2}, {7, 2}, {12, 7}} {
+		if img.RGBAAt(p.X, p.Y) != red {
+			t.Errorf("Expected the line at %v", p)
+		}
+	}
+	// Unlike a polygon the line isn't closed back to its start.
+	if img.RGBAAt(7, 7) == red || img.RGBAAt(2, 12) == red {
+		t.Error("Expected no closing segment")
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+)
+
+// Profiling are the --cpuprofile, --memprofile and --trace files of a generation run, none when empty.
+type Profiling struct {
+	CPUProfile string
+	MemProfile string
+	Trace      string
+
+	files []*os.File
+}
+
+// start begins the CPU profile and execution trace.
+func (p *Profiling) start() error {
+	if p.CPUProfile != "" {
+		file, err := p.create(p.CPUProfile)
+		if err != nil {
+			return err
+		}
+		if err := pprof.StartCPUProfile(file); err != nil {
+			return fmt.Errorf("Error: Cannot start CPU profile: %v", err)
+		}
+	}
+	if p.Trace != "" {
+		file, err := p.create(p.Trace)
+		if err != nil {
+			return err
+		}
+		if err := trace.Start(file); err != nil {
+			return fmt.Errorf("Error: Cannot start trace: %v", err)
+		}
+	}
+	return nil
+}
+
+// stop ends the CPU profile and execution trace and writes the heap profile.
+func (p *Profiling) stop() error {
+	if p.CPUProfile != "" {
+		pprof.StopCPUProfile()
+	}
+	if p.Trace != "" {
+		trace.Stop()
+	}
+	if p.MemProfile != "" {
+		file, err := p.create(p.MemProfile)
+		if err != nil {
+			return err
+		}
+		runtime.GC() // up to date statistics
+		if err := pprof.WriteHeapProfile(file); err != nil {
+			return fmt.Errorf("Error: Cannot write memory profile '%s': %v", p.MemProfile, err)
+		}
+	}
+
+	for _, file := range p.files {
+		if err := file.Close(); err != nil {
+			return err
+		}
+	}
+	p.files = nil
+	return nil
+}
+
+// create creates a profile file, closed by stop.
+func (p *Profiling) create(path string) (*os.File, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("Error: Cannot create profile '%s': %v", path, err)
+	}
+	p.files = append(p.files, file)
+	return file, nil
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_Profiling(t *testing.T) {
+	dir := t.TempDir()
+	profiling := Profiling{
+		CPUProfile: filepath.Join(dir, "cpu.pprof"),
+		MemProfile: filepath.Join(dir, "mem.pprof"),
+		Trace:      filepath.Join(dir, "trace.out"),
+	}
+	if err := profiling.start(); err != nil {
+		t.Fatal(err)
+	}
+	if err := profiling.stop(); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{profiling.CPUProfile, profiling.MemProfile, profiling.Trace} {
+		if info, err := os.Stat(path); err != nil || info.Size() == 0 {
+			t.Errorf("Expected a profile in %s: %v", path, err)
+		}
+	}
+}
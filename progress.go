@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// ProgressEvent is a line of --progress-json output: how far the run is in its current phase.
+type ProgressEvent struct {
+	Phase string  `json:"phase"`
+	Done  int     `json:"done"`
+	Total int     `json:"total"`
+	Label string  `json:"label,omitempty"`
+	Rate  float64 `json:"rate"`
+}
+
+// ProgressReporter writes progress events as NDJSON, at most one per Interval within a phase besides its first and last.
+type ProgressReporter struct {
+	Enabled  bool
+	Output   io.Writer
+	Interval time.Duration
+
+	mutex   sync.Mutex
+	event   ProgressEvent
+	started time.Time
+	emitted time.Time
+}
+
+// Progress reports the phases of generating a project on stderr, enabled by --progress-json.
+var Progress = &ProgressReporter{Output: os.Stderr, Interval: 100 * time.Millisecond}
+
+// begin starts a phase of total files, or an unknown number when total is 0.
+func (p *ProgressReporter) begin(phase string, total int) {
+	if !p.Enabled {
+		return
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.event = ProgressEvent{Phase: phase, Total: total}
+	p.started = time.Now()
+	p.emit()
+}
+
+// step counts a file of label as done, safe to call from concurrent workers.
+func (p *ProgressReporter) step(label string) {
+	if !p.Enabled {
+		return
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.event.Done++
+	p.event.Label = label
+	if p.event.Done == p.event.Total || time.Since(p.emitted) >= p.Interval {
+		p.emit()
+	}
+}
+
+// finish ends the phase with done files, for phases that don't count their files one by one.
+func (p *ProgressReporter) finish(done int) {
+	if !p.Enabled {
+		return
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.event.Total > 0 && p.event.Done == p.event.Total && done == p.event.Done {
+		return // the last step already reported it
+	}
+	p.event.Done = done
+	if p.event.Total == 0 {
+		p.event.Total = done
+	}
+	p.event.Label = ""
+	p.emit()
+}
+
+// emit writes the current event with the rate in files per second since the start of the phase.
+func (p *ProgressReporter) emit() {
+	p.emitted = time.Now()
+	if elapsed := p.emitted.Sub(p.started).Seconds(); elapsed > 0 {
+		p.event.Rate = float64(p.event.Done) / elapsed
+	}
+	data, err := json.Marshal(p.event)
+	if err != nil {
+		return
+	}
+	p.Output.Write(append(data, '\n'))
+}
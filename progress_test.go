@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_ProgressReporter(t *testing.T) {
+	var output bytes.Buffer
+	progress := &ProgressReporter{Enabled: true, Output: &output, Interval: time.Hour}
+
+	progress.begin("decode", 3)
+	progress.step("cat")
+	progress.step("cat")
+	progress.step("dog")
+	progress.finish(3)
+	progress.begin("write", 3)
+	progress.finish(3)
+
+	var events []ProgressEvent
+	for _, line := range strings.Split(strings.TrimSpace(output.String()), "\n") {
+		var event ProgressEvent
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			t.Fatalf("Expected a JSON line, found %q: %v", line, err)
+		}
+		events = append(events, event)
+	}
+
+	// The steps in between fall within the interval, finishing a phase its last step completed adds nothing.
+	expected := []ProgressEvent{
+		{Phase: "decode", Done: 0, Total: 3},
+		{Phase: "decode", Done: 3, Total: 3, Label: "dog"},
+		{Phase: "write", Done: 0, Total: 3},
+		{Phase: "write", Done: 3, Total: 3},
+	}
+	if len(events) != len(expected) {
+		t.Fatalf("Expected %d events, found %v", len(expected), events)
+	}
+	for i, event := range events {
+		event.Rate = 0
+		if event != expected[i] {
+			t.Errorf("Expected %+v, found %+v", expected[i], event)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HTTPClient is the client of the Custom Vision, Elasticsearch, MLflow and segmentation connectors when they have
+// none of their own, rate limited by --max-requests-per-second and --max-bandwidth and cached in --cache-dir.
+var HTTPClient = http.DefaultClient
+
+// byteUnits are the multipliers of byte count suffixes, decimal like network speeds.
+var byteUnits = []struct {
+	suffix     string
+	multiplier float64
+}{{"GB", 1e9}, {"MB", 1e6}, {"KB", 1e3}, {"B", 1}}
+
+// parseBytes parses a positive byte count like 10MB, 500KB or 2048. ok is false for anything else.
+func parseBytes(value string) (bytes float64, ok bool) {
+	number := strings.ToUpper(strings.TrimSpace(value))
+	multiplier := 1.0
+	for _, unit := range byteUnits {
+		if strings.HasSuffix(number, unit.suffix) {
+			number, multiplier = strings.TrimSpace(strings.TrimSuffix(number, unit.suffix)), unit.multiplier
+			break
+		}
+	}
+	bytes, err := strconv.ParseFloat(number, 64)
+	if err != nil || bytes <= 0 {
+		return 0, false
+	}
+	return bytes * multiplier, true
+}
+
+// parseBandwidth parses a --max-bandwidth like 10MB/s, 500KB or 2048 into bytes per second.
+func parseBandwidth(value string) (float64, error) {
+	bytes, ok := parseBytes(strings.TrimSuffix(strings.ToUpper(strings.TrimSpace(value)), "/S"))
+	if !ok {
+		return 0, fmt.Errorf("Error: Invalid --max-bandwidth '%s', expected bytes per second like 10MB/s", value)
+	}
+	return bytes, nil
+}
+
+// rateLimiter spaces out uses of a resource so they don't exceed rate per second, without bursts.
+type rateLimiter struct {
+	rate  float64
+	mutex sync.Mutex
+	next  time.Time
+}
+
+// wait blocks until n more units fit in the rate, or ctx is done.
+func (limiter *rateLimiter) wait(ctx context.Context, n float64) error {
+	limiter.mutex.Lock()
+	now := time.Now()
+	if limiter.next.Before(now) {
+		limiter.next = now
+	}
+	at := limiter.next
+	limiter.next = limiter.next.Add(time.Duration(n / limiter.rate * float64(time.Second)))
+	limiter.mutex.Unlock()
+
+	delay := time.Until(at)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// limitedTransport caps the requests per second and the bytes per second sent and received, over all connectors.
+type limitedTransport struct {
+	base      http.RoundTripper
+	requests  *rateLimiter
+	bandwidth *rateLimiter
+}
+
+// newLimitedTransport returns a transport over base that keeps to requestsPerSecond and bytesPerSecond, zero for no limit.
+func newLimitedTransport(base http.RoundTripper, requestsPerSecond float64, bytesPerSecond float64) *limitedTransport {
+	transport := &limitedTransport{base: base}
+	if requestsPerSecond > 0 {
+		transport.requests = &rateLimiter{rate: requestsPerSecond}
+	}
+	if bytesPerSecond > 0 {
+		transport.bandwidth = &rateLimiter{rate: bytesPerSecond}
+	}
+	return transport
+}
+
+// RoundTrip waits for its turn before sending the request, and throttles the request and response bodies.
+func (transport *limitedTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	ctx := request.Context()
+	if transport.requests != nil {
+		if err := transport.requests.wait(ctx, 1); err != nil {
+			return nil, err
+		}
+	}
+	if transport.bandwidth != nil && request.Body != nil {
+		request = request.Clone(ctx)
+		request.Body = &limitedBody{ReadCloser: request.Body, limiter: transport.bandwidth, ctx: ctx}
+	}
+	response, err := transport.base.RoundTrip(request)
+	if err != nil || transport.bandwidth == nil {
+		return response, err
+	}
+	response.Body = &limitedBody{ReadCloser: response.Body, limiter: transport.bandwidth, ctx: ctx}
+	return response, nil
+}
+
+// limitedBody is a request or response body read no faster than its limiter allows.
+type limitedBody struct {
+	io.ReadCloser
+	limiter *rateLimiter
+	ctx     context.Context
+}
+
+func (body *limitedBody) Read(p []byte) (int, error) {
+	n, err := body.ReadCloser.Read(p)
+	if n > 0 {
+		if waitErr := body.limiter.wait(body.ctx, float64(n)); waitErr != nil {
+			return n, waitErr
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_ParseBandwidth(t *testing.T) {
+	for value, expected := range map[string]float64{"10MB/s": 10e6, "500kb": 500e3, "2048": 2048, "1.5 GB": 1.5e9} {
+		if bytes, err := parseBandwidth(value); err != nil || bytes != expected {
+			t.Errorf("Expected %s to be %v bytes per second, found %v, %v", value, expected, bytes, err)
+		}
+	}
+	for _, value := range []string{"", "fast", "-1MB", "0"} {
+		if _, err := parseBandwidth(value); err == nil {
+			t.Errorf("Expected '%s' to be rejected", value)
+		}
+	}
+}
+
+func Test_RateLimitedClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.Write([]byte(strings.Repeat("x", 100)))
+	}))
+	defer server.Close()
+
+	// 5 requests at 50 per second take at least 80ms, the first goes right away.
+	client := &http.Client{Transport: newLimitedTransport(http.DefaultTransport, 50, 0)}
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		response, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response.Body.Close()
+	}
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Errorf("Expected the requests to be spaced out, took %v", elapsed)
+	}
+
+	// 100 bytes up and 100 bytes down at 2000 bytes per second take at least 50ms after the first read.
+	client = &http.Client{Transport: newLimitedTransport(http.DefaultTransport, 0, 2000)}
+	start = time.Now()
+	response, err := client.Post(server.URL, "text/plain", strings.NewReader(strings.Repeat("y", 100)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil || len(data) != 100 {
+		t.Fatalf("Expected the full response, found %d bytes, %v", len(data), err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("Expected the bodies to be throttled, took %v", elapsed)
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Types of Rekognition Custom Labels manifests: a box per region, or a label per image.
+const (
+	RekognitionDetection      = "detection"
+	RekognitionClassification = "classification"
+)
+
+// rekognitionJobName is the labeling job named in the manifest metadata, Rekognition only requires it to be there.
+const rekognitionJobName = "labeling-job/votter"
+
+// Label attributes of the manifest lines, each with an attribute-metadata block next to it.
+const (
+	rekognitionBoxAttribute   = "bounding-box"
+	rekognitionLabelAttribute = "label"
+)
+
+// Rekognition is the Custom Labels manifest written with --rekognition-manifest.
+type Rekognition struct {
+	Manifest string
+	S3Prefix string // s3://bucket/dataset/
+	Type     string
+}
+
+type rekognitionImageSize struct {
+	Width  int `json:"width"`
+	Height int `json:"height"`
+	Depth  int `json:"depth"`
+}
+
+type rekognitionAnnotation struct {
+	ClassID int `json:"class_id"`
+	Top     int `json:"top"`
+	Left    int `json:"left"`
+	Width   int `json:"width"`
+	Height  int `json:"height"`
+}
+
+type rekognitionBoundingBox struct {
+	ImageSize   []rekognitionImageSize  `json:"image_size"`
+	Annotations []rekognitionAnnotation `json:"annotations"`
+}
+
+type rekognitionConfidence struct {
+	Confidence float64 `json:"confidence"`
+}
+
+type rekognitionDetectionMetadata struct {
+	Objects        []rekognitionConfidence `json:"objects"`
+	ClassMap       map[string]string       `json:"class-map"`
+	Type           string                  `json:"type"`
+	HumanAnnotated string                  `json:"human-annotated"`
+	CreationDate   string                  `json:"creation-date"`
+	JobName        string                  `json:"job-name"`
+}
+
+type rekognitionClassificationMetadata struct {
+	Confidence     float64 `json:"confidence"`
+	ClassName      string  `json:"class-name"`
+	Type           string  `json:"type"`
+	HumanAnnotated string  `json:"human-annotated"`
+	CreationDate   string  `json:"creation-date"`
+	JobName        string  `json:"job-name"`
+}
+
+// validate checks the manifest settings.
+func (r Rekognition) validate() error {
+	if r.Type != RekognitionDetection && r.Type != RekognitionClassification {
+		return fmt.Errorf("Error: Unknown Rekognition manifest type '%s', expected detection or classification", r.Type)
+	}
+	if !strings.HasPrefix(r.S3Prefix, "s3://") {
+		return fmt.Errorf("Error: --rekognition-manifest needs a --rekognition-s3-prefix like s3://bucket/dataset/")
+	}
+	return nil
+}
+
+// writeRekognitionManifest writes a line of SageMaker Ground Truth JSON for every asset, as Rekognition Custom Labels
+// imports them. Images are referenced below the S3 prefix by their path relative to imagesPath, or else by label/name.
+func writeRekognitionManifest(r Rekognition, imagesPath string, assets []Asset, tags []string) error {
+	file, err := os.Create(r.Manifest)
+	if err != nil {
+		return fmt.Errorf("Error: Cannot write Rekognition manifest '%s': %v", r.Manifest, err)
+	}
+	defer file.Close()
+
+	classIDs := make(map[string]int)
+	for i, tag := range tags {
+		classIDs[tag] = i
+	}
+	creationDate := time.Now().UTC().Format("2006-01-02T15:04:05.000000")
+
+	writer := bufio.NewWriter(file)
+	for _, asset := range assets {
+		line := map[string]interface{}{"source-ref": rekognitionSourceRef(r.S3Prefix, imagesPath, asset)}
+		if r.Type == RekognitionClassification {
+			line[rekognitionLabelAttribute] = classIDs[asset.Label]
+			line[rekognitionLabelAttribute+"-metadata"] = rekognitionClassificationMetadata{
+				Confidence:     1,
+				ClassName:      asset.Label,
+				Type:           "groundtruth/image-classification",
+				HumanAnnotated: "yes",
+				CreationDate:   creationDate,
+				JobName:        rekognitionJobName,
+			}
+		} else {
+			box := rekognitionBoundingBox{
+				ImageSize:   []rekognitionImageSize{{Width: asset.Size.Width, Height: asset.Size.Height, Depth: 3}},
+				Annotations: []rekognitionAnnotation{},
+			}
+			metadata := rekognitionDetectionMetadata{
+				Objects:        []rekognitionConfidence{},
+				ClassMap:       make(map[string]string),
+				Type:           "groundtruth/object-detection",
+				HumanAnnotated: "yes",
+				CreationDate:   creationDate,
+				JobName:        rekognitionJobName,
+			}
+			regions := asset.Regions
+			if regions == nil {
+				regions = []Region{fullImageRegion(asset)}
+			}
+			for _, region := range regions {
+				for _, tag := range region.Tags {
+					b := region.BoundingBox
+					box.Annotations = append(box.Annotations, rekognitionAnnotation{ClassID: classIDs[tag], Top: int(math.Round(b.Top)), Left: int(math.Round(b.Left)), Width: int(math.Round(b.Width)), Height: int(math.Round(b.Height))})
+					metadata.Objects = append(metadata.Objects, rekognitionConfidence{Confidence: region.Score()})
+					metadata.ClassMap[strconv.Itoa(classIDs[tag])] = tag
+				}
+				if region.Confidence != nil {
+					metadata.HumanAnnotated = "no"
+				}
+			}
+			line[rekognitionBoxAttribute] = box
+			line[rekognitionBoxAttribute+"-metadata"] = metadata
+		}
+
+		data, err := json.Marshal(line)
+		if err != nil {
+			return err
+		}
+		writer.Write(append(data, '\n'))
+	}
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("Error: Cannot write Rekognition manifest '%s': %v", r.Manifest, err)
+	}
+	return file.Close()
+}
+
+// rekognitionSourceRef returns the S3 URI of an asset's image below prefix.
+func rekognitionSourceRef(prefix string, imagesPath string, asset Asset) string {
+	key := asset.Label + "/" + asset.Name
+	if root, err := filepath.Abs(imagesPath); err == nil {
+		if relative, err := filepath.Rel(root, assetFilePath(asset)); err == nil && !strings.HasPrefix(relative, "..") {
+			key = filepath.ToSlash(relative)
+		}
+	}
+	return strings.TrimSuffix(prefix, "/") + "/" + key
+}
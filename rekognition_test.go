@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_WriteRekognitionManifest(t *testing.T) {
+	imagesPath := t.TempDir()
+	imgPath := filepath.Join(imagesPath, "cat", "image1.jpg")
+	assets := []Asset{{
+		Name:    "image1.jpg",
+		Path:    "file:" + filepath.ToSlash(imgPath),
+		Size:    Size{Width: 100, Height: 50},
+		Label:   "cat",
+		Regions: []Region{{Tags: []string{"cat"}, BoundingBox: BoundingBox{Left: 10, Top: 5, Width: 50, Height: 25}}},
+	}}
+	manifest := filepath.Join(t.TempDir(), "manifest.jsonl")
+
+	rekognition := Rekognition{Manifest: manifest, S3Prefix: "s3://bucket/dataset/", Type: RekognitionDetection}
+	if err := writeRekognitionManifest(rekognition, imagesPath, assets, []string{"dog", "cat"}); err != nil {
+		t.Fatal(err)
+	}
+	var line struct {
+		SourceRef   string                       `json:"source-ref"`
+		BoundingBox rekognitionBoundingBox       `json:"bounding-box"`
+		Metadata    rekognitionDetectionMetadata `json:"bounding-box-metadata"`
+	}
+	readSingleLine(t, manifest, &line)
+	if line.SourceRef != "s3://bucket/dataset/cat/image1.jpg" {
+		t.Errorf("Expected the image below the S3 prefix, found %s", line.SourceRef)
+	}
+	expected := rekognitionAnnotation{ClassID: 1, Top: 5, Left: 10, Width: 50, Height: 25}
+	if len(line.BoundingBox.Annotations) != 1 || line.BoundingBox.Annotations[0] != expected {
+		t.Errorf("Expected annotation %+v, found %+v", expected, line.BoundingBox.Annotations)
+	}
+	if line.Metadata.ClassMap["1"] != "cat" || line.Metadata.Type != "groundtruth/object-detection" || len(line.Metadata.Objects) != 1 {
+		t.Errorf("Expected object detection metadata for cat, found %+v", line.Metadata)
+	}
+
+	rekognition.Type = RekognitionClassification
+	if err := writeRekognitionManifest(rekognition, imagesPath, assets, []string{"dog", "cat"}); err != nil {
+		t.Fatal(err)
+	}
+	var classification struct {
+		Label    int                               `json:"label"`
+		Metadata rekognitionClassificationMetadata `json:"label-metadata"`
+	}
+	readSingleLine(t, manifest, &classification)
+	if classification.Label != 1 || classification.Metadata.ClassName != "cat" || classification.Metadata.Type != "groundtruth/image-classification" {
+		t.Errorf("Expected image classification as cat, found %+v", classification)
+	}
+}
+
+func Test_RekognitionValidate(t *testing.T) {
+	if err := (Rekognition{S3Prefix: "s3://bucket/", Type: RekognitionDetection}).validate(); err != nil {
+		t.Error(err)
+	}
+	if err := (Rekognition{S3Prefix: "/local/", Type: RekognitionDetection}).validate(); err == nil {
+		t.Error("Expected an error for a prefix outside S3")
+	}
+	if err := (Rekognition{S3Prefix: "s3://bucket/", Type: "segmentation"}).validate(); err == nil {
+		t.Error("Expected an error for an unknown type")
+	}
+}
+
+// readSingleLine decodes the only line of a JSON lines file into value.
+func readSingleLine(t *testing.T, path string, value interface{}) {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	lines := 0
+	for scanner.Scan() {
+		lines++
+		if err := json.Unmarshal(scanner.Bytes(), value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if lines != 1 {
+		t.Fatalf("Expected 1 line in %s, found %d", path, lines)
+	}
+}
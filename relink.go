@@ -0,0 +1,195 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+// runRelink rewrites the start of asset paths in a VoTT file, for datasets moved to another drive, share or OS.
+//
+//	votter.exe relink [-o output.json] <oldPrefix> <newPrefix> <vott-annotations.json>
+func runRelink(args []string) int {
+	flags := flag.NewFlagSet("relink", flag.ExitOnError)
+	outputFlag := flags.String("o", "", "Write the relinked project here instead of overwriting the input")
+	flags.Usage = func() {
+		fmt.Println("Usage: votter relink [options] <oldPrefix> <newPrefix> <vott-annotations.json>")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 3 {
+		flags.Usage()
+		return ExitInvalidArguments
+	}
+	oldPrefix, newPrefix, annotationFile := flags.Arg(0), flags.Arg(1), flags.Arg(2)
+
+	data, err := ioutil.ReadFile(annotationFile)
+	if err != nil {
+		fmt.Println(err)
+		return ExitAnnotationsNotReadable
+	}
+
+	relinked, count, err := rewriteAssetPaths(data, func(path string) (string, bool) {
+		return relinkPath(path, oldPrefix, newPrefix)
+	})
+	if err != nil {
+		fmt.Println(err)
+		return ExitAnnotationsNotReadable
+	}
+
+	output := annotationFile
+	if *outputFlag != "" {
+		output = *outputFlag
+	}
+	if err := ioutil.WriteFile(output, relinked, 0644); err != nil {
+		fmt.Println(err)
+		return ExitAnnotationsWriteFailed
+	}
+
+	fmt.Printf("Relinked %d asset paths in '%s'.\n", count, output)
+	return ExitSuccesful
+}
+
+// relinkPath replaces oldPrefix at the start of a file: asset path with newPrefix. Prefixes may be given as
+// local paths or file URIs. The path keeps its percent-encoding style. Returns false if the prefix doesn't match.
+func relinkPath(assetPath, oldPrefix, newPrefix string) (string, bool) {
+	path := fileURIPath(assetPath)
+	oldPrefix = strings.TrimSuffix(fileURIPath(oldPrefix), "/")
+	newPrefix = strings.TrimSuffix(fileURIPath(newPrefix), "/")
+
+	rest, found := strings.CutPrefix(path, oldPrefix)
+	if !found || (rest != "" && !strings.HasPrefix(rest, "/")) {
+		return assetPath, false
+	}
+	if strings.HasPrefix(assetPath, "file://") {
+		return strictFileURI(newPrefix + rest), true
+	}
+	return formatFileURI(newPrefix+rest, strings.Contains(assetPath, "%")), true
+}
+
+// fileURIPath returns the forward slash path of a file: URI or local path, decoding percent-encoding.
+//
+//	file:C:/data/a%20b.jpg, file:///C:/data/a%20b.jpg, C:\data\a b.jpg -> C:/data/a b.jpg
+func fileURIPath(uri string) string {
+	path := strings.ReplaceAll(uri, "\\", "/")
+	if rest, found := strings.CutPrefix(path, "file:"); found {
+		path = rest
+		if strings.HasPrefix(path, "///") {
+			path = path[2:]
+		}
+		if unescaped, err := url.PathUnescape(path); err == nil {
+			path = unescaped
+		}
+	}
+	// Drop the slash before a drive letter: /C:/data -> C:/data
+	if len(path) > 2 && path[0] == '/' && path[2] == ':' {
+		path = path[1:]
+	}
+	return path
+}
+
+// assetFilePathIn returns the image file of an asset of a project in root. Relative paths, as --collect writes them,
+// are resolved against root and unescaped like the paths of file URIs, which collect percent-encodes them as.
+func assetFilePathIn(asset Asset, root string) string {
+	path := fileURIPath(asset.Path)
+	absolute := strings.HasPrefix(path, "/") || (len(path) > 1 && path[1] == ':')
+	if strings.HasPrefix(asset.Path, "file:") || absolute {
+		return filepath.FromSlash(path)
+	}
+	if unescaped, err := url.PathUnescape(path); err == nil {
+		path = unescaped
+	}
+	return filepath.Join(root, filepath.FromSlash(path))
+}
+
+// formatFileURI returns a file: asset path for a forward slash path, percent-encoding its segments if escape is set.
+func formatFileURI(path string, escape bool) string {
+	return "file:" + escapeURIPath(path, escape)
+}
+
+// Styles of --uri-style for asset paths: VoTT's historic file:C:/data/cat 1.jpg or RFC 8089 file:///C:/data/cat%201.jpg
+const (
+	URIStyleVott   = "vott"
+	URIStyleStrict = "strict"
+)
+
+// validURIStyle checks the --uri-style.
+func validURIStyle(style string) error {
+	if style != URIStyleVott && style != URIStyleStrict {
+		return fmt.Errorf("Error: Unknown URI style '%s', expected vott or strict", style)
+	}
+	return nil
+}
+
+// strictFileURI returns the RFC 8089 file URI of a forward slash path, with an empty authority and percent-encoded
+// segments: /data/cat 1.jpg -> file:///data/cat%201.jpg, C:/data -> file:///C:/data, //server/share -> file://server/share
+func strictFileURI(path string) string {
+	if strings.HasPrefix(path, "//") {
+		return "file://" + escapeURIPath(path[2:], true)
+	}
+	return "file:///" + escapeURIPath(strings.TrimPrefix(path, "/"), true)
+}
+
+// applyURIStyle rewrites the asset paths in the style, the assets are in the vott style already.
+func applyURIStyle(assets []Asset, style string) []Asset {
+	if style != URIStyleStrict {
+		return assets
+	}
+	for i := range assets {
+		assets[i].Path = strictFileURI(fileURIPath(assets[i].Path))
+	}
+	return assets
+}
+
+// escapeURIPath percent-encodes the segments of a forward slash path if escape is set.
+func escapeURIPath(path string, escape bool) string {
+	if !escape {
+		return path
+	}
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		// Keep the drive letter colon readable: C:
+		if i == 0 && len(segment) == 2 && segment[1] == ':' {
+			continue
+		}
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// rewriteAssetPaths applies rewrite to the path of every asset in VoTT project JSON and writes the project back in the
+// order votter writes it, with nothing but the paths changed. Returns the rewritten JSON and the number of paths changed.
+func rewriteAssetPaths(data []byte, rewrite func(path string) (string, bool)) ([]byte, int, error) {
+	var project struct {
+		VottJsonModel
+		Assets map[string]*struct {
+			AssetDetail
+			Asset *Asset `json:"asset"`
+		} `json:"assets"`
+	}
+	if err := json.Unmarshal(data, &project); err != nil {
+		return nil, 0, fmt.Errorf("Error: Cannot read the project: %v", err)
+	}
+
+	count := 0
+	model := project.VottJsonModel
+	model.Assets = make(map[string]AssetDetail, len(project.Assets))
+	for id, detail := range project.Assets {
+		if detail == nil || detail.Asset == nil {
+			return nil, 0, fmt.Errorf("Error: Asset '%s' of the project has no asset object", id)
+		}
+		if rewritten, ok := rewrite(detail.Asset.Path); ok {
+			detail.Asset.Path = rewritten
+			count++
+		}
+		detail.AssetDetail.Asset = *detail.Asset
+		model.Assets[id] = detail.AssetDetail
+	}
+
+	rewritten, err := encodeVottModel(model, FormatJSON)
+	return rewritten, count, err
+}
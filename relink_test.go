@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_FileURIPath(t *testing.T) {
+	cases := map[string]string{
+		"file:C:/data/a%20b.jpg":    "C:/data/a b.jpg",
+		"file:///C:/data/a.jpg":     "C:/data/a.jpg",
+		"file:/home/data/a.jpg":     "/home/data/a.jpg",
+		"file:///home/data/a.jpg":   "/home/data/a.jpg",
+		"C:\\data\\a b.jpg":         "C:/data/a b.jpg",
+		"//server/share/data/a.jpg": "//server/share/data/a.jpg",
+	}
+	for uri, expected := range cases {
+		if path := fileURIPath(uri); path != expected {
+			t.Errorf("fileURIPath(%s) = %s, expected %s", uri, path, expected)
+		}
+	}
+}
+
+func Test_AssetFilePathIn(t *testing.T) {
+	root := filepath.Join("out", "bundle")
+	cases := map[string]string{
+		"cat/a%20b.jpg":        filepath.Join(root, "cat", "a b.jpg"),
+		"cat/a b.jpg":          filepath.Join(root, "cat", "a b.jpg"),
+		"file:/data/a%20b.jpg": filepath.FromSlash("/data/a b.jpg"),
+		"/data/a%20b.jpg":      filepath.FromSlash("/data/a%20b.jpg"),
+	}
+	for path, expected := range cases {
+		if found := assetFilePathIn(Asset{Path: path}, root); found != expected {
+			t.Errorf("Expected %s for %s, found %s", expected, path, found)
+		}
+	}
+}
+
+func Test_RelinkPath(t *testing.T) {
+	if path, ok := relinkPath("file:C:/data/cat/a.jpg", "C:\\data", "/mnt/data"); !ok || path != "file:/mnt/data/cat/a.jpg" {
+		t.Errorf("Expected file:/mnt/data/cat/a.jpg, found %s", path)
+	}
+	if path, ok := relinkPath("file:C:/My%20Data/cat/a.jpg", "file:///C:/My%20Data/", "D:/New Data"); !ok || path != "file:D:/New%20Data/cat/a.jpg" {
+		t.Errorf("Expected percent-encoded file:D:/New%%20Data/cat/a.jpg, found %s", path)
+	}
+	if _, ok := relinkPath("file:C:/database/a.jpg", "C:/data", "D:/data"); ok {
+		t.Error("Expected the prefix to match whole directory names only")
+	}
+}
+
+func Test_RewriteAssetPaths(t *testing.T) {
+	model := buildVottModel([]Asset{{ID: "id1", Path: "file:/old/a.jpg", Size: Size{Width: 10, Height: 5}, Label: "cat"}}, []string{"cat"})
+	data, err := encodeVottModel(model, FormatJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rewritten, count, err := rewriteAssetPaths(data, func(path string) (string, bool) {
+		return relinkPath(path, "/old", "/new")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("Expected 1 rewritten path, found %d", count)
+	}
+
+	// Nothing but the path changes, the keys keep their order.
+	expected := bytes.Replace(data, []byte("file:/old/a.jpg"), []byte("file:/new/a.jpg"), 1)
+	if !bytes.Equal(rewritten, expected) {
+		t.Errorf("Expected only the path rewritten, found %s", rewritten)
+	}
+
+	for _, broken := range []string{`{"assets":{"id1":null}}`, `{"assets":{"id1":{"asset":null}}}`, `{"assets":{"id1":5}}`} {
+		if _, _, err := rewriteAssetPaths([]byte(broken), func(path string) (string, bool) { return path, false }); err == nil || !strings.HasPrefix(err.Error(), "Error: ") {
+			t.Errorf("Expected an error for %s, found %v", broken, err)
+		}
+	}
+}
+
+func Test_StrictFileURI(t *testing.T) {
+	tests := map[string]string{
+		"/data/cat 1.jpg":        "file:///data/cat%201.jpg",
+		"C:/data/chat/été.jpg":   "file:///C:/data/chat/%C3%A9t%C3%A9.jpg",
+		"//server/share/cat.jpg": "file://server/share/cat.jpg",
+	}
+	for path, expected := range tests {
+		uri := strictFileURI(path)
+		if uri != expected {
+			t.Errorf("Expected %s for %s, found %s", expected, path, uri)
+		}
+		if back := fileURIPath(uri); back != path {
+			t.Errorf("Expected %s back from %s, found %s", path, uri, back)
+		}
+	}
+
+	assets := applyURIStyle([]Asset{{Path: "file:C:/data/cat 1.jpg"}}, URIStyleStrict)
+	if assets[0].Path != "file:///C:/data/cat%201.jpg" {
+		t.Errorf("Expected the strict URI, found %s", assets[0].Path)
+	}
+	if relinked, _ := relinkPath(assets[0].Path, "C:/data", "D:/data"); relinked != "file:///D:/data/cat%201.jpg" {
+		t.Errorf("Expected relinking to keep the strict style, found %s", relinked)
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ConfigPollInterval is how often serve checks its config file for changes.
+const ConfigPollInterval = 2 * time.Second
+
+// ServeSettings are the settings of the config file that serve applies to the zips of /convert: the class map, the
+// color strategy and the --ignore patterns. They're read again when the config file changes or on SIGHUP.
+type ServeSettings struct {
+	ClassMap      map[string]string
+	ColorStrategy string
+	Ignore        []string
+}
+
+// loadServeSettings reads the settings of serve from the config file at path, see configFile for which file that
+// is, and the environment. No config file is no settings.
+func loadServeSettings(path string) (ServeSettings, error) {
+	settings := ServeSettings{ColorStrategy: ColorStrategyFixed}
+	config, err := loadConfig(path)
+	if err != nil {
+		return settings, err
+	}
+	if classMap, ok := configValue(config, "class-map"); ok && classMap != "" {
+		if settings.ClassMap, err = readClassMap(classMap); err != nil {
+			return settings, err
+		}
+	}
+	if strategy, ok := configValue(config, "color-strategy"); ok && strategy != "" {
+		if err := validColorStrategy(strategy); err != nil {
+			return settings, err
+		}
+		settings.ColorStrategy = strategy
+	}
+	if ignore, ok := configValue(config, "ignore"); ok && ignore != "" {
+		settings.Ignore = parseIgnorePatterns(ignore)
+	}
+	return settings, nil
+}
+
+// reloadableSettings holds the settings of serve, swapped when they're reloaded.
+type reloadableSettings struct {
+	mutex    sync.RWMutex
+	settings ServeSettings
+}
+
+func (r *reloadableSettings) get() ServeSettings {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.settings
+}
+
+func (r *reloadableSettings) set(settings ServeSettings) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.settings = settings
+}
+
+// watchServeSettings reloads the settings from the config file at path on SIGHUP, or when the file changes, until
+// ctx is done. A config file that fails to load is reported and the settings stay as they were, as do the projects
+// served and what they hold.
+func watchServeSettings(ctx context.Context, path string, settings *reloadableSettings) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+	ticker := time.NewTicker(ConfigPollInterval)
+	defer ticker.Stop()
+
+	modTime := configModTime(path)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangup:
+		case <-ticker.C:
+			changed := configModTime(path)
+			if changed.Equal(modTime) {
+				continue
+			}
+			modTime = changed
+		}
+		reloaded, err := loadServeSettings(path)
+		if err != nil {
+			fmt.Printf("%v, keeping the settings as they were.\n", err)
+			continue
+		}
+		settings.set(reloaded)
+		fmt.Printf("Reloaded the settings of '%s'.\n", configFile(path))
+	}
+}
+
+// configModTime returns when the config file changed last, the zero time when there's none.
+func configModTime(path string) time.Time {
+	if path = configFile(path); path == "" {
+		return time.Time{}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func Test_ReloadServeSettings(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no SIGHUP on Windows")
+	}
+	dir := t.TempDir()
+	classMap := filepath.Join(dir, "classmap.json")
+	os.WriteFile(classMap, []byte(`{"siamese": "cat"}`), 0644)
+	config := filepath.Join(dir, "votter.yaml")
+	os.WriteFile(config, []byte("color-strategy: fixed\n"), 0644)
+
+	var settings reloadableSettings
+	loaded, err := loadServeSettings(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings.set(loaded)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go watchServeSettings(ctx, config, &settings)
+	time.Sleep(50 * time.Millisecond)
+
+	os.WriteFile(config, []byte("class-map: "+classMap+"\nignore: [\"*.tmp.jpg\", drafts]\nresize: 1024\n"), 0644)
+	syscall.Kill(os.Getpid(), syscall.SIGHUP)
+	deadline := time.Now().Add(5 * time.Second)
+	for settings.get().ClassMap == nil && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	reloaded := settings.get()
+	if reloaded.ClassMap["siamese"] != "cat" || len(reloaded.Ignore) != 2 {
+		t.Fatalf("Expected the settings reloaded on SIGHUP, found %+v", reloaded)
+	}
+
+	// A broken config file keeps the settings.
+	os.WriteFile(config, []byte("color-strategy: rainbow\n"), 0644)
+	syscall.Kill(os.Getpid(), syscall.SIGHUP)
+	time.Sleep(100 * time.Millisecond)
+	if settings.get().ClassMap["siamese"] != "cat" {
+		t.Error("Expected the settings kept when the config file fails")
+	}
+
+	images := filepath.Join(dir, "images")
+	for _, path := range []string{"siamese/a.jpg", "siamese/b.tmp.jpg", "dog/c.jpg", "drafts/d.jpg"} {
+		os.MkdirAll(filepath.Dir(filepath.Join(images, path)), 0755)
+		writeTestImage(t, filepath.Join(images, path), 10, 10)
+	}
+	data, err := convertImagesFolder(context.Background(), images, "pets", FormatJSON, reloaded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var model VottJsonModel
+	json.Unmarshal(data, &model)
+	if len(model.Assets) != 2 || len(model.Tags) != 2 || model.Tags[0].Name != "cat" || model.Tags[1].Name != "dog" {
+		t.Errorf("Expected the class map and ignore patterns applied, found %d assets and tags %v", len(model.Assets), model.Tags)
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// renameAssets copies every asset image into outDir named by the asset ID (scheme "uuid") or the SHA-256 of its
+// contents (scheme "hash"), and writes outDir/mapping.csv from original to new paths. Returns the assets pointing at the copies.
+func renameAssets(assets []Asset, scheme string, outDir string) ([]Asset, error) {
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return nil, err
+	}
+
+	mappingFile, err := os.Create(filepath.Join(outDir, "mapping.csv"))
+	if err != nil {
+		return nil, err
+	}
+	defer mappingFile.Close()
+	mapping := csv.NewWriter(mappingFile)
+	mapping.Write([]string{"original", "renamed", "label"})
+
+	var renamed []Asset
+	for _, asset := range assets {
+		source := assetFilePath(asset)
+
+		name := asset.ID
+		if scheme == "hash" {
+			if name, err = fileSHA256(source); err != nil {
+				return nil, err
+			}
+		}
+		name += strings.ToLower(filepath.Ext(asset.Name))
+
+		// Identical images share a name when renamed by hash, one copy is enough.
+		target := filepath.Join(outDir, name)
+		if _, err := os.Stat(target); os.IsNotExist(err) {
+			if err := copyFile(source, target); err != nil {
+				return nil, err
+			}
+		}
+		targetAbsolutePath, err := filepath.Abs(target)
+		if err != nil {
+			return nil, err
+		}
+
+		mapping.Write([]string{source, targetAbsolutePath, asset.Label})
+
+		asset.Name = name
+		asset.Path = "file:" + filepath.ToSlash(targetAbsolutePath)
+		renamed = append(renamed, asset)
+	}
+
+	mapping.Flush()
+	return renamed, mapping.Error()
+}
+
+// fileSHA256 returns the hex encoded SHA-256 of the contents of a file.
+func fileSHA256(path string) (string, error) {
+	return retryRead(Retry, path, OpenFiles, func() (string, error) {
+		file, err := os.Open(path)
+		if err != nil {
+			return "", err
+		}
+		defer file.Close()
+
+		hash := sha256.New()
+		if _, err := io.Copy(hash, file); err != nil {
+			return "", err
+		}
+		return hex.EncodeToString(hash.Sum(nil)), nil
+	})
+}
+
+// validRenameScheme checks the value of the --rename flag.
+func validRenameScheme(scheme string) error {
+	if scheme != "" && scheme != "uuid" && scheme != "hash" {
+		return fmt.Errorf("Error: Unknown rename scheme '%s', expected uuid or hash", scheme)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_RenameAssets(t *testing.T) {
+	rootDir := t.TempDir()
+	var assets []Asset
+	for _, label := range []string{"cat", "dog"} {
+		if err := os.Mkdir(filepath.Join(rootDir, label), 0755); err != nil {
+			t.Fatal(err)
+		}
+		imgPath := filepath.Join(rootDir, label, "image1.JPG")
+		writeTestImage(t, imgPath, 10, 10)
+		assets = append(assets, Asset{ID: "id-" + label, Name: "image1.JPG", Path: "file:" + filepath.ToSlash(imgPath), Label: label})
+	}
+
+	outDir := filepath.Join(rootDir, "renamed")
+	renamed, err := renameAssets(assets, "uuid", outDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if renamed[0].Name != "id-cat.jpg" || renamed[1].Name != "id-dog.jpg" {
+		t.Errorf("Expected names by asset ID, found %s and %s", renamed[0].Name, renamed[1].Name)
+	}
+	if _, err := os.Stat(filepath.Join(outDir, "id-dog.jpg")); err != nil {
+		t.Error(err)
+	}
+
+	file, err := os.Open(filepath.Join(outDir, "mapping.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	rows, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 || rows[2][2] != "dog" {
+		t.Errorf("Expected a header and 2 mapping rows, found %v", rows)
+	}
+}
+
+func Test_RenameAssetsByHash(t *testing.T) {
+	rootDir := t.TempDir()
+	imgPath := filepath.Join(rootDir, "image1.jpg")
+	writeTestImage(t, imgPath, 10, 10)
+	asset := Asset{ID: "id1", Name: "image1.jpg", Path: "file:" + filepath.ToSlash(imgPath), Label: "cat"}
+
+	renamed, err := renameAssets([]Asset{asset, asset}, "hash", filepath.Join(rootDir, "renamed"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash, err := fileSHA256(imgPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if renamed[0].Name != hash+".jpg" || renamed[1].Name != renamed[0].Name {
+		t.Errorf("Expected both assets named %s.jpg, found %s and %s", hash, renamed[0].Name, renamed[1].Name)
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"image"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/image/draw"
+)
+
+// resizeAssets writes a copy of every asset image scaled down to fit within maxDimension into outDir/label/ and returns
+// the assets pointing at the copies, with sizes and regions scaled accordingly. Images already within the limit are copied as is.
+// Copies are named after their images, with the asset ID for images of the same name in a label folder.
+func resizeAssets(assets []Asset, maxDimension int, outDir string) ([]Asset, error) {
+	var resized []Asset
+
+	names := make([]string, len(assets))
+	ids := make([]string, len(assets))
+	for i, asset := range assets {
+		names[i] = filepath.Join(asset.Label, encodedName(asset.Name))
+		ids[i] = asset.ID
+	}
+	names = uniqueNames(names, ids)
+
+	for i, asset := range assets {
+		imgPath := filepath.Join(outDir, names[i])
+		if err := os.MkdirAll(filepath.Dir(imgPath), 0755); err != nil {
+			return nil, err
+		}
+
+		img, err := decodeImageFile(assetFilePath(asset))
+		if err != nil {
+			return nil, err
+		}
+
+		width, height := scaledSize(asset.Size.Width, asset.Size.Height, maxDimension)
+		if width != asset.Size.Width || height != asset.Size.Height {
+			scaled := image.NewRGBA(image.Rect(0, 0, width, height))
+			draw.CatmullRom.Scale(scaled, scaled.Bounds(), img, img.Bounds(), draw.Over, nil)
+			img = scaled
+		}
+
+		if err := encodeImageFile(imgPath, img); err != nil {
+			return nil, err
+		}
+		imgAbsolutePath, err := filepath.Abs(imgPath)
+		if err != nil {
+			return nil, err
+		}
+
+		scaleX := float64(width) / float64(asset.Size.Width)
+		scaleY := float64(height) / float64(asset.Size.Height)
+		regions := make([]Region, 0, len(asset.Regions))
+		for _, region := range asset.Regions {
+			regions = append(regions, scaleRegion(region, scaleX, scaleY))
+		}
+
+		asset.Name = filepath.Base(imgPath)
+		asset.Format = strings.TrimPrefix(filepath.Ext(imgPath), ".")
+		asset.Path = "file:" + filepath.ToSlash(imgAbsolutePath)
+		asset.ID = assetID(asset.Path)
+		asset.Size = Size{Width: width, Height: height}
+		if asset.Regions != nil {
+			asset.Regions = regions
+		}
+		resized = append(resized, asset)
+	}
+
+	return resized, nil
+}
+
+// scaledSize returns width and height scaled to fit within maxDimension, keeping the aspect ratio. Never scales up.
+func scaledSize(width, height, maxDimension int) (int, int) {
+	if width <= maxDimension && height <= maxDimension {
+		return width, height
+	}
+	if width >= height {
+		return maxDimension, max(1, height*maxDimension/width)
+	}
+	return max(1, width*maxDimension/height), maxDimension
+}
+
+// scaleRegion scales the bounding box and points of a region by the given factors.
+func scaleRegion(region Region, scaleX, scaleY float64) Region {
+	region.BoundingBox = BoundingBox{
+		Left:   region.BoundingBox.Left * scaleX,
+		Top:    region.BoundingBox.Top * scaleY,
+		Width:  region.BoundingBox.Width * scaleX,
+		Height: region.BoundingBox.Height * scaleY,
+	}
+	points := make([]Point, len(region.Points))
+	for i, point := range region.Points {
+		points[i] = Point{X: point.X * scaleX, Y: point.Y * scaleY}
+	}
+	region.Points = points
+	region.Keypoints = mapKeypoints(region.Keypoints, func(x, y float64) (float64, float64, bool) {
+		return x * scaleX, y * scaleY, true
+	})
+	return refitOrientedBox(region)
+}
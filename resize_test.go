@@ -0,0 +1,87 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_ScaledSize(t *testing.T) {
+	cases := []struct{ width, height, max, wantWidth, wantHeight int }{
+		{2000, 1000, 1024, 1024, 512},
+		{1000, 2000, 1024, 512, 1024},
+		{800, 600, 1024, 800, 600},
+	}
+	for _, c := range cases {
+		width, height := scaledSize(c.width, c.height, c.max)
+		if width != c.wantWidth || height != c.wantHeight {
+			t.Errorf("scaledSize(%d, %d, %d) = %dx%d, expected %dx%d", c.width, c.height, c.max, width, height, c.wantWidth, c.wantHeight)
+		}
+	}
+}
+
+func Test_ResizeAssets(t *testing.T) {
+	rootDir := t.TempDir()
+	imgPath := filepath.Join(rootDir, "image1.jpg")
+	writeTestImage(t, imgPath, 200, 100)
+
+	asset := Asset{Name: "image1.jpg", Path: "file:" + filepath.ToSlash(imgPath), Size: Size{Width: 200, Height: 100}, Label: "cat"}
+	asset.Regions = []Region{{BoundingBox: BoundingBox{Left: 20, Top: 10, Width: 100, Height: 50}, Points: []Point{{X: 20, Y: 10}, {X: 120, Y: 60}}}}
+
+	outDir := filepath.Join(rootDir, "resized")
+	resized, err := resizeAssets([]Asset{asset}, 100, outDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resized[0].ID != assetID(resized[0].Path) {
+		t.Errorf("Expected the ID of the copy's path, found %s", resized[0].ID)
+	}
+	if resized[0].Size.Width != 100 || resized[0].Size.Height != 50 {
+		t.Errorf("Expected size 100x50, found %dx%d", resized[0].Size.Width, resized[0].Size.Height)
+	}
+	box := resized[0].Regions[0].BoundingBox
+	if box != (BoundingBox{Left: 10, Top: 5, Width: 50, Height: 25}) {
+		t.Errorf("Expected scaled bounding box, found %+v", box)
+	}
+	if point := resized[0].Regions[0].Points[1]; point.X != 60 || point.Y != 30 {
+		t.Errorf("Expected scaled point 60,30, found %v,%v", point.X, point.Y)
+	}
+
+	img, err := decodeImageFile(filepath.Join(outDir, "cat", "image1.jpg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Bounds().Dx() != 100 || img.Bounds().Dy() != 50 {
+		t.Errorf("Expected written image of 100x50, found %v", img.Bounds())
+	}
+}
+
+func Test_ResizeAssetsSameNames(t *testing.T) {
+	rootDir := t.TempDir()
+	var assets []Asset
+	for i, folder := range []string{"a", "b"} {
+		os.MkdirAll(filepath.Join(rootDir, folder), 0755)
+		imgPath := filepath.Join(rootDir, folder, "image1.jpg")
+		writeTestImage(t, imgPath, 20*(i+1), 10)
+		path := "file:" + filepath.ToSlash(imgPath)
+		assets = append(assets, Asset{ID: assetID(path), Name: "image1.jpg", Path: path, Size: Size{Width: 20 * (i + 1), Height: 10}, Label: "cat"})
+	}
+
+	resized, err := resizeAssets(assets, 100, filepath.Join(rootDir, "resized"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resized[0].Path == resized[1].Path {
+		t.Fatalf("Expected a copy of each image, found both at %s", resized[0].Path)
+	}
+	for i, asset := range resized {
+		img, err := decodeImageFile(assetFilePath(asset))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if img.Bounds().Dx() != 20*(i+1) || asset.Name != "image1_"+assets[i].ID+".jpg" {
+			t.Errorf("Expected the copy of image %d named by its asset ID, found %s of %v", i, asset.Name, img.Bounds())
+		}
+	}
+}
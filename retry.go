@@ -0,0 +1,84 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy retries file reads that fail with transient errors, waiting Delay before the first retry and twice as long before each next one.
+// Reads taking longer than Timeout fail with ErrFileTimeout, when Timeout is set.
+type RetryPolicy struct {
+	Retries int
+	Delay   time.Duration
+	Timeout time.Duration
+}
+
+// ErrFileTimeout is the error of a read that took longer than --file-timeout.
+var ErrFileTimeout = errors.New("timed out")
+
+// Retry is the policy for reading images and directories, set from --retries, --retry-delay and --file-timeout.
+var Retry = RetryPolicy{Retries: 0, Delay: 500 * time.Millisecond}
+
+// transientErrors are errors of flaky disks and network mounts that may go away when trying again.
+var transientErrors = []error{syscall.EIO, syscall.EAGAIN, syscall.EINTR, syscall.EBUSY, syscall.ETIMEDOUT, syscall.ECONNRESET, syscall.ECONNABORTED, syscall.ESTALE}
+
+// isTransient reports whether err is worth retrying. Missing files and denied permissions are not.
+func isTransient(err error) bool {
+	for _, transient := range transientErrors {
+		if errors.Is(err, transient) {
+			return true
+		}
+	}
+	return false
+}
+
+// retryRead runs read under policy, retrying it with exponential backoff while it fails with a transient error, and
+// returns what it read. Each attempt holds a file of limit, nil for none. what names the file in messages.
+// A read that times out is not retried, it may still be hung on the file.
+func retryRead[T any](policy RetryPolicy, what string, limit FileLimit, read func() (T, error)) (T, error) {
+	delay := policy.Delay
+	value, err := attemptRead(policy, what, limit, read)
+	for attempt := 1; attempt <= policy.Retries && err != nil && isTransient(err); attempt++ {
+		fmt.Printf("Retrying '%s' in %v (%d of %d): %v\n", what, delay, attempt, policy.Retries, err)
+		time.Sleep(delay)
+		delay *= 2
+		value, err = attemptRead(policy, what, limit, read)
+	}
+	return value, err
+}
+
+// attemptRead runs read once, giving up on it after Timeout. A hung read is left behind in its goroutine, as reads
+// blocked in the kernel can't be cancelled, and its result is dropped when it returns. It keeps its file of limit until
+// then, as the file is still open, so waiting for a file counts against Timeout too: with every file held by hung
+// reads, the next read times out instead of waiting for them.
+func attemptRead[T any](policy RetryPolicy, what string, limit FileLimit, read func() (T, error)) (T, error) {
+	var zero T
+	if policy.Timeout <= 0 {
+		limit.acquire()
+		defer limit.release()
+		return read()
+	}
+	timeout := time.NewTimer(policy.Timeout)
+	defer timeout.Stop()
+	if !limit.acquireUntil(timeout.C) {
+		return zero, fmt.Errorf("%s: %w after %v waiting for a file of --max-open-files", what, ErrFileTimeout, policy.Timeout)
+	}
+	type result struct {
+		value T
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		defer limit.release()
+		value, err := read()
+		done <- result{value, err}
+	}()
+	select {
+	case read := <-done:
+		return read.value, read.err
+	case <-timeout.C:
+		return zero, fmt.Errorf("%s: %w after %v", what, ErrFileTimeout, policy.Timeout)
+	}
+}
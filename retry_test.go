@@ -0,0 +1,83 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func Test_IsTransient(t *testing.T) {
+	if !isTransient(&os.PathError{Op: "read", Path: "image1.jpg", Err: syscall.EIO}) {
+		t.Error("Expected EIO to be transient")
+	}
+	if isTransient(&os.PathError{Op: "open", Path: "image1.jpg", Err: syscall.ENOENT}) {
+		t.Error("Expected a missing file not to be transient")
+	}
+}
+
+func Test_RetryPolicy(t *testing.T) {
+	policy := RetryPolicy{Retries: 3, Delay: time.Millisecond}
+
+	attempts := 0
+	_, err := retryRead(policy, "image1.jpg", nil, func() (int, error) {
+		attempts++
+		if attempts < 3 {
+			return 0, fmt.Errorf("read image1.jpg: %w", syscall.EIO)
+		}
+		return attempts, nil
+	})
+	if err != nil || attempts != 3 {
+		t.Errorf("Expected success on the third attempt, found %d attempts and %v", attempts, err)
+	}
+
+	attempts = 0
+	_, err = retryRead(policy, "image1.jpg", nil, func() (int, error) {
+		attempts++
+		return 0, os.ErrNotExist
+	})
+	if err == nil || attempts != 1 {
+		t.Errorf("Expected no retries for a missing file, found %d attempts", attempts)
+	}
+
+	attempts = 0
+	_, err = retryRead(policy, "image1.jpg", nil, func() (int, error) {
+		attempts++
+		return 0, syscall.EIO
+	})
+	if err == nil || attempts != 4 {
+		t.Errorf("Expected to give up after 3 retries, found %d attempts", attempts)
+	}
+}
+
+func Test_RetryPolicyTimeout(t *testing.T) {
+	policy := RetryPolicy{Retries: 3, Delay: time.Millisecond, Timeout: 10 * time.Millisecond}
+
+	limit := newFileLimit(1)
+	hung := make(chan struct{})
+	defer close(hung)
+	var attempts atomic.Int32
+	_, err := retryRead(policy, "image1.jpg", limit, func() (int, error) {
+		attempts.Add(1)
+		<-hung
+		return 1, nil
+	})
+	if !errors.Is(err, ErrFileTimeout) || attempts.Load() > 1 {
+		t.Errorf("Expected a timeout without retries, found %d attempts and %v", attempts.Load(), err)
+	}
+
+	// The only file of the limit is still open by the hung read, the next read times out waiting for it.
+	if _, err := retryRead(policy, "image2.jpg", limit, func() (int, error) { return 2, nil }); !errors.Is(err, ErrFileTimeout) {
+		t.Errorf("Expected a timeout waiting for the file of the hung read, found %v", err)
+	}
+
+	// Once the hung read returns, its file is free again.
+	hung <- struct{}{}
+	value, err := retryRead(RetryPolicy{Timeout: time.Second}, "image2.jpg", limit, func() (int, error) { return 2, nil })
+	if err != nil || value != 2 {
+		t.Errorf("Expected a fast read to succeed, found %d and %v", value, err)
+	}
+}
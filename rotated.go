@@ -0,0 +1,153 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"votter/mod/votter"
+)
+
+// FormatCOCORotated is the --format of COCO with rotated boxes, as Detectron2 reads them.
+const FormatCOCORotated = "coco-rotated"
+
+// OrientedBox is a rotated box by its center, size and angle.
+type OrientedBox = votter.OrientedBox
+
+// SidecarOrientedBox is a rotated box in the orientedBoxes of an image sidecar.
+// It's tagged with the asset's label unless it has tags of its own.
+type SidecarOrientedBox struct {
+	Tags       []string               `json:"tags"`
+	Attributes map[string]interface{} `json:"attributes"`
+	OrientedBox
+}
+
+// orientedBoxOf returns the rotated box of four corners in order around it.
+func orientedBoxOf(corners []Point) OrientedBox {
+	var box OrientedBox
+	for _, corner := range corners {
+		box.CX += corner.X / 4
+		box.CY += corner.Y / 4
+	}
+	box.Width = math.Hypot(corners[1].X-corners[0].X, corners[1].Y-corners[0].Y)
+	box.Height = math.Hypot(corners[2].X-corners[1].X, corners[2].Y-corners[1].Y)
+	box.Angle = math.Atan2(corners[1].Y-corners[0].Y, corners[1].X-corners[0].X) * 180 / math.Pi
+	return box
+}
+
+// orientedRegion returns a region of a rotated box, a VoTT POLYGON of its corners carrying the box itself.
+func orientedRegion(asset Asset, box SidecarOrientedBox) Region {
+	region := fullImageRegion(asset)
+	region.Type = "POLYGON"
+	if len(box.Tags) > 0 {
+		region.Tags = box.Tags
+	}
+	region.Points = box.Corners()
+	region.BoundingBox = pointsBounds(region.Points)
+	oriented := box.OrientedBox
+	region.OrientedBox = &oriented
+	region.Attributes = box.Attributes
+	return region
+}
+
+// refitOrientedBox sets the rotated box of a region again from its corners after they were transformed.
+func refitOrientedBox(region Region) Region {
+	if region.OrientedBox != nil && len(region.Points) == 4 {
+		box := orientedBoxOf(region.Points)
+		region.OrientedBox = &box
+	}
+	return region
+}
+
+// regionQuad returns the four corners of a region for DOTA: those of its rotated box, a four point polygon, or its
+// bounding box. Reports false for regions of another shape.
+func regionQuad(region Region) ([]Point, bool) {
+	switch {
+	case region.OrientedBox != nil:
+		return region.OrientedBox.Corners(), true
+	case region.Type == "POLYGON" && len(region.Points) == 4:
+		return region.Points, true
+	case region.Type == "RECTANGLE" || region.Type == "":
+		box := region.BoundingBox
+		right, bottom := box.Left+box.Width, box.Top+box.Height
+		return []Point{{X: box.Left, Y: box.Top}, {X: right, Y: box.Top}, {X: right, Y: bottom}, {X: box.Left, Y: bottom}}, true
+	}
+	return nil, false
+}
+
+// runExportDOTA writes the regions of a VoTT file as DOTA label files.
+//
+//	votter.exe export-dota <vott-annotations.json> <outputDirectory>
+func runExportDOTA(args []string) int {
+	flags := flag.NewFlagSet("export-dota", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Println("Usage: votter export-dota <vott-annotations.json> <outputDirectory>")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return ExitInvalidArguments
+	}
+
+	model, err := readVottJSON(flags.Arg(0))
+	if err != nil {
+		fmt.Println(err)
+		return ExitAnnotationsNotReadable
+	}
+	files, err := exportDOTA(model, flags.Arg(1))
+	if err != nil {
+		fmt.Println(err)
+		return ExitAnnotationsWriteFailed
+	}
+	fmt.Printf("Wrote %d DOTA label files to '%s'.\n", files, flags.Arg(1))
+	return ExitSuccesful
+}
+
+// exportDOTA writes a text file per asset named after its image, with a line of four corners, tag and difficulty
+// per tag of every region: x1 y1 x2 y2 x3 y3 x4 y4 category difficult. Difficult is 1 for regions with a true
+// difficult attribute, else 0. Spaces in tags become dashes, as in DOTA's class names. Images of the same name get
+// the asset ID in their file name, and files of an earlier run are overwritten.
+// Returns the number of files written.
+func exportDOTA(model VottJsonModel, outDir string) (int, error) {
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return 0, err
+	}
+	ids := sortedAssetIDs(model)
+	names := make([]string, len(ids))
+	for i, id := range ids {
+		name := model.Assets[id].Asset.Name
+		names[i] = strings.TrimSuffix(name, filepath.Ext(name)) + ".txt"
+	}
+	names = uniqueNames(names, ids)
+
+	written := 0
+	for n, id := range ids {
+		detail := model.Assets[id]
+		var lines strings.Builder
+		for _, region := range detail.Regions {
+			quad, ok := regionQuad(region)
+			if !ok {
+				continue
+			}
+			difficult := 0
+			if region.Attributes["difficult"] == true {
+				difficult = 1
+			}
+			for _, tag := range region.Tags {
+				for _, corner := range quad {
+					fmt.Fprintf(&lines, "%g %g ", corner.X, corner.Y)
+				}
+				fmt.Fprintf(&lines, "%s %d\n", strings.Join(strings.Fields(tag), "-"), difficult)
+			}
+		}
+		if err := os.WriteFile(filepath.Join(outDir, names[n]), []byte(lines.String()), 0644); err != nil {
+			return written, err
+		}
+		written++
+	}
+	return written, nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_OrientedBoxCorners(t *testing.T) {
+	box := OrientedBox{CX: 10, CY: 10, Width: 8, Height: 4, Angle: 90}
+	corners := box.Corners()
+	expected := []Point{{X: 12, Y: 6}, {X: 12, Y: 14}, {X: 8, Y: 14}, {X: 8, Y: 6}}
+	for i := range expected {
+		if math.Abs(corners[i].X-expected[i].X) > 1e-9 || math.Abs(corners[i].Y-expected[i].Y) > 1e-9 {
+			t.Errorf("Expected corner %d at %v, found %v", i, expected[i], corners[i])
+		}
+	}
+
+	refit := orientedBoxOf(corners)
+	if math.Abs(refit.CX-10) > 1e-9 || math.Abs(refit.Width-8) > 1e-9 || math.Abs(refit.Height-4) > 1e-9 || math.Abs(refit.Angle-90) > 1e-9 {
+		t.Errorf("Expected the box back from its corners, found %+v", refit)
+	}
+}
+
+func Test_SidecarOrientedBoxes(t *testing.T) {
+	dir := t.TempDir()
+	image := filepath.Join(dir, "image1.jpg")
+	sidecar := `{"orientedBoxes": [{"tags": ["ship"], "cx": 20, "cy": 15, "w": 10, "h": 4, "angle": 30}]}`
+	if err := ioutil.WriteFile(sidecarPath(image), []byte(sidecar), 0644); err != nil {
+		t.Fatal(err)
+	}
+	assets := []Asset{{Path: "file:" + filepath.ToSlash(image), Label: "harbor", Size: Size{Width: 40, Height: 30}, Regions: []Region{{Tags: []string{"harbor"}}}}}
+	assets, _, err := applySidecars(assets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	region := assets[0].Regions[0]
+	if region.Type != "POLYGON" || len(region.Points) != 4 || region.OrientedBox == nil || region.OrientedBox.Angle != 30 {
+		t.Fatalf("Expected a POLYGON of the corners carrying the rotated box, found %+v", region)
+	}
+
+	scaled := scaleRegion(region, 2, 2)
+	if math.Abs(scaled.OrientedBox.CX-40) > 1e-9 || math.Abs(scaled.OrientedBox.Width-20) > 1e-9 || math.Abs(scaled.OrientedBox.Angle-30) > 1e-9 {
+		t.Errorf("Expected the rotated box to follow the resize, found %+v", scaled.OrientedBox)
+	}
+}
+
+func Test_ExportRotated(t *testing.T) {
+	oriented := OrientedBox{CX: 20, CY: 15, Width: 10, Height: 4, Angle: 30}
+	assets := []Asset{{
+		Format: "jpg", ID: "a1", Name: "image1.jpg", Path: "file:/data/image1.jpg", Size: Size{Width: 40, Height: 30}, Label: "ship",
+		Regions: []Region{
+			{ID: "r1", Type: "POLYGON", Tags: []string{"ship"}, Points: oriented.Corners(), OrientedBox: &oriented},
+			{ID: "r2", Type: "RECTANGLE", Tags: []string{"small vehicle"}, BoundingBox: BoundingBox{Left: 1, Top: 2, Width: 4, Height: 6}, Attributes: map[string]interface{}{"difficult": true}},
+			{ID: "r3", Type: "POLYLINE", Tags: []string{"road"}, Points: []Point{{X: 0, Y: 0}, {X: 5, Y: 5}}},
+		},
+	}}
+	model := buildVottModel(assets, []string{"ship"})
+
+	data, err := exportCOCORotated(model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dataset cocoDataset
+	if err := json.Unmarshal(data, &dataset); err != nil {
+		t.Fatal(err)
+	}
+	if bbox := dataset.Annotations[0].BBox; len(bbox) != 5 || bbox[0] != 20 || bbox[4] != -30 || dataset.Annotations[0].Area != 40 {
+		t.Errorf("Expected the rotated box with the angle counter-clockwise, found %+v", dataset.Annotations[0])
+	}
+	if bbox := dataset.Annotations[1].BBox; bbox[0] != 3 || bbox[1] != 5 || bbox[4] != 0 {
+		t.Errorf("Expected the center of the box at angle 0, found %v", bbox)
+	}
+
+	outDir := filepath.Join(t.TempDir(), "dota")
+	if written, err := exportDOTA(model, outDir); err != nil || written != 1 {
+		t.Fatalf("Expected a label file, found %d: %v", written, err)
+	}
+	labels, err := os.ReadFile(filepath.Join(outDir, "image1.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(labels)), "\n")
+	if len(lines) != 2 || !strings.HasSuffix(lines[0], " ship 0") || lines[1] != "1 2 5 2 5 8 1 8 small-vehicle 1" {
+		t.Errorf("Expected a line of corners per quadrilateral region, found %q", lines)
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"math"
+)
+
+// roundCoordinate rounds a coordinate to decimals places, keeping it as it is for negative decimals.
+func roundCoordinate(value float64, decimals int) float64 {
+	if decimals < 0 {
+		return value
+	}
+	scale := math.Pow10(decimals)
+	return math.Round(value*scale) / scale
+}
+
+// roundRegion rounds the bounding box and points of a region to decimals places, 0 for whole pixels.
+func roundRegion(region Region, decimals int) Region {
+	box := region.BoundingBox
+	region.BoundingBox = BoundingBox{
+		Left:   roundCoordinate(box.Left, decimals),
+		Top:    roundCoordinate(box.Top, decimals),
+		Width:  roundCoordinate(box.Width, decimals),
+		Height: roundCoordinate(box.Height, decimals),
+	}
+	points := make([]Point, len(region.Points))
+	for i, point := range region.Points {
+		points[i] = Point{X: roundCoordinate(point.X, decimals), Y: roundCoordinate(point.Y, decimals)}
+	}
+	region.Points = points
+	region.Keypoints = mapKeypoints(region.Keypoints, func(x, y float64) (float64, float64, bool) {
+		return roundCoordinate(x, decimals), roundCoordinate(y, decimals), true
+	})
+	if oriented := region.OrientedBox; oriented != nil {
+		region.OrientedBox = &OrientedBox{
+			CX:     roundCoordinate(oriented.CX, decimals),
+			CY:     roundCoordinate(oriented.CY, decimals),
+			Width:  roundCoordinate(oriented.Width, decimals),
+			Height: roundCoordinate(oriented.Height, decimals),
+			Angle:  oriented.Angle,
+		}
+	}
+	return region
+}
+
+// roundRegions rounds the regions of the assets for --coordinate-decimals. Negative decimals keep the coordinates
+// as they are.
+func roundRegions(assets []Asset, decimals int) []Asset {
+	if decimals < 0 {
+		return assets
+	}
+	for i := range assets {
+		for j := range assets[i].Regions {
+			assets[i].Regions[j] = roundRegion(assets[i].Regions[j], decimals)
+		}
+	}
+	return assets
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"testing"
+)
+
+func Test_RoundRegions(t *testing.T) {
+	region := Region{ID: "r1", BoundingBox: BoundingBox{Left: 1.44, Top: 2.56, Width: 10.05, Height: 4.5}, Points: []Point{{X: 1.44, Y: 2.56}}}
+	assets := []Asset{{ID: "a1", Regions: []Region{region}}}
+
+	if kept := roundRegions(assets, -1); kept[0].Regions[0].BoundingBox != region.BoundingBox {
+		t.Errorf("Expected the fractional coordinates to be kept, found %+v", kept[0].Regions[0].BoundingBox)
+	}
+	if rounded := roundRegion(region, 1); rounded.BoundingBox != (BoundingBox{Left: 1.4, Top: 2.6, Width: 10.1, Height: 4.5}) || rounded.Points[0] != (Point{X: 1.4, Y: 2.6}) {
+		t.Errorf("Expected coordinates to a decimal, found %+v", rounded)
+	}
+	rounded := roundRegions(assets, 0)[0].Regions[0]
+	if rounded.BoundingBox != (BoundingBox{Left: 1, Top: 3, Width: 10, Height: 5}) || rounded.Points[0] != (Point{X: 1, Y: 3}) {
+		t.Errorf("Expected whole pixels, found %+v", rounded)
+	}
+}
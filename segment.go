@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// Segmenter is the promptable segmentation service --segment-url asks for the regions of each image, such as
+// Grounding DINO with SAM behind a small HTTP wrapper. The image's label is the text prompt.
+//
+// It receives a multipart POST with the fields image and prompt, and answers with the detections:
+//
+//	{"detections": [{"label": "cat", "score": 0.92, "box": [left, top, width, height], "polygon": [[x, y], ...]}]}
+type Segmenter struct {
+	URL      string // http://localhost:8000/segment
+	MinScore float64
+	Client   *http.Client
+}
+
+type segmentDetection struct {
+	Label   string      `json:"label"`
+	Score   float64     `json:"score"`
+	Box     []float64   `json:"box"`
+	Polygon [][]float64 `json:"polygon"`
+}
+
+type segmentResult struct {
+	Detections []segmentDetection `json:"detections"`
+}
+
+// applySegmentation replaces the regions of every asset with the detections of the segmenter above the minimum score,
+// polygons where it returns masks and rectangles otherwise, each with its confidence. Assets without detections keep their
+// regions. Images are segmented by workers in parallel. Returns the tags of the detections too, in the order of the assets,
+// as detections may be labeled other than their prompt.
+func applySegmentation(assets []Asset, segmenter Segmenter, workers int) ([]Asset, []string, error) {
+	errs := make([]error, len(assets))
+	next := make(chan int)
+	var waitGroup sync.WaitGroup
+	for w := 0; w < max(1, workers); w++ {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			for i := range next {
+				var regions []Region
+				if regions, errs[i] = segmenter.segment(assets[i]); errs[i] == nil && len(regions) > 0 {
+					assets[i].Regions = regions
+				}
+				Progress.step(assets[i].Label)
+			}
+		}()
+	}
+	for i := range assets {
+		next <- i
+	}
+	close(next)
+	waitGroup.Wait()
+
+	var tags []string
+	for i, err := range errs {
+		if err != nil {
+			return nil, nil, fmt.Errorf("Error: Cannot segment image '%s': %v", assetFilePath(assets[i]), err)
+		}
+		for _, region := range assets[i].Regions {
+			tags = mergeTags(tags, region.Tags)
+		}
+	}
+	return assets, tags, nil
+}
+
+// segment sends the asset's image with its label as prompt and returns the detections above the minimum score as regions.
+func (s Segmenter) segment(asset Asset) ([]Region, error) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	if err := form.WriteField("prompt", asset.Label); err != nil {
+		return nil, err
+	}
+	part, err := form.CreateFormFile("image", filepath.Base(assetFilePath(asset)))
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(assetFilePath(asset))
+	if err != nil {
+		return nil, err
+	}
+	_, err = io.Copy(part, file)
+	file.Close()
+	if err != nil {
+		return nil, err
+	}
+	if err := form.Close(); err != nil {
+		return nil, err
+	}
+
+	request, err := http.NewRequest("POST", s.URL, &body)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", form.FormDataContentType())
+	client := s.Client
+	if client == nil {
+		client = HTTPClient
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("Error: Cannot reach the segmenter: %v", err)
+	}
+	defer response.Body.Close()
+	data, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("Error: Segmenter answered %s: %s", response.Status, strings.TrimSpace(string(data)))
+	}
+	var result segmentResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("Error: Cannot read the segmenter's answer: %v", err)
+	}
+
+	var regions []Region
+	for _, detection := range result.Detections {
+		if detection.Score < s.MinScore {
+			continue
+		}
+		if region, ok := detectionRegion(detection, asset.Label); ok {
+			regions = append(regions, region)
+		}
+	}
+	return regions, nil
+}
+
+// detectionRegion returns a polygon region for a detection with a polygon, or else a rectangle for its box, tagged with
+// the detection's label or the asset label. Returns false for detections with neither.
+func detectionRegion(detection segmentDetection, label string) (Region, bool) {
+	if detection.Label != "" {
+		label = detection.Label
+	}
+	score := detection.Score
+	region := Region{ID: uuid.New().String(), Type: "RECTANGLE", Tags: []string{label}, Confidence: &score}
+
+	switch {
+	case len(detection.Polygon) >= 3:
+		region.Type = "POLYGON"
+		left, top, right, bottom := detection.Polygon[0][0], detection.Polygon[0][1], detection.Polygon[0][0], detection.Polygon[0][1]
+		for _, point := range detection.Polygon {
+			if len(point) < 2 {
+				return Region{}, false
+			}
+			left, top = min(left, point[0]), min(top, point[1])
+			right, bottom = max(right, point[0]), max(bottom, point[1])
+			region.Points = append(region.Points, Point{X: point[0], Y: point[1]})
+		}
+		region.BoundingBox = BoundingBox{Left: left, Top: top, Width: right - left, Height: bottom - top}
+	case len(detection.Box) == 4:
+		box := BoundingBox{Left: detection.Box[0], Top: detection.Box[1], Width: detection.Box[2], Height: detection.Box[3]}
+		region.BoundingBox = box
+		region.Points = []Point{{X: box.Left, Y: box.Top}, {X: box.Left + box.Width, Y: box.Top + box.Height}}
+	default:
+		return Region{}, false
+	}
+	return region, true
+}
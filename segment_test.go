@@ -0,0 +1,66 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_ApplySegmentation(t *testing.T) {
+	dir := t.TempDir()
+	var assets []Asset
+	for _, label := range []string{"cat", "dog"} {
+		imgPath := filepath.Join(dir, label+".jpg")
+		writeTestImage(t, imgPath, 100, 50)
+		assets = append(assets, Asset{Name: label + ".jpg", Path: "file:" + filepath.ToSlash(imgPath), Size: Size{Width: 100, Height: 50}, Label: label})
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, _, err := r.FormFile("image"); err != nil {
+			http.Error(w, "no image", http.StatusBadRequest)
+			return
+		}
+		if r.FormValue("prompt") != "cat" {
+			w.Write([]byte(`{"detections": []}`))
+			return
+		}
+		w.Write([]byte(`{"detections": [
+			{"label": "kitten", "score": 0.9, "polygon": [[10, 5], [60, 5], [35.4, 30]]},
+			{"score": 0.6, "box": [0, 0, 20, 10]},
+			{"label": "cat", "score": 0.1, "box": [1, 1, 2, 2]}
+		]}`))
+	}))
+	defer server.Close()
+
+	assets, tags, err := applySegmentation(assets, Segmenter{URL: server.URL, MinScore: 0.3}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(tags, ",") != "kitten,cat" {
+		t.Errorf("Expected the labels of the detections as tags, found %v", tags)
+	}
+
+	regions := assets[0].Regions
+	if len(regions) != 2 {
+		t.Fatalf("Expected the 2 detections above the minimum score, found %v", regions)
+	}
+	polygon := regions[0]
+	if polygon.Type != "POLYGON" || polygon.Tags[0] != "kitten" || len(polygon.Points) != 3 || polygon.Points[2] != (Point{X: 35.4, Y: 30}) ||
+		polygon.BoundingBox != (BoundingBox{Left: 10, Top: 5, Width: 50, Height: 25}) || polygon.Score() != 0.9 {
+		t.Errorf("Expected a polygon region with its bounds and confidence, found %+v", polygon)
+	}
+	if box := regions[1]; box.Type != "RECTANGLE" || box.Tags[0] != "cat" || box.BoundingBox.Width != 20 || box.Score() != 0.6 {
+		t.Errorf("Expected a rectangle tagged with the prompt, found %+v", box)
+	}
+	if assets[1].Regions != nil {
+		t.Errorf("Expected the asset without detections to keep its regions, found %v", assets[1].Regions)
+	}
+
+	os.Remove(filepath.Join(dir, "dog.jpg"))
+	if _, _, err := applySegmentation(assets, Segmenter{URL: server.URL}, 1); err == nil {
+		t.Error("Expected an error for a missing image")
+	}
+}
@@ -0,0 +1,491 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ServeListenDefault is where serve listens without --listen, this machine only.
+const ServeListenDefault = "localhost:8080"
+
+// servedProject is a project file served by serve, read again when the file changes.
+type servedProject struct {
+	Name    string
+	Path    string
+	mutex   sync.Mutex
+	modTime time.Time
+	size    int64
+	model   VottJsonModel
+	assets  []Asset
+}
+
+// load returns the assets of the project, reading the file if it changed since it was last read.
+func (project *servedProject) load() (VottJsonModel, []Asset, error) {
+	project.mutex.Lock()
+	defer project.mutex.Unlock()
+	info, err := os.Stat(project.Path)
+	if err != nil {
+		return VottJsonModel{}, nil, err
+	}
+	if project.assets == nil || !info.ModTime().Equal(project.modTime) || info.Size() != project.size {
+		model, err := readVottJSON(project.Path)
+		if err != nil {
+			return VottJsonModel{}, nil, err
+		}
+		assets := make([]Asset, 0, len(model.Assets))
+		for _, id := range sortedAssetIDs(model) {
+			detail := model.Assets[id]
+			asset := detail.Asset
+			asset.Regions = detail.Regions
+			if asset.Label == "" && len(detail.Regions) > 0 && len(detail.Regions[0].Tags) > 0 {
+				asset.Label = detail.Regions[0].Tags[0]
+			}
+			assets = append(assets, asset)
+		}
+		model.Assets = nil
+		project.model, project.assets, project.modTime, project.size = model, assets, info.ModTime(), info.Size()
+	}
+	return project.model, project.assets, nil
+}
+
+// Server serves the projects of serve, named by their file name without extension, and converts uploaded zips.
+type Server struct {
+	Projects  []*servedProject
+	MaxUpload int64
+	Settings  reloadableSettings
+	mux       *http.ServeMux
+}
+
+// newServer serves the project files. Fails when two have the same name.
+func newServer(paths []string) (*Server, error) {
+	server := &Server{MaxUpload: ServeMaxUploadDefault, Settings: reloadableSettings{settings: ServeSettings{ColorStrategy: ColorStrategyFixed}}, mux: http.NewServeMux()}
+	names := make(map[string]string)
+	for _, path := range paths {
+		name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		if other, taken := names[name]; taken {
+			return nil, fmt.Errorf("Error: Projects '%s' and '%s' have the same name '%s'", other, path, name)
+		}
+		names[name] = path
+		server.Projects = append(server.Projects, &servedProject{Name: name, Path: path})
+	}
+	server.mux.HandleFunc("/graphql", server.serveGraphQL)
+	server.mux.HandleFunc("/convert", server.serveConvert)
+	return server, nil
+}
+
+func (server *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	server.mux.ServeHTTP(w, r)
+}
+
+// graphqlRequest is a GraphQL request, posted as JSON or as the query parameters of a GET.
+type graphqlRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables"`
+}
+
+// serveGraphQL answers GraphQL queries about the projects, see the GraphQL section of the README for the schema.
+func (server *Server) serveGraphQL(w http.ResponseWriter, r *http.Request) {
+	var request graphqlRequest
+	switch r.Method {
+	case http.MethodGet:
+		request.Query = r.URL.Query().Get("query")
+		if variables := r.URL.Query().Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &request.Variables); err != nil {
+				http.Error(w, "Invalid variables: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+	case http.MethodPost:
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, "Invalid GraphQL request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "GraphQL takes GET or POST", http.StatusMethodNotAllowed)
+		return
+	}
+
+	response := struct {
+		Data   graphqlResult  `json:"data"`
+		Errors []graphqlError `json:"errors,omitempty"`
+	}{}
+	data, err := executeGraphQL(server.queryObject(), request.Query, request.Variables)
+	if err != nil {
+		response.Errors = []graphqlError{{Message: err.Error()}}
+	} else {
+		response.Data = data
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+type graphqlError struct {
+	Message string `json:"message"`
+}
+
+// queryObject is the root of the queries: the projects.
+func (server *Server) queryObject() graphqlObject {
+	return func(field graphqlField) (interface{}, error) {
+		switch field.Name {
+		case "projects":
+			var projects []graphqlObject
+			for _, project := range server.Projects {
+				projects = append(projects, projectObject(project))
+			}
+			return projects, nil
+		case "project":
+			name, _, err := stringArg(field, "name")
+			if err != nil {
+				return nil, err
+			}
+			for _, project := range server.Projects {
+				if project.Name == name {
+					return projectObject(project), nil
+				}
+			}
+			return graphqlObject(nil), nil
+		}
+		return nil, unknownField("Query", field)
+	}
+}
+
+// projectObject resolves the fields of a project: name, file, tags, assets, assetCount and regions.
+func projectObject(project *servedProject) graphqlObject {
+	return func(field graphqlField) (interface{}, error) {
+		switch field.Name {
+		case "name":
+			return project.Name, nil
+		case "file":
+			return project.Path, nil
+		}
+		model, assets, err := project.load()
+		if err != nil {
+			return nil, fmt.Errorf("Cannot read project '%s': %v", project.Name, err)
+		}
+		switch field.Name {
+		case "version":
+			return model.Version, nil
+		case "tags":
+			var tags []graphqlObject
+			for _, tag := range model.Tags {
+				tags = append(tags, tagObject(tag, assets))
+			}
+			return tags, nil
+		case "assets", "assetCount":
+			matching, err := filterAssets(field, assets)
+			if err != nil {
+				return nil, err
+			}
+			if field.Name == "assetCount" {
+				return len(matching), nil
+			}
+			if matching, err = page(field, matching); err != nil {
+				return nil, err
+			}
+			var objects []graphqlObject
+			for _, asset := range matching {
+				objects = append(objects, assetObject(asset))
+			}
+			return objects, nil
+		case "regions", "regionCount":
+			var objects []graphqlObject
+			for _, asset := range assets {
+				regions, err := filterRegions(field, asset)
+				if err != nil {
+					return nil, err
+				}
+				for _, region := range regions {
+					objects = append(objects, regionObject(region, asset))
+				}
+			}
+			if field.Name == "regionCount" {
+				return len(objects), nil
+			}
+			return page(field, objects)
+		}
+		return nil, unknownField("Project", field)
+	}
+}
+
+// filterAssets keeps the assets of the label and tag arguments.
+func filterAssets(field graphqlField, assets []Asset) ([]Asset, error) {
+	label, byLabel, err := stringArg(field, "label")
+	if err != nil {
+		return nil, err
+	}
+	tag, byTag, err := stringArg(field, "tag")
+	if err != nil {
+		return nil, err
+	}
+	var matching []Asset
+	for _, asset := range assets {
+		if byLabel && asset.Label != label {
+			continue
+		}
+		if byTag && !assetHasTag(asset, tag) {
+			continue
+		}
+		matching = append(matching, asset)
+	}
+	return matching, nil
+}
+
+// assetHasTag reports whether a region of the asset has the tag.
+func assetHasTag(asset Asset, tag string) bool {
+	for _, region := range asset.Regions {
+		if slices.Contains(region.Tags, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterRegions keeps the regions of an asset of the tag and type arguments, and within the size arguments in pixels:
+// minArea, maxArea, minWidth, maxWidth, minHeight and maxHeight.
+func filterRegions(field graphqlField, asset Asset) ([]Region, error) {
+	tag, byTag, err := stringArg(field, "tag")
+	if err != nil {
+		return nil, err
+	}
+	regionType, byType, err := stringArg(field, "type")
+	if err != nil {
+		return nil, err
+	}
+	type bound struct {
+		name  string
+		value func(Region) float64
+		min   bool
+	}
+	area := func(region Region) float64 { return region.BoundingBox.Width * region.BoundingBox.Height }
+	width := func(region Region) float64 { return region.BoundingBox.Width }
+	height := func(region Region) float64 { return region.BoundingBox.Height }
+	bounds := []bound{{"minArea", area, true}, {"maxArea", area, false}, {"minWidth", width, true}, {"maxWidth", width, false}, {"minHeight", height, true}, {"maxHeight", height, false}}
+	limits := make([]float64, len(bounds))
+	set := make([]bool, len(bounds))
+	for i, b := range bounds {
+		if limits[i], set[i], err = floatArg(field, b.name); err != nil {
+			return nil, err
+		}
+	}
+
+	var matching []Region
+	for _, region := range asset.Regions {
+		if byType && region.Type != regionType {
+			continue
+		}
+		if byTag && !slices.Contains(region.Tags, tag) {
+			continue
+		}
+		inside := true
+		for i, b := range bounds {
+			if set[i] && ((b.min && b.value(region) < limits[i]) || (!b.min && b.value(region) > limits[i])) {
+				inside = false
+			}
+		}
+		if inside {
+			matching = append(matching, region)
+		}
+	}
+	return matching, nil
+}
+
+// page returns the items of the offset and first arguments, all of them by default.
+func page[T any](field graphqlField, items []T) ([]T, error) {
+	offset, _, err := intArg(field, "offset")
+	if err != nil {
+		return nil, err
+	}
+	first, limited, err := intArg(field, "first")
+	if err != nil {
+		return nil, err
+	}
+	items = items[min(offset, len(items)):]
+	if limited {
+		items = items[:min(first, len(items))]
+	}
+	return items, nil
+}
+
+// tagObject resolves the fields of a tag: name, color, description, shortcut, externalId, assetCount and regionCount.
+func tagObject(tag Tag, assets []Asset) graphqlObject {
+	return func(field graphqlField) (interface{}, error) {
+		switch field.Name {
+		case "name":
+			return tag.Name, nil
+		case "color":
+			return tag.Color, nil
+		case "description":
+			return tag.Description, nil
+		case "shortcut":
+			return tag.Shortcut, nil
+		case "externalId":
+			return tag.ExternalID, nil
+		case "assetCount", "regionCount":
+			assetCount, regionCount := 0, 0
+			for _, asset := range assets {
+				regions := 0
+				for _, region := range asset.Regions {
+					if slices.Contains(region.Tags, tag.Name) {
+						regions++
+					}
+				}
+				if regions > 0 {
+					assetCount++
+				}
+				regionCount += regions
+			}
+			if field.Name == "assetCount" {
+				return assetCount, nil
+			}
+			return regionCount, nil
+		}
+		return nil, unknownField("Tag", field)
+	}
+}
+
+// assetObject resolves the fields of an asset.
+func assetObject(asset Asset) graphqlObject {
+	return func(field graphqlField) (interface{}, error) {
+		switch field.Name {
+		case "id":
+			return asset.ID, nil
+		case "name":
+			return asset.Name, nil
+		case "path":
+			return asset.Path, nil
+		case "label":
+			return asset.Label, nil
+		case "format":
+			return asset.Format, nil
+		case "width":
+			return asset.Size.Width, nil
+		case "height":
+			return asset.Size.Height, nil
+		case "state":
+			return asset.State, nil
+		case "attributes":
+			return asset.Attributes, nil
+		case "regions":
+			regions, err := filterRegions(field, asset)
+			if err != nil {
+				return nil, err
+			}
+			var objects []graphqlObject
+			for _, region := range regions {
+				objects = append(objects, regionObject(region, asset))
+			}
+			return page(field, objects)
+		}
+		return nil, unknownField("Asset", field)
+	}
+}
+
+// regionObject resolves the fields of a region of an asset.
+func regionObject(region Region, asset Asset) graphqlObject {
+	return func(field graphqlField) (interface{}, error) {
+		switch field.Name {
+		case "id":
+			return region.ID, nil
+		case "type":
+			return region.Type, nil
+		case "tags":
+			return region.Tags, nil
+		case "left":
+			return region.BoundingBox.Left, nil
+		case "top":
+			return region.BoundingBox.Top, nil
+		case "width":
+			return region.BoundingBox.Width, nil
+		case "height":
+			return region.BoundingBox.Height, nil
+		case "area":
+			return region.BoundingBox.Width * region.BoundingBox.Height, nil
+		case "confidence":
+			return region.Confidence, nil
+		case "attributes":
+			return region.Attributes, nil
+		case "asset":
+			return assetObject(asset), nil
+		}
+		return nil, unknownField("Region", field)
+	}
+}
+
+// runServe serves the projects over HTTP until interrupted, with a GraphQL endpoint at /graphql to query them
+// without downloading whole project files, and /convert to annotate uploaded zips of label folders.
+//
+// The settings of the config file for /convert are reloaded when it changes or on SIGHUP.
+//
+//	votter.exe serve [--listen localhost:8080] [--max-upload 1GB] [--config votter.yaml] [annotation.json]...
+func runServe(args []string) int {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	listenFlag := flags.String("listen", ServeListenDefault, "Address to listen on, like :8080 for every interface")
+	maxUploadFlag := flags.String("max-upload", "1GB", "Largest zip /convert takes, and the most its files unpack to")
+	configFlag := flags.String("config", "", "Config file of the class-map, color-strategy and ignore settings of /convert, reloaded when it changes (default votter.yaml if there is one)")
+	flags.Usage = func() {
+		fmt.Println("Usage: votter serve [options] [annotation.json]...")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	maxUpload, ok := parseBytes(*maxUploadFlag)
+	if !ok || maxUpload < 1 {
+		fmt.Printf("Error: Invalid --max-upload '%s', expected bytes like 1GB\n", *maxUploadFlag)
+		return ExitInvalidArguments
+	}
+	server, err := newServer(flags.Args())
+	if err != nil {
+		fmt.Println(err)
+		return ExitInvalidArguments
+	}
+	server.MaxUpload = int64(maxUpload)
+	settings, err := loadServeSettings(*configFlag)
+	if err != nil {
+		fmt.Println(err)
+		return ExitInvalidArguments
+	}
+	server.Settings.set(settings)
+	for _, project := range server.Projects {
+		if _, _, err := project.load(); err != nil {
+			fmt.Println(err)
+			return ExitAnnotationsNotReadable
+		}
+	}
+
+	ctx, stop := interruptContext()
+	defer stop()
+	go watchServeSettings(ctx, *configFlag, &server.Settings)
+	httpServer := &http.Server{Addr: *listenFlag, Handler: server}
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		httpServer.Shutdown(shutdown)
+	}()
+
+	names := make([]string, 0, len(server.Projects))
+	for _, project := range server.Projects {
+		names = append(names, project.Name)
+	}
+	sort.Strings(names)
+	if len(names) > 0 {
+		fmt.Printf("Serving %s on http://%s/graphql\n", strings.Join(names, ", "), *listenFlag)
+	}
+	fmt.Printf("Converting zips of label folders on http://%s/convert\n", *listenFlag)
+	if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fmt.Println(err)
+		return ExitInvalidArguments
+	}
+	return ExitSuccesful
+}
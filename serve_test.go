@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_ServeGraphQL(t *testing.T) {
+	dir := t.TempDir()
+	assets := []Asset{
+		{ID: "a1", Name: "cat1.jpg", Path: "file:/data/cat/cat1.jpg", Label: "cat", Size: Size{Width: 100, Height: 100},
+			Regions: []Region{{ID: "r1", Type: "RECTANGLE", Tags: []string{"cat"}, BoundingBox: BoundingBox{Width: 100, Height: 100}}}},
+		{ID: "a2", Name: "dog1.jpg", Path: "file:/data/dog/dog1.jpg", Label: "dog", Size: Size{Width: 200, Height: 100},
+			Regions: []Region{
+				{ID: "r2", Type: "RECTANGLE", Tags: []string{"dog"}, BoundingBox: BoundingBox{Width: 10, Height: 10}},
+				{ID: "r3", Type: "RECTANGLE", Tags: []string{"dog", "toy"}, BoundingBox: BoundingBox{Width: 50, Height: 20}}}},
+	}
+	path := filepath.Join(dir, "pets.json")
+	if err := writeVottJSON(path, assets, []string{"cat", "dog"}); err != nil {
+		t.Fatal(err)
+	}
+	server, err := newServer([]string{path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	query := func(query string, variables map[string]interface{}) string {
+		body, _ := json.Marshal(graphqlRequest{Query: query, Variables: variables})
+		response, err := http.Post(httpServer.URL+"/graphql", "application/json", strings.NewReader(string(body)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer response.Body.Close()
+		var result json.RawMessage
+		json.NewDecoder(response.Body).Decode(&result)
+		return string(result)
+	}
+
+	cases := []struct {
+		query     string
+		variables map[string]interface{}
+		expected  string
+	}{
+		{`{ projects { name tags { name assetCount regionCount } } }`, nil,
+			`{"data":{"projects":[{"name":"pets","tags":[{"name":"cat","assetCount":1,"regionCount":1},{"name":"dog","assetCount":1,"regionCount":2}]}]}}`},
+		{`query Dogs($label: String = "cat") { project(name: "pets") { assets(label: $label) { name width } } }`, map[string]interface{}{"label": "dog"},
+			`{"data":{"project":{"assets":[{"name":"dog1.jpg","width":200}]}}}`},
+		{`{ project(name: "pets") { small: regions(maxArea: 500) { id asset { name } } big: regionCount(minWidth: 50) } }`, nil,
+			`{"data":{"project":{"small":[{"id":"r2","asset":{"name":"dog1.jpg"}}],"big":2}}}`},
+		{`{ project(name: "pets") { assets(tag: "toy", first: 1) { regions(tag: "toy") { tags area } } } }`, nil,
+			`{"data":{"project":{"assets":[{"regions":[{"tags":["dog","toy"],"area":1000}]}]}}}`},
+		{`{ project(name: "other") { name } }`, nil, `{"data":{"project":null}}`},
+		{`{ project(name: "pets") { owner } }`, nil, `{"data":null,"errors":[{"message":"Cannot query field 'owner' on type 'Project'"}]}`},
+		{`mutation { project { name } }`, nil, `{"data":null,"errors":[{"message":"Only queries are supported, not mutations"}]}`},
+		{`{ projects }`, nil, `{"data":null,"errors":[{"message":"Field 'projects' is an object, select its fields"}]}`},
+	}
+	for _, c := range cases {
+		if result := query(c.query, c.variables); result != c.expected {
+			t.Errorf("Expected %s for %s, found %s", c.expected, c.query, result)
+		}
+	}
+
+	response, err := http.Get(httpServer.URL + "/graphql?query=" + url.QueryEscape(`{ project(name: "pets") { assetCount(label: "cat") } }`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	var result json.RawMessage
+	json.NewDecoder(response.Body).Decode(&result)
+	if string(result) != `{"data":{"project":{"assetCount":1}}}` {
+		t.Errorf("Expected a GET query answered, found %s", result)
+	}
+}
+
+func Test_ParseGraphQL(t *testing.T) {
+	fields, err := parseGraphQL(`# comment
+		query { a: field(s: "x\"y", n: -1.5e1, b: true, list: [1, 2], object: {k: v}, none: null) { sub } }`, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	field := fields[0]
+	if field.Alias != "a" || field.Name != "field" || field.Args["s"] != `x"y` || field.Args["n"] != -15.0 || field.Args["b"] != true ||
+		len(field.Args["list"].([]interface{})) != 2 || field.Args["object"].(map[string]interface{})["k"] != "v" || field.Args["none"] != nil ||
+		field.Selections[0].Name != "sub" {
+		t.Errorf("Expected the parsed field, found %+v", field)
+	}
+	for _, query := range []string{`{ a { ...Fragment } }`, `{ a @skip(if: true) }`, `{ a `, `{ }`, `{ a } { b }`} {
+		if _, err := parseGraphQL(query, nil); err == nil {
+			t.Errorf("Expected an error for %s", query)
+		}
+	}
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// SFTPCommand is the OpenSSH sftp client that --sftp runs in batch mode. It authenticates with keys like ssh does:
+// the key of --sftp-key, the agent or the default keys, and never asks for a password.
+var SFTPCommand = "sftp"
+
+// SFTPSource is the remote images folder of --sftp, sftp://user@host:port/path.
+type SFTPSource struct {
+	User string
+	Host string
+	Port string
+	Path string
+}
+
+// parseSFTP parses an sftp://user@host/path URL. The path is absolute, or relative to the home folder with /~/.
+func parseSFTP(value string) (SFTPSource, error) {
+	remote, err := url.Parse(value)
+	if err != nil || remote.Scheme != "sftp" || remote.Hostname() == "" {
+		return SFTPSource{}, fmt.Errorf("Error: --sftp '%s' is not an sftp://user@host/path URL", value)
+	}
+	source := SFTPSource{Host: remote.Hostname(), Port: remote.Port(), Path: path.Clean("/" + remote.Path)}
+	if remote.User != nil {
+		source.User = remote.User.Username()
+		if _, hasPassword := remote.User.Password(); hasPassword {
+			return SFTPSource{}, fmt.Errorf("Error: --sftp takes no password, it authenticates with keys, see --sftp-key")
+		}
+	}
+	if rest, home := strings.CutPrefix(source.Path, "/~"); home {
+		source.Path = strings.TrimPrefix(rest, "/")
+		if source.Path == "" {
+			source.Path = "."
+		}
+	}
+	return source, nil
+}
+
+// String returns the URL of the source.
+func (source SFTPSource) String() string {
+	host := source.Host
+	if source.Port != "" {
+		host += ":" + source.Port
+	}
+	if source.User != "" {
+		host = source.User + "@" + host
+	}
+	return "sftp://" + host + "/" + strings.TrimPrefix(source.Path, "/")
+}
+
+// run runs the batch commands over one connection with the key, if any. Returns what they printed.
+func (source SFTPSource) run(ctx context.Context, key string, batch []string) (string, error) {
+	args := []string{"-b", "-", "-o", "BatchMode=yes"}
+	if key != "" {
+		args = append(args, "-i", key)
+	}
+	if source.Port != "" {
+		args = append(args, "-P", source.Port)
+	}
+	destination := source.Host
+	if source.User != "" {
+		destination = source.User + "@" + destination
+	}
+	args = append(args, destination)
+
+	command := exec.CommandContext(ctx, SFTPCommand, args...)
+	command.Stdin = strings.NewReader(strings.Join(batch, "\n") + "\n")
+	var stdout, stderr bytes.Buffer
+	command.Stdout = &stdout
+	command.Stderr = &stderr
+	if err := command.Run(); err != nil {
+		return "", fmt.Errorf("Error: sftp to '%s' failed: %v %s", source, err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+// sftpQuote quotes a path for an sftp batch command.
+func sftpQuote(path string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(path) + `"`
+}
+
+// sftpEntry is a file or folder of an sftp listing.
+type sftpEntry struct {
+	Name   string
+	Folder bool
+}
+
+// listSFTP lists the folders with one ls -l each, returning their entries by folder. The long listing prints the
+// path of every entry after eight fields of mode, links, owner, group, size and date.
+func listSFTP(ctx context.Context, source SFTPSource, key string, folders []string) (map[string][]sftpEntry, error) {
+	var batch []string
+	for _, folder := range folders {
+		batch = append(batch, "ls -l "+sftpQuote(folder))
+	}
+	output, err := source.run(ctx, key, batch)
+	if err != nil {
+		return nil, err
+	}
+
+	listings := make(map[string][]sftpEntry)
+	folder := ""
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if command, found := strings.CutPrefix(line, "sftp> "); found {
+			folder = ""
+			for _, candidate := range folders {
+				if command == "ls -l "+sftpQuote(candidate) {
+					folder = candidate
+				}
+			}
+			continue
+		}
+		if folder == "" || line == "" || (line[0] != 'd' && line[0] != '-') {
+			continue // links and other entries are left out
+		}
+		name := sftpListedPath(line)
+		name = strings.TrimPrefix(name, strings.TrimSuffix(folder, "/")+"/")
+		if name == "" || name == "." || name == ".." || strings.Contains(name, "/") {
+			continue
+		}
+		listings[folder] = append(listings[folder], sftpEntry{Name: name, Folder: line[0] == 'd'})
+	}
+	return listings, nil
+}
+
+// sftpListedPath returns what's after the first eight fields of a long listing line, keeping spaces in the path.
+func sftpListedPath(line string) string {
+	rest := line
+	for field := 0; field < 8; field++ {
+		rest = strings.TrimLeft(rest, " ")
+		i := strings.IndexByte(rest, ' ')
+		if i < 0 {
+			return ""
+		}
+		rest = rest[i:]
+	}
+	return strings.TrimLeft(rest, " ")
+}
+
+// sftpItems lists the images in the label folders of the source as downloads into the same folders below
+// imagesPath. The listing fails past MaxFiles files.
+func sftpItems(ctx context.Context, source SFTPSource, key string, imagesPath string) ([]downloadItem, error) {
+	guard := newWalkGuard(source.String(), MaxFiles)
+	root, err := listSFTP(ctx, source, key, []string{source.Path})
+	if err != nil {
+		return nil, err
+	}
+	if err := guard.count(len(root[source.Path])); err != nil {
+		return nil, err
+	}
+	var labels, folders []string
+	for _, entry := range root[source.Path] {
+		if entry.Folder {
+			labels = append(labels, entry.Name)
+			folders = append(folders, path.Join(source.Path, entry.Name))
+		}
+	}
+	if len(folders) == 0 {
+		return nil, fmt.Errorf("Error: No label folders in '%s'.", source)
+	}
+	listings, err := listSFTP(ctx, source, key, folders)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []downloadItem
+	for i, folder := range folders {
+		if err := guard.count(len(listings[folder])); err != nil {
+			return nil, err
+		}
+		for _, entry := range listings[folder] {
+			if entry.Folder || (!SniffImages && !isImage(entry.Name)) {
+				continue
+			}
+			items = append(items, downloadItem{URL: path.Join(folder, entry.Name), Label: labels[i], Path: filepath.Join(imagesPath, labels[i], entry.Name)})
+		}
+	}
+	return items, nil
+}
+
+// downloadSFTP gets the items that aren't there from an earlier run over one connection, each through a temporary
+// file. Returns the number downloaded and the items that failed.
+func downloadSFTP(ctx context.Context, source SFTPSource, key string, items []downloadItem) (int, []SkippedFile) {
+	var batch []string
+	var fetched []downloadItem
+	for _, item := range items {
+		if _, err := os.Stat(item.Path); err == nil {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(item.Path), 0755); err != nil {
+			continue
+		}
+		// A - lets the batch go on when a file fails, those are found missing after.
+		batch = append(batch, "-get "+sftpQuote(item.URL)+" "+sftpQuote(item.Path+".tmp"))
+		fetched = append(fetched, item)
+	}
+	if len(batch) == 0 {
+		return 0, nil
+	}
+
+	var failures []SkippedFile
+	_, err := source.run(ctx, key, batch)
+	downloaded := 0
+	for _, item := range fetched {
+		if err == nil {
+			if renameErr := os.Rename(item.Path+".tmp", item.Path); renameErr == nil {
+				downloaded++
+				continue
+			}
+		}
+		os.Remove(item.Path + ".tmp")
+		reason := "not downloaded"
+		if err != nil {
+			reason = err.Error()
+		}
+		failures = append(failures, SkippedFile{Path: source.String() + "/" + strings.TrimPrefix(item.URL, "/"), Label: item.Label, Reason: reason})
+	}
+	return downloaded, failures
+}
+
+// mirrorSFTP downloads the images in the label folders of the --sftp source into the images folder, so they're
+// annotated like a local images folder. Failures are reported like those of --download-urls. Returns the exit code.
+func mirrorSFTP(ctx context.Context, value string, key string, imagesPath string, annotationFile string) int {
+	source, err := parseSFTP(value)
+	if err != nil {
+		fmt.Println(err)
+		return ExitInvalidArguments
+	}
+	items, err := sftpItems(ctx, source, key, imagesPath)
+	if ctx.Err() != nil {
+		fmt.Printf("Error: %s while listing '%s'\n", interruption(ctx), source)
+		return ExitInterrupted
+	}
+	if err != nil {
+		fmt.Println(err)
+		return ExitImagesFolderNotFound
+	}
+
+	Progress.begin("download", len(items))
+	downloaded, failures := downloadSFTP(ctx, source, key, items)
+	Progress.finish(len(items))
+	if ctx.Err() != nil {
+		fmt.Printf("Error: %s while downloading images\n", interruption(ctx))
+		return ExitInterrupted
+	}
+	fmt.Printf("Downloaded %d of %d images from '%s' into '%s'.\n", downloaded, len(items), source, imagesPath)
+	return reportDownloadFailures(failures, annotationFile)
+}
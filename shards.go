@@ -0,0 +1,253 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"votter/mod/votter"
+)
+
+// ShardIndex lists the shard files a large project was split into.
+type ShardIndex struct {
+	Version   string  `json:"version"`
+	ShardSize int     `json:"shardSize"`
+	Assets    int     `json:"assets"`
+	Tags      []Tag   `json:"tags"`
+	Shards    []Shard `json:"shards"`
+}
+
+type Shard struct {
+	File   string `json:"file"`
+	Assets int    `json:"assets"`
+}
+
+// shardPath returns the path of a numbered shard next to the annotations file: annotations.json -> annotations-000.json
+func shardPath(annotationFile string, shard int) string {
+	ext := filepath.Ext(annotationFile)
+	return fmt.Sprintf("%s-%03d%s", strings.TrimSuffix(annotationFile, ext), shard, ext)
+}
+
+// shardIndexPath returns the path of the shard index next to the annotations file: annotations.json -> annotations-index.json
+func shardIndexPath(annotationFile string) string {
+	ext := filepath.Ext(annotationFile)
+	return strings.TrimSuffix(annotationFile, ext) + "-index" + ext
+}
+
+// writeVottShards splits the assets over VoTT project files of at most shardSize assets each, all with the settings and
+// tags of project, and writes an index listing them. Returns the path of the index.
+func writeVottShards(annotationFile string, project VottJsonModel, assets []Asset, shardSize int) (string, error) {
+	index := ShardIndex{Version: Version, ShardSize: shardSize, Assets: len(assets), Tags: project.Tags}
+
+	for start := 0; start < len(assets) || start == 0; start += shardSize {
+		end := min(start+shardSize, len(assets))
+		model := project
+		model.Assets = make(map[string]AssetDetail)
+		addVottAssets(&model, assets[start:end])
+
+		path := shardPath(annotationFile, len(index.Shards))
+		if err := writeVottModel(path, model); err != nil {
+			return "", err
+		}
+		// Shards are listed relative to the index, they're always next to it.
+		index.Shards = append(index.Shards, Shard{File: filepath.Base(path), Assets: end - start})
+	}
+
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	indexPath := shardIndexPath(annotationFile)
+	return indexPath, ioutil.WriteFile(indexPath, data, 0644)
+}
+
+// readShardIndex reads a shard index file.
+func readShardIndex(path string) (ShardIndex, error) {
+	var index ShardIndex
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return index, err
+	}
+	err = json.Unmarshal(data, &index)
+	return index, err
+}
+
+// runMergeShards combines the shards listed in an index back into one VoTT project, reading one shard at a time.
+//
+//	votter.exe merge-shards [-o output.json] [-no-history] <annotations-index.json>
+func runMergeShards(args []string) int {
+	flags := flag.NewFlagSet("merge-shards", flag.ExitOnError)
+	outputFlag := flags.String("o", "", "Merged project file (default the index path without -index, with the extension of -format)")
+	formatFlag := flags.String("format", FormatJSON, "Write the merged project in this format, a directory for directory formats. Formats other than json hold the merged project in memory")
+	noHistoryFlag := flags.Bool("no-history", false, "Don't keep a snapshot of the merged file in .votter-history for votter rollback")
+	flags.Usage = func() {
+		fmt.Println("Usage: votter merge-shards [options] <annotations-index.json>")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return ExitInvalidArguments
+	}
+	indexPath := flags.Arg(0)
+	format := *formatFlag
+	if alias, ok := formatAliases[format]; ok {
+		format = alias
+	}
+	_, directory := votter.LookupDirectoryExporter(format)
+	if _, ok := votter.LookupExporter(format); !ok && !directory {
+		fmt.Printf("Error: Unknown format '%s', expected %s\n", format, strings.Join(votter.OutputFormats(), " or "))
+		return ExitInvalidArguments
+	}
+
+	output := *outputFlag
+	if output == "" {
+		ext := filepath.Ext(indexPath)
+		output = strings.TrimSuffix(strings.TrimSuffix(indexPath, ext), "-index") + ext
+		if format != FormatJSON {
+			output = FormatOutput{Format: format}.path(output)
+		}
+	}
+
+	merged, err := mergeShards(indexPath, output, format)
+	if err != nil {
+		fmt.Println(err)
+		return ExitAnnotationsWriteFailed
+	}
+	// A directory has no snapshot to roll back to.
+	if !*noHistoryFlag && !directory {
+		if err := recordHistory(output, "merge-shards"); err != nil {
+			fmt.Println(err)
+			return ExitAnnotationsWriteFailed
+		}
+	}
+
+	fmt.Printf("Merged %d assets into '%s'.\n", merged, output)
+	return ExitSuccesful
+}
+
+// mergeShards streams the assets of every shard in the index into a single VoTT project file, holding one shard in memory
+// at a time. Project settings come from the first shard. Other formats are written from the merged project, read back
+// from a VoTT file next to output. Returns the number of assets written.
+func mergeShards(indexPath string, output string, format string) (int, error) {
+	index, err := readShardIndex(indexPath)
+	if err != nil {
+		return 0, err
+	}
+	if len(index.Shards) == 0 {
+		return 0, fmt.Errorf("Error: Shard index '%s' lists no shards", indexPath)
+	}
+	var paths []string
+	for _, shard := range index.Shards {
+		paths = append(paths, filepath.Join(filepath.Dir(indexPath), shard.File))
+	}
+	if format == FormatJSON {
+		merged, _, err := mergeProjects(paths, index.Tags, output)
+		return merged, err
+	}
+
+	project := output + ".merged.json"
+	defer os.Remove(project)
+	merged, _, err := mergeProjects(paths, index.Tags, project)
+	if err != nil {
+		return merged, err
+	}
+	model, err := readVottJSON(project)
+	if err != nil {
+		return merged, err
+	}
+	return merged, writeFormat(output, model, format)
+}
+
+// mergeProjects streams the assets of the projects into a single VoTT project file with the tags, holding one project
+// in memory at a time. Project settings come from the first project. Assets of an image merged already are left out.
+// The file is written next to output and renamed over it, so a failed merge leaves the previous project intact.
+// Returns the number of assets written and left out.
+func mergeProjects(paths []string, tags []Tag, output string) (int, int, error) {
+	temporary := output + ".tmp"
+	file, err := os.Create(temporary)
+	if err != nil {
+		return 0, 0, err
+	}
+	merged, duplicates, err := streamProjects(paths, tags, file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(temporary)
+		return merged, duplicates, err
+	}
+	return merged, duplicates, os.Rename(temporary, output)
+}
+
+// streamProjects writes the assets of the projects to out as one VoTT project, see mergeProjects.
+func streamProjects(paths []string, tags []Tag, out io.Writer) (int, int, error) {
+	writer := bufio.NewWriter(out)
+
+	merged, duplicates := 0, 0
+	mergedPaths := make(map[string]bool)
+	var after []byte
+	for i, path := range paths {
+		model, err := readVottJSON(path)
+		if err != nil {
+			return merged, duplicates, err
+		}
+
+		// Write the project fields around an empty assets object once, the assets go in its place.
+		if i == 0 {
+			project := model
+			project.Tags = tags
+			project.Assets = map[string]AssetDetail{}
+			head, err := json.MarshalIndent(project, "", "  ")
+			if err != nil {
+				return merged, duplicates, err
+			}
+			var before []byte
+			var found bool
+			if before, after, found = bytes.Cut(head, []byte(`"assets": {}`)); !found {
+				return merged, duplicates, fmt.Errorf("Error: Cannot find the assets of '%s'", path)
+			}
+			writer.Write(before)
+			writer.WriteString(`"assets": {`)
+		}
+
+		var ids []string
+		for id := range model.Assets {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			assetPath := model.Assets[id].Asset.Path
+			if assetPath != "" && mergedPaths[assetPath] {
+				duplicates++
+				continue
+			}
+			mergedPaths[assetPath] = true
+			key, _ := json.Marshal(id)
+			detail, err := json.MarshalIndent(model.Assets[id], "    ", "  ")
+			if err != nil {
+				return merged, duplicates, err
+			}
+			if merged > 0 {
+				writer.WriteString(",")
+			}
+			fmt.Fprintf(writer, "\n    %s: %s", key, detail)
+			merged++
+		}
+	}
+
+	if merged > 0 {
+		writer.WriteString("\n  ")
+	}
+	writer.WriteString("}")
+	writer.Write(after)
+	return merged, duplicates, writer.Flush()
+}
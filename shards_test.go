@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_ShardPath(t *testing.T) {
+	if path := shardPath(filepath.Join("out", "annotations.json"), 2); path != filepath.Join("out", "annotations-002.json") {
+		t.Errorf("Expected annotations-002.json, found %s", path)
+	}
+	if path := shardIndexPath("annotations.json"); path != "annotations-index.json" {
+		t.Errorf("Expected annotations-index.json, found %s", path)
+	}
+}
+
+func Test_WriteVottShards(t *testing.T) {
+	annotationFile := filepath.Join(t.TempDir(), "annotations.json")
+
+	var assets []Asset
+	for i := 0; i < 5; i++ {
+		assets = append(assets, Asset{ID: fmt.Sprintf("id%d", i), Name: fmt.Sprintf("image%d.jpg", i), Size: Size{Width: 10, Height: 10}, Label: "cat"})
+	}
+
+	indexPath, err := writeVottShards(annotationFile, buildVottModel(nil, []string{"cat"}), assets, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	index, err := readShardIndex(indexPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(index.Shards) != 3 || index.Assets != 5 {
+		t.Fatalf("Expected 3 shards of 5 assets, found %+v", index)
+	}
+
+	shard, err := readVottJSON(filepath.Join(filepath.Dir(indexPath), index.Shards[2].File))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(shard.Assets) != 1 || len(shard.Tags) != 1 {
+		t.Errorf("Expected the last shard to hold 1 asset and all tags, found %d assets and %d tags", len(shard.Assets), len(shard.Tags))
+	}
+}
+
+func Test_MergeShards(t *testing.T) {
+	dir := t.TempDir()
+	annotationFile := filepath.Join(dir, "annotations.json")
+
+	var assets []Asset
+	for i := 0; i < 5; i++ {
+		assets = append(assets, Asset{ID: fmt.Sprintf("id%d", i), Name: fmt.Sprintf("image%d.jpg", i), Size: Size{Width: 10, Height: 10}, Label: "cat"})
+	}
+	indexPath, err := writeVottShards(annotationFile, buildVottModel(nil, []string{"cat"}), assets, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output := filepath.Join(dir, "merged.json")
+	merged, err := mergeShards(indexPath, output, FormatJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if merged != 5 {
+		t.Errorf("Expected 5 merged assets, found %d", merged)
+	}
+
+	model, err := readVottJSON(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(model.Assets) != 5 || len(model.Tags) != 1 || model.Version != "2.2.0" {
+		t.Errorf("Expected a project of 5 assets and 1 tag, found %d assets and %d tags", len(model.Assets), len(model.Tags))
+	}
+	if len(model.Assets["id4"].Regions) != 1 {
+		t.Errorf("Expected regions to be merged, found %v", model.Assets["id4"])
+	}
+}
+
+func Test_MergeShardsFormat(t *testing.T) {
+	dir := t.TempDir()
+	annotationFile := filepath.Join(dir, "annotations.json")
+	assets := []Asset{{ID: "id1", Name: "image1.jpg", Size: Size{Width: 10, Height: 10}, Label: "cat"}}
+	indexPath, err := writeVottShards(annotationFile, buildVottModel(nil, []string{"cat"}), assets, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if code := runMergeShards([]string{"-format", "yaml", "-no-history", indexPath}); code != ExitSuccesful {
+		t.Fatalf("Expected the merge to succeed, found exit code %d", code)
+	}
+	model, err := readVottJSON(filepath.Join(dir, "annotations.yaml"))
+	if err != nil || len(model.Assets) != 1 {
+		t.Errorf("Expected the merged project in YAML next to the index, found %+v, %v", model.Assets, err)
+	}
+	entries, _ := os.ReadDir(dir)
+	for _, entry := range entries {
+		if strings.Contains(entry.Name(), ".merged") || strings.HasSuffix(entry.Name(), ".tmp") {
+			t.Errorf("Expected no files left of the merge, found %s", entry.Name())
+		}
+	}
+	if code := runMergeShards([]string{"-format", "bmp", indexPath}); code != ExitInvalidArguments {
+		t.Errorf("Expected an unknown format to be invalid, found exit code %d", code)
+	}
+}
+
+func Test_MergeProjectsFailure(t *testing.T) {
+	dir := t.TempDir()
+	output := filepath.Join(dir, "merged.json")
+	if err := os.WriteFile(output, []byte("previous"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	part := filepath.Join(dir, "part.json")
+	if err := writeVottModel(part, buildVottModel([]Asset{{ID: "id1", Label: "cat"}}, []string{"cat"})); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := mergeProjects([]string{part, filepath.Join(dir, "missing.json")}, nil, output); err == nil {
+		t.Fatal("Expected an error for a missing part")
+	}
+	if data, _ := os.ReadFile(output); string(data) != "previous" {
+		t.Errorf("Expected the previous project to be left intact, found %q", data)
+	}
+	if _, err := os.Stat(output + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("Expected the temporary file to be removed, found %v", err)
+	}
+}
+
+func Test_MergeProjectsWithMetadata(t *testing.T) {
+	dir := t.TempDir()
+	project := buildVottModel([]Asset{{ID: "id1", Path: "file:/data/cat/image1.jpg", Label: "cat"}}, []string{"cat"})
+	project.Metadata = &DatasetMetadata{Name: "pets", License: "CC-BY-4.0"}
+	part := filepath.Join(dir, "part.json")
+	if err := writeVottModel(part, project); err != nil {
+		t.Fatal(err)
+	}
+
+	output := filepath.Join(dir, "merged.json")
+	if _, _, err := mergeProjects([]string{part}, project.Tags, output); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var merged VottJsonModel
+	if err := json.Unmarshal(data, &merged); err != nil {
+		t.Fatalf("Expected the merged project to be valid JSON: %v\n%s", err, data)
+	}
+	if len(merged.Assets) != 1 || merged.Metadata == nil || merged.Metadata.Name != "pets" {
+		t.Errorf("Expected the asset and the metadata after it, found %+v", merged)
+	}
+}
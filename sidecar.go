@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+)
+
+// ImageSidecar holds the fields of an image1.jpg.json file that are passed through to the image's asset and regions.
+// Other fields are ignored.
+type ImageSidecar struct {
+	Tags          []string               `json:"tags"`
+	Attributes    map[string]interface{} `json:"attributes"`
+	CaptureTime   string                 `json:"captureTime"`
+	Keypoints     []SidecarKeypoints     `json:"keypoints"`
+	Polylines     []SidecarPolyline      `json:"polylines"`
+	OrientedBoxes []SidecarOrientedBox   `json:"orientedBoxes"`
+}
+
+// sidecarPath returns the JSON sidecar file of an image: image1.jpg -> image1.jpg.json
+func sidecarPath(imgPath string) string {
+	return imgPath + ".json"
+}
+
+// readImageSidecar reads the JSON sidecar of an image. Reports false when the image has none.
+func readImageSidecar(imgPath string) (ImageSidecar, bool, error) {
+	var sidecar ImageSidecar
+	path := sidecarPath(imgPath)
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return sidecar, false, nil
+	}
+	if err != nil {
+		return sidecar, false, err
+	}
+	if err := json.Unmarshal(data, &sidecar); err != nil {
+		return sidecar, false, fmt.Errorf("Error: Cannot parse sidecar '%s': %v", path, err)
+	}
+	return sidecar, true, nil
+}
+
+// applySidecars merges the JSON sidecars of the assets' images: keypoint instances, polylines and rotated boxes replace the regions, tags go onto
+// the regions, attributes and capture time onto the asset. Returns the assets and the sidecar tags, in order of appearance.
+func applySidecars(assets []Asset) ([]Asset, []string, error) {
+	var tags []string
+	for i, asset := range assets {
+		sidecar, found, err := readImageSidecar(assetFilePath(asset))
+		if err != nil {
+			return nil, nil, err
+		}
+		if !found {
+			continue
+		}
+
+		if len(sidecar.Keypoints) > 0 || len(sidecar.Polylines) > 0 || len(sidecar.OrientedBoxes) > 0 {
+			assets[i].Regions = nil
+			for _, instance := range sidecar.Keypoints {
+				region := keypointRegion(asset, instance)
+				assets[i].Regions = append(assets[i].Regions, region)
+				tags = mergeTags(tags, region.Tags)
+			}
+			for _, polyline := range sidecar.Polylines {
+				region := polylineRegion(asset, polyline)
+				assets[i].Regions = append(assets[i].Regions, region)
+				tags = mergeTags(tags, region.Tags)
+			}
+			for _, box := range sidecar.OrientedBoxes {
+				region := orientedRegion(asset, box)
+				assets[i].Regions = append(assets[i].Regions, region)
+				tags = mergeTags(tags, region.Tags)
+			}
+		}
+		for j := range assets[i].Regions {
+			assets[i].Regions[j].Tags = mergeTags(assets[i].Regions[j].Tags, sidecar.Tags)
+		}
+		tags = mergeTags(tags, sidecar.Tags)
+		if len(sidecar.Attributes) > 0 {
+			assets[i].Attributes = sidecar.Attributes
+		}
+		if sidecar.CaptureTime != "" {
+			assets[i].CaptureTime = sidecar.CaptureTime
+		}
+	}
+	return assets, tags, nil
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_ApplySidecars(t *testing.T) {
+	dir := t.TempDir()
+	withSidecar := filepath.Join(dir, "image1.jpg")
+	withoutSidecar := filepath.Join(dir, "image2.jpg")
+	sidecar := `{"tags": ["outdoor", "cat"], "attributes": {"camera": "gate-2"}, "captureTime": "2024-05-01T08:30:00Z", "other": 1}`
+	if err := ioutil.WriteFile(sidecarPath(withSidecar), []byte(sidecar), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	assets := []Asset{
+		{Path: "file:" + filepath.ToSlash(withSidecar), Regions: []Region{{Tags: []string{"cat"}}}},
+		{Path: "file:" + filepath.ToSlash(withoutSidecar), Regions: []Region{{Tags: []string{"cat"}}}},
+	}
+	assets, tags, err := applySidecars(assets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(tags, []string{"outdoor", "cat"}) {
+		t.Errorf("Expected sidecar tags [outdoor cat], found %v", tags)
+	}
+	if !reflect.DeepEqual(assets[0].Regions[0].Tags, []string{"cat", "outdoor"}) {
+		t.Errorf("Expected region tags [cat outdoor], found %v", assets[0].Regions[0].Tags)
+	}
+	if assets[0].Attributes["camera"] != "gate-2" || assets[0].CaptureTime != "2024-05-01T08:30:00Z" {
+		t.Errorf("Expected the attributes and capture time of the sidecar, found %+v", assets[0])
+	}
+	if !reflect.DeepEqual(assets[1].Regions[0].Tags, []string{"cat"}) || assets[1].Attributes != nil {
+		t.Errorf("Expected the asset without sidecar unchanged, found %+v", assets[1])
+	}
+
+	if err := os.WriteFile(sidecarPath(withoutSidecar), []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := applySidecars(assets); err == nil {
+		t.Error("Expected an error for an invalid sidecar")
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Splits are the image lists of --splits, like train.txt and val.txt, by the absolute path of their images.
+type Splits struct {
+	Names  []string
+	byPath map[string]string
+}
+
+// readSplits reads comma separated split files of one image path per line, named by their file name without
+// extension. Relative paths are resolved against the images folder, or else the split file's folder. Blank lines and
+// lines starting with # are skipped. Images listed in several splits fail, as they'd leak between them.
+func readSplits(value string, imagesPath string) (Splits, error) {
+	splits := Splits{byPath: make(map[string]string)}
+	for _, path := range strings.Split(value, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		lines, err := readLabelsFile(path)
+		if err != nil {
+			return splits, fmt.Errorf("Error: Cannot read split file '%s': %v", path, err)
+		}
+		splits.Names = append(splits.Names, name)
+		for _, line := range lines {
+			image := splitImagePath(filepath.FromSlash(line), imagesPath, filepath.Dir(path))
+			if other, found := splits.byPath[image]; found && other != name {
+				return splits, fmt.Errorf("Error: '%s' is in split %s and %s", line, other, name)
+			}
+			splits.byPath[image] = name
+		}
+	}
+	return splits, nil
+}
+
+// splitImagePath returns the absolute path of an image listed in a split file.
+func splitImagePath(path string, imagesPath string, splitDir string) string {
+	if !filepath.IsAbs(path) {
+		candidate := filepath.Join(imagesPath, path)
+		if _, err := os.Stat(candidate); err != nil {
+			candidate = filepath.Join(splitDir, path)
+		}
+		path = candidate
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.Clean(path)
+	}
+	return abs
+}
+
+// of returns the split of an image path, empty when it's in none.
+func (s Splits) of(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		abs = filepath.Clean(path)
+	}
+	return s.byPath[abs]
+}
+
+// splitImages keeps the images listed in a split. Returns them with the number left out.
+func splitImages(images []labeledImage, splits Splits) ([]labeledImage, int) {
+	var kept []labeledImage
+	for _, image := range images {
+		if splits.of(image.Path) != "" {
+			kept = append(kept, image)
+		}
+	}
+	return kept, len(images) - len(kept)
+}
+
+// applySplits records the split of every asset as its split attribute.
+func applySplits(assets []Asset, splits Splits) []Asset {
+	for i, asset := range assets {
+		split := splits.of(assetFilePath(asset))
+		if split == "" {
+			continue
+		}
+		attributes := make(map[string]interface{}, len(asset.Attributes)+1)
+		for key, value := range asset.Attributes {
+			attributes[key] = value
+		}
+		attributes["split"] = split
+		assets[i].Attributes = attributes
+	}
+	return assets
+}
+
+// splitPath returns the path of a split's project next to the annotations file: annotations.json -> annotations-train.json
+func splitPath(annotationFile string, split string) string {
+	ext := filepath.Ext(annotationFile)
+	return strings.TrimSuffix(annotationFile, ext) + "-" + split + ext
+}
+
+// writeSplitProjects writes a project per split next to the annotations file with the settings and tags of project
+// and the assets of the split, for --split-output. Returns the paths written. JSON projects are written asset by asset,
+// so spilled assets stay on disk.
+func writeSplitProjects(annotationFile string, project VottJsonModel, source assetSource, splits Splits, format string) ([]string, error) {
+	var paths []string
+	for _, name := range splits.Names {
+		splitSource := func(yield func(Asset) error) error {
+			return source(func(asset Asset) error {
+				if asset.Attributes["split"] != name {
+					return nil
+				}
+				return yield(asset)
+			})
+		}
+		path := splitPath(annotationFile, name)
+		var err error
+		if format == FormatJSON || format == "" {
+			err = writeVottJSONStream(path, project, splitSource)
+		} else {
+			splitProject := project
+			splitProject.Assets = make(map[string]AssetDetail)
+			if err = addSourceAssets(&splitProject, splitSource); err == nil {
+				err = writeVottModelAs(path, splitProject, format)
+			}
+		}
+		if err != nil {
+			return paths, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
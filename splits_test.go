@@ -0,0 +1,62 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_Splits(t *testing.T) {
+	dir := t.TempDir()
+	images := filepath.Join(dir, "images")
+	os.MkdirAll(filepath.Join(images, "cat"), 0755)
+	var labeled []labeledImage
+	for _, name := range []string{"image1.jpg", "image2.jpg", "image3.jpg"} {
+		path := filepath.Join(images, "cat", name)
+		writeTestImage(t, path, 10, 10)
+		labeled = append(labeled, labeledImage{Path: path, Label: "cat"})
+	}
+	train := filepath.Join(dir, "train.txt")
+	val := filepath.Join(dir, "val.txt")
+	os.WriteFile(train, []byte("# authors' split\ncat/image1.jpg\n\n"), 0644)
+	os.WriteFile(val, []byte(filepath.Join(images, "cat", "image2.jpg")+"\n"), 0644)
+
+	splits, err := readSplits(train+","+val, images)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kept, leftOut := splitImages(labeled, splits)
+	if len(kept) != 2 || leftOut != 1 {
+		t.Fatalf("Expected the 2 listed images kept and 1 left out, found %v and %d", kept, leftOut)
+	}
+
+	assets, err := generateImageEntries(kept, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assets = applySplits(assets, splits)
+	if assets[0].Attributes["split"] != "train" || assets[1].Attributes["split"] != "val" {
+		t.Errorf("Expected the split attributes, found %v and %v", assets[0].Attributes, assets[1].Attributes)
+	}
+
+	annotationFile := filepath.Join(dir, "annotations.json")
+	paths, err := writeSplitProjects(annotationFile, buildVottModel(nil, []string{"cat"}), sortedAssets(assets), splits, FormatJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 2 || paths[0] != filepath.Join(dir, "annotations-train.json") {
+		t.Fatalf("Expected a project per split, found %v", paths)
+	}
+	model, err := readVottJSON(paths[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(model.Assets) != 1 || len(model.Tags) != 1 {
+		t.Errorf("Expected the val asset with the project tags, found %+v", model)
+	}
+
+	os.WriteFile(val, []byte("cat/image1.jpg\n"), 0644)
+	if _, err := readSplits(train+","+val, images); err == nil {
+		t.Error("Expected an error for an image in two splits")
+	}
+}
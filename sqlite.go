@@ -0,0 +1,106 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+
+	_ "modernc.org/sqlite"
+)
+
+// sqliteSchema is the normalized schema of --sqlite databases, a row per asset, region and tag.
+const sqliteSchema = `
+CREATE TABLE tags (
+	id INTEGER PRIMARY KEY,
+	name TEXT NOT NULL UNIQUE
+);
+CREATE TABLE assets (
+	id TEXT PRIMARY KEY,
+	name TEXT NOT NULL,
+	path TEXT NOT NULL,
+	format TEXT NOT NULL,
+	width INTEGER NOT NULL,
+	height INTEGER NOT NULL,
+	label TEXT NOT NULL
+);
+CREATE TABLE regions (
+	id TEXT PRIMARY KEY,
+	asset_id TEXT NOT NULL REFERENCES assets(id),
+	type TEXT NOT NULL,
+	left REAL NOT NULL,
+	top REAL NOT NULL,
+	width REAL NOT NULL,
+	height REAL NOT NULL,
+	confidence REAL
+);
+CREATE TABLE region_tags (
+	region_id TEXT NOT NULL REFERENCES regions(id),
+	tag_id INTEGER NOT NULL REFERENCES tags(id),
+	PRIMARY KEY (region_id, tag_id)
+);
+CREATE INDEX regions_asset_id ON regions(asset_id);
+CREATE INDEX region_tags_tag_id ON region_tags(tag_id);
+`
+
+// writeSQLite writes the assets, their regions and the tags into a new SQLite database at path, replacing any file there.
+func writeSQLite(path string, assets []Asset, tags []string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("Error: Cannot replace database '%s': %v", path, err)
+	}
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(sqliteSchema); err != nil {
+		return fmt.Errorf("Error: Cannot create database '%s': %v", path, err)
+	}
+
+	tagIDs := make(map[string]int)
+	addTag := func(tag string) error {
+		if _, ok := tagIDs[tag]; ok {
+			return nil
+		}
+		tagIDs[tag] = len(tagIDs) + 1
+		_, err := tx.Exec("INSERT INTO tags (id, name) VALUES (?, ?)", tagIDs[tag], tag)
+		return err
+	}
+	for _, tag := range tags {
+		if err := addTag(tag); err != nil {
+			return err
+		}
+	}
+
+	for _, asset := range assets {
+		if _, err := tx.Exec("INSERT INTO assets (id, name, path, format, width, height, label) VALUES (?, ?, ?, ?, ?, ?, ?)",
+			asset.ID, asset.Name, asset.Path, asset.Format, asset.Size.Width, asset.Size.Height, asset.Label); err != nil {
+			return err
+		}
+		regions := asset.Regions
+		if regions == nil {
+			regions = []Region{fullImageRegion(asset)}
+		}
+		for _, region := range regions {
+			box := region.BoundingBox
+			if _, err := tx.Exec("INSERT INTO regions (id, asset_id, type, left, top, width, height, confidence) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+				region.ID, asset.ID, region.Type, box.Left, box.Top, box.Width, box.Height, region.Confidence); err != nil {
+				return err
+			}
+			for _, tag := range region.Tags {
+				if err := addTag(tag); err != nil {
+					return err
+				}
+				if _, err := tx.Exec("INSERT OR IGNORE INTO region_tags (region_id, tag_id) VALUES (?, ?)", region.ID, tagIDs[tag]); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+)
+
+func Test_WriteSQLite(t *testing.T) {
+	confidence := 0.8
+	assets := []Asset{
+		{ID: "a1", Name: "image1.jpg", Path: "file:/data/cat/image1.jpg", Format: "jpg", Size: Size{Width: 4, Height: 3}, Label: "cat",
+			Regions: []Region{{ID: "r1", Type: "RECTANGLE", Tags: []string{"cat", "animal"}, BoundingBox: BoundingBox{Left: 1, Top: 1, Width: 2, Height: 2}, Confidence: &confidence}}},
+		{ID: "a2", Name: "image2.jpg", Path: "file:/data/dog/image2.jpg", Format: "jpg", Size: Size{Width: 4, Height: 3}, Label: "dog"},
+	}
+	path := filepath.Join(t.TempDir(), "dataset.db")
+	for i := 0; i < 2; i++ { // writing again replaces the database
+		if err := writeSQLite(path, assets, []string{"cat", "dog"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var counts [4]int
+	for i, table := range []string{"assets", "regions", "region_tags", "tags"} {
+		if err := db.QueryRow("SELECT COUNT(*) FROM " + table).Scan(&counts[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if counts != [4]int{2, 2, 3, 3} {
+		t.Errorf("Expected 2 assets, 2 regions, 3 region tags and 3 tags, found %v", counts)
+	}
+
+	var left, width int
+	var score sql.NullFloat64
+	query := `SELECT r."left", r.width, r.confidence FROM regions r JOIN region_tags rt ON rt.region_id = r.id JOIN tags t ON t.id = rt.tag_id WHERE t.name = 'animal'`
+	if err := db.QueryRow(query).Scan(&left, &width, &score); err != nil {
+		t.Fatal(err)
+	}
+	if left != 1 || width != 2 {
+		t.Errorf("Expected the animal region at 1 of width 2, found %d and %d", left, width)
+	}
+	if !score.Valid || score.Float64 != 0.8 {
+		t.Errorf("Expected the region's confidence, found %v", score)
+	}
+}
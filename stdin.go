@@ -0,0 +1,39 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// readImageList reads newline separated image paths, or path<TAB>label lines, as from find or a database export.
+// Images without a label are labeled by their folder's name. Blank lines are skipped, files that aren't images are reported and skipped.
+func readImageList(r io.Reader) ([]labeledImage, error) {
+	var images []labeledImage
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		path, label, _ := strings.Cut(line, "\t")
+		if label = strings.TrimSpace(label); label == "" {
+			label = filepath.Base(filepath.Dir(path))
+		}
+		if !isImageFile(path) {
+			fmt.Printf("Skipped '%s', not an image.\n", path)
+			continue
+		}
+		images = append(images, labeledImage{Path: path, Label: label})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(images) == 0 {
+		return nil, fmt.Errorf("Error: No images listed on standard input.")
+	}
+	return images, nil
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_ReadImageList(t *testing.T) {
+	input := "/data/cat/image1.jpg\r\n\n/data/raw/image2.png\tdog\n/data/cat/notes.txt\n"
+	images, err := readImageList(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []labeledImage{{Path: "/data/cat/image1.jpg", Label: "cat"}, {Path: "/data/raw/image2.png", Label: "dog"}}
+	if !reflect.DeepEqual(images, expected) {
+		t.Errorf("Expected %v, found %v", expected, images)
+	}
+
+	if _, err := readImageList(strings.NewReader("\n")); err == nil {
+		t.Error("Expected an error for an empty list")
+	}
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"os"
+)
+
+// exifOrientationTag is the EXIF tag of how the pixels are rotated or flipped for display.
+const exifOrientationTag = 0x0112
+
+// validStripMetadata checks --strip-metadata strips copies of the images only, never the originals that hardlinks and
+// symlinks point at.
+func validStripMetadata(strip bool, collect string, mode string) error {
+	if strip && (collect == "" || mode != "copy") {
+		return fmt.Errorf("Error: --strip-metadata strips the copies of --collect, it needs --collect with --collect-mode copy")
+	}
+	return nil
+}
+
+// stripAssetsMetadata removes the EXIF, IPTC and XMP metadata, GPS positions included, from the collected JPEG, PNG
+// and WebP images below root, each image once however many assets share it. The EXIF orientation of JPEGs is kept,
+// so the images display as before and the dimensions and regions of the annotations stay right. Recorded checksums
+// are updated to the stripped contents. Returns the number of images stripped.
+func stripAssetsMetadata(assets []Asset, root string) (int, error) {
+	stripped := make(map[string]bool)
+	for _, asset := range assets {
+		path := assetFilePathIn(asset, root)
+		if _, done := stripped[path]; done {
+			continue
+		}
+		changed, err := stripImageMetadata(path)
+		if err != nil {
+			return len(stripped), fmt.Errorf("Error: Cannot strip the metadata of '%s': %v", path, err)
+		}
+		stripped[path] = changed
+	}
+
+	count := 0
+	for _, changed := range stripped {
+		if changed {
+			count++
+		}
+	}
+	return count, rehashAssets(assets, root, stripped)
+}
+
+// rehashAssets updates the recorded checksums of the assets whose collected image below root changed.
+func rehashAssets(assets []Asset, root string, changed map[string]bool) error {
+	for i, asset := range assets {
+		path := assetFilePathIn(asset, root)
+		if asset.SHA256 == "" || !changed[path] {
+			continue
+		}
+		sum, err := fileSHA256(path)
+		if err != nil {
+			return err
+		}
+		assets[i].SHA256 = sum
+	}
+	return nil
+}
+
+// stripImageMetadata rewrites the image at path without its metadata, through a temporary file. Reports false when
+// there was none, or the format isn't one whose metadata is known.
+func stripImageMetadata(path string) (bool, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+	var stripped []byte
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xD8}):
+		stripped = stripJPEGMetadata(data)
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		stripped = stripPNGMetadata(data)
+	case len(data) >= 12 && string(data[:4]) == "RIFF" && string(data[8:12]) == "WEBP":
+		stripped = stripWebPMetadata(data)
+	}
+	if stripped == nil || bytes.Equal(stripped, data) {
+		return false, nil
+	}
+	temporary := path + ".tmp"
+	if err := ioutil.WriteFile(temporary, stripped, 0644); err != nil {
+		return false, err
+	}
+	return true, os.Rename(temporary, path)
+}
+
+// stripJPEGMetadata returns the JPEG without its EXIF and XMP (APP1), IPTC (APP13) and other application segments and
+// comments. JFIF (APP0), ICC profiles (APP2) and Adobe color transforms (APP14) stay, they change how the pixels look.
+// An orientation other than the default is written back as an EXIF segment of only the orientation.
+func stripJPEGMetadata(data []byte) []byte {
+	stripped := []byte{0xFF, 0xD8}
+	orientation := uint16(1)
+	// EXIF goes right after the JFIF segment, if there is one, where readers look for it.
+	exifAt := len(stripped)
+	offset := 2
+	for offset+4 <= len(data) && data[offset] == 0xFF {
+		marker := data[offset+1]
+		length := int(binary.BigEndian.Uint16(data[offset+2:]))
+		if marker == 0xDA || length < 2 || offset+2+length > len(data) {
+			break
+		}
+		segment := data[offset : offset+2+length]
+		keep := true
+		switch {
+		case marker == 0xE1:
+			keep = false
+			if bytes.HasPrefix(segment[4:], []byte("Exif\x00\x00")) {
+				if value := exifOrientation(segment[10:]); value != 0 {
+					orientation = value
+				}
+			}
+		case marker == 0xE2:
+			keep = bytes.HasPrefix(segment[4:], []byte("ICC_PROFILE\x00"))
+		case marker == 0xFE, marker >= 0xE3 && marker <= 0xEF && marker != 0xEE:
+			keep = false
+		}
+		if keep {
+			stripped = append(stripped, segment...)
+			if marker == 0xE0 && offset == 2 {
+				exifAt = len(stripped)
+			}
+		}
+		offset += 2 + length
+	}
+	if orientation != 1 {
+		rest := append(orientationSegment(orientation), stripped[exifAt:]...)
+		stripped = append(stripped[:exifAt], rest...)
+	}
+	return append(stripped, data[offset:]...)
+}
+
+// exifOrientation returns the orientation in IFD0 of EXIF data, 0 when there is none.
+func exifOrientation(tiff []byte) uint16 {
+	if len(tiff) < 8 {
+		return 0
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0
+	}
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 0 || ifd+2 > len(tiff) {
+		return 0
+	}
+	entries := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < entries; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 0
+		}
+		if order.Uint16(tiff[entry:]) == exifOrientationTag {
+			return order.Uint16(tiff[entry+8:])
+		}
+	}
+	return 0
+}
+
+// orientationSegment returns an APP1 segment of EXIF data with only the orientation in it.
+func orientationSegment(orientation uint16) []byte {
+	var tiff bytes.Buffer
+	tiff.WriteString("MM\x00\x2a")
+	binary.Write(&tiff, binary.BigEndian, uint32(8))
+	binary.Write(&tiff, binary.BigEndian, uint16(1))
+	// The entry: tag, type SHORT, count 1, the value padded to 4 bytes.
+	binary.Write(&tiff, binary.BigEndian, []uint16{exifOrientationTag, 3, 0, 1, orientation, 0})
+	binary.Write(&tiff, binary.BigEndian, uint32(0))
+
+	payload := append([]byte("Exif\x00\x00"), tiff.Bytes()...)
+	segment := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(2+len(payload)))
+	return append(segment, payload...)
+}
+
+// stripPNGMetadata returns the PNG without its EXIF, text and time chunks.
+func stripPNGMetadata(data []byte) []byte {
+	stripped := append([]byte{}, data[:8]...)
+	offset := 8
+	for offset+12 <= len(data) {
+		length := int(binary.BigEndian.Uint32(data[offset:]))
+		end := offset + 12 + length
+		if length < 0 || end > len(data) {
+			return nil
+		}
+		switch string(data[offset+4 : offset+8]) {
+		case "eXIf", "tEXt", "zTXt", "iTXt", "tIME":
+		default:
+			stripped = append(stripped, data[offset:end]...)
+		}
+		offset = end
+	}
+	return append(stripped, data[offset:]...)
+}
+
+// stripWebPMetadata returns the WebP without its EXIF and XMP chunks, with their flags of the VP8X chunk cleared.
+func stripWebPMetadata(data []byte) []byte {
+	stripped := append([]byte{}, data[:12]...)
+	offset := 12
+	for offset+8 <= len(data) {
+		size := int(binary.LittleEndian.Uint32(data[offset+4:]))
+		end := offset + 8 + size + size%2
+		if size < 0 || end > len(data) {
+			return nil
+		}
+		chunk := data[offset:end]
+		switch string(chunk[:4]) {
+		case "EXIF", "XMP ":
+		case "VP8X":
+			chunk = append([]byte{}, chunk...)
+			if len(chunk) > 8 {
+				chunk[8] &^= 0x08 | 0x04
+			}
+			stripped = append(stripped, chunk...)
+		default:
+			stripped = append(stripped, chunk...)
+		}
+		offset = end
+	}
+	binary.LittleEndian.PutUint32(stripped[4:], uint32(len(stripped)-8))
+	return stripped
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// exifWithGPS returns EXIF data with an orientation and a GPS IFD pointer in IFD0, and a position after it.
+func exifWithGPS(orientation uint16) []byte {
+	exif := []byte("Exif\x00\x00II*\x00\x08\x00\x00\x00\x02\x00")
+	exif = append(exif, 0x12, 0x01, 3, 0, 1, 0, 0, 0)
+	exif = binary.LittleEndian.AppendUint16(exif, orientation)
+	exif = append(exif, 0, 0)
+	exif = append(exif, 0x25, 0x88, 4, 0, 1, 0, 0, 0)
+	exif = binary.LittleEndian.AppendUint32(exif, 38)
+	exif = binary.LittleEndian.AppendUint32(exif, 0)
+	return append(exif, "52.37N 4.89E"...)
+}
+
+func Test_StripJPEGMetadata(t *testing.T) {
+	data := jpegWithSegment(t, 0xE1, exifWithGPS(6))
+	stripped := stripJPEGMetadata(data)
+	if bytes.Contains(stripped, []byte("52.37N")) {
+		t.Error("Expected the GPS position to be removed")
+	}
+	segments := jpegSegments(stripped)
+	if len(segments) == 0 || segments[0].marker != 0xE1 || exifOrientation(segments[0].data[6:]) != 6 {
+		t.Errorf("Expected the orientation to be kept first, found %+v", segments)
+	}
+	if config, _, err := image.DecodeConfig(bytes.NewReader(stripped)); err != nil || config.Width != 4 || config.Height != 3 {
+		t.Errorf("Expected the stripped JPEG to decode as before, found %+v: %v", config, err)
+	}
+
+	// The default orientation needs no EXIF at all.
+	stripped = stripJPEGMetadata(jpegWithSegment(t, 0xE1, exifWithGPS(1)))
+	for _, segment := range jpegSegments(stripped) {
+		if segment.marker == 0xE1 {
+			t.Error("Expected no EXIF segment for the default orientation")
+		}
+	}
+	comment := jpegWithSegment(t, 0xFE, []byte("taken at home"))
+	if bytes.Contains(stripJPEGMetadata(comment), []byte("taken at home")) {
+		t.Error("Expected comments to be removed")
+	}
+}
+
+func Test_StripPNGMetadata(t *testing.T) {
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, image.NewRGBA(image.Rect(0, 0, 4, 3))); err != nil {
+		t.Fatal(err)
+	}
+	// A tEXt chunk after the header; the CRC isn't checked by the stripping.
+	text := []byte{0, 0, 0, 12, 't', 'E', 'X', 't'}
+	text = append(text, "GPS\x0052.37N 4"...)
+	text = append(text, 0, 0, 0, 0)
+	data := append([]byte{}, encoded.Bytes()[:33]...)
+	data = append(data, text...)
+	data = append(data, encoded.Bytes()[33:]...)
+
+	stripped := stripPNGMetadata(data)
+	if !bytes.Equal(stripped, encoded.Bytes()) {
+		t.Error("Expected the text chunk to be removed and the rest kept")
+	}
+}
+
+func Test_StripAssetsMetadata(t *testing.T) {
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "cat"), 0755)
+	path := filepath.Join(root, "cat", "image1.jpg")
+	ioutil.WriteFile(path, jpegWithSegment(t, 0xE1, exifWithGPS(1)), 0644)
+	writeTestImage(t, filepath.Join(root, "cat", "image2.jpg"), 4, 3)
+	sum, _ := fileSHA256(path)
+	assets := []Asset{
+		{Path: "cat/image1.jpg", SHA256: sum},
+		{Path: "cat/image1.jpg", SHA256: sum},
+		{Path: "cat/image2.jpg"},
+	}
+
+	stripped, err := stripAssetsMetadata(assets, root)
+	if err != nil || stripped != 1 {
+		t.Fatalf("Expected one image stripped, found %d: %v", stripped, err)
+	}
+	data, _ := ioutil.ReadFile(path)
+	if bytes.Contains(data, []byte("52.37N")) {
+		t.Error("Expected the GPS position to be removed from the copy")
+	}
+	if assets[0].SHA256 == sum || assets[0].SHA256 != assets[1].SHA256 {
+		t.Error("Expected the checksums of the stripped image")
+	}
+	if err := validStripMetadata(true, "bundle", "symlink"); err == nil {
+		t.Error("Expected symlinked images to fail, stripping them would strip the originals")
+	}
+}
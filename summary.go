@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"time"
+)
+
+// RunSummary is the machine-readable outcome of a generation run, for CI pipelines to gate on.
+type RunSummary struct {
+	Labels   []string      `json:"labels"`
+	Images   int           `json:"images"`
+	Assets   int           `json:"assets"`
+	Skipped  []SkippedFile `json:"skipped"`
+	Duration float64       `json:"durationSeconds"`
+	Outputs  []string      `json:"outputs"`
+	// LabelMapping are the folder names changed by --slugify-labels, to their tags.
+	LabelMapping map[string]string `json:"labelMapping,omitempty"`
+	// Drift are the labels whose asset count shifted past --drift-threshold since the previous project.
+	Drift []string `json:"drift,omitempty"`
+	// Merged are the duplicate images merged into one asset by --merge-duplicates.
+	Merged []string `json:"merged,omitempty"`
+}
+
+// finishRunSummary sets the duration since start, prints the summary as JSON and writes it to path, unless path is empty.
+func finishRunSummary(summary *RunSummary, start time.Time, path string) error {
+	summary.Duration = time.Since(start).Seconds()
+	if summary.Skipped == nil {
+		summary.Skipped = []SkippedFile{}
+	}
+
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+
+	if path == "" {
+		return nil
+	}
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("Error: Cannot write summary file '%s': %v", path, err)
+	}
+	return nil
+}
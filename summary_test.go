@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_GenerateSummaryFile(t *testing.T) {
+	rootDir := t.TempDir()
+	for _, dir := range []string{"cat", "dog"} {
+		if err := os.MkdirAll(filepath.Join(rootDir, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+		writeTestImage(t, filepath.Join(rootDir, dir, "image1.jpg"), 4, 3)
+	}
+	outDir := t.TempDir()
+	annotationFile := filepath.Join(outDir, "annotations.json")
+	summaryFile := filepath.Join(outDir, "summary.json")
+
+	if code := generate(context.Background(), rootDir, annotationFile, Options{Workers: 2, SummaryFile: summaryFile}); code != ExitSuccesful {
+		t.Fatalf("Expected success, found exit code %d", code)
+	}
+
+	data, err := ioutil.ReadFile(summaryFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var summary RunSummary
+	if err := json.Unmarshal(data, &summary); err != nil {
+		t.Fatal(err)
+	}
+	if len(summary.Labels) != 2 || summary.Images != 2 || summary.Assets != 2 || len(summary.Skipped) != 0 {
+		t.Errorf("Expected 2 labels, images and assets without skipped files, found %+v", summary)
+	}
+	if len(summary.Outputs) != 1 || summary.Outputs[0] != annotationFile {
+		t.Errorf("Expected the annotations file as output, found %v", summary.Outputs)
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// SynsetsOff is the --synsets value that keeps synset IDs as labels.
+const SynsetsOff = "off"
+
+// bundledSynsets are the names of common ImageNet synsets, in the format of ImageNet's words.txt.
+//
+//go:embed synsets.txt
+var bundledSynsets string
+
+// synsetID matches the WordNet noun synset IDs that ImageNet names its folders by, like n02084071.
+var synsetID = regexp.MustCompile(`^n[0-9]{8}$`)
+
+// parseSynsets reads synset IDs and their names, one per line as ImageNet's words.txt and LOC_synset_mapping.txt
+// have them: the ID, a tab or space, and comma separated names of which the first is the label. Blank lines and
+// lines starting with # are skipped.
+func parseSynsets(reader io.Reader, name string) (map[string]string, error) {
+	synsets := make(map[string]string)
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		id, names, _ := strings.Cut(strings.Replace(line, "\t", " ", 1), " ")
+		label, _, _ := strings.Cut(names, ",")
+		if !synsetID.MatchString(id) || strings.TrimSpace(label) == "" {
+			return nil, fmt.Errorf("Error: Cannot read synsets '%s': '%s' is not a synset ID and its names", name, line)
+		}
+		synsets[id] = strings.TrimSpace(label)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("Error: Cannot read synsets '%s': %v", name, err)
+	}
+	return synsets, nil
+}
+
+// readSynsets returns the bundled synset names, with those of the file at path in their place or added, for --synsets.
+func readSynsets(path string) (map[string]string, error) {
+	synsets, err := parseSynsets(strings.NewReader(bundledSynsets), "synsets.txt")
+	if err != nil || path == "" {
+		return synsets, err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("Error: Cannot read synsets '%s': %v", path, err)
+	}
+	defer file.Close()
+	more, err := parseSynsets(file, path)
+	if err != nil {
+		return nil, err
+	}
+	for id, label := range more {
+		synsets[id] = label
+	}
+	return synsets, nil
+}
+
+// resolveSynsets relabels the images of synset ID labels with the synset's name. Synsets of the same name, like the
+// bird and the machine named crane, keep their ID in parentheses to stay apart. Returns the images and the synset IDs
+// without a name, which stay as they are.
+func resolveSynsets(images []labeledImage, synsets map[string]string) ([]labeledImage, []string) {
+	ids := make(map[string][]string)
+	var unknown []string
+	for _, image := range images {
+		if !synsetID.MatchString(image.Label) {
+			continue
+		}
+		label, ok := synsets[image.Label]
+		if !ok {
+			unknown = mergeTags(unknown, []string{image.Label})
+			continue
+		}
+		ids[label] = mergeTags(ids[label], []string{image.Label})
+	}
+
+	resolved := make(map[string]string)
+	for label, sharing := range ids {
+		for _, id := range sharing {
+			resolved[id] = label
+			if len(sharing) > 1 {
+				resolved[id] = fmt.Sprintf("%s (%s)", label, id)
+			}
+		}
+	}
+	sort.Strings(unknown)
+	return remapImages(images, resolved), unknown
+}
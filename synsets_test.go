@@ -0,0 +1,57 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_ParseSynsets(t *testing.T) {
+	synsets, err := parseSynsets(strings.NewReader("# comment\nn02084071\tdog, domestic dog, Canis familiaris\n\nn01440764 tench, Tinca tinca\n"), "words.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(synsets, map[string]string{"n02084071": "dog", "n01440764": "tench"}) {
+		t.Errorf("Expected the first name of each synset, found %v", synsets)
+	}
+	if _, err := parseSynsets(strings.NewReader("dog\n"), "words.txt"); err == nil {
+		t.Error("Expected an error for a line without a synset ID")
+	}
+
+	bundled, err := readSynsets("")
+	if err != nil || bundled["n02121808"] != "domestic cat" {
+		t.Errorf("Expected the bundled synsets, found %d: %v", len(bundled), err)
+	}
+}
+
+func Test_ResolveSynsets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "words.txt")
+	os.WriteFile(path, []byte("n02084071\thound\nn09999999\tquokka\n"), 0644)
+	synsets, err := readSynsets(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	images := []labeledImage{
+		{Path: "n02084071/image1.jpg", Label: "n02084071"},
+		{Path: "n09999999/image1.jpg", Label: "n09999999"},
+		{Path: "n02012849/image1.jpg", Label: "n02012849"},
+		{Path: "n03126707/image1.jpg", Label: "n03126707"},
+		{Path: "n00000001/image1.jpg", Label: "n00000001"},
+		{Path: "cat/image1.jpg", Label: "cat"},
+	}
+	images, unknown := resolveSynsets(images, synsets)
+	var labels []string
+	for _, image := range images {
+		labels = append(labels, image.Label)
+	}
+	expected := []string{"hound", "quokka", "crane (n02012849)", "crane (n03126707)", "n00000001", "cat"}
+	if !reflect.DeepEqual(labels, expected) {
+		t.Errorf("Expected %v, found %v", expected, labels)
+	}
+	if !reflect.DeepEqual(unknown, []string{"n00000001"}) {
+		t.Errorf("Expected the unknown synset reported, found %v", unknown)
+	}
+}
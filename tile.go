@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// parseTileSize parses a tile size given as WIDTHxHEIGHT, or a single number for square tiles.
+func parseTileSize(value string) (Size, error) {
+	width, height, found := strings.Cut(strings.ToLower(value), "x")
+	if !found {
+		height = width
+	}
+	w, errW := strconv.Atoi(width)
+	h, errH := strconv.Atoi(height)
+	if errW != nil || errH != nil || w <= 0 || h <= 0 {
+		return Size{}, fmt.Errorf("Error: Invalid tile size '%s', expected for example 1024x1024", value)
+	}
+	return Size{Width: w, Height: h}, nil
+}
+
+// tileOffsets returns the start positions of tiles along one axis. Tiles step by length minus overlap,
+// and the last tile is aligned with the end so every tile is full length.
+func tileOffsets(total, length, overlap int) []int {
+	if total <= length {
+		return []int{0}
+	}
+	stride := max(1, length-overlap)
+	var offsets []int
+	for offset := 0; offset+length < total; offset += stride {
+		offsets = append(offsets, offset)
+	}
+	return append(offsets, total-length)
+}
+
+// tileAssets slices every asset image larger than the tile size into overlapping tiles written to outDir/label/
+// and returns an asset per tile, with regions clipped and translated to the tile. Smaller images are kept as they are.
+// Tiles are named after their images and position, with the asset ID for images of the same name in a label folder.
+func tileAssets(assets []Asset, tile Size, overlap int, outDir string) ([]Asset, error) {
+	var tiled []Asset
+
+	var names, ids []string
+	for _, asset := range assets {
+		if asset.Size.Width > tile.Width || asset.Size.Height > tile.Height {
+			names = append(names, filepath.Join(asset.Label, encodedName(asset.Name)))
+			ids = append(ids, asset.ID)
+		}
+	}
+	names = uniqueNames(names, ids)
+
+	for _, asset := range assets {
+		if asset.Size.Width <= tile.Width && asset.Size.Height <= tile.Height {
+			tiled = append(tiled, asset)
+			continue
+		}
+		name := names[0]
+		names = names[1:]
+
+		if err := os.MkdirAll(filepath.Join(outDir, asset.Label), 0755); err != nil {
+			return nil, err
+		}
+
+		img, err := decodeImageFile(assetFilePath(asset))
+		if err != nil {
+			return nil, err
+		}
+		bounds := img.Bounds()
+
+		for _, top := range tileOffsets(asset.Size.Height, tile.Height, overlap) {
+			for _, left := range tileOffsets(asset.Size.Width, tile.Width, overlap) {
+				rect := image.Rect(left, top, min(left+tile.Width, asset.Size.Width), min(top+tile.Height, asset.Size.Height))
+
+				patch := image.NewRGBA(image.Rect(0, 0, rect.Dx(), rect.Dy()))
+				draw.Draw(patch, patch.Bounds(), img, bounds.Min.Add(rect.Min), draw.Src)
+
+				ext := filepath.Ext(name)
+				imgPath := filepath.Join(outDir, fmt.Sprintf("%s_x%d_y%d%s", strings.TrimSuffix(name, ext), left, top, ext))
+				if err := encodeImageFile(imgPath, patch); err != nil {
+					return nil, err
+				}
+				imgAbsolutePath, err := filepath.Abs(imgPath)
+				if err != nil {
+					return nil, err
+				}
+
+				tileAsset := asset
+				tileAsset.Name = filepath.Base(imgPath)
+				tileAsset.Format = strings.TrimPrefix(ext, ".")
+				tileAsset.Path = "file:" + filepath.ToSlash(imgAbsolutePath)
+				tileAsset.ID = assetID(tileAsset.Path)
+				tileAsset.Size = Size{Width: rect.Dx(), Height: rect.Dy()}
+				tileAsset.Regions = []Region{}
+				for _, region := range asset.Regions {
+					if clipped, ok := clipRegion(region, rect); ok {
+						tileAsset.Regions = append(tileAsset.Regions, clipped)
+					}
+				}
+				tiled = append(tiled, tileAsset)
+			}
+		}
+	}
+
+	return tiled, nil
+}
+
+// clipRegion clips a region to rect and translates it to coordinates relative to rect. Returns false if nothing of the region is left.
+func clipRegion(region Region, rect image.Rectangle) (Region, bool) {
+	box := region.BoundingBox
+	minX, minY, maxX, maxY := float64(rect.Min.X), float64(rect.Min.Y), float64(rect.Max.X), float64(rect.Max.Y)
+	left, top := max(box.Left, minX), max(box.Top, minY)
+	right, bottom := min(box.Left+box.Width, maxX), min(box.Top+box.Height, maxY)
+	if right <= left || bottom <= top {
+		return region, false
+	}
+	region.BoundingBox = BoundingBox{Left: left - minX, Top: top - minY, Width: right - left, Height: bottom - top}
+
+	points := make([]Point, len(region.Points))
+	for i, point := range region.Points {
+		points[i] = Point{
+			X: min(max(point.X, minX), maxX) - minX,
+			Y: min(max(point.Y, minY), maxY) - minY,
+		}
+	}
+	region.Points = points
+	// Keypoints outside the tile are dropped rather than clamped to its edge.
+	region.Keypoints = mapKeypoints(region.Keypoints, func(x, y float64) (float64, float64, bool) {
+		return x - minX, y - minY, x >= minX && x < maxX && y >= minY && y < maxY
+	})
+	region.ID = uuid.New().String()
+	// Corners clamped to the tile make the rotated box an approximation.
+	return refitOrientedBox(region), true
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"image"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_ParseTileSize(t *testing.T) {
+	if size, err := parseTileSize("1024x512"); err != nil || size != (Size{Width: 1024, Height: 512}) {
+		t.Errorf("Expected 1024x512, found %+v (%v)", size, err)
+	}
+	if size, err := parseTileSize("256"); err != nil || size != (Size{Width: 256, Height: 256}) {
+		t.Errorf("Expected 256x256, found %+v (%v)", size, err)
+	}
+	if _, err := parseTileSize("0x10"); err == nil {
+		t.Error("Expected an error for an empty tile")
+	}
+}
+
+func Test_TileOffsets(t *testing.T) {
+	if offsets := tileOffsets(250, 100, 20); !reflect.DeepEqual(offsets, []int{0, 80, 150}) {
+		t.Errorf("Expected [0 80 150], found %v", offsets)
+	}
+	if offsets := tileOffsets(80, 100, 20); !reflect.DeepEqual(offsets, []int{0}) {
+		t.Errorf("Expected [0], found %v", offsets)
+	}
+}
+
+func Test_ClipRegion(t *testing.T) {
+	region := Region{
+		BoundingBox: BoundingBox{Left: 50, Top: 50, Width: 100, Height: 100},
+		Points:      []Point{{X: 50, Y: 50}, {X: 150, Y: 150}},
+	}
+
+	clipped, ok := clipRegion(region, image.Rect(100, 0, 200, 100))
+	if !ok {
+		t.Fatal("Expected the region to overlap the tile")
+	}
+	if clipped.BoundingBox != (BoundingBox{Left: 0, Top: 50, Width: 50, Height: 50}) {
+		t.Errorf("Expected clipped bounding box, found %+v", clipped.BoundingBox)
+	}
+	if !reflect.DeepEqual(clipped.Points, []Point{{X: 0, Y: 50}, {X: 50, Y: 100}}) {
+		t.Errorf("Expected clipped points, found %v", clipped.Points)
+	}
+
+	if _, ok := clipRegion(region, image.Rect(200, 200, 300, 300)); ok {
+		t.Error("Expected no region outside the tile")
+	}
+}
+
+func Test_TileAssets(t *testing.T) {
+	rootDir := t.TempDir()
+	imgPath := filepath.Join(rootDir, "image1.jpg")
+	writeTestImage(t, imgPath, 150, 100)
+
+	asset := Asset{Name: "image1.jpg", Path: "file:" + filepath.ToSlash(imgPath), Size: Size{Width: 150, Height: 100}, Label: "cat"}
+	asset.Regions = []Region{fullImageRegion(asset)}
+
+	tiled, err := tileAssets([]Asset{asset}, Size{Width: 100, Height: 100}, 0, filepath.Join(rootDir, "tiles"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tiled) != 2 {
+		t.Fatalf("Expected 2 tiles, found %d", len(tiled))
+	}
+	if tiled[1].Name != "image1_x50_y0.jpg" || tiled[1].Size != (Size{Width: 100, Height: 100}) {
+		t.Errorf("Expected tile image1_x50_y0.jpg of 100x100, found %s of %+v", tiled[1].Name, tiled[1].Size)
+	}
+	if len(tiled[1].Regions) != 1 || tiled[1].Regions[0].BoundingBox.Width != 100 {
+		t.Errorf("Expected the full image region clipped to the tile, found %v", tiled[1].Regions)
+	}
+}
+
+func Test_TileAssetsWithoutRegions(t *testing.T) {
+	rootDir := t.TempDir()
+	imgPath := filepath.Join(rootDir, "image1.jpg")
+	writeTestImage(t, imgPath, 150, 100)
+
+	asset := Asset{Name: "image1.jpg", Path: "file:" + filepath.ToSlash(imgPath), Size: Size{Width: 150, Height: 100}, Label: "cat"}
+	asset.Regions = []Region{{BoundingBox: BoundingBox{Left: 0, Top: 0, Width: 10, Height: 10}}}
+
+	tiled, err := tileAssets([]Asset{asset}, Size{Width: 100, Height: 100}, 0, filepath.Join(rootDir, "tiles"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tiled[1].Regions == nil || len(tiled[1].Regions) != 0 {
+		t.Errorf("Expected a tile without regions to stay empty, found %v", tiled[1].Regions)
+	}
+}
+
+func Test_TileAssetsSameNames(t *testing.T) {
+	rootDir := t.TempDir()
+	var assets []Asset
+	for _, folder := range []string{"a", "b"} {
+		os.MkdirAll(filepath.Join(rootDir, folder), 0755)
+		imgPath := filepath.Join(rootDir, folder, "image1.jpg")
+		writeTestImage(t, imgPath, 150, 100)
+		path := "file:" + filepath.ToSlash(imgPath)
+		assets = append(assets, Asset{ID: assetID(path), Name: "image1.jpg", Path: path, Size: Size{Width: 150, Height: 100}, Label: "cat"})
+	}
+
+	tiled, err := tileAssets(assets, Size{Width: 100, Height: 100}, 0, filepath.Join(rootDir, "tiles"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tiled) != 4 {
+		t.Fatalf("Expected 2 tiles of each image, found %d", len(tiled))
+	}
+	if expected := "image1_" + assets[1].ID + "_x50_y0.jpg"; tiled[3].Name != expected {
+		t.Errorf("Expected tile %s named by its asset ID, found %s", expected, tiled[3].Name)
+	}
+	paths := make(map[string]bool)
+	for _, tile := range tiled {
+		paths[tile.Path] = true
+	}
+	if len(paths) != 4 {
+		t.Errorf("Expected a file per tile, found %v", paths)
+	}
+}
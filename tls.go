@@ -0,0 +1,58 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+)
+
+// TLSConfig names the files of --ca-cert, --client-cert and --client-key, for servers behind private CAs or
+// asking for client certificates.
+type TLSConfig struct {
+	CACert     string
+	ClientCert string
+	ClientKey  string
+}
+
+// enabled reports whether any TLS flag was given.
+func (config TLSConfig) enabled() bool {
+	return config.CACert != "" || config.ClientCert != "" || config.ClientKey != ""
+}
+
+// transport returns a copy of the default transport with the CAs and client certificate added. Like the default
+// transport it honors HTTP_PROXY, HTTPS_PROXY and NO_PROXY.
+func (config TLSConfig) transport() (*http.Transport, error) {
+	if (config.ClientCert == "") != (config.ClientKey == "") {
+		return nil, fmt.Errorf("Error: --client-cert and --client-key go together")
+	}
+
+	tlsConfig := &tls.Config{}
+	if config.CACert != "" {
+		data, err := ioutil.ReadFile(config.CACert)
+		if err != nil {
+			return nil, fmt.Errorf("Error: Cannot read CA bundle '%s': %v", config.CACert, err)
+		}
+		// The private CAs are trusted in addition to the system's, so public servers keep working.
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("Error: No PEM certificates in CA bundle '%s'", config.CACert)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if config.ClientCert != "" {
+		certificate, err := tls.LoadX509KeyPair(config.ClientCert, config.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("Error: Cannot load client certificate '%s': %v", config.ClientCert, err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	return transport, nil
+}
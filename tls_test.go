@@ -0,0 +1,45 @@
+package main
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_TLSConfigCACert(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	// The test server's certificate is signed by no CA the system knows.
+	if _, err := http.Get(server.URL); err == nil {
+		t.Fatal("Expected the private certificate to be rejected without --ca-cert")
+	}
+
+	dir := t.TempDir()
+	bundle := filepath.Join(dir, "ca.pem")
+	os.WriteFile(bundle, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0644)
+	transport, err := TLSConfig{CACert: bundle}.transport()
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, err := (&http.Client{Transport: transport}).Get(server.URL)
+	if err != nil {
+		t.Fatalf("Expected the private CA to be trusted, found %v", err)
+	}
+	response.Body.Close()
+	if transport.Proxy == nil {
+		t.Error("Expected the proxy environment variables to be honored")
+	}
+
+	empty := filepath.Join(dir, "empty.pem")
+	os.WriteFile(empty, []byte("no certificates"), 0644)
+	if _, err := (TLSConfig{CACert: empty}).transport(); err == nil {
+		t.Error("Expected a bundle without certificates to be rejected")
+	}
+	if _, err := (TLSConfig{ClientCert: bundle}).transport(); err == nil {
+		t.Error("Expected --client-cert without --client-key to be rejected")
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// SecurityToken is a named key as VoTT keeps them in its application settings, to encrypt project secrets.
+type SecurityToken struct {
+	Name string `json:"name"`
+	Key  string `json:"key"`
+}
+
+// TokenStore is the part of VoTT's application settings that holds security tokens.
+type TokenStore struct {
+	SecurityTokens []SecurityToken `json:"securityTokens"`
+}
+
+// newSecurityToken returns a token with a random 256-bit key, base64 encoded as VoTT generates them.
+func newSecurityToken(name string) (SecurityToken, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return SecurityToken{}, err
+	}
+	return SecurityToken{Name: name, Key: base64.StdEncoding.EncodeToString(key)}, nil
+}
+
+// tokenStorePath returns the path of the token store next to the annotations file: annotations.json -> annotations-token.json
+func tokenStorePath(annotationFile string) string {
+	ext := filepath.Ext(annotationFile)
+	return strings.TrimSuffix(annotationFile, ext) + "-token" + ext
+}
+
+// writeSecurityToken creates a security token named after the annotations file, references it in the project and
+// writes it to a token store next to the annotations file, readable by the owner only. Returns the path of the token store.
+func writeSecurityToken(annotationFile string, project *VottJsonModel) (string, error) {
+	name := strings.TrimSuffix(filepath.Base(annotationFile), filepath.Ext(annotationFile)) + " Token"
+	token, err := newSecurityToken(name)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := json.MarshalIndent(TokenStore{SecurityTokens: []SecurityToken{token}}, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	path := tokenStorePath(annotationFile)
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		return "", err
+	}
+
+	project.SecurityToken = token.Name
+	return path, nil
+}
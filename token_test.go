@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_NewSecurityToken(t *testing.T) {
+	first, err := newSecurityToken("project Token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := newSecurityToken("project Token")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := base64.StdEncoding.DecodeString(first.Key)
+	if err != nil || len(key) != 32 {
+		t.Errorf("Expected a base64 encoded 32 byte key, found %s", first.Key)
+	}
+	if first.Key == second.Key {
+		t.Error("Expected every token to get a new key")
+	}
+}
+
+func Test_WriteSecurityToken(t *testing.T) {
+	annotationFile := filepath.Join(t.TempDir(), "annotations.json")
+	project := buildVottModel(nil, nil)
+
+	path, err := writeSecurityToken(annotationFile, &project)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if project.SecurityToken != "annotations Token" {
+		t.Errorf("Expected the project to reference 'annotations Token', found '%s'", project.SecurityToken)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var store TokenStore
+	if err := json.Unmarshal(data, &store); err != nil {
+		t.Fatal(err)
+	}
+	if len(store.SecurityTokens) != 1 || store.SecurityTokens[0].Name != project.SecurityToken {
+		t.Errorf("Expected the token store to hold the referenced token, found %+v", store)
+	}
+}
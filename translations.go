@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// readTranslations reads the names of labels in a locale from a CSV file with a header row of locales, like
+//
+//	label,de,fr
+//	dog,Hund,chien
+//
+// Labels without a name in the locale are left out.
+func readTranslations(path string, locale string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("Error: Cannot read translations '%s': %v", path, err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("Error: Cannot parse translations '%s': %v", path, err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("Error: Translations '%s' have no header row", path)
+	}
+
+	column := -1
+	for i, name := range records[0] {
+		if i > 0 && strings.EqualFold(strings.TrimSpace(name), locale) {
+			column = i
+		}
+	}
+	if column < 0 {
+		return nil, fmt.Errorf("Error: Translations '%s' have no column for locale '%s'", path, locale)
+	}
+
+	translations := make(map[string]string)
+	for _, record := range records[1:] {
+		if len(record) <= column || strings.TrimSpace(record[column]) == "" {
+			continue
+		}
+		translations[strings.TrimSpace(record[0])] = strings.TrimSpace(record[column])
+	}
+	return translations, nil
+}
+
+// untranslatedLabels returns the labels of the images missing from the translations, sorted.
+func untranslatedLabels(images []labeledImage, translations map[string]string) []string {
+	var missing []string
+	seen := make(map[string]bool)
+	for _, image := range images {
+		if _, ok := translations[image.Label]; !ok && !seen[image.Label] {
+			seen[image.Label] = true
+			missing = append(missing, image.Label)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_ReadTranslations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "labels.csv")
+	if err := os.WriteFile(path, []byte("label,de,fr\ndog,Hund,chien\ncat,,chat\nbird\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	translations, err := readTranslations(path, "DE")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(translations, map[string]string{"dog": "Hund"}) {
+		t.Errorf("Expected only dog translated to German, found %v", translations)
+	}
+	images := []labeledImage{{Label: "dog"}, {Label: "cat"}, {Label: "bird"}, {Label: "cat"}}
+	if missing := untranslatedLabels(images, translations); !reflect.DeepEqual(missing, []string{"bird", "cat"}) {
+		t.Errorf("Expected bird and cat untranslated, found %v", missing)
+	}
+
+	if translations, err = readTranslations(path, "fr"); err != nil || translations["cat"] != "chat" {
+		t.Errorf("Expected cat translated to French, found %v: %v", translations, err)
+	}
+	if _, err := readTranslations(path, "nl"); err == nil {
+		t.Error("Expected an error for a locale without column")
+	}
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"html/template"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"votter/mod/votter"
+)
+
+// ServeMaxUploadDefault is the largest zip /convert takes without --max-upload, and the most its files unpack to.
+const ServeMaxUploadDefault = 1 << 30
+
+// convertForm is the page of /convert for uploading a zip from a browser.
+var convertForm = template.Must(template.New("convert").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>votter</title></head>
+<body>
+<h1>Annotate a zip of labeled folders</h1>
+<form method="post" action="/convert" enctype="multipart/form-data">
+<p><input type="file" name="images" accept=".zip" required></p>
+<p><select name="format">{{range .}}<option>{{.}}</option>{{end}}</select></p>
+<p><button type="submit">Convert</button></p>
+</form>
+</body></html>
+`))
+
+// serveConvert takes a zip of label folders posted as the images field of a form, generates its project and sends it
+// back in the format of the format field, JSON by default. Asset paths are relative to the folder holding the label
+// folders, see votter paths to make them absolute where the images are unpacked. A GET shows a form to upload with.
+func (server *Server) serveConvert(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		convertForm.Execute(w, votter.ExportFormats())
+		return
+	case http.MethodPost:
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "Convert takes GET or POST", http.StatusMethodNotAllowed)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, server.MaxUpload)
+	upload, header, err := r.FormFile("images")
+	if err != nil {
+		http.Error(w, "Expected a zip of label folders as the images field: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer upload.Close()
+	defer r.MultipartForm.RemoveAll()
+	format := r.FormValue("format")
+	if format == "" {
+		format = FormatJSON
+	}
+	if err := validFormat(format); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	dir, err := os.MkdirTemp("", "votter-convert-")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer os.RemoveAll(dir)
+	archive, err := zip.NewReader(upload, header.Size)
+	if err != nil {
+		http.Error(w, "Cannot read the zip: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := unzipImages(archive, dir, server.MaxUpload); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	name := strings.TrimSuffix(filepath.Base(header.Filename), filepath.Ext(header.Filename))
+	data, err := convertImagesFolder(r.Context(), datasetRoot(dir), name, format, server.Settings.get())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	filename := FormatOutput{Format: format}.path("annotations.json")
+	contentType := mime.TypeByExtension(filepath.Ext(filename))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	w.Write(data)
+}
+
+// unzipImages unpacks the files of a zip into dir. Fails for files that would land outside of it, and once the files
+// unpack to more than limit bytes.
+func unzipImages(archive *zip.Reader, dir string, limit int64) error {
+	var unpacked int64
+	for _, file := range archive.File {
+		name := filepath.FromSlash(file.Name)
+		if !filepath.IsLocal(name) {
+			return fmt.Errorf("Error: '%s' in the zip is outside of it", file.Name)
+		}
+		path := filepath.Join(dir, name)
+		if file.FileInfo().IsDir() {
+			if err := os.MkdirAll(path, 0755); err != nil {
+				return err
+			}
+			continue
+		}
+		if !file.Mode().IsRegular() {
+			continue // links stay out
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		written, err := unzipFile(file, path, limit-unpacked)
+		if err != nil {
+			return err
+		}
+		unpacked += written
+	}
+	return nil
+}
+
+// unzipFile unpacks a file of a zip to path, failing past limit bytes.
+func unzipFile(file *zip.File, path string, limit int64) (int64, error) {
+	reader, err := file.Open()
+	if err != nil {
+		return 0, err
+	}
+	defer reader.Close()
+	output, err := os.Create(path)
+	if err != nil {
+		return 0, err
+	}
+	defer output.Close()
+	written, err := io.Copy(output, io.LimitReader(reader, limit+1))
+	if err != nil {
+		return written, err
+	}
+	if written > limit {
+		return written, fmt.Errorf("Error: The zip unpacks to more than %d bytes", limit)
+	}
+	return written, output.Close()
+}
+
+// datasetRoot returns the folder holding the label folders of an unpacked zip: the zip's own folder, or the folder
+// zipped along with them when that's all there is, leaving out the __MACOSX folder of macOS.
+func datasetRoot(dir string) string {
+	for {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return dir
+		}
+		var kept []os.DirEntry
+		for _, entry := range entries {
+			if entry.Name() != "__MACOSX" {
+				kept = append(kept, entry)
+			}
+		}
+		if len(kept) != 1 || !kept[0].IsDir() {
+			return dir
+		}
+		// A single label folder is a dataset of one label, not a folder holding them.
+		if images, _ := listImages(filepath.Join(dir, kept[0].Name())); len(images) > 0 {
+			return dir
+		}
+		dir = filepath.Join(dir, kept[0].Name())
+	}
+}
+
+// convertImagesFolder generates the project of the label folders in root with the settings of serve and encodes it
+// in format, with asset paths relative to root.
+func convertImagesFolder(ctx context.Context, root string, name string, format string, settings ServeSettings) ([]byte, error) {
+	found, err := findImages(ctx, root, runtime.NumCPU())
+	if err != nil {
+		return nil, err
+	}
+	images, _ := ignoreImages(labeledImages(root, found), root, settings.Ignore)
+	images = remapImages(images, settings.ClassMap)
+	if len(images) == 0 {
+		return nil, fmt.Errorf("Error: No images left after the ignore patterns")
+	}
+	assets, err := generateImageEntries(images, runtime.NumCPU())
+	if err != nil {
+		return nil, err
+	}
+
+	var labels []string
+	seen := make(map[string]bool)
+	for _, image := range images {
+		if !seen[image.Label] {
+			seen[image.Label] = true
+			labels = append(labels, image.Label)
+		}
+	}
+	sort.Strings(labels)
+	model := buildVottModel(nil, labels)
+	if settings.ColorStrategy == ColorStrategyDominant {
+		applyTagColors(&model, dominantTagColors(assets, labels))
+	}
+	for i, asset := range assets {
+		assets[i].Path, _ = relativeAssetPath(asset.Path, root)
+	}
+	addVottAssets(&model, assets)
+	model.Name = name
+	return encodeVottModel(model, format)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/jpeg"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// postZip posts a zip of the files to /convert in the format.
+func postZip(t *testing.T, url string, files []string, format string) *http.Response {
+	var archive bytes.Buffer
+	writer := zip.NewWriter(&archive)
+	for _, name := range files {
+		file, _ := writer.Create(name)
+		jpeg.Encode(file, image.NewRGBA(image.Rect(0, 0, 8, 6)), nil)
+	}
+	writer.Close()
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, _ := form.CreateFormFile("images", "pets.zip")
+	part.Write(archive.Bytes())
+	if format != "" {
+		form.WriteField("format", format)
+	}
+	form.Close()
+	response, err := http.Post(url+"/convert", form.FormDataContentType(), &body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return response
+}
+
+func Test_ServeConvert(t *testing.T) {
+	server, err := newServer(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	response := postZip(t, httpServer.URL, []string{"pets/cat/cat1.jpg", "pets/cat/cat2.jpg", "pets/dog/dog1.jpg", "__MACOSX/pets/._cat1.jpg"}, "")
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK || !strings.Contains(response.Header.Get("Content-Disposition"), "annotations.json") {
+		t.Fatalf("Expected the project as annotations.json, found %s %v", response.Status, response.Header)
+	}
+	var model VottJsonModel
+	if err := json.NewDecoder(response.Body).Decode(&model); err != nil {
+		t.Fatal(err)
+	}
+	if model.Name != "pets" || len(model.Assets) != 3 || len(model.Tags) != 2 {
+		t.Errorf("Expected 3 assets and 2 tags, found %d and %v", len(model.Assets), model.Tags)
+	}
+	for _, detail := range model.Assets {
+		if !strings.HasPrefix(detail.Asset.Path, "cat/") && !strings.HasPrefix(detail.Asset.Path, "dog/") {
+			t.Errorf("Expected paths relative to the label folders, found %s", detail.Asset.Path)
+		}
+	}
+
+	yamlResponse := postZip(t, httpServer.URL, []string{"cat/cat1.jpg"}, FormatYAML)
+	defer yamlResponse.Body.Close()
+	if yamlResponse.StatusCode != http.StatusOK || !strings.Contains(yamlResponse.Header.Get("Content-Disposition"), "annotations.yaml") {
+		t.Errorf("Expected the project as annotations.yaml, found %s %v", yamlResponse.Status, yamlResponse.Header)
+	}
+
+	for _, files := range [][]string{{"../outside.jpg"}, {"readme.jpg"}} {
+		bad := postZip(t, httpServer.URL, files, "")
+		bad.Body.Close()
+		if bad.StatusCode == http.StatusOK {
+			t.Errorf("Expected %v refused, found %s", files, bad.Status)
+		}
+	}
+
+	server.MaxUpload = 100
+	tooLarge := postZip(t, httpServer.URL, []string{"cat/cat1.jpg"}, "")
+	tooLarge.Body.Close()
+	if tooLarge.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected an upload over --max-upload refused, found %s", tooLarge.Status)
+	}
+
+	form, err := http.Get(httpServer.URL + "/convert")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer form.Body.Close()
+	if form.StatusCode != http.StatusOK || !strings.HasPrefix(form.Header.Get("Content-Type"), "text/html") {
+		t.Errorf("Expected the upload form, found %s", form.Status)
+	}
+}
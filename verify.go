@@ -0,0 +1,88 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// runVerify checks that every asset of a VoTT file points at a readable image, and lists the broken references.
+//
+//	votter.exe verify [-dimensions] [-checksums] <vott-annotations.json>
+func runVerify(args []string) int {
+	flags := flag.NewFlagSet("verify", flag.ExitOnError)
+	dimensionsFlag := flags.Bool("dimensions", false, "Also check the image dimensions match the asset size")
+	checksumsFlag := flags.Bool("checksums", false, "Also check the image contents match the SHA-256 recorded with --checksums")
+	flags.Usage = func() {
+		fmt.Println("Usage: votter verify [options] <vott-annotations.json>")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return ExitInvalidArguments
+	}
+
+	model, err := readVottJSON(flags.Arg(0))
+	if err != nil {
+		fmt.Println(err)
+		return ExitAnnotationsNotReadable
+	}
+
+	problems := verifyAssets(model, filepath.Dir(flags.Arg(0)), *dimensionsFlag, *checksumsFlag)
+	for _, problem := range problems {
+		fmt.Println(problem)
+	}
+	if len(problems) > 0 {
+		fmt.Printf("Error: %d of %d assets are broken.\n", len(problems), len(model.Assets))
+		return ExitVerifyFailed
+	}
+
+	fmt.Printf("All %d assets verified.\n", len(model.Assets))
+	return ExitSuccesful
+}
+
+// verifyAssets returns a description of every asset whose image cannot be read, sorted by asset path. Relative asset
+// paths are in root, the folder of the project file. With checkDimensions the image is decoded and its size compared
+// to the asset size, with checkChecksums its contents are hashed and compared to the recorded SHA-256.
+func verifyAssets(model VottJsonModel, root string, checkDimensions bool, checkChecksums bool) []string {
+	var problems []string
+
+	for _, detail := range model.Assets {
+		path := assetFilePathIn(detail.Asset, root)
+		problem := verifyAsset(detail.Asset, path, checkDimensions)
+		if problem == "" && checkChecksums {
+			problem = verifyChecksum(detail.Asset, path)
+		}
+		if problem != "" {
+			problems = append(problems, fmt.Sprintf("Asset '%s': %s", detail.Asset.Path, problem))
+		}
+	}
+
+	sort.Strings(problems)
+	return problems
+}
+
+// verifyAsset returns what is wrong with the image of an asset at path, or an empty string if nothing is.
+func verifyAsset(asset Asset, path string, checkDimensions bool) string {
+	file, err := os.Open(path)
+	if err != nil {
+		return err.Error()
+	}
+	defer file.Close()
+
+	if !checkDimensions {
+		return ""
+	}
+	imgConfig, _, err := image.DecodeConfig(file)
+	if err != nil {
+		return err.Error()
+	}
+	if imgConfig.Width != asset.Size.Width || imgConfig.Height != asset.Size.Height {
+		return fmt.Sprintf("image is %dx%d, asset size is %dx%d", imgConfig.Width, imgConfig.Height, asset.Size.Width, asset.Size.Height)
+	}
+	return ""
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_VerifyAssets(t *testing.T) {
+	rootDir := t.TempDir()
+	imgPath := filepath.Join(rootDir, "image1.jpg")
+	writeTestImage(t, imgPath, 20, 10)
+
+	model := VottJsonModel{Assets: map[string]AssetDetail{
+		"ok":      {Asset: Asset{Path: "file:" + filepath.ToSlash(imgPath), Size: Size{Width: 20, Height: 10}}},
+		"resized": {Asset: Asset{Path: "file:" + filepath.ToSlash(imgPath), Size: Size{Width: 40, Height: 20}}},
+		"missing": {Asset: Asset{Path: "file:" + filepath.ToSlash(filepath.Join(rootDir, "moved.jpg"))}},
+	}}
+
+	problems := verifyAssets(model, "", false, false)
+	if len(problems) != 1 || !strings.Contains(problems[0], "moved.jpg") {
+		t.Errorf("Expected the missing image to be reported, found %v", problems)
+	}
+
+	problems = verifyAssets(model, "", true, false)
+	if len(problems) != 2 {
+		t.Errorf("Expected the missing and resized images to be reported, found %v", problems)
+	}
+}
+
+func Test_VerifyCollectedAssets(t *testing.T) {
+	rootDir := t.TempDir()
+	os.MkdirAll(filepath.Join(rootDir, "cat"), 0755)
+	imgPath := filepath.Join(rootDir, "cat", "image 1.jpg")
+	writeTestImage(t, imgPath, 20, 10)
+	sum, _ := fileSHA256(imgPath)
+
+	// Paths relative to the project file, percent-encoded as --collect writes them for strict URIs.
+	model := VottJsonModel{Assets: map[string]AssetDetail{
+		"plain":   {Asset: Asset{Path: "cat/image 1.jpg", Size: Size{Width: 20, Height: 10}, SHA256: sum}},
+		"escaped": {Asset: Asset{Path: "cat/image%201.jpg", Size: Size{Width: 20, Height: 10}, SHA256: sum}},
+	}}
+	if problems := verifyAssets(model, rootDir, true, true); len(problems) != 0 {
+		t.Errorf("Expected the collected images found next to the project file, found %v", problems)
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"votter/mod/votter"
+)
+
+// FormatVOC is the format of directories of Pascal VOC XML annotation files.
+const FormatVOC = "voc"
+
+// vocAnnotation is a Pascal VOC XML annotation file, the annotations of an image.
+type vocAnnotation struct {
+	Filename string `xml:"filename"`
+	Path     string `xml:"path"`
+	Size     struct {
+		Width  int `xml:"width"`
+		Height int `xml:"height"`
+	} `xml:"size"`
+	Objects []struct {
+		Name   string `xml:"name"`
+		BndBox struct {
+			XMin float64 `xml:"xmin"`
+			YMin float64 `xml:"ymin"`
+			XMax float64 `xml:"xmax"`
+			YMax float64 `xml:"ymax"`
+		} `xml:"bndbox"`
+	} `xml:"object"`
+}
+
+func init() {
+	votter.RegisterDirectoryImporter(FormatVOC, importVOC)
+}
+
+// importVOC reads a directory of Pascal VOC XML files, its subdirectories included: an asset per file at its path, or
+// its filename when it has none, and a rectangle per object tagged with its name. Tags are in the order they're seen.
+func importVOC(dir string) (VottJsonModel, error) {
+	var assets []Asset
+	var tags []string
+	err := filepath.WalkDir(dir, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || strings.ToLower(filepath.Ext(filePath)) != ".xml" {
+			return err
+		}
+		data, err := ioutil.ReadFile(filePath)
+		if err != nil {
+			return err
+		}
+		var annotation vocAnnotation
+		if err := xml.Unmarshal(data, &annotation); err != nil {
+			return fmt.Errorf("Error: Cannot read Pascal VOC file '%s': %v", filePath, err)
+		}
+
+		imagePath := annotation.Path
+		if imagePath == "" {
+			imagePath = annotation.Filename
+		}
+		imagePath = strings.ReplaceAll(imagePath, "\\", "/")
+		asset := Asset{
+			Format:  strings.TrimPrefix(path.Ext(imagePath), "."),
+			ID:      assetID(imagePath),
+			Name:    path.Base(imagePath),
+			Path:    "file:" + imagePath,
+			Size:    Size{Width: annotation.Size.Width, Height: annotation.Size.Height},
+			Regions: []Region{},
+		}
+		for _, object := range annotation.Objects {
+			box := object.BndBox
+			asset.Regions = append(asset.Regions, Region{
+				ID:          uuid.New().String(),
+				Type:        "RECTANGLE",
+				Tags:        []string{object.Name},
+				BoundingBox: BoundingBox{Left: box.XMin, Top: box.YMin, Width: box.XMax - box.XMin, Height: box.YMax - box.YMin},
+				Points:      []Point{{X: box.XMin, Y: box.YMin}, {X: box.XMax, Y: box.YMax}},
+			})
+			tags = mergeTags(tags, []string{object.Name})
+		}
+		if len(asset.Regions) > 0 {
+			asset.Label = asset.Regions[0].Tags[0]
+		}
+		assets = append(assets, asset)
+		return nil
+	})
+	if err != nil {
+		return VottJsonModel{}, err
+	}
+	return buildVottModel(assets, tags), nil
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_ConvertVOC(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "Annotations")
+	os.MkdirAll(input, 0755)
+	voc := `<annotation><folder>JPEGImages</folder><filename>image1.jpg</filename><size><width>40</width><height>30</height></size>
+		<object><name>cat</name><bndbox><xmin>1</xmin><ymin>2</ymin><xmax>11</xmax><ymax>7</ymax></bndbox></object>
+		<object><name>dog</name><bndbox><xmin>20</xmin><ymin>10</ymin><xmax>30</xmax><ymax>20</ymax></bndbox></object></annotation>`
+	os.WriteFile(filepath.Join(input, "image1.xml"), []byte(voc), 0644)
+	output := filepath.Join(dir, "annotations.json")
+
+	if code := runConvert([]string{input, output}); code != ExitSuccesful {
+		t.Fatalf("Expected the conversion to succeed, found exit code %d", code)
+	}
+	model, err := readVottJSON(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(model.Assets) != 1 || len(model.Tags) != 2 {
+		t.Fatalf("Expected an asset and two tags, found %+v", model)
+	}
+	for _, detail := range model.Assets {
+		if detail.Asset.Name != "image1.jpg" || detail.Asset.Label != "cat" || detail.Asset.Size != (Size{Width: 40, Height: 30}) || len(detail.Regions) != 2 {
+			t.Errorf("Expected the labeled image with its regions, found %+v", detail)
+		}
+		if box := detail.Regions[0].BoundingBox; box != (BoundingBox{Left: 1, Top: 2, Width: 10, Height: 5}) {
+			t.Errorf("Expected the box of the cat, found %+v", box)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Versions of --vott-version, the VoTT app the project is written for.
+const (
+	VottVersion1 = 1
+	VottVersion2 = 2
+)
+
+// VottV1Project is the JSON that VoTT 1.x, the Windows app, keeps for an image folder as <folder>.json next to it.
+type VottV1Project struct {
+	Frames         map[string][]VottV1Region `json:"frames"`
+	Framerate      string                    `json:"framerate"`
+	InputTags      string                    `json:"inputTags"`
+	SuggestionType string                    `json:"suggestiontype"`
+	SCD            bool                      `json:"scd"`
+	VisitedFrames  []string                  `json:"visitedFrames"`
+	TagColors      []string                  `json:"tag_colors"`
+}
+
+// VottV1Region is a box of a frame of VoTT 1.x, in the pixels of an image of width and height.
+type VottV1Region struct {
+	X1     float64   `json:"x1"`
+	Y1     float64   `json:"y1"`
+	X2     float64   `json:"x2"`
+	Y2     float64   `json:"y2"`
+	Width  int       `json:"width"`
+	Height int       `json:"height"`
+	Box    VottV1Box `json:"box"`
+	Points []Point   `json:"points"`
+	UID    string    `json:"UID"`
+	ID     int       `json:"id"`
+	Type   string    `json:"type"`
+	Tags   []string  `json:"tags"`
+	Name   int       `json:"name"`
+}
+
+// VottV1Box is the corners of a VoTT 1.x region.
+type VottV1Box struct {
+	X1 float64 `json:"x1"`
+	Y1 float64 `json:"y1"`
+	X2 float64 `json:"x2"`
+	Y2 float64 `json:"y2"`
+}
+
+func validVottVersion(version int) error {
+	if version != VottVersion1 && version != VottVersion2 {
+		return fmt.Errorf("Error: Unknown VoTT version %d, expected 1 or 2", version)
+	}
+	return nil
+}
+
+// vottV1Path returns where VoTT 1.x looks for the project of an image folder: images/cat -> images/cat.json
+func vottV1Path(folder string) string {
+	return filepath.Clean(folder) + ".json"
+}
+
+// vottV1Projects returns the VoTT 1.x project of every folder of the assets, by folder. VoTT 1.x only knows
+// rectangles, other regions become their bounding box. Every frame is marked visited, as annotated.
+func vottV1Projects(assets []Asset, tags []Tag) map[string]VottV1Project {
+	var names, colors []string
+	for _, tag := range tags {
+		names = append(names, tag.Name)
+		colors = append(colors, tag.Color)
+	}
+
+	projects := make(map[string]VottV1Project)
+	for _, asset := range assets {
+		path := assetFilePath(asset)
+		folder := filepath.Dir(path)
+		project, ok := projects[folder]
+		if !ok {
+			project = VottV1Project{
+				Frames:         make(map[string][]VottV1Region),
+				Framerate:      "1",
+				InputTags:      strings.Join(names, ","),
+				SuggestionType: "track",
+				TagColors:      colors,
+			}
+		}
+
+		name := filepath.Base(path)
+		if _, seen := project.Frames[name]; !seen {
+			project.Frames[name] = []VottV1Region{}
+			project.VisitedFrames = append(project.VisitedFrames, name)
+		}
+		for _, region := range assetDetail(asset).Regions {
+			// Regions are numbered within their frame, from 0 as id and from 1 as name.
+			id := len(project.Frames[name])
+			box := region.BoundingBox
+			x2, y2 := box.Left+box.Width, box.Top+box.Height
+			project.Frames[name] = append(project.Frames[name], VottV1Region{
+				X1: box.Left, Y1: box.Top, X2: x2, Y2: y2,
+				Width: asset.Size.Width, Height: asset.Size.Height,
+				Box:    VottV1Box{X1: box.Left, Y1: box.Top, X2: x2, Y2: y2},
+				Points: []Point{{X: box.Left, Y: box.Top}, {X: x2, Y: box.Top}, {X: x2, Y: y2}, {X: box.Left, Y: y2}},
+				UID:    region.ID,
+				ID:     id,
+				Type:   "Rectangle",
+				Tags:   region.Tags,
+				Name:   id + 1,
+			})
+		}
+		projects[folder] = project
+	}
+	for folder, project := range projects {
+		sort.Strings(project.VisitedFrames)
+		projects[folder] = project
+	}
+	return projects
+}
+
+// writeVottV1Projects writes the VoTT 1.x project of every folder of the assets next to the folder, for --vott-version 1.
+// Returns the paths written, sorted.
+func writeVottV1Projects(assets []Asset, tags []Tag) ([]string, error) {
+	var paths []string
+	for folder, project := range vottV1Projects(assets, tags) {
+		data, err := json.Marshal(project)
+		if err != nil {
+			return nil, err
+		}
+		path := vottV1Path(folder)
+		temporary := path + ".tmp"
+		if err := ioutil.WriteFile(temporary, data, 0644); err != nil {
+			return nil, err
+		}
+		if err := os.Rename(temporary, path); err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
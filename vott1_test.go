@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_WriteVottV1Projects(t *testing.T) {
+	root := t.TempDir()
+	cat := filepath.Join(root, "cat")
+	assets := []Asset{
+		{ID: "id1", Path: "file:" + filepath.ToSlash(filepath.Join(cat, "image1.jpg")), Size: Size{Width: 40, Height: 30}, Label: "cat", Regions: []Region{
+			{ID: "r1", Type: "RECTANGLE", Tags: []string{"cat"}, BoundingBox: BoundingBox{Left: 1, Top: 2, Width: 10, Height: 20}},
+			{ID: "r2", Type: "POLYGON", Tags: []string{"dog"}, BoundingBox: BoundingBox{Left: 5, Top: 5, Width: 5, Height: 5}},
+		}},
+		{ID: "id2", Path: "file:" + filepath.ToSlash(filepath.Join(cat, "image2.jpg")), Size: Size{Width: 40, Height: 30}, Label: "cat"},
+		{ID: "id3", Path: "file:" + filepath.ToSlash(filepath.Join(root, "dog", "image1.jpg")), Size: Size{Width: 8, Height: 6}, Label: "dog"},
+	}
+	tags := []Tag{{Name: "cat", Color: "#ff0000"}, {Name: "dog", Color: "#00ff00"}}
+
+	paths, err := writeVottV1Projects(assets, tags)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(paths, []string{cat + ".json", filepath.Join(root, "dog") + ".json"}) {
+		t.Fatalf("Expected a project next to each folder, found %v", paths)
+	}
+
+	data, err := ioutil.ReadFile(paths[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var project VottV1Project
+	if err := json.Unmarshal(data, &project); err != nil {
+		t.Fatal(err)
+	}
+	if project.InputTags != "cat,dog" || !reflect.DeepEqual(project.TagColors, []string{"#ff0000", "#00ff00"}) {
+		t.Errorf("Expected the tags and their colors, found '%s' and %v", project.InputTags, project.TagColors)
+	}
+	if !reflect.DeepEqual(project.VisitedFrames, []string{"image1.jpg", "image2.jpg"}) {
+		t.Errorf("Expected both images visited, found %v", project.VisitedFrames)
+	}
+	regions := project.Frames["image1.jpg"]
+	if len(regions) != 2 || regions[1].ID != 1 || regions[1].Name != 2 || regions[1].Type != "Rectangle" {
+		t.Fatalf("Expected the polygon as a second rectangle, found %+v", regions)
+	}
+	expected := VottV1Region{X1: 1, Y1: 2, X2: 11, Y2: 22, Width: 40, Height: 30, Box: VottV1Box{X1: 1, Y1: 2, X2: 11, Y2: 22},
+		Points: []Point{{X: 1, Y: 2}, {X: 11, Y: 2}, {X: 11, Y: 22}, {X: 1, Y: 22}}, UID: "r1", Tags: []string{"cat"}, Name: 1, Type: "Rectangle"}
+	if !reflect.DeepEqual(regions[0], expected) {
+		t.Errorf("Expected %+v, found %+v", expected, regions[0])
+	}
+	if whole := project.Frames["image2.jpg"]; len(whole) != 1 || whole[0].X2 != 40 || whole[0].Y2 != 30 {
+		t.Errorf("Expected a region over the whole image without regions, found %+v", whole)
+	}
+}
@@ -2,23 +2,37 @@
 // Takes a folder of images labelled by directory name and writes a VoTT file with regions for the labels.
 //
 //	votter.exe [pathToImages] [vott-coco-annotations.json]
+//	votter.exe <command> [arguments]
 //	go run votter.go test/dataset test/dataset/vott-coca-annotations.json
 package main
 
 import (
+	"bytes"
+	"context"
+	"crypto/md5"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"image"
-	_ "image/gif"
-	_ "image/jpeg"
-	_ "image/png"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
 	"io/ioutil"
+	"net/http"
 	"os"
 	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/google/uuid"
+
+	"votter/mod/votter"
 )
 
 const Version = "1"
@@ -28,83 +42,271 @@ const ExitSuccesful = 0
 const ExitImagesFolderNotFound = 1
 const ExitImagesFolderEmpty = 2
 const ExitAnnotationsFolderNotFound = 3
+const ExitImageWriteFailed = 4
+const ExitInvalidArguments = 5
+const ExitAnnotationsNotReadable = 6
+const ExitVerifyFailed = 7
+const ExitAnnotationsWriteFailed = 8
+const ExitUnknownLabels = 9
+const ExitInterrupted = 10
+const ExitCheckFailed = 11
 
-type VottJsonModel struct {
-	Name                   string                 `json:"name"`
-	SecurityToken          string                 `json:"securityToken"`
-	VideoSettings          VideoSettings          `json:"videoSettings"`
-	Tags                   []Tag                  `json:"tags"`
-	ID                     string                 `json:"id"`
-	ActiveLearningSettings ActiveLearningSettings `json:"activeLearningSettings"`
-	Version                string                 `json:"version"`
-	LastVisitedAssetID     string                 `json:"lastVisitedAssetId"`
-	Assets                 map[string]AssetDetail `json:"assets"`
-}
-
-type VideoSettings struct {
-	FrameExtractionRate int `json:"frameExtractionRate"`
-}
-
-type Tag struct {
-	Name  string `json:"name"`
-	Color string `json:"color"`
-}
-
-type ActiveLearningSettings struct {
-	AutoDetect    bool   `json:"autoDetect"`
-	PredictTag    bool   `json:"predictTag"`
-	ModelPathType string `json:"modelPathType"`
-}
-
-type AssetDetail struct {
-	Asset   Asset    `json:"asset"`
-	Regions []Region `json:"regions"`
-	Version string   `json:"version"`
-}
-
-type Asset struct {
-	Format string `json:"format"`
-	ID     string `json:"id"`
-	Name   string `json:"name"`
-	Path   string `json:"path"`
-	Size   Size   `json:"size"`
-	State  int    `json:"state"`
-	Type   int    `json:"type"`
-	Label  string
-}
-
-type Size struct {
-	Width  int `json:"width"`
-	Height int `json:"height"`
-}
-
-type Region struct {
-	ID          string      `json:"id"`
-	Type        string      `json:"type"`
-	Tags        []string    `json:"tags"`
-	BoundingBox BoundingBox `json:"boundingBox"`
-	Points      []Point     `json:"points"`
+// Commands run in place of generating annotations when named as the first argument.
+var Commands = map[string]func(args []string) int{
+	"extract-crops":  runExtractCrops,
+	"export-masks":   runExportMasks,
+	"contact-sheets": runContactSheets,
+	"organize":       runOrganize,
+	"verify":         runVerify,
+	"relink":         runRelink,
+	"paths":          runPaths,
+	"merge-shards":   runMergeShards,
+	"plan":           runPlan,
+	"merge-parts":    runMergeParts,
+	"init":           runInit,
+	"history":        runHistory,
+	"rollback":       runRollback,
+	"convert":        runConvert,
+	"export-dota":    runExportDOTA,
+	"serve":          runServe,
+	"check":          runCheck,
+	"doctor":         runDoctor,
+	"audit":          runAudit,
 }
 
-type BoundingBox struct {
-	Height int `json:"height"`
-	Width  int `json:"width"`
-	Left   int `json:"left"`
-	Top    int `json:"top"`
-}
+// The project and its parts are those of the votter package, which formats registered from outside main use too.
+type (
+	VottJsonModel          = votter.VottJsonModel
+	VideoSettings          = votter.VideoSettings
+	Tag                    = votter.Tag
+	ActiveLearningSettings = votter.ActiveLearningSettings
+	AssetDetail            = votter.AssetDetail
+	Asset                  = votter.Asset
+	Size                   = votter.Size
+	Region                 = votter.Region
+	BoundingBox            = votter.BoundingBox
+	Point                  = votter.Point
+)
 
-type Point struct {
-	X int `json:"x"`
-	Y int `json:"y"`
+// Options are the generation settings, from the command line, config file and environment.
+type Options struct {
+	Resize              int
+	ResizeDir           string
+	Augment             string
+	Augmentations       []string
+	AugmentDir          string
+	Tile                string
+	TileSize            Size
+	Overlap             int
+	TileDir             string
+	Rename              string
+	RenameDir           string
+	Checksums           bool
+	DrawOverlays        string
+	Collect             string
+	CollectLayout       string
+	CollectMode         string
+	Anonymize           string
+	AnonymizeDetector   string
+	StripMetadata       bool
+	Incremental         bool
+	NoHistory           bool
+	JSONPatch           string
+	OnlyLabels          string
+	DriftThreshold      string
+	Drift               float64
+	ShardSize           int
+	PartialOnInterrupt  bool
+	PerDirProject       bool
+	LabelsFrom          string
+	Stdin               bool
+	NDJSON              string
+	ClassMap            string
+	Synsets             string
+	Ignore              string
+	TagHierarchy        string
+	Hierarchy           map[string]string
+	AncestorTags        bool
+	SecurityToken       bool
+	MasksDir            string
+	MaskRegions         string
+	Segmenter           Segmenter
+	LabelsFile          string
+	StrictLabels        StrictLabels
+	Translations        string
+	Locale              string
+	NormalizeLabels     string
+	SlugifyLabels       bool
+	TagsFromMetadata    bool
+	MultiFrame          string
+	FramesDir           string
+	BBoxFromName        string
+	BBoxPattern         *regexp.Regexp
+	OnError             OnError
+	QuarantineDir       string
+	QuarantineMode      string
+	Format              string
+	VottVersion         int
+	CoordinateDecimals  int
+	KeypointRegions     bool
+	ColorStrategy       string
+	Splits              string
+	DownloadURLs        string
+	WebDAV              string
+	WebDAVClient        *http.Client
+	SFTP                string
+	SFTPKey             string
+	Plan                string
+	Part                int
+	PartLabels          []string
+	MaxMemory           int64
+	MergeDuplicates     bool
+	SplitOutput         bool
+	MoreFormats         []FormatOutput
+	URIStyle            string
+	SQLite              string
+	Parquet             string
+	SummaryFile         string
+	PushCustomVision    bool
+	CustomVision        CustomVision
+	PushElasticsearch   string
+	ElasticsearchClient *http.Client
+	Rekognition         Rekognition
+	MLflow              MLflow
+	Quiet               bool
+	LogEvery            int
+	Workers             int
+	ScanWorkers         int
+	DecodeWorkers       int
 }
 
 func main() {
 
 	// --- Step 1. Command line parameters ------------------------------------
 	//
+	// Commands such as extract-crops take over with their own arguments.
+	if len(os.Args) > 1 {
+		if command, ok := Commands[os.Args[1]]; ok {
+			os.Exit(command(os.Args[2:]))
+		}
+	}
+
 	// Command line flags for -v (version) and -h (help).
+	var options Options
 	versionFlag := flag.Bool("v", false, "Print version")
 	helpFlag := flag.Bool("h", false, "Show help")
+	flag.IntVar(&options.Resize, "resize", 0, "Write copies of the images scaled down to this maximum width or height")
+	flag.StringVar(&options.ResizeDir, "resize-dir", "", "Directory for resized copies (default 'resized' next to the annotations file)")
+	flag.StringVar(&options.Augment, "augment", "", "Comma separated augmentations to add as extra assets: hflip, vflip, rot90, rot180, rot270")
+	flag.StringVar(&options.AugmentDir, "augment-dir", "", "Directory for augmented copies (default 'augmented' next to the annotations file)")
+	flag.StringVar(&options.MultiFrame, "multi-frame", MultiFrameFirst, "Animated GIFs: 'first' frame's size as one asset, an asset per frame with 'frames', or 'skip' them")
+	flag.StringVar(&options.FramesDir, "frames-dir", "", "Directory for the frames of animated GIFs (default 'frames' next to the annotations file)")
+	flag.StringVar(&options.Tile, "tile", "", "Slice images larger than WIDTHxHEIGHT into tiles, each its own asset")
+	flag.IntVar(&options.Overlap, "overlap", 0, "Overlap in pixels between neighbouring tiles")
+	flag.StringVar(&options.TileDir, "tile-dir", "", "Directory for tiles (default 'tiles' next to the annotations file)")
+	flag.StringVar(&options.Rename, "rename", "", "Copy images with collision-free names, by asset 'uuid' or content 'hash', and write mapping.csv")
+	flag.StringVar(&options.RenameDir, "rename-dir", "", "Directory for renamed copies (default 'renamed' next to the annotations file)")
+	flag.StringVar(&options.DrawOverlays, "draw-overlays", "", "Write copies of the images with their regions and tags drawn on them to this folder")
+	flag.BoolVar(&options.Checksums, "checksums", false, "Record the SHA-256 of every image in its asset, for verify -checksums")
+	flag.StringVar(&options.Collect, "collect", "", "Place the images and the annotations file in this folder, with relative asset paths, as a portable bundle")
+	flag.StringVar(&options.CollectMode, "collect-mode", "copy", "How to place images in the --collect folder: copy, hardlink or symlink")
+	flag.StringVar(&options.Anonymize, "anonymize", "", "Blur the regions of these comma separated tags, like face,license-plate, in the images of the --collect folder")
+	flag.StringVar(&options.AnonymizeDetector, "anonymize-detector", "", "Command that prints the boxes to blur of the image path added to it, one 'left top width height' per line")
+	flag.BoolVar(&options.StripMetadata, "strip-metadata", false, "Remove the EXIF, IPTC and XMP metadata, GPS positions included, from the images of the --collect folder")
+	flag.StringVar(&options.CollectLayout, "collect-layout", "label", "Layout of the --collect folder: a folder per 'label', or content-addressed by 'hash' as ab/cd/abcdef....jpg")
+	flag.BoolVar(&options.Incremental, "incremental", false, "Only regenerate assets of images whose content changed since the last run")
+	flag.StringVar(&options.OnlyLabels, "only-labels", "", "Regenerate the assets of these comma separated labels only, keeping the other assets of the existing project as they are")
+	flag.StringVar(&options.DriftThreshold, "drift-threshold", "", "Report labels whose asset count changed by more than this percentage since the previous project, e.g. 10%")
+	flag.BoolVar(&options.NoHistory, "no-history", false, "Don't keep a snapshot of the annotations file in .votter-history for votter rollback")
+	flag.StringVar(&options.JSONPatch, "json-patch", "", "Write an RFC 6902 JSON Patch from the previous annotations file to the new one to this file")
+	flag.BoolVar(&options.PushCustomVision, "push-customvision", false, "Upload the images and regions to a Custom Vision project instead of writing an annotations file")
+	flag.StringVar(&options.CustomVision.Endpoint, "customvision-endpoint", "", "Custom Vision training endpoint, e.g. https://westeurope.api.cognitive.microsoft.com")
+	flag.StringVar(&options.CustomVision.Project, "customvision-project", "", "ID of the Custom Vision project to upload to")
+	flag.StringVar(&options.CustomVision.Key, "customvision-key", "", "Custom Vision training key, best set with VOTTER_CUSTOMVISION_KEY")
+	flag.StringVar(&options.PushElasticsearch, "push-elasticsearch", "", "Also index a document per asset into Elasticsearch or OpenSearch at <url>/<index>")
+	flag.StringVar(&options.Rekognition.Manifest, "rekognition-manifest", "", "Also write an AWS Rekognition Custom Labels manifest to this file")
+	flag.StringVar(&options.Rekognition.S3Prefix, "rekognition-s3-prefix", "", "S3 location of the images for the Rekognition manifest, e.g. s3://bucket/dataset/")
+	flag.StringVar(&options.Rekognition.Type, "rekognition-type", RekognitionDetection, "Rekognition manifest of a box per region with 'detection', or a label per image with 'classification'")
+	flag.StringVar(&options.MLflow.URI, "mlflow-uri", "", "MLflow tracking server to log the dataset to, e.g. http://localhost:5000")
+	flag.StringVar(&options.MLflow.RunID, "mlflow-run", "", "ID of the MLflow run to log the annotations file, summary and label distribution to")
+	flag.BoolVar(&options.SecurityToken, "security-token", false, "Generate a security token for the project and write it for VoTT's application settings")
+	flag.StringVar(&options.URIStyle, "uri-style", URIStyleVott, "Asset paths as VoTT's historic 'vott' file:C:/data/a b.jpg, or RFC 8089 'strict' file:///C:/data/a%20b.jpg")
+	flag.IntVar(&options.VottVersion, "vott-version", VottVersion2, "VoTT version to write the project for, 1 writes a VoTT 1.x <folder>.json next to every image folder instead of the annotations file")
+	flag.StringVar(&options.Format, "format", FormatJSON, "Formats of the project file, "+strings.Join(votter.OutputFormats(), ", ")+", the first for the annotations file and the others next to it, as format or format=path")
+	flag.StringVar(&options.DownloadURLs, "download-urls", "", "CSV of image URLs with an optional label, downloaded into a folder per label in the images folder before generating")
+	flag.StringVar(&options.WebDAV, "webdav", "", "WebDAV folder of label folders, like a NAS share, whose images are downloaded into the images folder before generating")
+	flag.StringVar(&options.SFTP, "sftp", "", "sftp://user@host/path of label folders whose images are downloaded into the images folder before generating")
+	flag.StringVar(&options.SFTPKey, "sftp-key", "", "Private key file for --sftp (default the ssh agent and default keys)")
+	flag.StringVar(&options.Plan, "plan", "", "Plan file of votter plan, generate the label folders of one --part of it only")
+	flag.IntVar(&options.Part, "part", 0, "Part of the --plan to generate, numbered from 1")
+	flag.BoolVar(&options.MergeDuplicates, "merge-duplicates", false, "Merge identical image files under several labels into one asset with a region per label")
+	flag.StringVar(&options.Splits, "splits", "", "Comma separated split files like train.txt,val.txt listing image paths, only their images are generated")
+	flag.BoolVar(&options.SplitOutput, "split-output", false, "Also write a project per split file next to the annotations file, annotations-train.json and so on")
+	flag.StringVar(&options.ColorStrategy, "color-strategy", ColorStrategyFixed, "Tag colors: red for all with 'fixed', or the 'dominant' color of each tag's sample images")
+	flag.BoolVar(&options.KeypointRegions, "keypoint-regions", false, "Add a POINT region for every keypoint, tagged with the keypoint's name")
+	flag.IntVar(&options.CoordinateDecimals, "coordinate-decimals", -1, "Round region coordinates to this many decimals, 0 for whole pixels, or keep them fractional as they are with -1")
+	flag.StringVar(&options.SQLite, "sqlite", "", "Also write the assets, regions and tags into a SQLite database at this path")
+	flag.StringVar(&options.Parquet, "parquet", "", "Also write a row per region with its image path, size, label and box into a Parquet file")
+	flag.IntVar(&options.ShardSize, "shard-size", 0, "Split the annotations over files of at most this many assets, with an index file")
+	flag.BoolVar(&options.PartialOnInterrupt, "partial-on-interrupt", false, "On SIGINT or SIGTERM write the assets so far to annotations.partial.json next to the annotation file")
+	flag.BoolVar(&options.PerDirProject, "per-dir-project", false, "Write a project for every top-level directory, each holding its own label folders")
+	flag.BoolVar(&options.Stdin, "stdin", false, "Read the image paths from standard input, a path or path<TAB>label per line, instead of scanning folders")
+	flag.StringVar(&options.NDJSON, "ndjson", "", "Read the assets from NDJSON lines of path, labels, boxes and attributes instead of scanning folders, - for standard input")
+	flag.StringVar(&options.LabelsFrom, "labels-from", "", "Label images in a flat folder from 'sidecar' files (image1.txt or image1.cls) or a synset_labels.txt style file")
+	flag.StringVar(&options.Ignore, "ignore", "", "Comma separated glob patterns of files and folders to skip, along with those of .votterignore")
+	flag.StringVar(&options.ClassMap, "class-map", "", "JSON file mapping labels to the tags they merge into")
+	flag.StringVar(&options.Synsets, "synsets", "", "ImageNet words.txt naming the synset IDs of folders like n02084071, in place of or in addition to the bundled names, or 'off' to keep the IDs")
+	flag.StringVar(&options.Translations, "translations", "", "CSV file of label names per locale, with a header row like label,de,fr")
+	flag.StringVar(&options.Locale, "locale", "", "Locale column of the translations file to name the tags by")
+	flag.StringVar(&options.NormalizeLabels, "normalize-labels", "", "Merge labels differing only in case or surrounding spaces into their 'lower', 'upper' or most 'common' spelling")
+	flag.BoolVar(&options.SlugifyLabels, "slugify-labels", false, "Turn folder names into clean tags: 'Red Pandas (2023)' becomes 'red-pandas-2023'")
+	flag.StringVar(&options.TagHierarchy, "tag-hierarchy", "", "Comma separated child:parent tag pairs, e.g. siamese:cat,cat:animal")
+	flag.BoolVar(&options.AncestorTags, "ancestor-tags", true, "Tag regions with the ancestors of their tags in the tag hierarchy too")
+	flag.StringVar(&options.BBoxFromName, "bbox-from-name", "", "Regular expression with groups x, y, w and h for the region box encoded in image names")
+	flag.StringVar(&options.Segmenter.URL, "segment-url", "", "Promptable segmentation service to ask for regions of each image, with its label as the prompt")
+	flag.Float64Var(&options.Segmenter.MinScore, "segment-min-score", 0.3, "Lowest confidence of the segmentation service's detections to keep as regions")
+	flag.StringVar(&options.MasksDir, "masks", "", "Directory of binary masks by label and image name, each blob becomes a region")
+	flag.StringVar(&options.MaskRegions, "mask-regions", "box", "Shape of the regions for mask blobs: box or polygon")
+	flag.StringVar(&options.LabelsFile, "labels", "", "File of one label per line fixing the tag order (default labels.txt in the images root if present)")
+	flag.Var(&options.StrictLabels, "strict-labels", "Fail when a label folder is not in the labels file, or only report it with -strict-labels=warn")
+	flag.BoolVar(&options.TagsFromMetadata, "tags-from-metadata", false, "Tag regions with the keywords embedded in their images: XMP subject, IPTC keywords and EXIF XPKeywords")
+	flag.Var(&options.OnError, "on-error", "What to do with images that cannot be read: fail the run, skip them or quarantine them")
+	flag.StringVar(&options.QuarantineDir, "quarantine-dir", "", "Directory for quarantined images (default 'quarantine' next to the annotations file)")
+	flag.StringVar(&options.QuarantineMode, "quarantine-mode", QuarantineCopy, "Quarantine a 'copy' of the images left out, or 'move' them out of the images folder")
+	flag.StringVar(&options.SummaryFile, "summary-file", "", "Also write the JSON summary of the run to this file")
+	flag.BoolVar(&options.Quiet, "quiet", false, "Don't print a line for every labeled image")
+	flag.IntVar(&options.LogEvery, "log-every", 1, "Print the line for only every n-th labeled image, e.g. 1000 for million-image runs")
+	flag.BoolVar(&Progress.Enabled, "progress-json", false, "Write progress events as JSON lines on stderr: phase, files done and total, current label and rate")
+	flag.IntVar(&options.Workers, "workers", runtime.NumCPU(), "Number of directories listed and images decoded concurrently")
+	flag.IntVar(&options.ScanWorkers, "scan-workers", 0, "Number of directories listed concurrently (default --workers)")
+	flag.IntVar(&options.DecodeWorkers, "decode-workers", 0, "Number of images decoded or hashed concurrently (default --workers)")
+	maxRequestsPerSecond := flag.Float64("max-requests-per-second", 0, "Most requests per second to Custom Vision, Elasticsearch, MLflow and the segmentation service together")
+	maxBandwidth := flag.String("max-bandwidth", "", "Most bytes per second sent and received by those connectors together, e.g. 10MB/s")
+	var tlsConfig TLSConfig
+	flag.StringVar(&tlsConfig.CACert, "ca-cert", "", "PEM bundle of private CAs to trust for the connectors, in addition to the system's")
+	flag.StringVar(&tlsConfig.ClientCert, "client-cert", "", "PEM client certificate for the connectors to present, with --client-key")
+	flag.StringVar(&tlsConfig.ClientKey, "client-key", "", "PEM private key of --client-cert")
+	customVisionProfile := flag.String("customvision-profile", "", "Credential profile of the config file for Custom Vision")
+	elasticsearchProfile := flag.String("elasticsearch-profile", "", "Credential profile of the config file for --push-elasticsearch")
+	mlflowProfile := flag.String("mlflow-profile", "", "Credential profile of the config file for MLflow")
+	segmentProfile := flag.String("segment-profile", "", "Credential profile of the config file for --segment-url")
+	webdavProfile := flag.String("webdav-profile", "", "Credential profile of the config file for --webdav")
+	cacheDir := flag.String("cache-dir", "", "Keep the responses of the connectors' GET requests here by ETag, so unchanged objects aren't fetched again")
+	maxMemory := flag.String("max-memory", "", "Memory budget like 512MB: the garbage collector keeps below it, and when the project wouldn't fit its assets are spilled to disk and written from there asset by asset")
+	cacheSize := flag.String("cache-size", DefaultCacheSize, "Most bytes kept in --cache-dir, evicting the least recently used")
+	maxOpenFiles := flag.Int("max-open-files", defaultMaxOpenFiles(), "Most files open at the same time while listing, decoding and hashing, the soft limit of ulimit -n less 32 if not set")
+	flag.IntVar(&Retry.Retries, "retries", Retry.Retries, "Times to retry reading a file after a transient error such as EIO")
+	flag.DurationVar(&Retry.Delay, "retry-delay", Retry.Delay, "Wait before the first retry, doubling for each next one")
+	flag.DurationVar(&Retry.Timeout, "file-timeout", 0, "Give up on reading a file or directory that takes longer than this, e.g. 30s")
+	extensions := flag.String("extensions", "", "Comma separated file extensions taken for images, like jpg,png,tif (default "+strings.Join(DefaultImageExtensions, ",")+")")
+	flag.BoolVar(&SniffImages, "sniff", false, "Take files for images by their content rather than extension, for misnamed and extensionless files")
+	flag.IntVar(&MaxFiles, "max-files", DefaultMaxFiles, "Number of files the scan for images looks at before it stops, so a wrong images folder like / fails fast (0 for no limit)")
+	runTimeout := flag.Duration("run-timeout", 0, "Stop generating after this long, e.g. 2h, like an interrupt")
+	var profiling Profiling
+	flag.StringVar(&profiling.CPUProfile, "cpuprofile", "", "Write a CPU profile of the run to this file, for go tool pprof")
+	flag.StringVar(&profiling.MemProfile, "memprofile", "", "Write a heap profile at the end of the run to this file, for go tool pprof")
+	flag.StringVar(&profiling.Trace, "trace", "", "Write an execution trace of the run to this file, for go tool trace")
+	configFlag := flag.String("config", "", "YAML file of settings by flag name (default votter.yaml if present)")
 	flag.Parse()
 
 	if *versionFlag {
@@ -117,11 +319,30 @@ func main() {
 		return
 	}
 
+	// Flags not on the command line come from the config file, or else VOTTER_* environment variables.
+	config, err := loadConfig(*configFlag)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(ExitInvalidArguments)
+	}
+	if err := applyConfig(flag.CommandLine, config); err != nil {
+		fmt.Println(err)
+		os.Exit(ExitInvalidArguments)
+	}
+
 	// Command line positional arguments for:  votter.exe <pathToImages> <vott-coco-annotations.json>
 	args := flag.Args()
 	imagesPath := OptionalPathToImagesDefault
 	annotationFile := OptionalAnnotationsFilenameDefault
 
+	if value, ok := configValue(config, ConfigImages); ok {
+		imagesPath = value
+	}
+
+	if value, ok := configValue(config, ConfigOutput); ok {
+		annotationFile = value
+	}
+
 	if len(args) > 0 {
 		imagesPath = args[0]
 	}
@@ -130,191 +351,1596 @@ func main() {
 		annotationFile = args[1]
 	}
 
-	// Verify the paths for images and annotations ara available.
-	if !isDirectory(imagesPath) {
-		fmt.Printf("Error: '%s' is not an existing directory\n", imagesPath)
-		os.Exit(ExitImagesFolderNotFound)
+	// Without any arguments or config on a terminal, ask rather than write into the current folder.
+	if len(os.Args) == 1 && len(config) == 0 && os.Getenv(envName(ConfigImages)) == "" && isTerminal(os.Stdin) && isTerminal(os.Stdout) {
+		if imagesPath, annotationFile, err = runWizard(os.Stdin, os.Stdout, imagesPath, annotationFile, &options); err != nil {
+			fmt.Println(err)
+			os.Exit(ExitInvalidArguments)
+		}
 	}
 
-	if !isDirectory(filepath.Dir(annotationFile)) {
-		fmt.Printf("Error: Cannot write annotations to directory '%s'\n", annotationFile)
-		os.Exit(ExitAnnotationsFolderNotFound)
+	if options.Augmentations, err = parseAugmentations(options.Augment); err != nil {
+		fmt.Println(err)
+		os.Exit(ExitInvalidArguments)
 	}
 
-	// --- Step 2. Generate VoTT assets --------------------------------------
-	//
-	// Find images in subdirectories, folder names are the labels.
-	imagesPerLabelDirectoryMap, err := findImages(imagesPath)
-	if err != nil {
+	if err := validRenameScheme(options.Rename); err != nil {
 		fmt.Println(err)
-		os.Exit(ExitImagesFolderEmpty)
+		os.Exit(ExitInvalidArguments)
 	}
 
-	// Make a distinct list of labels from the directory names found with the labeled images.
-	var labels []string
-	for label := range imagesPerLabelDirectoryMap {
-		labels = append(labels, label)
+	if options.Hierarchy, err = parseTagHierarchy(options.TagHierarchy); err != nil {
+		fmt.Println(err)
+		os.Exit(ExitInvalidArguments)
 	}
 
-	// Generate VoTT assets with image names and regions.
-	assets, err := generateVottEntries(imagesPath, imagesPerLabelDirectoryMap)
-	if err != nil {
+	if (options.Translations == "") != (options.Locale == "") {
+		fmt.Println("Error: --translations and --locale go together")
+		os.Exit(ExitInvalidArguments)
+	}
+
+	if err := validNormalizeForm(options.NormalizeLabels); err != nil {
 		fmt.Println(err)
-		os.Exit(ExitImagesFolderEmpty)
+		os.Exit(ExitInvalidArguments)
 	}
 
-	// --- Step 3. Write JSON file --------------------------------------------
-	//
-	// Print label and image info to std out.
-	for label, images := range imagesPerLabelDirectoryMap {
-		for _, image := range images {
-			fmt.Printf("Label '%s' for image '%s'.\n", label, image)
+	if options.BBoxFromName != "" {
+		if options.BBoxPattern, err = parseBBoxPattern(options.BBoxFromName); err != nil {
+			fmt.Println(err)
+			os.Exit(ExitInvalidArguments)
 		}
 	}
 
-	// Write JSON file vott-cocoa-annotation.json
-	if err := writeVottJSON(annotationFile, assets, labels); err != nil {
+	if err := validMultiFrame(options.MultiFrame); err != nil {
 		fmt.Println(err)
-		os.Exit(ExitImagesFolderNotFound)
+		os.Exit(ExitInvalidArguments)
 	}
 
-	os.Exit(ExitSuccesful)
-}
+	if err := validMaskShape(options.MaskRegions); err != nil {
+		fmt.Println(err)
+		os.Exit(ExitInvalidArguments)
+	}
 
-// isDirectory checks if the given path is a directory.
-func isDirectory(path string) bool {
-	info, err := os.Stat(path)
-	return err == nil && info.IsDir()
-}
+	if options.Tile != "" {
+		if options.TileSize, err = parseTileSize(options.Tile); err != nil {
+			fmt.Println(err)
+			os.Exit(ExitInvalidArguments)
+		}
+	}
 
-// findImages get all the labeled images in the given directory and its subdirectories. Returns a map of the directory name (label) to containing image paths.
-func findImages(root string) (map[string][]string, error) {
-	labels := make(map[string][]string)
-	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+	if options.Rekognition.Manifest != "" {
+		if err := options.Rekognition.validate(); err != nil {
+			fmt.Println(err)
+			os.Exit(ExitInvalidArguments)
+		}
+	}
+
+	if err := validCollectMode(options.CollectMode); err != nil {
+		fmt.Println(err)
+		os.Exit(ExitInvalidArguments)
+	}
+	if err := validCollectLayout(options.CollectLayout); err != nil {
+		fmt.Println(err)
+		os.Exit(ExitInvalidArguments)
+	}
+	if err := validAnonymize(parseAnonymize(options.Anonymize, options.AnonymizeDetector), options.Collect, options.CollectMode); err != nil {
+		fmt.Println(err)
+		os.Exit(ExitInvalidArguments)
+	}
+	if err := validStripMetadata(options.StripMetadata, options.Collect, options.CollectMode); err != nil {
+		fmt.Println(err)
+		os.Exit(ExitInvalidArguments)
+	}
+	if options.DriftThreshold != "" {
+		if options.Drift, err = parseDriftThreshold(options.DriftThreshold); err != nil {
+			fmt.Println(err)
+			os.Exit(ExitInvalidArguments)
+		}
+	}
+
+	if (options.Stdin || options.NDJSON != "") && options.PerDirProject {
+		fmt.Println("Error: --stdin and --ndjson don't go together with --per-dir-project")
+		os.Exit(ExitInvalidArguments)
+	}
+	if options.Stdin && options.NDJSON != "" {
+		fmt.Println("Error: --stdin and --ndjson don't go together")
+		os.Exit(ExitInvalidArguments)
+	}
+
+	if (options.Plan == "") != (options.Part == 0) {
+		fmt.Println("Error: --plan and --part go together")
+		os.Exit(ExitInvalidArguments)
+	}
+	if options.Plan != "" {
+		if options.Stdin || options.NDJSON != "" || options.LabelsFrom != "" || options.PerDirProject {
+			fmt.Println("Error: --plan splits label folders, it doesn't go together with --stdin, --ndjson, --labels-from or --per-dir-project")
+			os.Exit(ExitInvalidArguments)
+		}
+		plan, err := readPlan(options.Plan)
 		if err != nil {
-			return err
+			fmt.Println(err)
+			os.Exit(ExitInvalidArguments)
 		}
-		if info.IsDir() && path != root {
-			label := filepath.Base(path)
-			images, err := listImages(path)
-			if err != nil {
-				return err
-			}
-			if len(images) > 0 {
-				labels[label] = images
+		part, err := plan.part(options.Part)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(ExitInvalidArguments)
+		}
+		options.PartLabels = part.Labels
+	}
+
+	if err := validIncremental(options); err != nil {
+		fmt.Println(err)
+		os.Exit(ExitInvalidArguments)
+	}
+
+	if err := validURIStyle(options.URIStyle); err != nil {
+		fmt.Println(err)
+		os.Exit(ExitInvalidArguments)
+	}
+	if err := validColorStrategy(options.ColorStrategy); err != nil {
+		fmt.Println(err)
+		os.Exit(ExitInvalidArguments)
+	}
+	if options.SplitOutput && options.Splits == "" {
+		fmt.Println("Error: --split-output needs --splits")
+		os.Exit(ExitInvalidArguments)
+	}
+	if options.SplitOutput && (options.ShardSize > 0 || options.PushCustomVision) {
+		fmt.Println("Error: --split-output can't be combined with --shard-size or --push-customvision")
+		os.Exit(ExitInvalidArguments)
+	}
+
+	if options.Format, options.MoreFormats, err = parseFormats(options.Format); err != nil {
+		fmt.Println(err)
+		os.Exit(ExitInvalidArguments)
+	}
+	if (options.Format != FormatJSON || len(options.MoreFormats) > 0) && options.ShardSize > 0 {
+		fmt.Println("Error: --shard-size writes json only")
+		os.Exit(ExitInvalidArguments)
+	}
+	if len(options.MoreFormats) > 0 && options.PushCustomVision {
+		fmt.Println("Error: --push-customvision writes no annotations file, so it takes one --format")
+		os.Exit(ExitInvalidArguments)
+	}
+	if err := validQuarantineMode(options.QuarantineMode); err != nil {
+		fmt.Println(err)
+		os.Exit(ExitInvalidArguments)
+	}
+	if err := validVottVersion(options.VottVersion); err != nil {
+		fmt.Println(err)
+		os.Exit(ExitInvalidArguments)
+	}
+	if options.VottVersion == VottVersion1 && (options.Format != FormatJSON || len(options.MoreFormats) > 0 || options.ShardSize > 0 ||
+		options.SplitOutput || options.PushCustomVision || options.Collect != "") {
+		fmt.Println("Error: --vott-version 1 writes a project per image folder, it can't be combined with --format, --shard-size, --split-output, --push-customvision or --collect")
+		os.Exit(ExitInvalidArguments)
+	}
+	if options.JSONPatch != "" && (options.VottVersion == VottVersion1 || options.ShardSize > 0 || options.PushCustomVision) {
+		fmt.Println("Error: --json-patch compares annotation files, it can't be combined with --vott-version 1, --shard-size or --push-customvision")
+		os.Exit(ExitInvalidArguments)
+	}
+
+	if err := options.MLflow.validate(); err != nil {
+		fmt.Println(err)
+		os.Exit(ExitInvalidArguments)
+	}
+
+	// The connectors' requests go through the cache first, then the rate limits, then the proxy and TLS settings.
+	var transport http.RoundTripper = http.DefaultTransport
+	if tlsConfig.enabled() {
+		var err error
+		if transport, err = tlsConfig.transport(); err != nil {
+			fmt.Println(err)
+			os.Exit(ExitInvalidArguments)
+		}
+	}
+	if *maxRequestsPerSecond > 0 || *maxBandwidth != "" {
+		var bytesPerSecond float64
+		if *maxBandwidth != "" {
+			var err error
+			if bytesPerSecond, err = parseBandwidth(*maxBandwidth); err != nil {
+				fmt.Println(err)
+				os.Exit(ExitInvalidArguments)
 			}
 		}
-		return nil
-	})
+		transport = newLimitedTransport(transport, *maxRequestsPerSecond, bytesPerSecond)
+	}
+	if *extensions != "" {
+		if ImageExtensions, err = parseExtensions(*extensions); err != nil {
+			fmt.Println(err)
+			os.Exit(ExitInvalidArguments)
+		}
+	}
+	if *maxMemory != "" {
+		budget, ok := parseBytes(*maxMemory)
+		if !ok {
+			fmt.Printf("Error: Invalid --max-memory '%s', expected bytes like 512MB\n", *maxMemory)
+			os.Exit(ExitInvalidArguments)
+		}
+		options.MaxMemory = int64(budget)
+		setMemoryBudget(options.MaxMemory)
+	}
+	if *cacheDir != "" {
+		maxSize, ok := parseBytes(*cacheSize)
+		if !ok {
+			fmt.Printf("Error: Invalid --cache-size '%s', expected bytes like 1GB\n", *cacheSize)
+			os.Exit(ExitInvalidArguments)
+		}
+		cache, err := newCachingTransport(transport, *cacheDir, int64(maxSize))
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(ExitInvalidArguments)
+		}
+		transport = cache
+	}
+	if transport != http.DefaultTransport {
+		HTTPClient = &http.Client{Transport: transport}
+	}
 
+	// Connectors with a credential profile add its credentials to their requests.
+	profiles, err := loadProfiles(*configFlag)
 	if err != nil {
-		return nil, err
+		fmt.Println(err)
+		os.Exit(ExitInvalidArguments)
+	}
+	for _, connector := range []struct {
+		name    string
+		profile string
+		client  **http.Client
+	}{
+		{"customvision", *customVisionProfile, &options.CustomVision.Client},
+		{"elasticsearch", *elasticsearchProfile, &options.ElasticsearchClient},
+		{"mlflow", *mlflowProfile, &options.MLflow.Client},
+		{"segment", *segmentProfile, &options.Segmenter.Client},
+		{"webdav", *webdavProfile, &options.WebDAVClient},
+	} {
+		if connector.profile == "" {
+			continue
+		}
+		profile, err := findProfile(profiles, connector.name, connector.profile)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(ExitInvalidArguments)
+		}
+		if connector.name == "customvision" {
+			// Custom Vision takes the key as its Training-Key, a key on the command line wins.
+			if options.CustomVision.Key == "" {
+				options.CustomVision.Key = profile.Key
+			}
+			profile.Key = ""
+		}
+		*connector.client = profile.client(HTTPClient)
 	}
 
-	if len(labels) == 0 {
-		fmt.Print("Error: No images found in subdirectories.")
-		os.Exit(ExitImagesFolderEmpty)
+	if options.PushElasticsearch != "" {
+		if _, err := parseElasticsearch(options.PushElasticsearch); err != nil {
+			fmt.Println(err)
+			os.Exit(ExitInvalidArguments)
+		}
 	}
 
-	return labels, nil
+	if options.PushCustomVision {
+		if err := options.CustomVision.validate(); err != nil {
+			fmt.Println(err)
+			os.Exit(ExitInvalidArguments)
+		}
+	}
+
+	if options.WebDAV != "" {
+		if _, err := parseWebDAV(options.WebDAV); err != nil {
+			fmt.Println(err)
+			os.Exit(ExitInvalidArguments)
+		}
+		if options.WebDAVClient == nil {
+			options.WebDAVClient = HTTPClient
+		}
+	}
+
+	if options.SFTP != "" {
+		if _, err := parseSFTP(options.SFTP); err != nil {
+			fmt.Println(err)
+			os.Exit(ExitInvalidArguments)
+		}
+	}
+
+	// Downloads go into the images folder, which may not be there yet.
+	if options.DownloadURLs != "" || options.WebDAV != "" || options.SFTP != "" {
+		if err := os.MkdirAll(imagesPath, 0755); err != nil {
+			fmt.Println(err)
+			os.Exit(ExitImagesFolderNotFound)
+		}
+	}
+
+	// Verify the paths for images and annotations ara available.
+	if !isDirectory(imagesPath) {
+		fmt.Printf("Error: '%s' is not an existing directory\n", imagesPath)
+		os.Exit(ExitImagesFolderNotFound)
+	}
+
+	if !isDirectory(filepath.Dir(annotationFile)) {
+		fmt.Printf("Error: Cannot write annotations to directory '%s'\n", annotationFile)
+		os.Exit(ExitAnnotationsFolderNotFound)
+	}
+
+	OpenFiles = newFileLimit(*maxOpenFiles)
+
+	if err := profiling.start(); err != nil {
+		fmt.Println(err)
+		os.Exit(ExitInvalidArguments)
+	}
+
+	ctx, stop := interruptContext()
+	defer stop()
+	if *runTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *runTimeout)
+		defer cancel()
+	}
+
+	// Download the images of a scrape list, then annotate them like any other images folder.
+	if options.DownloadURLs != "" {
+		if code := downloadURLList(ctx, options.DownloadURLs, imagesPath, annotationFile, options.Workers); code != ExitSuccesful {
+			os.Exit(code)
+		}
+	}
+
+	// Mirror the label folders of a network share, then annotate them like any other images folder.
+	if options.WebDAV != "" {
+		if code := mirrorWebDAV(ctx, options.WebDAVClient, options.WebDAV, imagesPath, annotationFile, options.Workers); code != ExitSuccesful {
+			os.Exit(code)
+		}
+	}
+
+	if options.SFTP != "" {
+		if code := mirrorSFTP(ctx, options.SFTP, options.SFTPKey, imagesPath, annotationFile); code != ExitSuccesful {
+			os.Exit(code)
+		}
+	}
+
+	// Every top-level directory is a dataset of its own.
+	var exitCode int
+	if options.PerDirProject {
+		exitCode = generatePerDirectory(ctx, imagesPath, annotationFile, options)
+	} else {
+		exitCode = generate(ctx, imagesPath, annotationFile, options)
+	}
+
+	if err := profiling.stop(); err != nil {
+		fmt.Println(err)
+	}
+	os.Exit(exitCode)
 }
 
-func listImages(dir string) ([]string, error) {
-	var images []string
-	files, err := ioutil.ReadDir(dir)
+// generate finds the labeled images below imagesPath and writes their VoTT project to annotationFile. Returns the exit code.
+// Cancelling ctx stops scanning, or stops decoding after the current images, see --partial-on-interrupt.
+func generate(ctx context.Context, imagesPath string, annotationFile string, options Options) int {
+	start := time.Now()
+	var summary RunSummary
+
+	// --- Step 2. Generate VoTT assets --------------------------------------
+	//
+	// Find images in subdirectories, folder names are the labels. Flat folders get their labels from label files,
+	// --stdin lists the images and --ndjson describes their assets.
+	var images []labeledImage
+	var err error
+	// Images that fail to decode are quarantined by --on-error, moved or copied into the quarantine folder.
+	quarantine := Quarantine{Dir: options.QuarantineDir, Root: imagesPath, Mode: options.QuarantineMode}
+	if quarantine.Dir == "" {
+		quarantine.Dir = filepath.Join(filepath.Dir(annotationFile), "quarantine")
+	}
+	// The quarantine folder may be below the images folder, with the annotations file, and its images aren't labels.
+	var skipDirs []string
+	if options.OnError == OnErrorQuarantine {
+		skipDirs = append(skipDirs, quarantine.Dir)
+	}
+	datasetMetadata, err := readDatasetMetadata(imagesPath)
 	if err != nil {
-		return nil, err
+		fmt.Println(err)
+		return ExitInvalidArguments
+	}
+	Progress.begin("scan", 0)
+	var assetLines map[string]AssetLine
+	if options.Stdin {
+		images, err = readImageList(os.Stdin)
+	} else if options.NDJSON != "" {
+		images, assetLines, err = readAssetLinesFile(options.NDJSON)
+	} else if options.LabelsFrom != "" {
+		images, err = findFlatImages(imagesPath, options.LabelsFrom)
+	} else {
+		var imagesPerLabelDirectoryMap map[string][]string
+		imagesPerLabelDirectoryMap, err = findImagesIn(ctx, imagesPath, options.PartLabels, skipDirs, stageWorkers(options.ScanWorkers, options.Workers))
+		images = labeledImages(imagesPath, imagesPerLabelDirectoryMap)
+	}
+	if ctx.Err() != nil {
+		fmt.Printf("Error: %s while scanning for images\n", interruption(ctx))
+		return ExitInterrupted
+	}
+	if err != nil {
+		fmt.Println(err)
+		return ExitImagesFolderEmpty
 	}
-	for _, file := range files {
-		if isImage(file.Name()) {
-			images = append(images, file.Name())
+	Progress.finish(len(images))
+	if options.Ignore != "" {
+		var ignored int
+		if images, ignored = ignoreImages(images, imagesPath, parseIgnorePatterns(options.Ignore)); ignored > 0 {
+			fmt.Printf("Ignored %d images matching --ignore.\n", ignored)
 		}
 	}
-	return images, nil
-}
 
-func isImage(filename string) bool {
-	ext := strings.ToLower(filepath.Ext(filename))
-	return ext == ".png" || ext == ".jpg" || ext == ".jpeg" || ext == ".gif" || ext == ".bmp"
-}
+	// Keep the images of the dataset authors' split files.
+	var splits Splits
+	if options.Splits != "" {
+		if splits, err = readSplits(options.Splits, imagesPath); err != nil {
+			fmt.Println(err)
+			return ExitInvalidArguments
+		}
+		var leftOut int
+		images, leftOut = splitImages(images, splits)
+		if leftOut > 0 {
+			fmt.Printf("Left out %d images not in the split files.\n", leftOut)
+		}
+	}
 
-func generateVottEntries(pathToImagesDataset string, labels map[string][]string) ([]Asset, error) {
-	var entries []Asset
+	// Apply the label.yaml overrides of the label folders: skip, rename and place them in the tag hierarchy.
+	labelSettings, err := readLabelSettings(imagesPath, images)
+	if err != nil {
+		fmt.Println(err)
+		return ExitInvalidArguments
+	}
+	if len(labelSettings) > 0 {
+		var skippedLabels []string
+		images, skippedLabels = applyLabelSettings(images, labelSettings)
+		for _, label := range skippedLabels {
+			fmt.Printf("Skipped label '%s' by its %s.\n", label, LabelSettingsFile)
+		}
+		if options.Hierarchy, err = labelSettingsHierarchy(options.Hierarchy, labelSettings); err != nil {
+			fmt.Println(err)
+			return ExitInvalidArguments
+		}
+	}
 
-	for label, images := range labels {
-		for _, imgFileName := range images {
-			imgRelativePath := filepath.Join(pathToImagesDataset, label, imgFileName) // dataset/label/image.jpg
-			imgAbsolutePath, err := filepath.Abs(imgRelativePath)                     // /home/example/dataset/label/image.jpg or C:\example\dataset\label\image.jpg
-			if err != nil {
-				return nil, err
-			}
+	// The labels and box tags of --ndjson lines are remapped along with the labels of the images, as images of their
+	// own taken out again after.
+	var lineTags []string
+	if assetLines != nil {
+		lineTags = assetLineTags(images, assetLines, nil)
+		images = append(images, tagImages(lineTags)...)
+	}
 
-			imgFile, err := os.Open(imgRelativePath)
-			if err != nil {
-				return nil, err
-			}
-			imgConfig, _, err := image.DecodeConfig(imgFile)
-			imgFile.Close()
-			if err != nil {
-				return nil, err
-			}
+	// Merge labels into the coarser tags of the class map, before anything else sees them.
+	if options.ClassMap != "" {
+		classMap, err := readClassMap(options.ClassMap)
+		if err != nil {
+			fmt.Println(err)
+			return ExitInvalidArguments
+		}
+		images = remapImages(images, classMap)
+	}
 
-			entry := Asset{
-				Format: strings.TrimPrefix(filepath.Ext(imgFileName), "."),
-				ID:     uuid.New().String(),
-				Name:   imgFileName,
-				Path:   "file:" + filepath.ToSlash(imgAbsolutePath), // file:/home/example/dataset/label/image.jpg or file:C:/example/dataset/label/image.jpg
-				Size: Size{
-					Width:  imgConfig.Width,
-					Height: imgConfig.Height,
-				},
-				State: 0,
-				Type:  0,
-				Label: label,
-			}
-			entries = append(entries, entry)
+	// Name ImageNet folders like n02084071 by their synset, dog.
+	if options.Synsets != SynsetsOff {
+		synsets, err := readSynsets(options.Synsets)
+		if err != nil {
+			fmt.Println(err)
+			return ExitInvalidArguments
+		}
+		var unknown []string
+		images, unknown = resolveSynsets(images, synsets)
+		for _, id := range unknown {
+			fmt.Printf("No name for synset '%s', keeping it. Pass ImageNet's words.txt with --synsets to name it.\n", id)
 		}
 	}
 
-	return entries, nil
-}
+	// Name the tags in the language of the annotators.
+	if options.Translations != "" {
+		translations, err := readTranslations(options.Translations, options.Locale)
+		if err != nil {
+			fmt.Println(err)
+			return ExitInvalidArguments
+		}
+		for _, label := range untranslatedLabels(images, translations) {
+			fmt.Printf("No '%s' translation for label '%s', keeping it.\n", options.Locale, label)
+		}
+		images = remapImages(images, translations)
+	}
 
-func writeVottJSON(path string, assets []Asset, tags []string) error {
+	// Merge labels that differ only in case or surrounding whitespace.
+	var merges []string
+	images, merges = normalizeLabels(images, options.NormalizeLabels)
+	for _, merge := range merges {
+		fmt.Println(merge)
+	}
 
-	model := VottJsonModel{
-		ActiveLearningSettings: ActiveLearningSettings{AutoDetect: false, PredictTag: true, ModelPathType: "coco"},
-		Assets:                 make(map[string]AssetDetail),
-		Tags:                   []Tag{},
-		Version:                "2.2.0",
+	// Turn folder names into clean tag names.
+	if options.SlugifyLabels {
+		images, summary.LabelMapping = slugifyLabels(images)
+	}
+	relabel := make(map[string]string)
+	if assetLines != nil {
+		for i, image := range images[len(images)-len(lineTags):] {
+			relabel[lineTags[i]] = image.Label
+		}
+		images = images[:len(images)-len(lineTags)]
 	}
 
-	for _, asset := range assets {
-		region := Region{
-			ID:          uuid.New().String(),
-			Type:        "RECTANGLE",
-			Tags:        []string{asset.Label},
-			BoundingBox: BoundingBox{Height: asset.Size.Height, Width: asset.Size.Width, Left: 0, Top: 0},
-			Points:      []Point{{X: 0, Y: 0}, {X: asset.Size.Width, Y: asset.Size.Height}},
+	// Make a distinct list of labels from the directory names found with the labeled images, in the order of the labels file.
+	var labels []string
+	imagesPerLabel := make(map[string][]string)
+	for _, image := range images {
+		if imagesPerLabel[image.Label] == nil {
+			labels = append(labels, image.Label)
+		}
+		imagesPerLabel[image.Label] = append(imagesPerLabel[image.Label], image.Path)
+	}
+	var fixedLabels []string
+	if labelsFile := findLabelsFile(options.LabelsFile, imagesPath); labelsFile != "" {
+		if fixedLabels, err = readLabelsFile(labelsFile); err != nil {
+			fmt.Println(err)
+			return ExitInvalidArguments
+		}
+	}
+	if options.StrictLabels != StrictLabelsOff {
+		if fixedLabels == nil {
+			fmt.Println("Error: --strict-labels needs a labels file")
+			return ExitInvalidArguments
+		}
+		unknown := unknownLabels(labels, fixedLabels)
+		for _, problem := range unknown {
+			fmt.Println(problem)
 		}
-		assetDetail := AssetDetail{
-			Asset:   asset,
-			Regions: []Region{region},
-			Version: "2.2.0", // last version
+		if len(unknown) > 0 && options.StrictLabels == StrictLabelsFail {
+			fmt.Printf("Error: %d labels are not in the labels file.\n", len(unknown))
+			return ExitUnknownLabels
 		}
-		model.Assets[asset.ID] = assetDetail
+	}
+	labels = orderLabels(labels, fixedLabels)
+	if options.AncestorTags {
+		labels = withAncestorTags(labels, options.Hierarchy)
 	}
 
-	for _, label := range tags {
-		tag := Tag{
-			Name:  label,
-			Color: "#ff0000", // red
+	// Incremental runs carry forward the assets of images with unchanged content and only generate the rest.
+	imagesToGenerate := images
+	var unchangedAssets []Asset
+	var hashState HashState
+	if options.Incremental {
+		unchangedAssets, imagesToGenerate, hashState, err = incrementalChanges(images, annotationFile, stageWorkers(options.DecodeWorkers, options.Workers))
+		if err != nil {
+			fmt.Println(err)
+			return ExitImagesFolderEmpty
 		}
-		model.Tags = append(model.Tags, tag)
 	}
 
-	data, err := json.MarshalIndent(model, "", "  ")
+	// Only the images of --only-labels are generated, the existing project's other assets stay as they are.
+	var otherAssets []Asset
+	if options.OnlyLabels != "" {
+		onlyLabels := parseOnlyLabels(options.OnlyLabels)
+		var previousTags []string
+		otherAssets, previousTags, err = otherLabelAssets(annotationFile, onlyLabels)
+		if err != nil {
+			fmt.Println(err)
+			return ExitAnnotationsNotReadable
+		}
+		imagesToGenerate = onlyLabelImages(imagesToGenerate, onlyLabels)
+		labels = mergeTags(previousTags, labels)
+	}
+
+	// Generate VoTT assets with image names and regions. Images that fail to decode abort the run, or are skipped by --on-error.
+	Progress.begin("decode", len(imagesToGenerate))
+	entries, errs := generateEachImageEntry(ctx, imagesToGenerate, stageWorkers(options.DecodeWorkers, options.Workers))
+	// An interrupt leaves the images from the first one not started on without assets.
+	imagesToGenerate = imagesToGenerate[:len(entries)]
+	assets, skipped, err := skipFailedImages(imagesToGenerate, entries, errs, options.OnError, quarantine)
 	if err != nil {
-		return err
+		fmt.Println(err)
+		return ExitImagesFolderEmpty
+	}
+	summary.Images = len(images)
+	summary.Skipped = skipped
+	if options.OnError == OnErrorQuarantine && len(skipped) > 0 {
+		summary.Outputs = append(summary.Outputs, quarantine.Dir)
+	}
+	skippedPaths := make(map[string]bool)
+	for _, file := range skipped {
+		skippedPaths[file.Path] = true
+		if options.Incremental {
+			// Forget the hash so the next run tries the image again.
+			if assetPath, err := imageAssetPath(file.Path); err == nil {
+				delete(hashState.Files, assetPath)
+			}
+		}
+	}
+	carriedAssets := make(map[string]bool)
+	for _, carried := range [][]Asset{unchangedAssets, otherAssets} {
+		for _, asset := range carried {
+			carriedAssets[asset.ID] = true
+		}
+	}
+	if options.Incremental {
+		fmt.Printf("Carried forward %d unchanged assets, generated %d.\n", len(unchangedAssets), len(assets))
+		assets = append(unchangedAssets, assets...)
+	}
+	if options.OnlyLabels != "" {
+		fmt.Printf("Kept %d assets of other labels, generated %d.\n", len(otherAssets), len(assets))
+	}
+
+	// An interrupt stops the run between stages, with the assets so far as the partial project of --partial-on-interrupt.
+	stop := func(when string) int {
+		return stopInterrupted(ctx, annotationFile, when, append(otherAssets, assets...), labels, options)
+	}
+	if ctx.Err() != nil {
+		return stop(fmt.Sprintf("after %d of %d images", len(imagesToGenerate), len(images)))
+	}
+
+	// Replace the full image regions with the boxes encoded in the image names.
+	if options.BBoxPattern != nil {
+		assets = applyNameBoundingBoxes(assets, options.BBoxPattern)
+	}
+
+	// Give the assets the boxes, labels and attributes of their --ndjson lines.
+	if assetLines != nil {
+		assets = applyAssetLines(assets, assetLines, relabel)
+		labels = mergeTags(labels, assetLineTags(images, assetLines, relabel))
+		if options.AncestorTags {
+			labels = withAncestorTags(labels, options.Hierarchy)
+		}
+	}
+
+	// Replace the full image regions with a region per blob of the image's mask.
+	if options.MasksDir != "" {
+		if ctx.Err() != nil {
+			return stop("before the masks stage")
+		}
+		Progress.begin("masks", len(assets))
+		assets, err = applyMasks(assets, options.MasksDir, options.MaskRegions)
+		if err != nil {
+			fmt.Println(err)
+			return ExitImagesFolderEmpty
+		}
+		Progress.finish(len(assets))
+	}
+
+	// Replace the full image regions with the prelabels of a segmentation model prompted by the label.
+	if options.Segmenter.URL != "" {
+		if ctx.Err() != nil {
+			return stop("before the segment stage")
+		}
+		Progress.begin("segment", len(assets))
+		var detectionTags []string
+		assets, detectionTags, err = applySegmentation(assets, options.Segmenter, stageWorkers(options.DecodeWorkers, options.Workers))
+		if err != nil {
+			fmt.Println(err)
+			return ExitImagesFolderEmpty
+		}
+		Progress.finish(len(assets))
+		labels = mergeTags(labels, detectionTags)
+		if options.AncestorTags {
+			labels = withAncestorTags(labels, options.Hierarchy)
+		}
+	}
+
+	// Tag the regions with the keywords embedded in their images too.
+	if options.TagsFromMetadata {
+		var keywords []string
+		assets, keywords, err = addMetadataTags(assets)
+		if err != nil {
+			fmt.Println(err)
+			return ExitImagesFolderEmpty
+		}
+		labels = mergeTags(labels, keywords)
+		if options.AncestorTags {
+			labels = withAncestorTags(labels, options.Hierarchy)
+		}
+	}
+
+	// Merge the tags, attributes and capture time of image1.jpg.json sidecars.
+	var sidecarTags []string
+	assets, sidecarTags, err = applySidecars(assets)
+	if err != nil {
+		fmt.Println(err)
+		return ExitImagesFolderEmpty
+	}
+	if len(sidecarTags) > 0 {
+		labels = mergeTags(labels, sidecarTags)
+		if options.AncestorTags {
+			labels = withAncestorTags(labels, options.Hierarchy)
+		}
+	}
+	if options.KeypointRegions {
+		var keypointNames []string
+		assets, keypointNames = keypointPointRegions(assets)
+		labels = mergeTags(labels, keypointNames)
+	}
+	if options.Splits != "" {
+		assets = applySplits(assets, splits)
+	}
+	assets = applyLabelSettingsAttributes(assets, imagesPath, labelSettings)
+
+	// Make one asset of the same image filed under several labels.
+	if options.MergeDuplicates {
+		if ctx.Err() != nil {
+			return stop("before the merge stage")
+		}
+		Progress.begin("merge", len(assets))
+		assets, summary.Merged, err = mergeDuplicateAssets(assets, stageWorkers(options.DecodeWorkers, options.Workers))
+		if err != nil {
+			fmt.Println(err)
+			return ExitImagesFolderEmpty
+		}
+		Progress.finish(len(assets))
+		for _, merge := range summary.Merged {
+			fmt.Println(merge)
+		}
+	}
+
+	// Tag the regions with the parents of their tags.
+	if options.AncestorTags && len(options.Hierarchy) > 0 {
+		assets = addAncestorTags(assets, options.Hierarchy)
+	}
+
+	// Expand animated GIFs into an asset per frame, or leave them out.
+	if options.MultiFrame == MultiFrameFrames || options.MultiFrame == MultiFrameSkip {
+		framesDir := options.FramesDir
+		if framesDir == "" {
+			framesDir = filepath.Join(filepath.Dir(annotationFile), "frames")
+		}
+		var skippedAnimations []SkippedFile
+		assets, skippedAnimations, err = expandFrames(assets, options.MultiFrame, framesDir)
+		if err != nil {
+			fmt.Println(err)
+			return ExitImageWriteFailed
+		}
+		// Animated GIFs left out are quarantined like images that fail to decode.
+		if options.OnError == OnErrorQuarantine && len(skippedAnimations) > 0 {
+			if err := quarantine.files(skippedAnimations); err != nil {
+				fmt.Println(err)
+				return ExitImageWriteFailed
+			}
+			if !slices.Contains(summary.Outputs, quarantine.Dir) {
+				summary.Outputs = append(summary.Outputs, quarantine.Dir)
+			}
+		}
+		summary.Skipped = append(summary.Skipped, skippedAnimations...)
+		if options.MultiFrame == MultiFrameFrames {
+			summary.Outputs = append(summary.Outputs, framesDir)
+		}
+	}
+
+	// Slice large images into tiles, clipping the regions to each tile.
+	if options.Tile != "" {
+		tileDir := options.TileDir
+		if tileDir == "" {
+			tileDir = filepath.Join(filepath.Dir(annotationFile), "tiles")
+		}
+		if ctx.Err() != nil {
+			return stop("before the tile stage")
+		}
+		Progress.begin("tile", len(assets))
+		assets, err = tileAssets(assets, options.TileSize, options.Overlap, tileDir)
+		if err != nil {
+			fmt.Println(err)
+			return ExitImageWriteFailed
+		}
+		Progress.finish(len(assets))
+		summary.Outputs = append(summary.Outputs, tileDir)
+	}
+
+	// Write resized copies and scale the regions along with them.
+	if options.Resize > 0 {
+		resizeDir := options.ResizeDir
+		if resizeDir == "" {
+			resizeDir = filepath.Join(filepath.Dir(annotationFile), "resized")
+		}
+		if ctx.Err() != nil {
+			return stop("before the resize stage")
+		}
+		Progress.begin("resize", len(assets))
+		assets, err = resizeAssets(assets, options.Resize, resizeDir)
+		if err != nil {
+			fmt.Println(err)
+			return ExitImageWriteFailed
+		}
+		Progress.finish(len(assets))
+		summary.Outputs = append(summary.Outputs, resizeDir)
+	}
+
+	// Add flipped and rotated copies as extra assets.
+	if len(options.Augmentations) > 0 {
+		augmentDir := options.AugmentDir
+		if augmentDir == "" {
+			augmentDir = filepath.Join(filepath.Dir(annotationFile), "augmented")
+		}
+		if ctx.Err() != nil {
+			return stop("before the augment stage")
+		}
+		Progress.begin("augment", len(assets))
+		augmented, err := augmentAssets(assets, options.Augmentations, augmentDir)
+		if err != nil {
+			fmt.Println(err)
+			return ExitImageWriteFailed
+		}
+		Progress.finish(len(augmented))
+		assets = append(assets, augmented...)
+		summary.Outputs = append(summary.Outputs, augmentDir)
+	}
+
+	// Copy images under collision-free names, with a mapping back to the originals.
+	if options.Rename != "" {
+		renameDir := options.RenameDir
+		if renameDir == "" {
+			renameDir = filepath.Join(filepath.Dir(annotationFile), "renamed")
+		}
+		if ctx.Err() != nil {
+			return stop("before the rename stage")
+		}
+		Progress.begin("rename", len(assets))
+		assets, err = renameAssets(assets, options.Rename, renameDir)
+		if err != nil {
+			fmt.Println(err)
+			return ExitImageWriteFailed
+		}
+		Progress.finish(len(assets))
+		summary.Outputs = append(summary.Outputs, renameDir)
+	}
+
+	// --- Step 3. Write JSON file --------------------------------------------
+	//
+	// Print label and image info to std out, for every --log-every'th image unless --quiet.
+	if !options.Quiet {
+		logged := 0
+		for _, label := range labels {
+			for _, image := range imagesPerLabel[label] {
+				if skippedPaths[image] {
+					continue
+				}
+				if logged%max(1, options.LogEvery) == 0 {
+					fmt.Printf("Label '%s' for image '%s'.\n", label, filepath.Base(image))
+				}
+				logged++
+			}
+		}
+	}
+
+	// Record the SHA-256 of every image for verify -checksums.
+	if options.Checksums {
+		if ctx.Err() != nil {
+			return stop("before the checksums stage")
+		}
+		Progress.begin("checksums", len(assets))
+		if err := addChecksums(assets, stageWorkers(options.DecodeWorkers, options.Workers)); err != nil {
+			fmt.Println(err)
+			return ExitImageWriteFailed
+		}
+		Progress.finish(len(assets))
+	}
+
+	// Draw the regions on copies of the images, to eyeball them without VoTT.
+	if options.DrawOverlays != "" {
+		if ctx.Err() != nil {
+			return stop("before the overlays stage")
+		}
+		Progress.begin("overlays", len(assets))
+		if err := drawOverlays(assets, labels, options.DrawOverlays); err != nil {
+			fmt.Println(err)
+			return ExitImageWriteFailed
+		}
+		Progress.finish(len(assets))
+		summary.Outputs = append(summary.Outputs, options.DrawOverlays)
+	}
+
+	// Round the coordinates for tools that want whole pixels.
+	assets = roundRegions(assets, options.CoordinateDecimals)
+
+	// Regions that didn't change keep their IDs, so --json-patch and other consumers see what changed only.
+	stableRegionIDs(assets, carriedAssets)
+
+	// Write asset paths as RFC 8089 file URIs with --uri-style strict.
+	assets = applyURIStyle(assets, options.URIStyle)
+
+	// Gather the images next to the annotations file in the --collect folder, as paths relative to it.
+	if options.Collect != "" {
+		if ctx.Err() != nil {
+			return stop("before the collect stage")
+		}
+		Progress.begin("collect", len(assets))
+		assets, err = collectAssets(assets, options.Collect, options.CollectMode, options.CollectLayout)
+		if err != nil {
+			fmt.Println(err)
+			return ExitImageWriteFailed
+		}
+		Progress.finish(len(assets))
+		annotationFile = filepath.Join(options.Collect, filepath.Base(annotationFile))
+
+		// Blur faces and plates in the copies before the bundle is shared.
+		if anonymize := parseAnonymize(options.Anonymize, options.AnonymizeDetector); anonymize.enabled() {
+			blurred, err := anonymizeAssets(ctx, assets, options.Collect, anonymize)
+			if err != nil {
+				fmt.Println(err)
+				return ExitImageWriteFailed
+			}
+			fmt.Printf("Anonymized %d images in '%s'.\n", blurred, options.Collect)
+		}
+		if options.StripMetadata {
+			stripped, err := stripAssetsMetadata(assets, options.Collect)
+			if err != nil {
+				fmt.Println(err)
+				return ExitImageWriteFailed
+			}
+			fmt.Printf("Stripped the metadata of %d images in '%s'.\n", stripped, options.Collect)
+		}
+	}
+
+	if ctx.Err() != nil {
+		return stop("before writing")
+	}
+
+	// The assets of other labels are kept as they are in the project, past the stages that would process them again.
+	assets = append(otherAssets, assets...)
+
+	// The project settings and tags, assets are added when writing.
+	project := buildVottModel(nil, labels)
+	applyDatasetMetadata(&project, datasetMetadata)
+	if options.ColorStrategy == ColorStrategyDominant {
+		applyTagColors(&project, dominantTagColors(assets, labels))
+	}
+	applyTagColors(&project, labelSettingsColors(labelSettings))
+	applyLabelSettingsTags(&project, labelSettings)
+	applyTagHierarchy(&project, options.Hierarchy)
+
+	// Write JSON file vott-cocoa-annotation-token.json with a new security token for VoTT's application settings.
+	if options.SecurityToken {
+		tokenPath, err := writeSecurityToken(annotationFile, &project)
+		if err != nil {
+			fmt.Println(err)
+			return ExitAnnotationsWriteFailed
+		}
+		fmt.Printf("Wrote security token '%s' to '%s', add it in VoTT under Application Settings > Security Tokens.\n", project.SecurityToken, tokenPath)
+		summary.Outputs = append(summary.Outputs, tokenPath)
+	}
+
+	// Compare the label distribution to the project being replaced, to notice sources that stopped delivering.
+	if options.Drift > 0 {
+		previous, err := previousLabelDistribution(annotationFile)
+		if err != nil {
+			fmt.Println(err)
+			return ExitAnnotationsNotReadable
+		}
+		if previous != nil {
+			summary.Drift = labelDrift(previous, labelDistribution(assets), options.Drift)
+			for _, drift := range summary.Drift {
+				fmt.Println(drift)
+			}
+		}
+	}
+
+	// Write JSON files vott-cocoa-annotation-000.json, -001.json, ... and vott-cocoa-annotation-index.json
+	written := len(assets)
+	Progress.begin("write", written)
+	var spill *assetSpill
+	if options.PushCustomVision {
+		// Upload straight to Custom Vision, without an annotations file.
+		uploaded, err := options.CustomVision.push(assets, labels)
+		if err != nil {
+			fmt.Println(err)
+			return ExitAnnotationsWriteFailed
+		}
+		fmt.Printf("Uploaded %d images to Custom Vision project '%s'.\n", uploaded, options.CustomVision.Project)
+	} else if options.VottVersion == VottVersion1 {
+		// The legacy Windows app reads a project per image folder, next to it.
+		paths, err := writeVottV1Projects(assets, project.Tags)
+		if err != nil {
+			fmt.Println(err)
+			return ExitAnnotationsWriteFailed
+		}
+		for _, path := range paths {
+			fmt.Printf("Wrote VoTT 1 project '%s'.\n", path)
+		}
+		summary.Outputs = append(summary.Outputs, paths...)
+	} else if options.ShardSize > 0 {
+		indexPath, err := writeVottShards(annotationFile, project, assets, options.ShardSize)
+		if err != nil {
+			fmt.Println(err)
+			return ExitImagesFolderNotFound
+		}
+		fmt.Printf("Wrote shard index '%s'.\n", indexPath)
+		summary.Outputs = append(summary.Outputs, indexPath)
+	} else {
+		// The project being replaced, to patch downstream copies of it rather than send them the new one.
+		var previous interface{}
+		if options.JSONPatch != "" {
+			if previous, err = readProjectDocument(annotationFile); err != nil {
+				fmt.Println(err)
+				return ExitAnnotationsNotReadable
+			}
+		}
+
+		// When the project wouldn't fit in --max-memory, the assets are spilled to disk in chunks sorted by ID and let go
+		// of, and the project files read them back one at a time.
+		source := sortedAssets(assets)
+		if spillsAssets(assets, options.MaxMemory) {
+			if spill, err = spillAssets(filepath.Dir(annotationFile), assets, options.MaxMemory/spillChunkShare); err != nil {
+				fmt.Println(err)
+				return ExitAnnotationsWriteFailed
+			}
+			defer spill.remove()
+			fmt.Printf("Spilled %d assets in %d chunks to '%s' to stay within --max-memory.\n", written, len(spill.chunks), spill.dir)
+			assets, source = nil, spill.each
+		}
+
+		// Write JSON file vott-cocoa-annotation.json, asset by asset from the spilled chunks.
+		if spill != nil && (options.Format == FormatJSON || options.Format == "") {
+			err = writeVottJSONStream(annotationFile, project, source)
+		} else if err = addSourceAssets(&project, source); err == nil {
+			err = writeVottModelAs(annotationFile, project, options.Format)
+		}
+		if err != nil {
+			fmt.Println(err)
+			return ExitImagesFolderNotFound
+		}
+		if !options.NoHistory {
+			if err := recordHistory(annotationFile, "generate"); err != nil {
+				fmt.Println(err)
+				return ExitAnnotationsWriteFailed
+			}
+		}
+		summary.Outputs = append(summary.Outputs, annotationFile)
+		if options.JSONPatch != "" {
+			operations, err := writeJSONPatch(options.JSONPatch, previous, annotationFile)
+			if err != nil {
+				fmt.Println(err)
+				return ExitAnnotationsWriteFailed
+			}
+			fmt.Printf("Wrote %d JSON Patch operations to '%s'.\n", operations, options.JSONPatch)
+			summary.Outputs = append(summary.Outputs, options.JSONPatch)
+		}
+
+		// The other formats are exported from the same project, without reading the images again. Spilled assets are
+		// streamed into JSON, the formats that encode a whole project build it in memory.
+		for _, output := range options.MoreFormats {
+			path := output.path(annotationFile)
+			if spill != nil && output.Format == FormatJSON {
+				err = writeVottJSONStream(path, project, source)
+			} else if len(project.Assets) == 0 {
+				if err = addSourceAssets(&project, source); err == nil {
+					err = writeFormat(path, project, output.Format)
+				}
+			} else {
+				err = writeFormat(path, project, output.Format)
+			}
+			if err != nil {
+				fmt.Println(err)
+				return ExitAnnotationsWriteFailed
+			}
+			summary.Outputs = append(summary.Outputs, path)
+		}
+
+		if options.SplitOutput {
+			paths, err := writeSplitProjects(annotationFile, project, source, splits, options.Format)
+			if err != nil {
+				fmt.Println(err)
+				return ExitAnnotationsWriteFailed
+			}
+			summary.Outputs = append(summary.Outputs, paths...)
+		}
+	}
+	Progress.finish(written)
+
+	// The outputs below take the assets in memory, they're read back once the project files are written.
+	needsAssets := options.Rekognition.Manifest != "" || options.SQLite != "" || options.Parquet != "" || options.PushElasticsearch != "" ||
+		options.MLflow.RunID != ""
+	if spill != nil && needsAssets {
+		if assets, err = spill.readAll(); err != nil {
+			fmt.Println(err)
+			return ExitAnnotationsWriteFailed
+		}
+	}
+
+	// Write the Rekognition Custom Labels manifest next to the project.
+	if options.Rekognition.Manifest != "" {
+		if err := writeRekognitionManifest(options.Rekognition, imagesPath, assets, labels); err != nil {
+			fmt.Println(err)
+			return ExitAnnotationsWriteFailed
+		}
+		summary.Outputs = append(summary.Outputs, options.Rekognition.Manifest)
+	}
+
+	// Write the assets, regions and tags into a SQLite database for ad-hoc SQL.
+	if options.SQLite != "" {
+		if err := writeSQLite(options.SQLite, assets, labels); err != nil {
+			fmt.Println(err)
+			return ExitAnnotationsWriteFailed
+		}
+		summary.Outputs = append(summary.Outputs, options.SQLite)
+	}
+
+	// Write a row per region into a Parquet file for analytics.
+	if options.Parquet != "" {
+		if err := writeParquet(options.Parquet, assets); err != nil {
+			fmt.Println(err)
+			return ExitAnnotationsWriteFailed
+		}
+		summary.Outputs = append(summary.Outputs, options.Parquet)
+	}
+
+	// Index a document per asset, for Kibana dashboards.
+	if options.PushElasticsearch != "" {
+		elasticsearch, _ := parseElasticsearch(options.PushElasticsearch)
+		elasticsearch.Client = options.ElasticsearchClient
+		if _, err := elasticsearch.push(assets); err != nil {
+			fmt.Println(err)
+			return ExitAnnotationsWriteFailed
+		}
+	}
+
+	// Write JSON file vott-cocoa-annotation-hashes.json for the next incremental run.
+	if options.Incremental {
+		if err := writeHashState(hashStatePath(annotationFile), hashState); err != nil {
+			fmt.Println(err)
+			return ExitAnnotationsWriteFailed
+		}
+		summary.Outputs = append(summary.Outputs, hashStatePath(annotationFile))
+	}
+
+	// Print the run summary as JSON, and write it to the summary file.
+	summary.Labels = labels
+	summary.Assets = written
+	if err := finishRunSummary(&summary, start, options.SummaryFile); err != nil {
+		fmt.Println(err)
+		return ExitAnnotationsWriteFailed
+	}
+
+	// Log the dataset to the MLflow run, tying it to the experiment.
+	if options.MLflow.RunID != "" {
+		loggedFile := annotationFile
+		if options.PushCustomVision || options.ShardSize > 0 {
+			loggedFile = ""
+		}
+		if err := options.MLflow.log(summary, assets, loggedFile); err != nil {
+			fmt.Println(err)
+			return ExitAnnotationsWriteFailed
+		}
+		fmt.Printf("Logged the dataset to MLflow run '%s'.\n", options.MLflow.RunID)
+	}
+
+	return ExitSuccesful
+}
+
+// isDirectory checks if the given path is a directory.
+func isDirectory(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// findImages get all the labeled images in the given directory and its subdirectories. Returns a map of the directory name (label) to containing image paths.
+// Directories are listed concurrently by at most workers goroutines at a time. Paths matching .votterignore are skipped.
+// Symlinked directories are followed unless they lead back to a directory listed already, and the scan fails once it
+// has seen more than MaxFiles files.
+func findImages(ctx context.Context, root string, workers int) (map[string][]string, error) {
+	return findImagesIn(ctx, root, nil, nil, workers)
+}
+
+// findImagesIn is findImages for the label folders of root named in only, all of them when only is nil.
+// Other folders aren't listed at all, so a part of a plan scans its own share of the images only. The folders
+// in skip aren't listed either, like the quarantine folder when it's below root.
+func findImagesIn(ctx context.Context, root string, only []string, skip []string, workers int) (map[string][]string, error) {
+	labels := make(map[string][]string)
+	var mutex sync.Mutex
+	var waitGroup sync.WaitGroup
+	var walkErr error
+	semaphore := make(chan struct{}, max(1, workers))
+	guard := newWalkGuard(root, MaxFiles)
+	ignore, err := readIgnoreFile(root)
+	if err != nil {
+		return nil, err
+	}
+	skipped := skippedDirs(root, skip)
+
+	var visit func(dir string)
+	visit = func(dir string) {
+		defer waitGroup.Done()
+		if !guard.enter(dir) {
+			return
+		}
+
+		semaphore <- struct{}{}
+		if ctx.Err() != nil {
+			<-semaphore
+			return
+		}
+		entries, err := retryRead(Retry, dir, OpenFiles, func() ([]os.DirEntry, error) {
+			return os.ReadDir(dir)
+		})
+		<-semaphore
+		if err == nil {
+			err = guard.count(len(entries))
+		}
+		if err != nil {
+			mutex.Lock()
+			if walkErr == nil {
+				walkErr = err
+			}
+			mutex.Unlock()
+			return
+		}
+
+		if len(ignore) > 0 || len(skipped) > 0 {
+			var kept []os.DirEntry
+			for _, entry := range entries {
+				relative, _ := filepath.Rel(root, filepath.Join(dir, entry.Name()))
+				if !isIgnored(ignore, relative) && !skipped[relative] {
+					kept = append(kept, entry)
+				}
+			}
+			entries = kept
+		}
+		if dir == root && only != nil {
+			var kept []os.DirEntry
+			for _, entry := range entries {
+				if slices.Contains(only, entry.Name()) {
+					kept = append(kept, entry)
+				}
+			}
+			entries = kept
+		}
+
+		for _, entry := range entries {
+			if isWalkedDir(dir, entry) {
+				waitGroup.Add(1)
+				go visit(filepath.Join(dir, entry.Name()))
+			}
+		}
+
+		if images := imageNames(dir, entries); dir != root && len(images) > 0 {
+			// Folders of the same name below others add to the label, by their path from where labeledImages
+			// looks for the label's images: a/cat/image.jpg is ../a/cat/image.jpg of label cat.
+			label := filepath.Base(dir)
+			if labelDir := filepath.Join(root, label); labelDir != dir {
+				for i, name := range images {
+					images[i], _ = filepath.Rel(labelDir, filepath.Join(dir, name))
+				}
+			}
+			mutex.Lock()
+			labels[label] = append(labels[label], images...)
+			mutex.Unlock()
+		}
+	}
+
+	waitGroup.Add(1)
+	go visit(root)
+	waitGroup.Wait()
+
+	if walkErr != nil {
+		return nil, walkErr
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	if len(labels) == 0 {
+		return nil, fmt.Errorf("Error: No images found in subdirectories of '%s'.", root)
+	}
+	// Folders are listed concurrently, sort so the order doesn't depend on which finished first.
+	for _, images := range labels {
+		sort.Strings(images)
+	}
+
+	return labels, nil
+}
+
+// skippedDirs returns the folders of dirs below root by their path from root, for findImagesIn to leave out.
+func skippedDirs(root string, dirs []string) map[string]bool {
+	skipped := make(map[string]bool)
+	absoluteRoot, err := filepath.Abs(root)
+	if err != nil {
+		return skipped
+	}
+	for _, dir := range dirs {
+		absolute, err := filepath.Abs(dir)
+		if err != nil {
+			continue
+		}
+		if relative, err := filepath.Rel(absoluteRoot, absolute); err == nil && filepath.IsLocal(relative) {
+			skipped[relative] = true
+		}
+	}
+	return skipped
+}
+
+func listImages(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	return imageNames(dir, entries), nil
+}
+
+// imageNames returns the names of the image files among the entries of dir.
+func imageNames(dir string, entries []os.DirEntry) []string {
+	var images []string
+	for _, entry := range entries {
+		if !entry.IsDir() && isImageFile(filepath.Join(dir, entry.Name())) {
+			images = append(images, entry.Name())
+		}
+	}
+	return images
+}
+
+// assetFilePath converts the file: path of an asset back to a path on the local filesystem.
+func assetFilePath(asset Asset) string {
+	return filepath.FromSlash(fileURIPath(asset.Path))
+}
+
+// decodeImageFile reads and decodes the image at path.
+func decodeImageFile(path string) (image.Image, error) {
+	return retryRead(Retry, path, nil, func() (image.Image, error) {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		img, _, err := image.Decode(file)
+		return img, err
+	})
+}
+
+// encodedName returns the name an image is written under by encodeImageFile: formats without an encoder (e.g. bmp)
+// are written as PNG, under the .png extension.
+func encodedName(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".jpg", ".jpeg", ".png", ".gif":
+		return name
+	}
+	return strings.TrimSuffix(name, filepath.Ext(name)) + ".png"
+}
+
+// encodeImageFile writes img to path, encoded by the format of the file extension. Formats without an encoder are written as PNG.
+func encodeImageFile(path string, img image.Image) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".jpg", ".jpeg":
+		err = jpeg.Encode(file, img, &jpeg.Options{Quality: 90})
+	case ".gif":
+		err = gif.Encode(file, img, nil)
+	default:
+		err = png.Encode(file, img)
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// generateVottEntries creates an asset for every labeled image, decoding the image headers with at most workers goroutines at a time.
+func generateVottEntries(pathToImagesDataset string, labels map[string][]string, workers int) ([]Asset, error) {
+	return generateImageEntries(labeledImages(pathToImagesDataset, labels), workers)
+}
+
+// labeledImage is an image file and the label it's annotated with.
+type labeledImage struct {
+	Path  string
+	Label string
+}
+
+// labeledImages lists the images of label directories below pathToImagesDataset, ordered by label.
+func labeledImages(pathToImagesDataset string, labels map[string][]string) []labeledImage {
+	var labelNames []string
+	for label := range labels {
+		labelNames = append(labelNames, label)
+	}
+	sort.Strings(labelNames)
+
+	var images []labeledImage
+	for _, label := range labelNames {
+		for _, imgFileName := range labels[label] {
+			images = append(images, labeledImage{Path: filepath.Join(pathToImagesDataset, label, imgFileName), Label: label}) // dataset/label/image.jpg
+		}
+	}
+	return images
+}
+
+// generateImageEntries creates an asset for every labeled image, decoding the image headers with at most workers goroutines at a time.
+func generateImageEntries(images []labeledImage, workers int) ([]Asset, error) {
+	entries, errs := generateEachImageEntry(context.Background(), images, workers)
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
+
+// generateEachImageEntry creates the asset for every labeled image like generateImageEntries, with the error of every image by index.
+// Cancelling ctx lets the workers finish their current images, the results are cut off before the first image not started on.
+func generateEachImageEntry(ctx context.Context, images []labeledImage, workers int) ([]Asset, []error) {
+	entries := make([]Asset, len(images))
+	errs := make([]error, len(images))
+	next := make(chan int)
+	var waitGroup sync.WaitGroup
+	for w := 0; w < max(1, workers); w++ {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			for i := range next {
+				entries[i], errs[i] = generateVottEntry(images[i].Path, images[i].Label)
+				Progress.step(images[i].Label)
+			}
+		}()
+	}
+	started := 0
+feed:
+	for started < len(images) {
+		select {
+		case next <- started:
+			started++
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(next)
+	waitGroup.Wait()
+	return entries[:started], errs[:started]
+}
+
+// generateVottEntry creates the asset for a labeled image, with a region covering the whole image.
+func generateVottEntry(imgRelativePath string, label string) (Asset, error) {
+	imgFileName := filepath.Base(imgRelativePath)
+	imgAbsolutePath, err := filepath.Abs(imgRelativePath) // /home/example/dataset/label/image.jpg or C:\example\dataset\label\image.jpg
+	if err != nil {
+		return Asset{}, err
+	}
+
+	type imageHeader struct {
+		config            image.Config
+		format, mediaType string
+	}
+	header, err := retryRead(Retry, imgRelativePath, OpenFiles, func() (header imageHeader, err error) {
+		imgFile, err := os.Open(imgRelativePath)
+		if err != nil {
+			return header, err
+		}
+		defer imgFile.Close()
+		// The start of the file is sniffed for its MIME type, then decoded along with the rest.
+		head := make([]byte, sniffLength)
+		n, err := io.ReadFull(imgFile, head)
+		if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+			return header, err
+		}
+		header.mediaType = sniffMediaType(head[:n])
+		header.config, header.format, err = image.DecodeConfig(io.MultiReader(bytes.NewReader(head[:n]), imgFile))
+		return header, err
+	})
+	if err != nil {
+		return Asset{}, err
+	}
+	imgConfig, imgFormat, mediaType := header.config, header.format, header.mediaType
+
+	entry := Asset{
+		Format: assetFormat(imgFileName, mediaType, imgFormat),
+		ID:     assetID("file:" + filepath.ToSlash(imgAbsolutePath)),
+		Name:   imgFileName,
+		Path:   "file:" + filepath.ToSlash(imgAbsolutePath), // file:/home/example/dataset/label/image.jpg or file:C:/example/dataset/label/image.jpg
+		Size: Size{
+			Width:  imgConfig.Width,
+			Height: imgConfig.Height,
+		},
+		State: 0,
+		Type:  assetType(mediaType, imgFormat),
+		Label: label,
+	}
+	entry.Regions = []Region{fullImageRegion(entry)}
+	return entry, nil
+}
+
+// assetID returns the ID of the asset at path, the MD5 of the path as VoTT makes it, so an image keeps its ID from
+// run to run.
+func assetID(path string) string {
+	return fmt.Sprintf("%x", md5.Sum([]byte(path)))
+}
+
+// stableRegionIDs gives the regions of the assets IDs derived from their asset, position and content, so a region
+// that didn't change keeps its ID from run to run. The regions of the assets in carried, carried forward from the
+// previous project, keep the IDs they have.
+func stableRegionIDs(assets []Asset, carried map[string]bool) {
+	for _, asset := range assets {
+		if carried[asset.ID] {
+			continue
+		}
+		for i := range asset.Regions {
+			region := asset.Regions[i]
+			region.ID = ""
+			content, err := json.Marshal(region)
+			if err != nil {
+				continue
+			}
+			asset.Regions[i].ID = uuid.NewSHA1(uuid.NameSpaceOID, []byte(fmt.Sprintf("%s/%d/%s", asset.ID, i, content))).String()
+		}
+	}
+}
+
+// fullImageRegion returns a rectangle covering the whole image, tagged with the asset's label.
+func fullImageRegion(asset Asset) Region {
+	return Region{
+		ID:          uuid.New().String(),
+		Type:        "RECTANGLE",
+		Tags:        []string{asset.Label},
+		BoundingBox: BoundingBox{Height: float64(asset.Size.Height), Width: float64(asset.Size.Width), Left: 0, Top: 0},
+		Points:      []Point{{X: 0, Y: 0}, {X: float64(asset.Size.Width), Y: float64(asset.Size.Height)}},
+	}
+}
+
+func writeVottJSON(path string, assets []Asset, tags []string) error {
+	return writeVottModel(path, buildVottModel(assets, tags))
+}
+
+// buildVottModel creates the VoTT project for the assets and tags.
+func buildVottModel(assets []Asset, tags []string) VottJsonModel {
+	model := VottJsonModel{
+		ActiveLearningSettings: ActiveLearningSettings{AutoDetect: false, PredictTag: true, ModelPathType: "coco"},
+		Assets:                 make(map[string]AssetDetail),
+		Tags:                   []Tag{},
+		Version:                "2.2.0",
+	}
+
+	addVottAssets(&model, assets)
+
+	for _, label := range tags {
+		tag := Tag{
+			Name:  label,
+			Color: "#ff0000", // red
+		}
+		model.Tags = append(model.Tags, tag)
+	}
+
+	return model
+}
+
+// addVottAssets adds an asset detail with the regions of each asset to the project. Assets without regions get a region covering the whole image.
+func addVottAssets(model *VottJsonModel, assets []Asset) {
+	for _, asset := range assets {
+		model.Assets[asset.ID] = assetDetail(asset)
+	}
+}
+
+// assetDetail returns the asset detail of an asset in the project, with a region covering the whole image if it has none.
+func assetDetail(asset Asset) AssetDetail {
+	regions := asset.Regions
+	if regions == nil {
+		regions = []Region{fullImageRegion(asset)}
+	}
+	return AssetDetail{
+		Asset:   asset,
+		Regions: regions,
+		Version: "2.2.0", // last version
+	}
+}
+
+// writeVottModel writes a VoTT project file.
+func writeVottModel(path string, model VottJsonModel) error {
+	return writeVottModelAs(path, model, FormatJSON)
+}
+
+// writeVottModelAs writes a VoTT project file in a registered format, json or yaml by default.
+// The file is written next to path and renamed over it, so an interrupted write leaves the previous project intact.
+func writeVottModelAs(path string, model VottJsonModel, format string) error {
+	data, err := encodeVottModel(model, format)
+	if err != nil {
+		return err
+	}
+	temporary := path + ".tmp"
+	if err := ioutil.WriteFile(temporary, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(temporary, path)
+}
+
+// readVottJSON reads a VoTT project file with the importer registered for its extension, JSON by default.
+func readVottJSON(path string) (VottJsonModel, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return VottJsonModel{}, err
 	}
-	return ioutil.WriteFile(path, data, 0644)
+	return votter.ImporterFor(path)(data)
 }
@@ -0,0 +1,172 @@
+// Package votter has the VoTT project that votter writes, and the registry of the formats it reads and writes
+// projects in. Programs import it to add formats to the votter command, from an init in a package built along with it,
+// or to read and write projects themselves.
+package votter
+
+import (
+	"image"
+	"math"
+)
+
+// VottJsonModel is a VoTT project: its settings, tags and assets by ID.
+type VottJsonModel struct {
+	Name                   string                 `json:"name"`
+	Description            string                 `json:"description,omitempty"`
+	SecurityToken          string                 `json:"securityToken"`
+	VideoSettings          VideoSettings          `json:"videoSettings"`
+	Tags                   []Tag                  `json:"tags"`
+	ID                     string                 `json:"id"`
+	ActiveLearningSettings ActiveLearningSettings `json:"activeLearningSettings"`
+	Version                string                 `json:"version"`
+	LastVisitedAssetID     string                 `json:"lastVisitedAssetId"`
+	Assets                 map[string]AssetDetail `json:"assets"`
+	// Metadata describes the dataset, from dataset.yaml or README.md front matter in the images folder.
+	Metadata *DatasetMetadata `json:"metadata,omitempty"`
+}
+
+type VideoSettings struct {
+	FrameExtractionRate int `json:"frameExtractionRate"`
+}
+
+type Tag struct {
+	Name  string `json:"name"`
+	Color string `json:"color"`
+	// Description, Shortcut and ExternalID come from label.yaml. VoTT ignores them, they're left out when empty.
+	Description string `json:"description,omitempty"`
+	// Shortcut is a hint of the key to tag with, VoTT itself numbers the tags in order.
+	Shortcut string `json:"shortcut,omitempty"`
+	// ExternalID is the ID of the class in another system, like a taxonomy or a label studio.
+	ExternalID string `json:"externalId,omitempty"`
+	// Parent is the parent tag of --tag-hierarchy, the supercategory of COCO.
+	Parent string `json:"parent,omitempty"`
+}
+
+type ActiveLearningSettings struct {
+	AutoDetect    bool   `json:"autoDetect"`
+	PredictTag    bool   `json:"predictTag"`
+	ModelPathType string `json:"modelPathType"`
+}
+
+// AssetDetail is an asset of the project with its regions.
+type AssetDetail struct {
+	Asset   Asset    `json:"asset"`
+	Regions []Region `json:"regions"`
+	Version string   `json:"version"`
+}
+
+// Asset is an image of the project. Label is the tag of its folder.
+type Asset struct {
+	Format string `json:"format"`
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Path   string `json:"path"`
+	Size   Size   `json:"size"`
+	State  int    `json:"state"`
+	Type   int    `json:"type"`
+	Label  string
+	// Attributes and CaptureTime come from the image's JSON sidecar.
+	Attributes  map[string]interface{} `json:"attributes,omitempty"`
+	CaptureTime string                 `json:"captureTime,omitempty"`
+	// SHA256 of the image contents with --checksums, for verify --checksums to recheck after transfers.
+	SHA256 string `json:"sha256,omitempty"`
+	// Regions are written to the asset detail, not to the asset itself.
+	Regions []Region `json:"-"`
+}
+
+type Size struct {
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// Region is a tagged rectangle or polygon of an asset.
+type Region struct {
+	ID          string      `json:"id"`
+	Type        string      `json:"type"`
+	Tags        []string    `json:"tags"`
+	BoundingBox BoundingBox `json:"boundingBox"`
+	Points      []Point     `json:"points"`
+	// Confidence of prelabels from model inference, 0 to 1. Regions from folders, names or masks have none.
+	Confidence *float64 `json:"confidence,omitempty"`
+	// Keypoints are the named points of the region, like the joints of a person.
+	Keypoints []Keypoint `json:"keypoints,omitempty"`
+	// OrientedBox is the rotated box of a POLYGON region of its four corners.
+	OrientedBox *OrientedBox `json:"orientedBox,omitempty"`
+	// Attributes of the region from sidecars or asset lines, like occluded, truncated or pose.
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+}
+
+// Score returns the confidence of the region, 1 for regions that aren't predicted.
+func (region Region) Score() float64 {
+	if region.Confidence == nil {
+		return 1
+	}
+	return *region.Confidence
+}
+
+// BoundingBox and Point are in pixels, fractional like VoTT's own.
+type BoundingBox struct {
+	Height float64 `json:"height"`
+	Width  float64 `json:"width"`
+	Left   float64 `json:"left"`
+	Top    float64 `json:"top"`
+}
+
+// Pixel returns the pixel a point falls on, rounded to the nearest.
+func (point Point) Pixel() image.Point {
+	return image.Pt(int(math.Round(point.X)), int(math.Round(point.Y)))
+}
+
+// Rect returns the pixels whose centers are inside the box.
+func (box BoundingBox) Rect() image.Rectangle {
+	return image.Rect(int(math.Round(box.Left)), int(math.Round(box.Top)), int(math.Round(box.Left+box.Width)), int(math.Round(box.Top+box.Height)))
+}
+
+type Point struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// DatasetMetadata is the description of a dataset, written to the project's metadata block.
+type DatasetMetadata struct {
+	Name        string `json:"name,omitempty" yaml:"name"`
+	Description string `json:"description,omitempty" yaml:"description"`
+	Version     string `json:"version,omitempty" yaml:"version"`
+	License     string `json:"license,omitempty" yaml:"license"`
+}
+
+// Keypoint visibilities, as in COCO.
+const (
+	KeypointUnlabeled = 0
+	KeypointHidden    = 1
+	KeypointVisible   = 2
+)
+
+// Keypoint is a named point of a region, like the nose of a COCO person, with its COCO visibility.
+type Keypoint struct {
+	Name       string  `json:"name"`
+	X          float64 `json:"x"`
+	Y          float64 `json:"y"`
+	Visibility int     `json:"visibility"`
+}
+
+// OrientedBox is a rotated box by its center, size and angle in degrees, clockwise in image coordinates from the
+// x axis to its width, as aerial imagery is labeled.
+type OrientedBox struct {
+	CX     float64 `json:"cx"`
+	CY     float64 `json:"cy"`
+	Width  float64 `json:"w"`
+	Height float64 `json:"h"`
+	Angle  float64 `json:"angle"`
+}
+
+// Corners returns the corners of the box, clockwise from the top left before rotation.
+func (o OrientedBox) Corners() []Point {
+	sin, cos := math.Sincos(o.Angle * math.Pi / 180)
+	offsets := [4][2]float64{{-1, -1}, {1, -1}, {1, 1}, {-1, 1}}
+	corners := make([]Point, len(offsets))
+	for i, offset := range offsets {
+		dx, dy := offset[0]*o.Width/2, offset[1]*o.Height/2
+		corners[i] = Point{X: o.CX + dx*cos - dy*sin, Y: o.CY + dx*sin + dy*cos}
+	}
+	return corners
+}
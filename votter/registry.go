@@ -0,0 +1,271 @@
+package votter
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Formats of the project file that the registry starts with.
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+)
+
+// Exporter encodes a project into the bytes of a project file.
+type Exporter func(model VottJsonModel) ([]byte, error)
+
+// DirectoryExporter writes a project into a directory of files, for formats with a file per image like YOLO.
+type DirectoryExporter func(model VottJsonModel, dir string) error
+
+// Importer decodes the bytes of a project file into a project.
+type Importer func(data []byte) (VottJsonModel, error)
+
+// DirectoryImporter reads a directory of annotation files into a project, for formats with a file per image like
+// Pascal VOC and YOLO.
+type DirectoryImporter func(dir string) (VottJsonModel, error)
+
+// StreamImporter decodes a project file as it's read, for formats whose files can outgrow memory.
+type StreamImporter func(reader io.Reader) (VottJsonModel, error)
+
+// importerEntry is a registered importer with the file extensions it reads, and its streaming form if it has one.
+type importerEntry struct {
+	name       string
+	extensions []string
+	importer   Importer
+	stream     StreamImporter
+}
+
+var (
+	registryMutex      sync.RWMutex
+	exporters          = make(map[string]Exporter)
+	directoryExporters = make(map[string]DirectoryExporter)
+	importers          []importerEntry
+	directoryImporters = make(map[string]DirectoryImporter)
+)
+
+// RegisterExporter makes a format available to --format by name, analogous to image.RegisterFormat.
+// Registering a name again replaces its exporter.
+func RegisterExporter(name string, exporter Exporter) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+	exporters[name] = exporter
+}
+
+// RegisterDirectoryExporter makes a format that writes a directory available to --format by name, for the formats
+// after the first, which is the annotations file.
+func RegisterDirectoryExporter(name string, exporter DirectoryExporter) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+	directoryExporters[name] = exporter
+}
+
+// RegisterImporter makes project files with one of the extensions, e.g. ".yaml", readable by the importer.
+// Later registrations take precedence for the same extension.
+func RegisterImporter(name string, extensions []string, importer Importer) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+	lowered := make([]string, len(extensions))
+	for i, ext := range extensions {
+		lowered[i] = strings.ToLower(ext)
+	}
+	importers = append(importers, importerEntry{name: name, extensions: lowered, importer: importer})
+}
+
+// RegisterDirectoryImporter makes directories of annotation files readable by convert as the format name.
+func RegisterDirectoryImporter(name string, importer DirectoryImporter) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+	directoryImporters[name] = importer
+}
+
+// RegisterStreamImporter registers an importer that reads project files as a stream, like RegisterImporter. It reads
+// bytes as well, for the callers of LookupImporter and ImporterFor.
+func RegisterStreamImporter(name string, extensions []string, stream StreamImporter) {
+	RegisterImporter(name, extensions, func(data []byte) (VottJsonModel, error) {
+		return stream(bytes.NewReader(data))
+	})
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+	importers[len(importers)-1].stream = stream
+}
+
+// ExportFormats returns the names of the registered exporters, sorted.
+func ExportFormats() []string {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+	names := make([]string, 0, len(exporters))
+	for name := range exporters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// OutputFormats returns the names of the registered exporters, directory exporters included, sorted.
+func OutputFormats() []string {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+	names := make([]string, 0, len(exporters)+len(directoryExporters))
+	for name := range exporters {
+		names = append(names, name)
+	}
+	for name := range directoryExporters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ImportFormats returns the names of the registered importers, directory importers included, sorted.
+func ImportFormats() []string {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+	seen := make(map[string]bool)
+	var names []string
+	for _, entry := range importers {
+		if !seen[entry.name] {
+			seen[entry.name] = true
+			names = append(names, entry.name)
+		}
+	}
+	for name := range directoryImporters {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LookupDirectoryExporter returns the directory exporter registered under name.
+func LookupDirectoryExporter(name string) (DirectoryExporter, bool) {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+	exporter, ok := directoryExporters[name]
+	return exporter, ok
+}
+
+// LookupExporter returns the exporter registered under name.
+func LookupExporter(name string) (Exporter, bool) {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+	exporter, ok := exporters[name]
+	return exporter, ok
+}
+
+// LookupImporter returns the importer registered under name, the latest for a name registered again.
+func LookupImporter(name string) (Importer, bool) {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+	for i := len(importers) - 1; i >= 0; i-- {
+		if importers[i].name == name {
+			return importers[i].importer, true
+		}
+	}
+	return nil, false
+}
+
+// LookupDirectoryImporter returns the directory importer registered under name.
+func LookupDirectoryImporter(name string) (DirectoryImporter, bool) {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+	importer, ok := directoryImporters[name]
+	return importer, ok
+}
+
+// LookupStreamImporter returns the streaming importer registered under name, false when the latest importer of the
+// name reads bytes only.
+func LookupStreamImporter(name string) (StreamImporter, bool) {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+	for i := len(importers) - 1; i >= 0; i-- {
+		if importers[i].name == name {
+			return importers[i].stream, importers[i].stream != nil
+		}
+	}
+	return nil, false
+}
+
+// ImporterFor returns the importer for the extension of path, the JSON importer when none claims it.
+func ImporterFor(path string) Importer {
+	ext := strings.ToLower(filepath.Ext(path))
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+	for i := len(importers) - 1; i >= 0; i-- {
+		for _, candidate := range importers[i].extensions {
+			if candidate == ext {
+				return importers[i].importer
+			}
+		}
+	}
+	return importJSON
+}
+
+func init() {
+	RegisterExporter(FormatJSON, exportJSON)
+	RegisterExporter(FormatYAML, exportYAML)
+	RegisterImporter(FormatJSON, []string{".json"}, importJSON)
+	RegisterImporter(FormatYAML, []string{".yaml", ".yml"}, importYAML)
+}
+
+func exportJSON(model VottJsonModel) ([]byte, error) {
+	return json.MarshalIndent(model, "", "  ")
+}
+
+// exportYAML converts the JSON encoding, so both formats have the same field names and order.
+func exportYAML(model VottJsonModel) ([]byte, error) {
+	data, err := exportJSON(model)
+	if err != nil {
+		return nil, err
+	}
+	return jsonToYAML(data)
+}
+
+func importJSON(data []byte) (VottJsonModel, error) {
+	var model VottJsonModel
+	err := json.Unmarshal(data, &model)
+	return model, err
+}
+
+func importYAML(data []byte) (VottJsonModel, error) {
+	data, err := yamlToJSON(data)
+	if err != nil {
+		return VottJsonModel{}, err
+	}
+	return importJSON(data)
+}
+
+// jsonToYAML converts JSON to block style YAML, keeping the order of the keys.
+func jsonToYAML(data []byte) ([]byte, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+	blockStyle(&document)
+	return yaml.Marshal(&document)
+}
+
+// blockStyle clears the flow and quoting styles that JSON parses into, leaving the YAML encoder free to choose.
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
+
+// yamlToJSON converts a YAML document to JSON.
+func yamlToJSON(data []byte) ([]byte, error) {
+	var document interface{}
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+	return json.Marshal(document)
+}
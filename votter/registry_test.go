@@ -0,0 +1,64 @@
+package votter_test
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"votter/mod/votter"
+)
+
+func Test_RegisterExporterAndImporter(t *testing.T) {
+	votter.RegisterExporter("lines", func(model votter.VottJsonModel) ([]byte, error) {
+		return []byte(model.Name + "\n"), nil
+	})
+	votter.RegisterStreamImporter("lines", []string{".LINES"}, func(reader io.Reader) (votter.VottJsonModel, error) {
+		data, err := io.ReadAll(reader)
+		return votter.VottJsonModel{Name: strings.TrimSpace(string(data))}, err
+	})
+
+	if !reflect.DeepEqual(votter.ExportFormats(), []string{"json", "lines", "yaml"}) {
+		t.Errorf("Expected the registered formats sorted, found %v", votter.ExportFormats())
+	}
+	if !reflect.DeepEqual(votter.ImportFormats(), []string{"json", "lines", "yaml"}) {
+		t.Errorf("Expected the registered importers sorted, found %v", votter.ImportFormats())
+	}
+
+	exporter, ok := votter.LookupExporter("lines")
+	if !ok {
+		t.Fatal("Expected the exporter registered")
+	}
+	data, err := exporter(votter.VottJsonModel{Name: "pets"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	model, err := votter.ImporterFor("project.lines")(data)
+	if err != nil || model.Name != "pets" {
+		t.Errorf("Expected the importer of the extension to read the project, found %+v: %v", model, err)
+	}
+	if _, ok := votter.LookupStreamImporter("lines"); !ok {
+		t.Error("Expected the streaming importer registered")
+	}
+	if _, ok := votter.LookupExporter("xml"); ok {
+		t.Error("Expected no exporter for an unregistered format")
+	}
+}
+
+func Test_JSONAndYAML(t *testing.T) {
+	project := votter.VottJsonModel{Name: "pets", Tags: []votter.Tag{{Name: "cat", Color: "#ff0000", Parent: "animal"}}}
+	for _, format := range []string{votter.FormatJSON, votter.FormatYAML} {
+		exporter, ok := votter.LookupExporter(format)
+		if !ok {
+			t.Fatalf("Expected %s registered", format)
+		}
+		data, err := exporter(project)
+		if err != nil {
+			t.Fatal(err)
+		}
+		model, err := votter.ImporterFor("project." + format)(data)
+		if err != nil || !reflect.DeepEqual(model, project) {
+			t.Errorf("Expected the %s project read back, found %+v: %v", format, model, err)
+		}
+	}
+}
@@ -1,7 +1,10 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"image"
+	"image/jpeg"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -57,7 +60,7 @@ func Test_FindImages(t *testing.T) {
 		}
 	}
 
-	labels, err := findImages(rootDir)
+	labels, err := findImages(context.Background(), rootDir, 4)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -82,16 +85,11 @@ func Test_GenerateVottEntries(t *testing.T) {
 	if err := os.Mkdir(labelDir, 0755); err != nil {
 		t.Fatal(err)
 	}
-	imgPath := filepath.Join(labelDir, imageFile)
-	file, err := os.Create(imgPath)
-	if err != nil {
-		t.Fatal(err)
-	}
-	file.Close()
+	writeTestImage(t, filepath.Join(labelDir, imageFile), 40, 30)
 
 	labels := map[string][]string{label: {imageFile}}
 
-	entries, err := generateVottEntries(rootDir, labels)
+	entries, err := generateVottEntries(rootDir, labels, 4)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -104,6 +102,25 @@ func Test_GenerateVottEntries(t *testing.T) {
 	if entry.Name != imageFile || entry.Label != label {
 		t.Errorf("Expected entry with name %s and label %s, found %s and %s", imageFile, label, entry.Name, entry.Label)
 	}
+	if entry.Size.Width != 40 || entry.Size.Height != 30 {
+		t.Errorf("Expected size 40x30, found %dx%d", entry.Size.Width, entry.Size.Height)
+	}
+	if len(entry.Regions) != 1 || entry.Regions[0].BoundingBox.Width != 40 {
+		t.Errorf("Expected one full image region, found %v", entry.Regions)
+	}
+}
+
+// writeTestImage writes a blank JPEG image of the given size.
+func writeTestImage(t *testing.T, path string, width, height int) {
+	t.Helper()
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if err := jpeg.Encode(file, image.NewRGBA(image.Rect(0, 0, width, height)), nil); err != nil {
+		t.Fatal(err)
+	}
 }
 
 func Test_WriteVottJSON(t *testing.T) {
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// DefaultMaxFiles is how many files the scan for images looks at before it gives up, see --max-files.
+const DefaultMaxFiles = 1000000
+
+// MaxFiles is the file count of --max-files that aborts the scan for images, 0 for no limit. A mis-pointed images
+// folder, like /, would otherwise be walked for hours.
+var MaxFiles = DefaultMaxFiles
+
+// walkGuard keeps a walk of the images folder finite: it follows symlinked folders once, skipping those that lead
+// back to a folder walked already, and counts the files seen against a maximum.
+type walkGuard struct {
+	root    string
+	max     int
+	mutex   sync.Mutex
+	visited map[string]string
+	files   int
+}
+
+func newWalkGuard(root string, max int) *walkGuard {
+	return &walkGuard{root: root, max: max, visited: make(map[string]string)}
+}
+
+// enter reports whether dir is walked: false when it's a folder walked already under another path, through a symlink
+// loop or a second link to it. Such folders are reported as skipped.
+func (g *walkGuard) enter(dir string) bool {
+	real, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		real = filepath.Clean(dir)
+	}
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	if first, seen := g.visited[real]; seen {
+		fmt.Printf("Skipped '%s', a symlink to '%s' walked already.\n", dir, first)
+		return false
+	}
+	g.visited[real] = dir
+	return true
+}
+
+// count adds the files of a folder to the count, failing once it's over the maximum.
+func (g *walkGuard) count(files int) error {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.files += files
+	if g.max > 0 && g.files > g.max {
+		return fmt.Errorf("Error: More than %d files below '%s', is it the images folder? Raise --max-files to scan them all.", g.max, g.root)
+	}
+	return nil
+}
+
+// isWalkedDir reports whether an entry of dir is a folder to walk, a folder or a symlink to one.
+func isWalkedDir(dir string, entry os.DirEntry) bool {
+	if entry.IsDir() {
+		return true
+	}
+	if entry.Type()&os.ModeSymlink == 0 {
+		return false
+	}
+	info, err := os.Stat(filepath.Join(dir, entry.Name()))
+	return err == nil && info.IsDir()
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_FindImagesSymlinkLoop(t *testing.T) {
+	rootDir := t.TempDir()
+	os.MkdirAll(filepath.Join(rootDir, "cat"), 0755)
+	writeTestImage(t, filepath.Join(rootDir, "cat", "image1.jpg"), 10, 10)
+	dogDir := t.TempDir()
+	writeTestImage(t, filepath.Join(dogDir, "image2.jpg"), 10, 10)
+	if err := os.Symlink(dogDir, filepath.Join(rootDir, "dog")); err != nil {
+		t.Skip("symlinks not supported:", err)
+	}
+	// Loops back to the root, and a second link to the same folder.
+	os.Symlink(rootDir, filepath.Join(rootDir, "cat", "loop"))
+	os.Symlink(filepath.Join(rootDir, "cat"), filepath.Join(rootDir, "cat2"))
+
+	labels, err := findImages(context.Background(), rootDir, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(labels["dog"]) != 1 || len(labels["cat"])+len(labels["cat2"]) != 1 {
+		t.Errorf("Expected the images of the symlinked folder once, found %v", labels)
+	}
+}
+
+func Test_FindImagesMaxFiles(t *testing.T) {
+	defer func() { MaxFiles = DefaultMaxFiles }()
+	rootDir := t.TempDir()
+	for _, name := range []string{"image1.jpg", "image2.jpg", "image3.jpg"} {
+		os.MkdirAll(filepath.Join(rootDir, "cat"), 0755)
+		writeTestImage(t, filepath.Join(rootDir, "cat", name), 10, 10)
+	}
+
+	MaxFiles = 3
+	if _, err := findImages(context.Background(), rootDir, 2); err == nil || !strings.Contains(err.Error(), "--max-files") {
+		t.Errorf("Expected the scan to stop past --max-files, found %v", err)
+	}
+	MaxFiles = 0
+	labels, err := findImages(context.Background(), rootDir, 2)
+	if err != nil || !reflect.DeepEqual(labels["cat"], []string{"image1.jpg", "image2.jpg", "image3.jpg"}) {
+		t.Errorf("Expected no limit with 0, found %v: %v", labels, err)
+	}
+}
+
+func Test_FindImagesSameFolderNames(t *testing.T) {
+	rootDir := t.TempDir()
+	for _, dir := range []string{"cat", filepath.Join("a", "cat"), filepath.Join("b", "cat")} {
+		os.MkdirAll(filepath.Join(rootDir, dir), 0755)
+		writeTestImage(t, filepath.Join(rootDir, dir, "image1.jpg"), 10, 10)
+	}
+
+	for run := 0; run < 5; run++ {
+		labels, err := findImages(context.Background(), rootDir, 4)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var paths []string
+		for _, image := range labeledImages(rootDir, labels) {
+			if _, err := os.Stat(image.Path); err != nil {
+				t.Errorf("Expected the image of label %s to exist: %v", image.Label, err)
+			}
+			paths = append(paths, image.Path)
+		}
+		expected := []string{filepath.Join(rootDir, "a", "cat", "image1.jpg"), filepath.Join(rootDir, "b", "cat", "image1.jpg"), filepath.Join(rootDir, "cat", "image1.jpg")}
+		if !reflect.DeepEqual(paths, expected) {
+			t.Fatalf("Expected the images of every cat folder, found %v", paths)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// webdavPropfind asks a WebDAV server for the type of the members of a folder.
+const webdavPropfind = `<?xml version="1.0" encoding="utf-8"?><propfind xmlns="DAV:"><prop><resourcetype/></prop></propfind>`
+
+// webdavMultistatus is the answer to a PROPFIND, a response per member of the folder and the folder itself.
+type webdavMultistatus struct {
+	Responses []struct {
+		Href     string `xml:"href"`
+		Propstat []struct {
+			Collection *struct{} `xml:"prop>resourcetype>collection"`
+		} `xml:"propstat"`
+	} `xml:"response"`
+}
+
+// webdavEntry is a member of a WebDAV folder, a file or a folder.
+type webdavEntry struct {
+	URL    *url.URL
+	Folder bool
+}
+
+// parseWebDAV parses the folder URL of --webdav.
+func parseWebDAV(value string) (*url.URL, error) {
+	folder, err := url.Parse(value)
+	if err != nil || (folder.Scheme != "http" && folder.Scheme != "https") || folder.Host == "" {
+		return nil, fmt.Errorf("Error: --webdav '%s' is not an http or https URL", value)
+	}
+	if !strings.HasSuffix(folder.Path, "/") {
+		folder.Path += "/"
+	}
+	return folder, nil
+}
+
+// listWebDAV lists the members of a WebDAV folder with client.
+func listWebDAV(ctx context.Context, client *http.Client, folder *url.URL) ([]webdavEntry, error) {
+	request, err := http.NewRequestWithContext(ctx, "PROPFIND", folder.String(), strings.NewReader(webdavPropfind))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Depth", "1")
+	request.Header.Set("Content-Type", "application/xml")
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusMultiStatus {
+		return nil, fmt.Errorf("Error: Cannot list WebDAV folder '%s': %s", folder.Redacted(), response.Status)
+	}
+	var status webdavMultistatus
+	if err := xml.NewDecoder(response.Body).Decode(&status); err != nil {
+		return nil, fmt.Errorf("Error: Cannot read the listing of WebDAV folder '%s': %v", folder.Redacted(), err)
+	}
+
+	var entries []webdavEntry
+	for _, member := range status.Responses {
+		href, err := url.Parse(strings.TrimSpace(member.Href))
+		if err != nil {
+			continue
+		}
+		entry := webdavEntry{URL: folder.ResolveReference(href)}
+		for _, propstat := range member.Propstat {
+			entry.Folder = entry.Folder || propstat.Collection != nil
+		}
+		if strings.TrimSuffix(entry.URL.Path, "/") == strings.TrimSuffix(folder.Path, "/") {
+			continue // the folder itself
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// webdavItems lists the images below a WebDAV folder as downloads into the same folders below imagesPath, labeled by
+// their top-level folder. Members outside the folder are left out, and the listing fails past MaxFiles files.
+func webdavItems(ctx context.Context, client *http.Client, root *url.URL, imagesPath string) ([]downloadItem, error) {
+	guard := newWalkGuard(root.Redacted(), MaxFiles)
+	visited := map[string]bool{root.Path: true}
+	folders := []*url.URL{root}
+	var items []downloadItem
+	for len(folders) > 0 {
+		folder := folders[0]
+		folders = folders[1:]
+		entries, err := listWebDAV(ctx, client, folder)
+		if err != nil {
+			return nil, err
+		}
+		if err := guard.count(len(entries)); err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			relative, found := strings.CutPrefix(path.Clean(entry.URL.Path), root.Path)
+			if !found || relative == "" {
+				continue
+			}
+			if entry.Folder {
+				if !visited[entry.URL.Path] {
+					visited[entry.URL.Path] = true
+					folders = append(folders, entry.URL)
+				}
+				continue
+			}
+			if !SniffImages && !isImage(relative) {
+				continue
+			}
+			label, _, _ := strings.Cut(relative, "/")
+			items = append(items, downloadItem{URL: entry.URL.String(), Label: label, Path: filepath.Join(imagesPath, filepath.FromSlash(relative))})
+		}
+	}
+	return items, nil
+}
+
+// mirrorWebDAV downloads the images below the WebDAV folder of --webdav into the images folder with client, keeping
+// its folders, so the labels of a NAS share are annotated like a local images folder. Images there from an earlier
+// run aren't downloaded again. Failures are reported like those of --download-urls. Returns the exit code.
+func mirrorWebDAV(ctx context.Context, client *http.Client, folder string, imagesPath string, annotationFile string, workers int) int {
+	root, err := parseWebDAV(folder)
+	if err != nil {
+		fmt.Println(err)
+		return ExitInvalidArguments
+	}
+	items, err := webdavItems(ctx, client, root, imagesPath)
+	if ctx.Err() != nil {
+		fmt.Printf("Error: %s while listing WebDAV folder '%s'\n", interruption(ctx), root.Redacted())
+		return ExitInterrupted
+	}
+	if err != nil {
+		fmt.Println(err)
+		return ExitImagesFolderNotFound
+	}
+
+	Progress.begin("download", len(items))
+	downloaded, failures := downloadURLs(ctx, client, items, workers)
+	Progress.finish(len(items))
+	if ctx.Err() != nil {
+		fmt.Printf("Error: %s while downloading images\n", interruption(ctx))
+		return ExitInterrupted
+	}
+	fmt.Printf("Downloaded %d of %d images from '%s' into '%s'.\n", downloaded, len(items), root.Redacted(), imagesPath)
+	return reportDownloadFailures(failures, annotationFile)
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_MirrorWebDAV(t *testing.T) {
+	var pngData bytes.Buffer
+	png.Encode(&pngData, image.NewRGBA(image.Rect(0, 0, 4, 4)))
+	folders := map[string][]string{
+		"/dav/dataset/":         {"/dav/dataset/cat/", "/dav/dataset/dog%20s/", "/dav/dataset/readme.txt", "/elsewhere/"},
+		"/dav/dataset/cat/":     {"/dav/dataset/cat/image1.png", "/dav/dataset/"},
+		"/dav/dataset/dog%20s/": {"/dav/dataset/dog%20s/image2.png"},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if username, password, _ := r.BasicAuth(); username != "labeling" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Method == http.MethodGet {
+			if strings.HasSuffix(r.URL.Path, ".png") {
+				w.Header().Set("Content-Type", "application/octet-stream")
+				w.Write(pngData.Bytes())
+				return
+			}
+			http.NotFound(w, r)
+			return
+		}
+		members, ok := folders[r.URL.EscapedPath()]
+		if r.Method != "PROPFIND" || r.Header.Get("Depth") != "1" || !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.WriteHeader(http.StatusMultiStatus)
+		fmt.Fprintf(w, `<?xml version="1.0"?><d:multistatus xmlns:d="DAV:"><d:response><d:href>%s</d:href><d:propstat><d:prop><d:resourcetype><d:collection/></d:resourcetype></d:prop></d:propstat></d:response>`, r.URL.EscapedPath())
+		for _, member := range members {
+			resourceType := ""
+			if strings.HasSuffix(member, "/") {
+				resourceType = "<d:collection/>"
+			}
+			fmt.Fprintf(w, `<d:response><d:href>%s</d:href><d:propstat><d:prop><d:resourcetype>%s</d:resourcetype></d:prop></d:propstat></d:response>`, member, resourceType)
+		}
+		fmt.Fprint(w, `</d:multistatus>`)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	images := filepath.Join(dir, "images")
+	annotations := filepath.Join(dir, "annotations.json")
+	if code := mirrorWebDAV(context.Background(), HTTPClient, server.URL+"/dav/dataset", images, annotations, 2); code != ExitImagesFolderNotFound {
+		t.Errorf("Expected the listing to fail without credentials, found exit code %d", code)
+	}
+
+	client := CredentialProfile{Username: "labeling", Password: "secret"}.client(HTTPClient)
+	if code := mirrorWebDAV(context.Background(), client, server.URL+"/dav/dataset", images, annotations, 2); code != ExitSuccesful {
+		t.Fatalf("Expected the share mirrored, found exit code %d", code)
+	}
+	for _, path := range []string{filepath.Join(images, "cat", "image1.png"), filepath.Join(images, "dog s", "image2.png")} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("Expected the image of the share: %v", err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(images, "readme.txt")); err == nil {
+		t.Error("Expected files that aren't images left out")
+	}
+
+	if _, err := parseWebDAV("ftp://nas/dataset"); err == nil {
+		t.Error("Expected an error for a URL that isn't http or https")
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// isTerminal reports whether a file is an interactive terminal rather than a pipe or file.
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// runWizard asks for the images folder, annotations file and main options, offering the current values as defaults,
+// and prints the command line that does the same without asking. Returns the chosen images folder and annotations file.
+func runWizard(in io.Reader, out io.Writer, imagesPath string, annotationFile string, options *Options) (string, string, error) {
+	reader := bufio.NewReader(in)
+	ask := func(question string, value string, valid func(string) error) (string, error) {
+		for {
+			fmt.Fprintf(out, "%s [%s]: ", question, value)
+			answer, err := reader.ReadString('\n')
+			if err != nil && (err != io.EOF || answer == "") {
+				return "", fmt.Errorf("Error: Wizard ended without an answer: %v", err)
+			}
+			if answer = strings.TrimSpace(answer); answer == "" {
+				answer = value
+			}
+			if err := valid(answer); err != nil {
+				fmt.Fprintln(out, err)
+				continue
+			}
+			return answer, nil
+		}
+	}
+	anyAnswer := func(string) error { return nil }
+
+	fmt.Fprintln(out, "No arguments given, answer a few questions or press Enter for the defaults.")
+	var err error
+	if imagesPath, err = ask("Images folder, with a folder per label", imagesPath, func(path string) error {
+		if !isDirectory(path) {
+			return fmt.Errorf("Error: Folder '%s' not found", path)
+		}
+		return nil
+	}); err != nil {
+		return "", "", err
+	}
+	if annotationFile, err = ask("Annotations file to write", annotationFile, anyAnswer); err != nil {
+		return "", "", err
+	}
+	if options.Format, err = ask("Format, json or yaml", options.Format, validFormat); err != nil {
+		return "", "", err
+	}
+	resize, err := ask("Resize to a maximum width or height in pixels, 0 to keep the size", strconv.Itoa(options.Resize), func(answer string) error {
+		if size, err := strconv.Atoi(answer); err != nil || size < 0 {
+			return fmt.Errorf("Error: Expected a size in pixels, found '%s'", answer)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", "", err
+	}
+	options.Resize, _ = strconv.Atoi(resize)
+	augment := options.Augment
+	if augment == "" {
+		augment = "none"
+	}
+	if augment, err = ask("Augmentations, comma separated hflip, vflip, rot90, rot180, rot270", augment, func(answer string) error {
+		if answer == "none" {
+			return nil
+		}
+		_, err := parseAugmentations(answer)
+		return err
+	}); err != nil {
+		return "", "", err
+	}
+	if augment == "none" {
+		augment = ""
+	}
+	options.Augment = augment
+
+	command := []string{"votter"}
+	if options.Format != FormatJSON {
+		command = append(command, "-format", options.Format)
+	}
+	if options.Resize > 0 {
+		command = append(command, "-resize", resize)
+	}
+	if options.Augment != "" {
+		command = append(command, "-augment", options.Augment)
+	}
+	command = append(command, strconv.Quote(imagesPath), strconv.Quote(annotationFile))
+	fmt.Fprintf(out, "Next time run: %s\n", strings.Join(command, " "))
+	return imagesPath, annotationFile, nil
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func Test_RunWizard(t *testing.T) {
+	rootDir := t.TempDir()
+	options := Options{Format: FormatJSON}
+	answers := strings.Join([]string{"missing", rootDir, "", "yaml", "big", "1024", "hflip,rot90"}, "\n") + "\n"
+
+	var out bytes.Buffer
+	imagesPath, annotationFile, err := runWizard(strings.NewReader(answers), &out, ".", "annotations.json", &options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imagesPath != rootDir || annotationFile != "annotations.json" {
+		t.Errorf("Expected the answered images folder and default annotations file, found '%s', '%s'", imagesPath, annotationFile)
+	}
+	if options.Format != FormatYAML || options.Resize != 1024 || options.Augment != "hflip,rot90" {
+		t.Errorf("Expected the answered options, found %+v", options)
+	}
+	if !strings.Contains(out.String(), "Folder 'missing' not found") || !strings.Contains(out.String(), "-resize 1024") {
+		t.Errorf("Expected invalid answers to be asked again and the command line printed, found:\n%s", out.String())
+	}
+}
+
+func Test_RunWizardEnded(t *testing.T) {
+	options := Options{Format: FormatJSON}
+	if _, _, err := runWizard(strings.NewReader(""), &bytes.Buffer{}, ".", "annotations.json", &options); err == nil {
+		t.Error("Expected an error when the input ends")
+	}
+}
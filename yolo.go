@@ -0,0 +1,222 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"votter/mod/votter"
+)
+
+// FormatYOLO is the --format of YOLO labels, a directory with a text file per image.
+const FormatYOLO = "yolo"
+
+// yoloLine is a line of a YOLO label file: class and the normalized center and size of the box.
+var yoloLine = regexp.MustCompile(`^\d+( -?\d*\.?\d+(e-?\d+)?){4}$`)
+
+func init() {
+	votter.RegisterDirectoryExporter(FormatYOLO, exportYOLO)
+	votter.RegisterDirectoryImporter(FormatYOLO, importYOLO)
+}
+
+// exportYOLO writes a project as YOLO labels into dir: classes.txt with a tag per line, the line its class index, and
+// a text file per image in a folder named like the image's folder, cat/image1.txt for cat/image1.jpg. A label file has
+// a line per tag of every region, the class index and the box by its center and size relative to the image.
+// Images without regions get an empty file, a background image to YOLO. Files are overwritten, so reruns are the same.
+// Label files of images of the same name in a folder, or in folders of the same name, get the asset ID in their name.
+func exportYOLO(model VottJsonModel, dir string) error {
+	var classes []string
+	indexes := make(map[string]int)
+	class := func(tag string) int {
+		if index, ok := indexes[tag]; ok {
+			return index
+		}
+		indexes[tag] = len(classes)
+		classes = append(classes, tag)
+		return indexes[tag]
+	}
+	for _, tag := range model.Tags {
+		class(tag.Name)
+	}
+
+	ids := sortedAssetIDs(model)
+	names := make([]string, len(ids))
+	for i, id := range ids {
+		path := filepath.FromSlash(fileURIPath(model.Assets[id].Asset.Path))
+		names[i] = filepath.Join(filepath.Base(filepath.Dir(path)), strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))+".txt")
+	}
+	names = uniqueNames(names, ids)
+
+	for i, id := range ids {
+		detail := model.Assets[id]
+		size := detail.Asset.Size
+		var lines bytes.Buffer
+		for _, region := range detail.Regions {
+			if size.Width <= 0 || size.Height <= 0 {
+				break
+			}
+			box := region.BoundingBox
+			for _, tag := range region.Tags {
+				fmt.Fprintf(&lines, "%d %.6f %.6f %.6f %.6f\n", class(tag),
+					(box.Left+box.Width/2)/float64(size.Width), (box.Top+box.Height/2)/float64(size.Height),
+					box.Width/float64(size.Width), box.Height/float64(size.Height))
+			}
+		}
+
+		labelPath := filepath.Join(dir, names[i])
+		if err := os.MkdirAll(filepath.Dir(labelPath), 0755); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(labelPath, lines.Bytes(), 0644); err != nil {
+			return err
+		}
+	}
+
+	var classNames bytes.Buffer
+	for _, name := range classes {
+		classNames.WriteString(name + "\n")
+	}
+	return ioutil.WriteFile(filepath.Join(dir, "classes.txt"), classNames.Bytes(), 0644)
+}
+
+// importYOLO reads a directory of YOLO label files, its subdirectories included, as exportYOLO writes them. Classes
+// are named by the lines of classes.txt, or by their index without it. Boxes are relative to the image, so the image of
+// a label file is looked up for its size: next to it with the same name, or in the images folder of a labels folder,
+// images/cat/image1.jpg for labels/cat/image1.txt.
+func importYOLO(dir string) (VottJsonModel, error) {
+	var classes []string
+	if data, err := ioutil.ReadFile(filepath.Join(dir, "classes.txt")); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				classes = append(classes, line)
+			}
+		}
+	}
+	className := func(index int) string {
+		if index < len(classes) {
+			return classes[index]
+		}
+		return strconv.Itoa(index)
+	}
+
+	var assets []Asset
+	tags := append([]string{}, classes...)
+	err := filepath.WalkDir(dir, func(labelPath string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || strings.ToLower(filepath.Ext(labelPath)) != ".txt" || labelPath == filepath.Join(dir, "classes.txt") {
+			return err
+		}
+		data, err := ioutil.ReadFile(labelPath)
+		if err != nil {
+			return err
+		}
+		// Other text files, like lists of images, are skipped. An empty file is the label file of a background image.
+		lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+		if lines[0] != "" && !yoloLine.MatchString(strings.TrimSpace(lines[0])) {
+			return nil
+		}
+		imagePath, found := yoloImage(labelPath)
+		if !found {
+			return fmt.Errorf("Error: Cannot find the image of YOLO label file '%s' for its size", labelPath)
+		}
+		size, err := imageSize(imagePath)
+		if err != nil {
+			return err
+		}
+		asset := Asset{
+			Format:  strings.TrimPrefix(filepath.Ext(imagePath), "."),
+			ID:      assetID(filepath.ToSlash(imagePath)),
+			Name:    filepath.Base(imagePath),
+			Path:    "file:" + filepath.ToSlash(imagePath),
+			Size:    size,
+			Regions: []Region{},
+		}
+		for i, text := range lines {
+			line := i + 1
+			fields := strings.Fields(text)
+			if len(fields) == 0 {
+				continue
+			}
+			if len(fields) != 5 {
+				return fmt.Errorf("Error: Line %d of YOLO label file '%s' isn't class x y width height", line, labelPath)
+			}
+			index, err := strconv.Atoi(fields[0])
+			values := make([]float64, 4)
+			for i := range values {
+				if err == nil {
+					values[i], err = strconv.ParseFloat(fields[i+1], 64)
+				}
+			}
+			if err != nil {
+				return fmt.Errorf("Error: Line %d of YOLO label file '%s': %v", line, labelPath, err)
+			}
+			width, height := values[2]*float64(size.Width), values[3]*float64(size.Height)
+			left, top := values[0]*float64(size.Width)-width/2, values[1]*float64(size.Height)-height/2
+			tag := className(index)
+			asset.Regions = append(asset.Regions, Region{
+				ID:          uuid.New().String(),
+				Type:        "RECTANGLE",
+				Tags:        []string{tag},
+				BoundingBox: BoundingBox{Left: left, Top: top, Width: width, Height: height},
+				Points:      []Point{{X: left, Y: top}, {X: left + width, Y: top + height}},
+			})
+			tags = mergeTags(tags, []string{tag})
+		}
+		if len(asset.Regions) > 0 {
+			asset.Label = asset.Regions[0].Tags[0]
+		}
+		assets = append(assets, asset)
+		return nil
+	})
+	if err != nil {
+		return VottJsonModel{}, err
+	}
+	return buildVottModel(assets, tags), nil
+}
+
+// yoloImage returns the image of a YOLO label file: an image of the same name next to it, or in the images folder
+// that mirrors the labels folder it's in.
+func yoloImage(labelPath string) (string, bool) {
+	stem := strings.TrimSuffix(labelPath, filepath.Ext(labelPath))
+	candidates := []string{stem}
+	parts := strings.Split(filepath.ToSlash(stem), "/")
+	for i := len(parts) - 2; i >= 0; i-- {
+		if parts[i] == "labels" {
+			mirrored := append(append(append([]string{}, parts[:i]...), "images"), parts[i+1:]...)
+			candidates = append(candidates, filepath.FromSlash(strings.Join(mirrored, "/")))
+			break
+		}
+	}
+	for _, candidate := range candidates {
+		for _, ext := range ImageExtensions {
+			for _, name := range []string{candidate + ext, candidate + strings.ToUpper(ext)} {
+				if info, err := os.Stat(name); err == nil && !info.IsDir() {
+					return name, true
+				}
+			}
+		}
+	}
+	return "", false
+}
+
+// imageSize reads the size of the image at path from its header.
+func imageSize(path string) (Size, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Size{}, err
+	}
+	defer file.Close()
+	config, _, err := image.DecodeConfig(file)
+	if err != nil {
+		return Size{}, fmt.Errorf("Error: Cannot read the size of '%s': %v", path, err)
+	}
+	return Size{Width: config.Width, Height: config.Height}, nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_ExportYOLO(t *testing.T) {
+	assets := []Asset{
+		{ID: "a1", Name: "image1.jpg", Path: "file:/data/cat/image1.jpg", Size: Size{Width: 40, Height: 20}, Label: "cat",
+			Regions: []Region{{ID: "r1", Type: "RECTANGLE", Tags: []string{"cat"}, BoundingBox: BoundingBox{Left: 10, Top: 5, Width: 20, Height: 10}}}},
+		{ID: "b2", Name: "image1.jpg", Path: "file:/data/dog/image1.jpg", Size: Size{Width: 10, Height: 10}, Label: "dog",
+			Regions: []Region{{ID: "r2", Type: "RECTANGLE", Tags: []string{"dog", "pet"}, BoundingBox: BoundingBox{Left: 0, Top: 0, Width: 10, Height: 10}}}},
+		{ID: "c3", Name: "image2.jpg", Path: "file:/data/dog/image2.jpg", Size: Size{Width: 10, Height: 10}, Label: "dog", Regions: []Region{}},
+	}
+	model := buildVottModel(nil, []string{"cat", "dog"})
+	addVottAssets(&model, assets)
+
+	dir := filepath.Join(t.TempDir(), "yolo")
+	if err := writeFormat(dir, model, FormatYOLO); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{
+		"classes.txt":                      "cat\ndog\npet\n",
+		filepath.Join("cat", "image1.txt"): "0 0.500000 0.500000 0.500000 0.500000\n",
+		filepath.Join("dog", "image1.txt"): "1 0.500000 0.500000 1.000000 1.000000\n2 0.500000 0.500000 1.000000 1.000000\n",
+		filepath.Join("dog", "image2.txt"): "",
+	}
+	for name, content := range expected {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != content {
+			t.Errorf("Expected %s to be %q, found %q", name, content, data)
+		}
+	}
+}
+
+func Test_ExportYOLOSameNames(t *testing.T) {
+	var assets []Asset
+	for _, path := range []string{"file:/a/cat/image1.jpg", "file:/b/cat/image1.jpg", "file:/b/cat/image1.png"} {
+		assets = append(assets, Asset{ID: assetID(path), Name: "image1", Path: path, Size: Size{Width: 10, Height: 10}, Label: "cat", Regions: []Region{}})
+	}
+	model := buildVottModel(nil, []string{"cat"})
+	addVottAssets(&model, assets)
+
+	dir := filepath.Join(t.TempDir(), "yolo")
+	if err := writeFormat(dir, model, FormatYOLO); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := os.ReadDir(filepath.Join(dir, "cat"))
+	if err != nil || len(entries) != 3 {
+		t.Fatalf("Expected a label file per image, found %v: %v", entries, err)
+	}
+	for _, asset := range assets {
+		if _, err := os.Stat(filepath.Join(dir, "cat", "image1_"+asset.ID+".txt")); err != nil {
+			t.Errorf("Expected the label file of %s named by its asset ID: %v", asset.Path, err)
+		}
+	}
+}
+
+func Test_ParseFormatsYOLO(t *testing.T) {
+	first, others, err := parseFormats("vott,yolo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != FormatJSON || len(others) != 1 || others[0].path(filepath.Join("data", "annotations.json")) != filepath.Join("data", "annotations-yolo") {
+		t.Errorf("Expected json and the yolo directory next to the annotations file, found %s and %+v", first, others)
+	}
+	if _, _, err := parseFormats("yolo,json"); err == nil {
+		t.Error("Expected yolo to be rejected as the annotations file")
+	}
+}
+
+func Test_ImportYOLO(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "images", "train"), 0755)
+	os.MkdirAll(filepath.Join(dir, "labels", "train"), 0755)
+	writeTestImage(t, filepath.Join(dir, "images", "train", "image1.jpg"), 40, 20)
+	os.WriteFile(filepath.Join(dir, "classes.txt"), []byte("cat\ndog\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "labels", "train", "image1.txt"), []byte("1 0.5 0.5 0.5 0.5\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "train.txt"), []byte("images/train/image1.jpg\n"), 0644)
+
+	model, err := importYOLO(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(model.Assets) != 1 || len(model.Tags) != 2 {
+		t.Fatalf("Expected an asset and the classes as tags, found %+v", model)
+	}
+	for _, detail := range model.Assets {
+		if detail.Asset.Label != "dog" || detail.Asset.Size != (Size{Width: 40, Height: 20}) || len(detail.Regions) != 1 {
+			t.Errorf("Expected the image labeled dog with its size, found %+v", detail)
+		}
+		if box := detail.Regions[0].BoundingBox; box != (BoundingBox{Left: 10, Top: 5, Width: 20, Height: 10}) {
+			t.Errorf("Expected the box in pixels, found %+v", box)
+		}
+	}
+
+	os.WriteFile(filepath.Join(dir, "labels", "train", "image2.txt"), []byte("0 0.5 0.5 0.5 0.5\n"), 0644)
+	if _, err := importYOLO(dir); err == nil {
+		t.Error("Expected a label file without its image to be rejected")
+	}
+}